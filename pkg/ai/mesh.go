@@ -0,0 +1,179 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ai
+
+/*
+#include "assimp/mesh.h"
+*/
+import "C"
+
+import (
+	"azul3d.org/v1/gfx"
+	"unsafe"
+)
+
+// The maximum number of bones that may influence a single vertex when
+// converting to a gfx.Mesh, see Mesh.GfxMesh.
+const maxBoneInfluences = 4
+
+// Mesh represents a single mesh of an imported scene. The mesh data is owned
+// by the assimp library and should be considered read-only.
+type Mesh struct {
+	c *C.struct_aiMesh
+}
+
+// aiMesh returns a *Mesh given a pointer to an element of the aiScene's
+// mMeshes array (i.e. a pointer to a *C.struct_aiMesh).
+func aiMesh(p unsafe.Pointer) *Mesh {
+	return &Mesh{
+		c: *(**C.struct_aiMesh)(p),
+	}
+}
+
+// Name returns the name of the mesh, which may be empty.
+func (m *Mesh) Name() string {
+	return goString(&m.c.mName)
+}
+
+// MaterialIndex returns the index of the material used by this mesh, see the
+// Scene.Materials method.
+func (m *Mesh) MaterialIndex() int {
+	return int(m.c.mMaterialIndex)
+}
+
+// vec3s returns a Go slice over the C array of n vectors.
+func vec3s(p *C.struct_aiVector3D, n int) []C.struct_aiVector3D {
+	return (*[1 << 26]C.struct_aiVector3D)(unsafe.Pointer(p))[:n:n]
+}
+
+func gfxVec3(v C.struct_aiVector3D) gfx.Vec3 {
+	return gfx.Vec3{float32(v.x), float32(v.y), float32(v.z)}
+}
+
+// GfxMesh converts this mesh into a new gfx.Mesh, copying the data out of
+// the memory owned by the assimp library.
+//
+// Vertices, normals, the first vertex color set and each texture coordinate
+// set are stored in the respective fields of the gfx.Mesh. Faces are stored as
+// indices, only triangles are converted so the Triangulate post processing
+// flag should be used.
+//
+// The following custom vertex attributes are stored in the Attribs map of the
+// returned mesh, if the mesh has them:
+//  "Tangent"     []gfx.Vec3 (see the CalcTangentSpace post processing flag)
+//  "Bitangent"   []gfx.Vec3 (see the CalcTangentSpace post processing flag)
+//  "BoneIndices" []gfx.Vec4 (index of the up to four most influential bones)
+//  "BoneWeights" []gfx.Vec4 (weight of the up to four most influential bones)
+func (m *Mesh) GfxMesh() *gfx.Mesh {
+	c := m.c
+	n := int(c.mNumVertices)
+	out := &gfx.Mesh{
+		Vertices:        make([]gfx.Vec3, n),
+		VerticesChanged: true,
+	}
+	for i, v := range vec3s(c.mVertices, n) {
+		out.Vertices[i] = gfxVec3(v)
+	}
+
+	if c.mNormals != nil {
+		out.Normals = make([]gfx.Vec3, n)
+		for i, v := range vec3s(c.mNormals, n) {
+			out.Normals[i] = gfxVec3(v)
+		}
+		out.NormalsChanged = true
+	}
+
+	if c.mColors[0] != nil {
+		colors := (*[1 << 26]C.struct_aiColor4D)(unsafe.Pointer(c.mColors[0]))[:n:n]
+		out.Colors = make([]gfx.Color, n)
+		for i, v := range colors {
+			out.Colors[i] = gfx.Color{float32(v.r), float32(v.g), float32(v.b), float32(v.a)}
+		}
+		out.ColorsChanged = true
+	}
+
+	for set := 0; set < C.AI_MAX_NUMBER_OF_TEXTURECOORDS; set++ {
+		if c.mTextureCoords[set] == nil {
+			break
+		}
+		tcs := gfx.TexCoordSet{
+			Slice:   make([]gfx.TexCoord, n),
+			Changed: true,
+		}
+		for i, v := range vec3s(c.mTextureCoords[set], n) {
+			tcs.Slice[i] = gfx.TexCoord{float32(v.x), float32(v.y)}
+		}
+		out.TexCoords = append(out.TexCoords, tcs)
+	}
+
+	// Faces.
+	faces := (*[1 << 26]C.struct_aiFace)(unsafe.Pointer(c.mFaces))[:c.mNumFaces:c.mNumFaces]
+	for _, f := range faces {
+		if f.mNumIndices != 3 {
+			continue
+		}
+		idx := (*[3]C.uint)(unsafe.Pointer(f.mIndices))
+		out.Indices = append(out.Indices, uint32(idx[0]), uint32(idx[1]), uint32(idx[2]))
+	}
+	out.IndicesChanged = len(out.Indices) > 0
+
+	// Tangent space.
+	attribs := make(map[string]gfx.VertexAttrib)
+	if c.mTangents != nil && c.mBitangents != nil {
+		tangents := make([]gfx.Vec3, n)
+		bitangents := make([]gfx.Vec3, n)
+		for i, v := range vec3s(c.mTangents, n) {
+			tangents[i] = gfxVec3(v)
+		}
+		for i, v := range vec3s(c.mBitangents, n) {
+			bitangents[i] = gfxVec3(v)
+		}
+		attribs["Tangent"] = gfx.VertexAttrib{Data: tangents, Changed: true}
+		attribs["Bitangent"] = gfx.VertexAttrib{Data: bitangents, Changed: true}
+	}
+
+	// Bone weights, keeping the most influential bones of each vertex.
+	if c.mNumBones > 0 {
+		var (
+			indices = make([][maxBoneInfluences]float32, n)
+			weights = make([][maxBoneInfluences]float32, n)
+			bones   = (*[1 << 26]*C.struct_aiBone)(unsafe.Pointer(c.mBones))[:c.mNumBones:c.mNumBones]
+		)
+		for b, bone := range bones {
+			vw := (*[1 << 26]C.struct_aiVertexWeight)(unsafe.Pointer(bone.mWeights))[:bone.mNumWeights:bone.mNumWeights]
+			for _, w := range vw {
+				v := int(w.mVertexId)
+				weight := float32(w.mWeight)
+
+				// Replace the least influential bone, if this one has more.
+				least := 0
+				for k := 1; k < maxBoneInfluences; k++ {
+					if weights[v][k] < weights[v][least] {
+						least = k
+					}
+				}
+				if weight > weights[v][least] {
+					indices[v][least] = float32(b)
+					weights[v][least] = weight
+				}
+			}
+		}
+		boneIndices := make([]gfx.Vec4, n)
+		boneWeights := make([]gfx.Vec4, n)
+		for v := range boneIndices {
+			i, w := indices[v], weights[v]
+			boneIndices[v] = gfx.Vec4{i[0], i[1], i[2], i[3]}
+			boneWeights[v] = gfx.Vec4{w[0], w[1], w[2], w[3]}
+		}
+		attribs["BoneIndices"] = gfx.VertexAttrib{Data: boneIndices, Changed: true}
+		attribs["BoneWeights"] = gfx.VertexAttrib{Data: boneWeights, Changed: true}
+	}
+	if len(attribs) > 0 {
+		out.Attribs = attribs
+	}
+
+	out.CalculateBounds()
+	return out
+}
@@ -0,0 +1,306 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package atlas
+
+import (
+	"azul3d.org/v1/gfx"
+	"errors"
+	"image"
+	"image/draw"
+	"sort"
+)
+
+// ErrTooLarge is returned by Pack when the images cannot be packed into an
+// atlas no larger than the maximum size.
+var ErrTooLarge = errors.New("atlas: images do not fit within maximum size")
+
+// Image is a single named image to be packed into an atlas.
+type Image struct {
+	// The name of the image, used to identify it's region.
+	Name string
+
+	// The source image.
+	Image image.Image
+}
+
+// Options specifies how images are packed into an atlas.
+type Options struct {
+	// The packing algorithm to use.
+	Algorithm Algorithm
+
+	// The number of pixels of padding between images, which avoids the
+	// texture filtering of one region bleeding into another.
+	Padding int
+
+	// Whether or not images may be rotated 90 degrees to pack them tighter.
+	Rotate bool
+
+	// Whether or not the atlas dimensions must be powers of two.
+	PowerOfTwo bool
+
+	// The maximum width and height of the atlas, if zero then a maximum of
+	// 4096 is used.
+	MaxWidth, MaxHeight int
+}
+
+// DefaultOptions are the default options used when nil options are given to
+// Pack.
+var DefaultOptions = &Options{
+	Algorithm: MaxRects,
+	Padding:   2,
+}
+
+// Region describes where a single image is stored inside an atlas.
+type Region struct {
+	// The name of the image.
+	Name string
+
+	// The rectangle of the atlas image occupied by the image. If the region
+	// is rotated then the rectangle's width and height are swapped in
+	// respect to the original image.
+	Rect image.Rectangle
+
+	// Whether or not the image is stored rotated 90 degrees counter-clockwise
+	// (as in the Spine atlas format).
+	Rotated bool
+
+	// The texture coordinates of the top-left (U, V) and bottom-right (U2, V2)
+	// corners of the rectangle.
+	U, V, U2, V2 float32
+}
+
+// Size returns the size of the original (i.e. unrotated) image.
+func (r *Region) Size() image.Point {
+	if r.Rotated {
+		return image.Pt(r.Rect.Dy(), r.Rect.Dx())
+	}
+	return r.Rect.Size()
+}
+
+// TexCoords returns the texture coordinates of the top-left, top-right,
+// bottom-right and bottom-left corners of the original image, in that order,
+// taking rotation into account.
+func (r *Region) TexCoords() [4]gfx.TexCoord {
+	if r.Rotated {
+		return [4]gfx.TexCoord{
+			{r.U, r.V2},
+			{r.U, r.V},
+			{r.U2, r.V},
+			{r.U2, r.V2},
+		}
+	}
+	return [4]gfx.TexCoord{
+		{r.U, r.V},
+		{r.U2, r.V},
+		{r.U2, r.V2},
+		{r.U, r.V2},
+	}
+}
+
+// Atlas is a single image with many smaller images packed into it.
+type Atlas struct {
+	// The atlas image.
+	Image *image.RGBA
+
+	// The regions of the atlas, in the same order as the images given to
+	// Pack.
+	Regions []*Region
+
+	byName map[string]*Region
+}
+
+// Region returns the region of the image with the given name, or nil if there
+// is no such region.
+func (a *Atlas) Region(name string) *Region {
+	return a.byName[name]
+}
+
+// Texture returns a new texture whose source is the atlas image. The texture
+// clamps at it's edges and uses linear filtering.
+func (a *Atlas) Texture() *gfx.Texture {
+	t := new(gfx.Texture)
+	t.Source = a.Image
+	t.Bounds = a.Image.Bounds()
+	t.WrapU = gfx.Clamp
+	t.WrapV = gfx.Clamp
+	t.MinFilter = gfx.Linear
+	t.MagFilter = gfx.Linear
+	return t
+}
+
+// pow2 returns the smallest power of two greater than or equal to v.
+func pow2(v int) int {
+	p := 1
+	for p < v {
+		p *= 2
+	}
+	return p
+}
+
+// Pack packs the given images into a new atlas using the given options. If
+// the options are nil then DefaultOptions are used.
+//
+// The atlas is made as small as possible: power-of-two sizes are tried in
+// order of increasing area, and (unless o.PowerOfTwo is set) the resulting
+// atlas is cropped to the area actually used.
+//
+// If the images cannot be packed into an atlas of the maximum size then
+// ErrTooLarge is returned.
+func Pack(images []Image, o *Options) (*Atlas, error) {
+	if o == nil {
+		o = DefaultOptions
+	}
+	maxW, maxH := o.MaxWidth, o.MaxHeight
+	if maxW == 0 {
+		maxW = 4096
+	}
+	if maxH == 0 {
+		maxH = 4096
+	}
+
+	// Insert larger images first, as it produces much tighter packings.
+	order := &byArea{images: images, order: make([]int, len(images))}
+	var totalArea, minW, minH int
+	for i, img := range images {
+		order.order[i] = i
+		s := img.Image.Bounds().Size()
+		totalArea += (s.X + o.Padding) * (s.Y + o.Padding)
+		if o.Rotate {
+			// Either side of the atlas must fit the short side of the image.
+			short := s.X
+			if s.Y < short {
+				short = s.Y
+			}
+			if short > minW {
+				minW, minH = short, short
+			}
+			continue
+		}
+		if s.X > minW {
+			minW = s.X
+		}
+		if s.Y > minH {
+			minH = s.Y
+		}
+	}
+	sort.Stable(order)
+
+	// Candidate atlas sizes, in order of increasing area.
+	sizes := new(bySize)
+	for w := 1; w <= pow2(maxW); w *= 2 {
+		for h := 1; h <= pow2(maxH); h *= 2 {
+			sw, sh := w, h
+			if sw > maxW {
+				sw = maxW
+			}
+			if sh > maxH {
+				sh = maxH
+			}
+			if sw*sh < totalArea || sw < minW || sh < minH {
+				continue
+			}
+			*sizes = append(*sizes, image.Pt(sw, sh))
+		}
+	}
+	sort.Stable(sizes)
+
+	for _, s := range *sizes {
+		if a := pack(images, order.order, s.X, s.Y, o); a != nil {
+			return a, nil
+		}
+	}
+	return nil, ErrTooLarge
+}
+
+// byArea sorts image indices by decreasing image area (and then perimeter).
+type byArea struct {
+	images []Image
+	order  []int
+}
+
+func (b *byArea) Len() int      { return len(b.order) }
+func (b *byArea) Swap(i, j int) { b.order[i], b.order[j] = b.order[j], b.order[i] }
+func (b *byArea) Less(i, j int) bool {
+	x := b.images[b.order[i]].Image.Bounds().Size()
+	y := b.images[b.order[j]].Image.Bounds().Size()
+	if x.X*x.Y != y.X*y.Y {
+		return x.X*x.Y > y.X*y.Y
+	}
+	return x.X+x.Y > y.X+y.Y
+}
+
+// bySize sorts atlas sizes by increasing area, preferring square-ish sizes.
+type bySize []image.Point
+
+func (b bySize) Len() int      { return len(b) }
+func (b bySize) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b bySize) Less(i, j int) bool {
+	if b[i].X*b[i].Y != b[j].X*b[j].Y {
+		return b[i].X*b[i].Y < b[j].X*b[j].Y
+	}
+	return b[i].X+b[i].Y < b[j].X+b[j].Y
+}
+
+// pack tries to pack the images into an atlas of the given size, returning
+// nil if they do not fit.
+func pack(images []Image, order []int, width, height int, o *Options) *Atlas {
+	// Padding is added to the right and bottom of each image, so the bin is
+	// enlarged by the padding to allow images to touch the right and bottom
+	// edges.
+	p := NewPacker(o.Algorithm, width+o.Padding, height+o.Padding)
+	a := &Atlas{
+		Regions: make([]*Region, len(images)),
+		byName:  make(map[string]*Region, len(images)),
+	}
+	var used image.Rectangle
+	for _, i := range order {
+		s := images[i].Image.Bounds().Size()
+		r, rotated, ok := p.Insert(s.X+o.Padding, s.Y+o.Padding, o.Rotate)
+		if !ok {
+			return nil
+		}
+		r.Max = r.Max.Sub(image.Pt(o.Padding, o.Padding))
+		used = used.Union(r)
+		a.Regions[i] = &Region{
+			Name:    images[i].Name,
+			Rect:    r,
+			Rotated: rotated,
+		}
+	}
+	if !o.PowerOfTwo {
+		width, height = used.Max.X, used.Max.Y
+	}
+
+	a.Image = image.NewRGBA(image.Rect(0, 0, width, height))
+	for i, r := range a.Regions {
+		src := images[i].Image
+		if r.Rotated {
+			drawRotated(a.Image, r.Rect.Min, src)
+		} else {
+			draw.Draw(a.Image, r.Rect, src, src.Bounds().Min, draw.Src)
+		}
+		r.U = float32(r.Rect.Min.X) / float32(width)
+		r.V = float32(r.Rect.Min.Y) / float32(height)
+		r.U2 = float32(r.Rect.Max.X) / float32(width)
+		r.V2 = float32(r.Rect.Max.Y) / float32(height)
+		if _, dup := a.byName[r.Name]; !dup {
+			a.byName[r.Name] = r
+		}
+	}
+	return a
+}
+
+// drawRotated draws the source image rotated 90 degrees counter-clockwise
+// into dst at the given point.
+func drawRotated(dst *image.RGBA, at image.Point, src image.Image) {
+	b := src.Bounds()
+	w := b.Dx()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			sx, sy := x-b.Min.X, y-b.Min.Y
+			dst.Set(at.X+sy, at.Y+w-1-sx, src.At(x, y))
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package atlas
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// randomImages returns n images of random sizes, each pixel of which encodes
+// it's image index and position so that it can be verified after packing.
+func randomImages(n int, seed int64) []Image {
+	r := rand.New(rand.NewSource(seed))
+	images := make([]Image, n)
+	for i := range images {
+		img := image.NewRGBA(image.Rect(0, 0, 1+r.Intn(40), 1+r.Intn(40)))
+		b := img.Bounds()
+		for y := 0; y < b.Dy(); y++ {
+			for x := 0; x < b.Dx(); x++ {
+				img.SetRGBA(x, y, color.RGBA{uint8(i), uint8(x), uint8(y), 255})
+			}
+		}
+		images[i] = Image{Name: string(rune('a'+i%26)) + string(rune('0'+i/26)), Image: img}
+	}
+	return images
+}
+
+func testPacker(t *testing.T, p Packer, rotate bool) {
+	r := rand.New(rand.NewSource(1))
+	bin := image.Rect(0, 0, 256, 256)
+	var placed []image.Rectangle
+	for i := 0; i < 500; i++ {
+		w, h := 1+r.Intn(30), 1+r.Intn(30)
+		rect, rotated, ok := p.Insert(w, h, rotate)
+		if !ok {
+			continue
+		}
+		if rotated && !rotate {
+			t.Fatal("rotated when rotation is not allowed")
+		}
+		if rotated {
+			w, h = h, w
+		}
+		if rect.Dx() != w || rect.Dy() != h {
+			t.Fatalf("got %v, want size %dx%d", rect, w, h)
+		}
+		if !rect.In(bin) {
+			t.Fatalf("%v is outside of the bin", rect)
+		}
+		for _, other := range placed {
+			if rect.Overlaps(other) {
+				t.Fatalf("%v overlaps %v", rect, other)
+			}
+		}
+		placed = append(placed, rect)
+	}
+	if occ := p.Occupancy(); occ < 0.75 {
+		t.Errorf("occupancy %v is too low", occ)
+	}
+}
+
+func TestMaxRects(t *testing.T) {
+	testPacker(t, NewMaxRects(256, 256), false)
+	testPacker(t, NewMaxRects(256, 256), true)
+}
+
+func TestSkyline(t *testing.T) {
+	testPacker(t, NewSkyline(256, 256), false)
+	testPacker(t, NewSkyline(256, 256), true)
+}
+
+func TestPack(t *testing.T) {
+	images := randomImages(60, 2)
+	for _, alg := range []Algorithm{MaxRects, Skyline} {
+		for _, rotate := range []bool{false, true} {
+			o := &Options{Algorithm: alg, Padding: 1, Rotate: rotate}
+			a, err := Pack(images, o)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkAtlas(t, a, images, o)
+		}
+	}
+}
+
+// checkAtlas verifies that every image of the atlas is present at it's
+// region (via the region's texture coordinates) and that regions respect the
+// padding.
+func checkAtlas(t *testing.T, a *Atlas, images []Image, o *Options) {
+	size := a.Image.Bounds().Size()
+	for i, r := range a.Regions {
+		if r.Name != images[i].Name || a.Region(r.Name) != r {
+			t.Fatalf("region %d: name %q does not match image %q", i, r.Name, images[i].Name)
+		}
+		for j, other := range a.Regions[:i] {
+			if r.Rect.Inset(-o.Padding).Overlaps(other.Rect) {
+				t.Fatalf("region %d %v is too close to region %d %v", i, r.Rect, j, other.Rect)
+			}
+		}
+
+		// Sample the center of each pixel of the original image using the
+		// region's texture coordinates.
+		tc := r.TexCoords()
+		s := r.Size()
+		if s != images[i].Image.Bounds().Size() {
+			t.Fatalf("region %d: size %v, want %v", i, s, images[i].Image.Bounds().Size())
+		}
+		for y := 0; y < s.Y; y++ {
+			for x := 0; x < s.X; x++ {
+				fx, fy := (float32(x)+0.5)/float32(s.X), (float32(y)+0.5)/float32(s.Y)
+				top := [2]float32{tc[0].U + (tc[1].U-tc[0].U)*fx, tc[0].V + (tc[1].V-tc[0].V)*fx}
+				bot := [2]float32{tc[3].U + (tc[2].U-tc[3].U)*fx, tc[3].V + (tc[2].V-tc[3].V)*fx}
+				u := top[0] + (bot[0]-top[0])*fy
+				v := top[1] + (bot[1]-top[1])*fy
+				px := int(u * float32(size.X))
+				py := int(v * float32(size.Y))
+				want := color.RGBA{uint8(i), uint8(x), uint8(y), 255}
+				if got := a.Image.RGBAAt(px, py); got != want {
+					t.Fatalf("region %d (rotated=%v) pixel (%d, %d): got %v, want %v", i, r.Rotated, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestPackPowerOfTwo(t *testing.T) {
+	a, err := Pack(randomImages(20, 3), &Options{PowerOfTwo: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := a.Image.Bounds().Size()
+	if pow2(s.X) != s.X || pow2(s.Y) != s.Y {
+		t.Fatalf("atlas size %v is not a power of two", s)
+	}
+}
+
+func TestPackTooLarge(t *testing.T) {
+	_, err := Pack(randomImages(20, 4), &Options{MaxWidth: 32, MaxHeight: 32})
+	if err != ErrTooLarge {
+		t.Fatalf("got %v, want ErrTooLarge", err)
+	}
+}
+
+func TestWriteSpine(t *testing.T) {
+	images := randomImages(3, 5)
+	a, err := Pack(images, &Options{Rotate: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Regions[0].Rotated = true
+	var buf bytes.Buffer
+	if err := a.WriteSpine(&buf, "page.png"); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	want := []string{
+		"",
+		"page.png",
+		"format: RGBA8888",
+		"filter: Linear,Linear",
+		"repeat: none",
+		a.Regions[0].Name,
+		"  rotate: true",
+	}
+	for i, w := range want {
+		if lines[i] != w {
+			t.Fatalf("line %d: got %q, want %q", i, lines[i], w)
+		}
+	}
+	if len(lines) != 5+7*3+1 {
+		t.Fatalf("got %d lines, want %d", len(lines), 5+7*3+1)
+	}
+}
+
+func BenchmarkMaxRects(b *testing.B) {
+	images := randomImages(200, 6)
+	o := &Options{Algorithm: MaxRects, Padding: 1, Rotate: true}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		Pack(images, o)
+	}
+}
+
+func BenchmarkSkyline(b *testing.B) {
+	images := randomImages(200, 6)
+	o := &Options{Algorithm: Skyline, Padding: 1, Rotate: true}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		Pack(images, o)
+	}
+}
@@ -0,0 +1,20 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package atlas implements texture atlas packing for gfx textures.
+//
+// Many small images (e.g. glyphs, sprites or tiles) are packed into a single
+// larger image using a rectangle bin-packing algorithm, such that they may be
+// drawn using a single gfx.Texture. Each packed image is described by a
+// Region, which holds it's location inside the atlas and it's texture
+// coordinates.
+//
+// Two packing algorithms are provided: MaxRects, which produces tightly packed
+// atlases, and Skyline, which is faster and well suited to incrementally
+// growing atlases (such as a glyph cache). Both are exposed via the Packer
+// interface for direct use.
+//
+// Atlases may be written in the text format used by Spine (and libgdx), such
+// that they can be read by Spine runtimes.
+package atlas
@@ -0,0 +1,109 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package atlas
+
+import "image"
+
+// MaxRectsPacker implements the maximal rectangles packing algorithm, it
+// keeps a list of the (possibly overlapping) maximal free rectangles of the
+// bin and places each new rectangle in the free rectangle that it fits best.
+type MaxRectsPacker struct {
+	bounds image.Rectangle
+	free   []image.Rectangle
+	used   int
+}
+
+// Insert implements the Packer interface.
+func (p *MaxRectsPacker) Insert(width, height int, rotate bool) (r image.Rectangle, rotated, ok bool) {
+	if width <= 0 || height <= 0 {
+		return image.Rectangle{}, false, false
+	}
+	bestShort, bestLong := -1, -1
+	try := func(f image.Rectangle, w, h int, rot bool) {
+		if w > f.Dx() || h > f.Dy() {
+			return
+		}
+		short, long := f.Dx()-w, f.Dy()-h
+		if short > long {
+			short, long = long, short
+		}
+		if bestShort < 0 || short < bestShort || (short == bestShort && long < bestLong) {
+			bestShort, bestLong = short, long
+			r = image.Rect(f.Min.X, f.Min.Y, f.Min.X+w, f.Min.Y+h)
+			rotated = rot
+		}
+	}
+	for _, f := range p.free {
+		try(f, width, height, false)
+		if rotate && width != height {
+			try(f, height, width, true)
+		}
+	}
+	if bestShort < 0 {
+		return image.Rectangle{}, false, false
+	}
+	p.place(r)
+	return r, rotated, true
+}
+
+// place marks the rectangle as used, splitting the free rectangles that it
+// overlaps.
+func (p *MaxRectsPacker) place(r image.Rectangle) {
+	p.used += area(r)
+	free := p.free[:0:0]
+	for _, f := range p.free {
+		if !f.Overlaps(r) {
+			free = append(free, f)
+			continue
+		}
+		// Split the free rectangle into up to four maximal rectangles around
+		// the used one.
+		if r.Min.X > f.Min.X {
+			free = append(free, image.Rect(f.Min.X, f.Min.Y, r.Min.X, f.Max.Y))
+		}
+		if r.Max.X < f.Max.X {
+			free = append(free, image.Rect(r.Max.X, f.Min.Y, f.Max.X, f.Max.Y))
+		}
+		if r.Min.Y > f.Min.Y {
+			free = append(free, image.Rect(f.Min.X, f.Min.Y, f.Max.X, r.Min.Y))
+		}
+		if r.Max.Y < f.Max.Y {
+			free = append(free, image.Rect(f.Min.X, r.Max.Y, f.Max.X, f.Max.Y))
+		}
+	}
+
+	// Prune free rectangles that are contained by another.
+	p.free = free[:0]
+	for i, a := range free {
+		contained := false
+		for j, b := range free {
+			if i == j {
+				continue
+			}
+			if a.In(b) && (a != b || j < i) {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			p.free = append(p.free, a)
+		}
+	}
+}
+
+// Occupancy implements the Packer interface.
+func (p *MaxRectsPacker) Occupancy() float64 {
+	return float64(p.used) / float64(area(p.bounds))
+}
+
+// NewMaxRects returns a new maximal rectangles packer for a bin of the given
+// width and height.
+func NewMaxRects(width, height int) *MaxRectsPacker {
+	b := image.Rect(0, 0, width, height)
+	return &MaxRectsPacker{
+		bounds: b,
+		free:   []image.Rectangle{b},
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package atlas
+
+import "image"
+
+// Packer is a rectangle bin-packing algorithm, which places rectangles inside
+// a fixed-size bin without any overlap.
+type Packer interface {
+	// Insert finds space for a rectangle of the given width and height and
+	// returns it's location inside the bin. If rotate is true the rectangle
+	// may be rotated 90 degrees, in which case rotated is true and the
+	// returned rectangle has it's width and height swapped.
+	//
+	// If there is no space left for the rectangle then ok is false.
+	Insert(width, height int, rotate bool) (r image.Rectangle, rotated, ok bool)
+
+	// Occupancy returns the ratio of the bin's area that is used by inserted
+	// rectangles, in the range of zero to one.
+	Occupancy() float64
+}
+
+// Algorithm specifies a single rectangle packing algorithm.
+type Algorithm uint8
+
+const (
+	// MaxRects is the maximal rectangles algorithm using the best short side
+	// fit heuristic.
+	MaxRects Algorithm = iota
+
+	// Skyline is the skyline algorithm using the bottom-left heuristic.
+	Skyline
+)
+
+// String returns a string representation of this algorithm.
+//
+// For example: MaxRects -> "MaxRects", Skyline -> "Skyline".
+func (a Algorithm) String() string {
+	switch a {
+	case MaxRects:
+		return "MaxRects"
+	case Skyline:
+		return "Skyline"
+	}
+	return "Algorithm(invalid)"
+}
+
+// NewPacker returns a new packer using the given algorithm for a bin of the
+// given width and height.
+func NewPacker(a Algorithm, width, height int) Packer {
+	if a == Skyline {
+		return NewSkyline(width, height)
+	}
+	return NewMaxRects(width, height)
+}
+
+// area returns the area of the rectangle.
+func area(r image.Rectangle) int {
+	return r.Dx() * r.Dy()
+}
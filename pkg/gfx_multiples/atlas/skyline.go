@@ -0,0 +1,123 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package atlas
+
+import "image"
+
+// segment is a single horizontal segment of a skyline.
+type segment struct {
+	x, y, width int
+}
+
+// SkylinePacker implements the skyline packing algorithm, it keeps track of
+// only the top edge (the skyline) of the rectangles placed so far and places
+// each new rectangle as low as possible on top of it.
+//
+// It is faster than MaxRectsPacker but wastes the space below the skyline,
+// making it well suited to packing rectangles of similar height (e.g. glyphs).
+type SkylinePacker struct {
+	bounds  image.Rectangle
+	skyline []segment
+	used    int
+}
+
+// fit returns the Y position at which a rectangle of the given width would be
+// placed on the skyline starting at segment i, or -1 if it doesn't fit.
+func (p *SkylinePacker) fit(i, width, height int) int {
+	x := p.skyline[i].x
+	if x+width > p.bounds.Max.X {
+		return -1
+	}
+	y := 0
+	for left := width; left > 0; i++ {
+		if p.skyline[i].y > y {
+			y = p.skyline[i].y
+		}
+		left -= p.skyline[i].width
+	}
+	if y+height > p.bounds.Max.Y {
+		return -1
+	}
+	return y
+}
+
+// Insert implements the Packer interface.
+func (p *SkylinePacker) Insert(width, height int, rotate bool) (r image.Rectangle, rotated, ok bool) {
+	if width <= 0 || height <= 0 {
+		return image.Rectangle{}, false, false
+	}
+	best, bestTop, bestWidth := -1, 0, 0
+	try := func(w, h int, rot bool) {
+		for i, s := range p.skyline {
+			y := p.fit(i, w, h)
+			if y < 0 {
+				continue
+			}
+			if best < 0 || y+h < bestTop || (y+h == bestTop && s.width < bestWidth) {
+				best, bestTop, bestWidth = i, y+h, s.width
+				r = image.Rect(s.x, y, s.x+w, y+h)
+				rotated = rot
+			}
+		}
+	}
+	try(width, height, false)
+	if rotate && width != height {
+		try(height, width, true)
+	}
+	if best < 0 {
+		return image.Rectangle{}, false, false
+	}
+	p.place(best, r)
+	return r, rotated, true
+}
+
+// place adds the rectangle, whose left edge is at skyline segment i, to the
+// skyline.
+func (p *SkylinePacker) place(i int, r image.Rectangle) {
+	p.used += area(r)
+	s := segment{r.Min.X, r.Max.Y, r.Dx()}
+	p.skyline = append(p.skyline, segment{})
+	copy(p.skyline[i+1:], p.skyline[i:])
+	p.skyline[i] = s
+
+	// Shrink or remove the segments now covered by the new one.
+	for j := i + 1; j < len(p.skyline); {
+		seg := &p.skyline[j]
+		if seg.x >= s.x+s.width {
+			break
+		}
+		shrink := s.x + s.width - seg.x
+		seg.x += shrink
+		seg.width -= shrink
+		if seg.width > 0 {
+			break
+		}
+		p.skyline = append(p.skyline[:j], p.skyline[j+1:]...)
+	}
+
+	// Merge neighbouring segments of equal height.
+	for j := 0; j+1 < len(p.skyline); {
+		if p.skyline[j].y == p.skyline[j+1].y {
+			p.skyline[j].width += p.skyline[j+1].width
+			p.skyline = append(p.skyline[:j+1], p.skyline[j+2:]...)
+			continue
+		}
+		j++
+	}
+}
+
+// Occupancy implements the Packer interface.
+func (p *SkylinePacker) Occupancy() float64 {
+	return float64(p.used) / float64(area(p.bounds))
+}
+
+// NewSkyline returns a new skyline packer for a bin of the given width and
+// height.
+func NewSkyline(width, height int) *SkylinePacker {
+	return &SkylinePacker{
+		bounds:  image.Rect(0, 0, width, height),
+		skyline: []segment{{0, 0, width}},
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package atlas
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteSpine writes the atlas in the text format used by Spine (and libgdx)
+// .atlas files to w. The atlas image itself is not written, pageName is the
+// file name that it should be saved under (e.g. "atlas.png").
+//
+// The page size line of newer versions of the format is omitted, as Spine
+// runtimes determine the page size by loading the page image.
+func (a *Atlas) WriteSpine(w io.Writer, pageName string) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "\n%s\n", pageName)
+	fmt.Fprintf(bw, "format: RGBA8888\n")
+	fmt.Fprintf(bw, "filter: Linear,Linear\n")
+	fmt.Fprintf(bw, "repeat: none\n")
+	for _, r := range a.Regions {
+		s := r.Size()
+		fmt.Fprintf(bw, "%s\n", strings.TrimSpace(r.Name))
+		fmt.Fprintf(bw, "  rotate: %t\n", r.Rotated)
+		fmt.Fprintf(bw, "  xy: %d, %d\n", r.Rect.Min.X, r.Rect.Min.Y)
+		fmt.Fprintf(bw, "  size: %d, %d\n", s.X, s.Y)
+		fmt.Fprintf(bw, "  orig: %d, %d\n", s.X, s.Y)
+		fmt.Fprintf(bw, "  offset: 0, 0\n")
+		fmt.Fprintf(bw, "  index: -1\n")
+	}
+	return bw.Flush()
+}
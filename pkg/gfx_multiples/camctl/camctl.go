@@ -0,0 +1,119 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package camctl implements camera controllers for gfx cameras.
+//
+// Controllers move and rotate a camera's transform in response to abstract
+// input (e.g. mouse movement and key presses that have been mapped to look,
+// move and zoom deltas) and time steps, such that they can be driven by any
+// input system and tested without a window.
+//
+// Three controllers are provided:
+//  Orbit  - rotates and zooms around a target point (e.g. a model viewer).
+//  Fly    - free movement, like a first person spectator camera.
+//  Follow - smoothly follows behind a target transform (third person).
+//
+// Rotations use the Z-up right-handed coordinate system of gfx: the camera
+// looks down it's +Y axis, yaw is the rotation about the Z axis (positive
+// values turn left) and pitch is the rotation about the X axis (positive
+// values look up), both in degrees.
+package camctl
+
+import (
+	"azul3d.org/v1/gfx"
+	gmath "azul3d.org/v1/math"
+	"math"
+)
+
+// Input is the abstract input to a controller for a single time step.
+type Input struct {
+	// Look is the change in yaw (X) and pitch (Y) in degrees, e.g. the mouse
+	// movement multiplied by a sensitivity. It is not scaled by the time
+	// step.
+	Look gmath.Vec2
+
+	// Move is the desired movement direction relative to the camera: X is
+	// right, Y is forward and Z is up. Each component is typically in the
+	// range of -1 to +1 (e.g. from key presses or a joystick) and is scaled by
+	// the controller's speed and the time step.
+	Move gmath.Vec3
+
+	// Zoom is the change in zoom, positive values zoom in (e.g. the mouse
+	// wheel). It is not scaled by the time step.
+	Zoom float64
+}
+
+// Controller is a camera controller.
+type Controller interface {
+	// Update updates the controller's state using the given input for a time
+	// step of dt seconds, and then applies it to the transform of the given
+	// camera.
+	//
+	// The camera's read lock must be held for this method to operate safely
+	// (the camera's transform itself is safe for concurrent use).
+	Update(c *gfx.Camera, in Input, dt float64)
+}
+
+// Forward returns the normalized forward direction of a camera with the
+// given yaw and pitch in degrees.
+func Forward(yaw, pitch float64) gmath.Vec3 {
+	y, p := yaw*math.Pi/180, pitch*math.Pi/180
+	return gmath.Vec3{
+		-math.Sin(y) * math.Cos(p),
+		math.Cos(y) * math.Cos(p),
+		math.Sin(p),
+	}
+}
+
+// Right returns the normalized right direction of a camera with the given
+// yaw in degrees (it is always horizontal).
+func Right(yaw float64) gmath.Vec3 {
+	y := yaw * math.Pi / 180
+	return gmath.Vec3{math.Cos(y), math.Sin(y), 0}
+}
+
+// YawPitch returns the yaw and pitch in degrees of a camera looking in the
+// given direction. If the direction is straight up or down the yaw is zero.
+func YawPitch(dir gmath.Vec3) (yaw, pitch float64) {
+	horiz := math.Sqrt(dir.X*dir.X + dir.Y*dir.Y)
+	if horiz != 0 {
+		yaw = math.Atan2(-dir.X, dir.Y) * 180 / math.Pi
+	}
+	pitch = math.Atan2(dir.Z, horiz) * 180 / math.Pi
+	return
+}
+
+// LookAt positions the transform at eye and rotates it such that it looks at
+// the target point.
+func LookAt(t *gfx.Transform, eye, target gmath.Vec3) {
+	yaw, pitch := YawPitch(target.Sub(eye))
+	apply(t, eye, yaw, pitch)
+}
+
+// apply sets the position and rotation of the transform.
+func apply(t *gfx.Transform, pos gmath.Vec3, yaw, pitch float64) {
+	t.SetPos(pos)
+	t.SetRot(gmath.Vec3{pitch, 0, yaw})
+}
+
+// clamp clamps v to the range of min to max.
+func clamp(v, min, max float64) float64 {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// wrapYaw wraps the yaw angle into the range of -180 to +180 degrees, to
+// avoid losing precision as it grows.
+func wrapYaw(yaw float64) float64 {
+	yaw = math.Mod(yaw+180, 360)
+	if yaw < 0 {
+		yaw += 360
+	}
+	return yaw - 180
+}
@@ -0,0 +1,152 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camctl
+
+import (
+	"azul3d.org/v1/gfx"
+	"azul3d.org/v1/math"
+	"testing"
+)
+
+func near(a, b math.Vec3) bool {
+	return a.Sub(b).Length() < 1e-6
+}
+
+// viewDir returns the world space direction that the transform looks in
+// (i.e. it's +Y axis).
+func viewDir(t *gfx.Transform) math.Vec3 {
+	m := t.Mat4()
+	origin := math.Vec3Zero.TransformMat4(m)
+	d, _ := math.Vec3{0, 1, 0}.TransformMat4(m).Sub(origin).Normalized()
+	return d
+}
+
+func TestForward(t *testing.T) {
+	tf := gfx.NewTransform()
+	for _, yp := range [][2]float64{{0, 0}, {90, 0}, {-45, 30}, {170, -80}} {
+		apply(tf, math.Vec3Zero, yp[0], yp[1])
+		want := Forward(yp[0], yp[1])
+		if got := viewDir(tf); !near(got, want) {
+			t.Errorf("yaw=%v pitch=%v: transform looks %v, Forward = %v", yp[0], yp[1], got, want)
+		}
+		yaw, pitch := YawPitch(want)
+		if !near(math.Vec3{yaw, pitch, 0}, math.Vec3{yp[0], yp[1], 0}) {
+			t.Errorf("YawPitch(%v) = %v, %v, want %v", want, yaw, pitch, yp)
+		}
+	}
+	if !near(Forward(90, 0), math.Vec3{-1, 0, 0}) {
+		t.Errorf("positive yaw should turn left, got %v", Forward(90, 0))
+	}
+	if !near(Right(0), math.Vec3{1, 0, 0}) {
+		t.Errorf("Right(0) = %v", Right(0))
+	}
+}
+
+func TestOrbit(t *testing.T) {
+	c := gfx.NewCamera()
+	target := math.Vec3{1, 2, 3}
+	o := NewOrbit(target, 10)
+	o.Update(c, Input{Look: math.Vec2{30, 200}}, 0.1)
+	if o.Pitch != o.MaxPitch {
+		t.Errorf("pitch %v not clamped to %v", o.Pitch, o.MaxPitch)
+	}
+	pos := c.Transform.Pos()
+	if d := pos.Sub(target).Length(); d < 10-1e-6 || d > 10+1e-6 {
+		t.Errorf("distance %v, want 10", d)
+	}
+	want, _ := target.Sub(pos).Normalized()
+	if got := viewDir(c.Transform); !near(got, want) {
+		t.Errorf("looking %v, want %v (at target)", got, want)
+	}
+
+	o.Update(c, Input{Zoom: 5}, 0.1)
+	if o.Distance != 5 {
+		t.Errorf("zoomed distance %v, want 5", o.Distance)
+	}
+	o.Update(c, Input{Zoom: 100}, 0.1)
+	if o.Distance != o.MinDistance {
+		t.Errorf("zoomed distance %v, want clamped to %v", o.Distance, o.MinDistance)
+	}
+
+	o = NewOrbit(target, 10)
+	o.Update(c, Input{Move: math.Vec3{1, 0, 0}}, 0.5)
+	if !near(o.Target, target.Add(math.Vec3{5, 0, 0})) {
+		t.Errorf("panned target %v", o.Target)
+	}
+}
+
+func TestFly(t *testing.T) {
+	c := gfx.NewCamera()
+	f := NewFly(math.Vec3Zero)
+	f.Speed = 2
+	f.Update(c, Input{Move: math.Vec3{0, 1, 0}}, 0.5)
+	if !near(c.Transform.Pos(), math.Vec3{0, 1, 0}) {
+		t.Errorf("moved to %v, want forward by 1", c.Transform.Pos())
+	}
+	f.Update(c, Input{Look: math.Vec2{90, 0}}, 0.5)
+	f.Update(c, Input{Move: math.Vec3{0, 1, 1}}, 0.5)
+	if !near(c.Transform.Pos(), math.Vec3{-1, 1, 1}) {
+		t.Errorf("moved to %v, want {-1, 1, 1}", c.Transform.Pos())
+	}
+	if !near(viewDir(c.Transform), math.Vec3{-1, 0, 0}) {
+		t.Errorf("looking %v, want -X", viewDir(c.Transform))
+	}
+	f.Update(c, Input{Zoom: 1}, 0)
+	if f.Speed != 2*f.SpeedStep {
+		t.Errorf("speed %v, want %v", f.Speed, 2*f.SpeedStep)
+	}
+	f.Update(c, Input{Zoom: -1}, 0)
+	if s := f.Speed; s < 2-1e-9 || s > 2+1e-9 {
+		t.Errorf("speed %v, want 2", s)
+	}
+}
+
+func TestFollow(t *testing.T) {
+	c := gfx.NewCamera()
+	target := gfx.NewTransform()
+	f := NewFollow(target, math.Vec3{0, -10, 0})
+
+	// The first update snaps into place.
+	f.Update(c, Input{}, 0.1)
+	if !near(c.Transform.Pos(), math.Vec3{0, -10, 0}) {
+		t.Fatalf("initial position %v", c.Transform.Pos())
+	}
+
+	// After the target moves the camera lags behind, but catches up.
+	target.SetPos(math.Vec3{0, 10, 0})
+	f.Update(c, Input{}, 0.1)
+	pos := c.Transform.Pos()
+	if pos.Y <= -10 || pos.Y >= 0 {
+		t.Errorf("smoothed position %v, want between -10 and 0", pos)
+	}
+	if !near(viewDir(c.Transform), math.Vec3{0, 1, 0}) {
+		t.Errorf("looking %v, want at target", viewDir(c.Transform))
+	}
+	for i := 0; i < 100; i++ {
+		f.Update(c, Input{}, 0.1)
+	}
+	if !near(c.Transform.Pos(), math.Vec3{0, 0, 0}) {
+		t.Errorf("final position %v, want {0, 0, 0}", c.Transform.Pos())
+	}
+
+	// Follows the target's rotation.
+	target.SetRot(math.Vec3{0, 0, 90})
+	f.Reset()
+	f.Update(c, Input{}, 0.1)
+	if !near(c.Transform.Pos(), math.Vec3{10, 10, 0}) {
+		t.Errorf("rotated position %v, want {10, 10, 0}", c.Transform.Pos())
+	}
+
+	// Zooming in is limited to the minimum distance, and can be undone.
+	f.Stiffness = 0
+	f.Update(c, Input{Zoom: 20}, 0.1)
+	if !near(f.Offset, math.Vec3{0, -0.01, 0}) {
+		t.Fatalf("zoomed in offset %v, want {0, -0.01, 0}", f.Offset)
+	}
+	f.Update(c, Input{Zoom: -10}, 0.1)
+	if !near(f.Offset, math.Vec3{0, -0.02, 0}) {
+		t.Fatalf("zoomed out offset %v, want {0, -0.02, 0}", f.Offset)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camctl
+
+import (
+	"azul3d.org/v1/gfx"
+	"azul3d.org/v1/math"
+)
+
+// Fly is a free-fly controller, such as a first person spectator camera.
+//
+// Look input rotates the camera, move input moves it forward along the view
+// direction, right, and up along the world Z axis. Zoom input changes the
+// speed.
+type Fly struct {
+	// The current position of the camera.
+	Pos math.Vec3
+
+	// The current yaw and pitch of the camera in degrees, and the range that
+	// pitch is limited to.
+	Yaw, Pitch, MinPitch, MaxPitch float64
+
+	// The movement speed in units per second.
+	Speed float64
+
+	// SpeedStep is the factor that the speed is multiplied (or divided, for
+	// negative input) by for each unit of zoom input.
+	SpeedStep float64
+}
+
+// Update implements the Controller interface.
+func (f *Fly) Update(c *gfx.Camera, in Input, dt float64) {
+	f.Yaw = wrapYaw(f.Yaw + in.Look.X)
+	f.Pitch = clamp(f.Pitch+in.Look.Y, f.MinPitch, f.MaxPitch)
+
+	switch {
+	case in.Zoom > 0:
+		f.Speed *= 1 + in.Zoom*(f.SpeedStep-1)
+	case in.Zoom < 0:
+		f.Speed /= 1 - in.Zoom*(f.SpeedStep-1)
+	}
+
+	if in.Move != math.Vec3Zero {
+		move := Right(f.Yaw).MulScalar(in.Move.X)
+		move = move.Add(Forward(f.Yaw, f.Pitch).MulScalar(in.Move.Y))
+		move = move.Add(math.Vec3{0, 0, in.Move.Z})
+		f.Pos = f.Pos.Add(move.MulScalar(f.Speed * dt))
+	}
+	apply(c.Transform, f.Pos, f.Yaw, f.Pitch)
+}
+
+// NewFly returns a new free-fly controller at the given position, with default
+// limits and speeds.
+func NewFly(pos math.Vec3) *Fly {
+	return &Fly{
+		Pos:       pos,
+		MinPitch:  -89,
+		MaxPitch:  89,
+		Speed:     5,
+		SpeedStep: 1.25,
+	}
+}
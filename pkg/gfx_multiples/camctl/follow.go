@@ -0,0 +1,113 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camctl
+
+import (
+	"azul3d.org/v1/gfx"
+	gmath "azul3d.org/v1/math"
+	"math"
+)
+
+// Follow is a smoothed third-person controller that follows a target
+// transform, such as a player character.
+//
+// The camera is positioned at an offset in the target's local space (e.g.
+// behind and above it) and looks at a point in the target's local space. Look
+// input orbits the offset around the target, zoom input moves the offset
+// towards or away from the look-at point.
+type Follow struct {
+	// The transform to follow.
+	Target *gfx.Transform
+
+	// The desired position of the camera, in the target's local space.
+	Offset gmath.Vec3
+
+	// The point to look at, in the target's local space.
+	LookAt gmath.Vec3
+
+	// Stiffness controls how quickly the camera catches up with it's desired
+	// position: each second the remaining distance shrinks by a factor of
+	// e^Stiffness. Zero (or less) makes the camera snap to it's desired
+	// position.
+	Stiffness float64
+
+	// ZoomSpeed is the fraction of the offset's distance from the look-at
+	// point that one unit of zoom input moves the camera (e.g. 0.1 for 10%).
+	ZoomSpeed float64
+
+	// The range that zooming limits the offset's distance from the look-at
+	// point to.
+	MinDistance, MaxDistance float64
+
+	// The current yaw and pitch offsets (from look input) in degrees.
+	Yaw, Pitch float64
+
+	pos    gmath.Vec3
+	placed bool
+}
+
+// Update implements the Controller interface.
+func (f *Follow) Update(c *gfx.Camera, in Input, dt float64) {
+	f.Yaw = wrapYaw(f.Yaw + in.Look.X)
+	f.Pitch = clamp(f.Pitch+in.Look.Y, -89, 89)
+	if in.Zoom != 0 {
+		rel := f.Offset.Sub(f.LookAt)
+		if dist := rel.Length(); dist > 0 {
+			zoomed := dist - dist*in.Zoom*f.ZoomSpeed
+			zoomed = clamp(zoomed, f.MinDistance, f.MaxDistance)
+			f.Offset = f.LookAt.Add(rel.MulScalar(zoomed / dist))
+		}
+	}
+
+	desired, look := f.Desired()
+	if !f.placed || f.Stiffness <= 0 {
+		f.pos = desired
+		f.placed = true
+	} else {
+		alpha := 1 - math.Exp(-f.Stiffness*dt)
+		f.pos = f.pos.Add(desired.Sub(f.pos).MulScalar(alpha))
+	}
+	LookAt(c.Transform, f.pos, look)
+}
+
+// Desired returns the world space position that the camera is moving towards
+// and the world space point that it looks at.
+func (f *Follow) Desired() (pos, look gmath.Vec3) {
+	// Orbit the offset around the look-at point by the yaw and pitch offsets.
+	offset := f.Offset.Sub(f.LookAt)
+	yaw, pitch := YawPitch(offset)
+	dist := offset.Length()
+	offset = Forward(yaw+f.Yaw, clamp(pitch+f.Pitch, -89, 89)).MulScalar(dist)
+	local := f.LookAt.Add(offset)
+
+	pos = f.Target.ConvertPos(local, gfx.LocalToWorld)
+	look = f.Target.ConvertPos(f.LookAt, gfx.LocalToWorld)
+	return
+}
+
+// Pos returns the current (smoothed) world space position of the camera.
+func (f *Follow) Pos() gmath.Vec3 {
+	return f.pos
+}
+
+// Reset makes the camera snap to it's desired position on the next update,
+// e.g. after the target has teleported.
+func (f *Follow) Reset() {
+	f.placed = false
+}
+
+// NewFollow returns a new follow controller for the given target transform,
+// positioned at the given offset in the target's local space and looking at
+// the target's origin, with default limits and speeds.
+func NewFollow(target *gfx.Transform, offset gmath.Vec3) *Follow {
+	return &Follow{
+		Target:      target,
+		Offset:      offset,
+		Stiffness:   5,
+		ZoomSpeed:   0.1,
+		MinDistance: 0.01,
+		MaxDistance: 1e6,
+	}
+}
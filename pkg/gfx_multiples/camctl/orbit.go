@@ -0,0 +1,77 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package camctl
+
+import (
+	"azul3d.org/v1/gfx"
+	"azul3d.org/v1/math"
+)
+
+// Orbit is a controller that rotates the camera around a target point at a
+// distance, such as in a model viewer.
+//
+// Look input rotates around the target, zoom input moves towards or away from
+// the target, and move input pans the target relative to the camera's view.
+type Orbit struct {
+	// The point that the camera looks at and orbits around.
+	Target math.Vec3
+
+	// The distance of the camera from the target, and the range that zooming
+	// is limited to.
+	Distance, MinDistance, MaxDistance float64
+
+	// The current yaw and pitch of the camera in degrees, and the range that
+	// pitch is limited to.
+	Yaw, Pitch, MinPitch, MaxPitch float64
+
+	// ZoomSpeed is the fraction of the distance that one unit of zoom input
+	// moves the camera (e.g. 0.1 for 10%).
+	ZoomSpeed float64
+
+	// PanSpeed is the speed at which move input pans the target, in units of
+	// distance per second (i.e. panning is faster when zoomed out).
+	PanSpeed float64
+}
+
+// Update implements the Controller interface.
+func (o *Orbit) Update(c *gfx.Camera, in Input, dt float64) {
+	o.Yaw = wrapYaw(o.Yaw + in.Look.X)
+	o.Pitch = clamp(o.Pitch+in.Look.Y, o.MinPitch, o.MaxPitch)
+
+	o.Distance -= o.Distance * in.Zoom * o.ZoomSpeed
+	o.Distance = clamp(o.Distance, o.MinDistance, o.MaxDistance)
+
+	if in.Move != math.Vec3Zero {
+		fwd := Forward(o.Yaw, o.Pitch)
+		right := Right(o.Yaw)
+		up := right.Cross(fwd)
+		pan := right.MulScalar(in.Move.X)
+		pan = pan.Add(fwd.MulScalar(in.Move.Y))
+		pan = pan.Add(up.MulScalar(in.Move.Z))
+		o.Target = o.Target.Add(pan.MulScalar(o.PanSpeed * o.Distance * dt))
+	}
+	apply(c.Transform, o.Eye(), o.Yaw, o.Pitch)
+}
+
+// Eye returns the position of the camera given the current target, distance,
+// yaw and pitch.
+func (o *Orbit) Eye() math.Vec3 {
+	return o.Target.Sub(Forward(o.Yaw, o.Pitch).MulScalar(o.Distance))
+}
+
+// NewOrbit returns a new orbit controller around the given target at the given
+// distance, with default limits and speeds.
+func NewOrbit(target math.Vec3, distance float64) *Orbit {
+	return &Orbit{
+		Target:      target,
+		Distance:    distance,
+		MinDistance: 0.01,
+		MaxDistance: 1e6,
+		MinPitch:    -89,
+		MaxPitch:    89,
+		ZoomSpeed:   0.1,
+		PanSpeed:    1,
+	}
+}
@@ -70,17 +70,23 @@ func (c *Camera) SetPersp(view image.Rectangle, fov, near, far float64) {
 	c.Projection = ConvertMat4(m)
 }
 
+// ViewProjection returns the matrix which transforms world coordinates into
+// clip space coordinates for this camera: the inverse of the camera's
+// transformation, the Z-up to Y-up coordinate system conversion, and the
+// projection matrix (in that order, as used by renderers).
+func (c *Camera) ViewProjection() math.Mat4 {
+	cameraInv, _ := c.Object.Transform.Mat4().Inverse()
+	cameraInv = cameraInv.Mul(zUpRightToYUpRight)
+	return cameraInv.Mul(c.Projection.Mat4())
+}
+
 // Project returns a 2D point in normalized device space coordinates given a 3D
 // point in the world.
 //
 // If ok=false is returned then the point is outside of the camera's view and
 // the returned point may not be meaningful.
 func (c *Camera) Project(p3 math.Vec3) (p2 math.Vec2, ok bool) {
-	cameraInv, _ := c.Object.Transform.Mat4().Inverse()
-	cameraInv = cameraInv.Mul(zUpRightToYUpRight)
-
-	projection := c.Projection.Mat4()
-	vp := cameraInv.Mul(projection)
+	vp := c.ViewProjection()
 
 	p4 := math.Vec4{p3.X, p3.Y, p3.Z, 1.0}
 	p4 = p4.Transform(vp)
@@ -99,6 +105,153 @@ func (c *Camera) Project(p3 math.Vec3) (p2 math.Vec2, ok bool) {
 	return
 }
 
+// Unproject returns the 3D point in the world given a point in normalized
+// device space coordinates, whose Z component is the depth (-1 at the near
+// clipping plane, +1 at the far clipping plane).
+//
+// If ok=false is returned then the camera's view-projection matrix is not
+// invertible (e.g. a zero projection matrix) and the returned point is not
+// meaningful.
+func (c *Camera) Unproject(ndc math.Vec3) (p3 math.Vec3, ok bool) {
+	inv, ok := c.ViewProjection().Inverse()
+	if !ok {
+		return math.Vec3Zero, false
+	}
+	p4 := math.Vec4{ndc.X, ndc.Y, ndc.Z, 1.0}
+	p4 = p4.Transform(inv)
+	if p4.W == 0 {
+		return math.Vec3Zero, false
+	}
+	recipW := 1.0 / p4.W
+	return math.Vec3{p4.X * recipW, p4.Y * recipW, p4.Z * recipW}, true
+}
+
+// WindowToNDC converts the given point in window coordinates (e.g. the mouse
+// cursor position, where Y increases downwards) into normalized device space
+// coordinates (where Y increases upwards) for the given viewing rectangle.
+//
+// Integer window coordinates are treated as the top-left corner of a pixel,
+// use p+0.5 to refer to a pixel's center.
+func WindowToNDC(view image.Rectangle, p math.Vec2) math.Vec2 {
+	w, h := float64(view.Dx()), float64(view.Dy())
+	return math.Vec2{
+		(p.X-float64(view.Min.X))/w*2 - 1,
+		1 - (p.Y-float64(view.Min.Y))/h*2,
+	}
+}
+
+// NDCToWindow converts the given point in normalized device space
+// coordinates into window coordinates for the given viewing rectangle. It is
+// the inverse of WindowToNDC.
+func NDCToWindow(view image.Rectangle, p math.Vec2) math.Vec2 {
+	w, h := float64(view.Dx()), float64(view.Dy())
+	return math.Vec2{
+		float64(view.Min.X) + (p.X+1)/2*w,
+		float64(view.Min.Y) + (1-p.Y)/2*h,
+	}
+}
+
+// ProjectWindow is short-hand for projecting the 3D world point using the
+// Project method and converting the result into window coordinates for the
+// given viewing rectangle using NDCToWindow.
+func (c *Camera) ProjectWindow(view image.Rectangle, p3 math.Vec3) (p2 math.Vec2, ok bool) {
+	p2, ok = c.Project(p3)
+	return NDCToWindow(view, p2), ok
+}
+
+// Ray returns the world space ray that starts at the camera's near clipping
+// plane and passes through the given point in window coordinates (see
+// WindowToNDC) of the given viewing rectangle, e.g. for picking objects under
+// the mouse cursor. The ray's direction is normalized.
+//
+// This works for both perspective and orthographic projections. If ok=false
+// is returned then the camera's matrices are not invertible and the returned
+// ray is not meaningful.
+func (c *Camera) Ray(view image.Rectangle, p math.Vec2) (r Ray, ok bool) {
+	ndc := WindowToNDC(view, p)
+	near, ok := c.Unproject(math.Vec3{ndc.X, ndc.Y, -1})
+	if !ok {
+		return
+	}
+	far, ok := c.Unproject(math.Vec3{ndc.X, ndc.Y, 1})
+	if !ok {
+		return
+	}
+	dir, ok := far.Sub(near).Normalized()
+	return Ray{Origin: near, Dir: dir}, ok
+}
+
+// Frustum returns the six world space planes of the camera's viewing
+// frustum.
+func (c *Camera) Frustum() Frustum {
+	return FrustumFromMat4(c.ViewProjection())
+}
+
+// FrustumCorners returns the eight world space corner points of the camera's
+// viewing frustum. The first four are on the near plane and the last four on
+// the far plane, each in the order bottom-left, bottom-right, top-right and
+// top-left.
+//
+// If ok=false is returned then the camera's matrices are not invertible and
+// the returned corners are not meaningful.
+func (c *Camera) FrustumCorners() (corners [8]math.Vec3, ok bool) {
+	inv, ok := c.ViewProjection().Inverse()
+	if !ok {
+		return
+	}
+	i := 0
+	for _, z := range []float64{-1, 1} {
+		for _, xy := range [4][2]float64{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}} {
+			p4 := math.Vec4{xy[0], xy[1], z, 1.0}.Transform(inv)
+			if p4.W == 0 {
+				return corners, false
+			}
+			recipW := 1.0 / p4.W
+			corners[i] = math.Vec3{p4.X * recipW, p4.Y * recipW, p4.Z * recipW}
+			i++
+		}
+	}
+	return corners, true
+}
+
+// FrustumBounds returns the world space axis-aligned bounding box of the
+// camera's viewing frustum. If the camera's matrices are not invertible then
+// an empty rectangle is returned.
+func (c *Camera) FrustumBounds() math.Rect3 {
+	corners, ok := c.FrustumCorners()
+	if !ok {
+		return math.Rect3Zero
+	}
+	b := math.Rect3{corners[0], corners[0]}
+	for _, p := range corners[1:] {
+		b.Min = b.Min.Min(p)
+		b.Max = b.Max.Max(p)
+	}
+	return b
+}
+
+// FrustumSphere returns a world space sphere enclosing the camera's viewing
+// frustum, centered at the center of the frustum's corners. If the camera's
+// matrices are not invertible then a zero sphere is returned.
+func (c *Camera) FrustumSphere() math.Sphere {
+	corners, ok := c.FrustumCorners()
+	if !ok {
+		return math.Sphere{}
+	}
+	var center math.Vec3
+	for _, p := range corners {
+		center = center.Add(p)
+	}
+	center = center.MulScalar(1.0 / 8.0)
+	var radius float64
+	for _, p := range corners {
+		if d := p.Sub(center).Length(); d > radius {
+			radius = d
+		}
+	}
+	return math.Sphere{Center: center, Radius: radius}
+}
+
 // NewCamera returns a new *Camera with the default values.
 func NewCamera() *Camera {
 	return &Camera{
@@ -0,0 +1,156 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gfx
+
+import (
+	"azul3d.org/v1/math"
+	"image"
+	"testing"
+)
+
+// testCamera returns a perspective camera at (1, -10, 2) looking down the +Y
+// axis (the forward axis in Z-up right-handed space).
+func testCamera(view image.Rectangle) *Camera {
+	c := NewCamera()
+	c.SetPersp(view, 75, 0.1, 100)
+	c.Transform.SetPos(math.Vec3{1, -10, 2})
+	return c
+}
+
+func vecNear(a, b math.Vec3, epsilon float64) bool {
+	d := a.Sub(b)
+	return d.X*d.X+d.Y*d.Y+d.Z*d.Z < epsilon*epsilon
+}
+
+func TestCameraUnproject(t *testing.T) {
+	view := image.Rect(0, 0, 640, 480)
+	c := testCamera(view)
+	for _, p := range []math.Vec3{
+		{1, 0, 2},
+		{3, 5, -1},
+		{-2, 40, 7},
+	} {
+		ndc, ok := c.Project(p)
+		if !ok {
+			t.Fatalf("Project(%v) not ok", p)
+		}
+		// Find the depth of the point by marching along the ray through it.
+		r, ok := c.Ray(view, NDCToWindow(view, ndc))
+		if !ok {
+			t.Fatal("Ray not ok")
+		}
+		dist := p.Sub(r.Origin).Length()
+		if got := r.At(dist); !vecNear(got, p, 1e-6) {
+			t.Errorf("ray through %v: got %v", p, got)
+		}
+	}
+
+	// The center of the view is straight ahead.
+	r, _ := c.Ray(view, math.Vec2{320, 240})
+	if !vecNear(r.Dir, math.Vec3{0, 1, 0}, 1e-9) {
+		t.Errorf("center ray direction %v, want +Y", r.Dir)
+	}
+	if !vecNear(r.Origin, math.Vec3{1, -9.9, 2}, 1e-6) {
+		t.Errorf("center ray origin %v, want on the near plane", r.Origin)
+	}
+
+	// The top of the window is up (+Z).
+	r, _ = c.Ray(view, math.Vec2{320, 0})
+	if r.Dir.Z <= 0 {
+		t.Errorf("top ray direction %v, want +Z component", r.Dir)
+	}
+}
+
+func TestWindowToNDC(t *testing.T) {
+	view := image.Rect(100, 50, 300, 150)
+	tests := []struct {
+		window, ndc math.Vec2
+	}{
+		{math.Vec2{100, 50}, math.Vec2{-1, 1}},
+		{math.Vec2{300, 150}, math.Vec2{1, -1}},
+		{math.Vec2{200, 100}, math.Vec2{0, 0}},
+	}
+	for _, tst := range tests {
+		if got := WindowToNDC(view, tst.window); got != tst.ndc {
+			t.Errorf("WindowToNDC(%v) = %v, want %v", tst.window, got, tst.ndc)
+		}
+		if got := NDCToWindow(view, tst.ndc); got != tst.window {
+			t.Errorf("NDCToWindow(%v) = %v, want %v", tst.ndc, got, tst.window)
+		}
+	}
+}
+
+func TestCameraFrustum(t *testing.T) {
+	view := image.Rect(0, 0, 640, 480)
+	c := testCamera(view)
+	f := c.Frustum()
+
+	inside := []math.Vec3{{1, 0, 2}, {1, 80, 2}, {5, 0, 4}}
+	outside := []math.Vec3{{1, -20, 2}, {1, 200, 2}, {100, 0, 2}, {1, 0, 50}}
+	for _, p := range inside {
+		if !f.ContainsPoint(p) {
+			t.Errorf("%v should be inside the frustum", p)
+		}
+	}
+	for _, p := range outside {
+		if f.ContainsPoint(p) {
+			t.Errorf("%v should be outside the frustum", p)
+		}
+	}
+
+	corners, ok := c.FrustumCorners()
+	if !ok {
+		t.Fatal("FrustumCorners not ok")
+	}
+	bounds := c.FrustumBounds()
+	sphere := c.FrustumSphere()
+	for i, p := range corners {
+		for j, plane := range f {
+			if d := plane.Dist(p); d < -1e-6 {
+				t.Errorf("corner %d is outside of plane %d by %v", i, j, d)
+			}
+		}
+		if p.X < bounds.Min.X-1e-9 || p.Y < bounds.Min.Y-1e-9 || p.Z < bounds.Min.Z-1e-9 ||
+			p.X > bounds.Max.X+1e-9 || p.Y > bounds.Max.Y+1e-9 || p.Z > bounds.Max.Z+1e-9 {
+			t.Errorf("corner %d %v is outside of bounds %v", i, p, bounds)
+		}
+		if p.Sub(sphere.Center).Length() > sphere.Radius+1e-9 {
+			t.Errorf("corner %d %v is outside of sphere %v", i, p, sphere)
+		}
+	}
+	if near := f[FrustumNear].Dist(math.Vec3{1, -9.9, 2}); near > 1e-6 || near < -1e-6 {
+		t.Errorf("near plane distance %v, want zero", near)
+	}
+
+	if !f.OverlapsRect3(math.Rect3{math.Vec3{-1, 10, -1}, math.Vec3{1, 12, 1}}) {
+		t.Error("box in front of the camera should overlap")
+	}
+	if f.OverlapsRect3(math.Rect3{math.Vec3{-1, -30, -1}, math.Vec3{1, -20, 1}}) {
+		t.Error("box behind the camera should not overlap")
+	}
+	if !f.OverlapsSphere(math.Sphere{math.Vec3{1, -11, 2}, 2}) {
+		t.Error("sphere around the camera should overlap")
+	}
+	if f.OverlapsSphere(math.Sphere{math.Vec3{1, -15, 2}, 2}) {
+		t.Error("sphere behind the camera should not overlap")
+	}
+}
+
+func TestRayIntersect(t *testing.T) {
+	r := Ray{Origin: math.Vec3{0, 0, 0}, Dir: math.Vec3{0, 1, 0}}
+	box := math.Rect3{math.Vec3{-1, 5, -1}, math.Vec3{1, 7, 1}}
+	if d, ok := r.IntersectRect3(box); !ok || d != 5 {
+		t.Errorf("IntersectRect3 = %v, %v, want 5, true", d, ok)
+	}
+	if _, ok := (Ray{Origin: math.Vec3{0, 0, 0}, Dir: math.Vec3{0, -1, 0}}).IntersectRect3(box); ok {
+		t.Error("IntersectRect3 behind the ray should fail")
+	}
+	if d, ok := r.IntersectSphere(math.Sphere{math.Vec3{0, 10, 0}, 2}); !ok || d != 8 {
+		t.Errorf("IntersectSphere = %v, %v, want 8, true", d, ok)
+	}
+	if d, ok := r.IntersectPlane(Plane{math.Vec3{0, -1, 0}, 3}); !ok || d != 3 {
+		t.Errorf("IntersectPlane = %v, %v, want 3, true", d, ok)
+	}
+}
@@ -0,0 +1,338 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debugdraw
+
+import (
+	"azul3d.org/v1/gfx"
+	gmath "azul3d.org/v1/math"
+	"image"
+	"sync"
+	"time"
+)
+
+// Commonly used colors.
+var (
+	White   = gfx.Color{1, 1, 1, 1}
+	Black   = gfx.Color{0, 0, 0, 1}
+	Red     = gfx.Color{1, 0, 0, 1}
+	Green   = gfx.Color{0, 1, 0, 1}
+	Blue    = gfx.Color{0, 0, 1, 1}
+	Yellow  = gfx.Color{1, 1, 0, 1}
+	Cyan    = gfx.Color{0, 1, 1, 1}
+	Magenta = gfx.Color{1, 0, 1, 1}
+)
+
+// glslVert expands each line, given as a quad whose four vertices are both
+// of it's end points, into a quad of LineWidth pixels wide on screen.
+//
+// Each vertex has the world position of it's end point (Vertex) and of the
+// other end point (Other), a pixel offset for both from their projected
+// positions (Offset, used for text) and the side of the line that the vertex
+// is on (Side).
+var glslVert = []byte(`
+#version 120
+
+attribute vec3 Vertex;
+attribute vec4 Color;
+attribute vec3 Other;
+attribute vec4 Offset;
+attribute float Side;
+
+uniform mat4 MVP;
+uniform vec3 Viewport;
+uniform float LineWidth;
+
+varying vec4 color;
+
+void main()
+{
+	vec4 a = MVP * vec4(Vertex, 1.0);
+	vec4 b = MVP * vec4(Other, 1.0);
+
+	// Clip the other end point to the near plane, such that lines passing
+	// behind the camera have the correct direction on screen.
+	if(a.w > 0.0001 && b.w < 0.0001) {
+		b = mix(a, b, (a.w - 0.0001) / (a.w - b.w));
+	}
+
+	vec2 halfSize = Viewport.xy * 0.5;
+	vec2 pa = a.xy / a.w * halfSize + Offset.xy;
+	vec2 pb = b.xy / b.w * halfSize + Offset.zw;
+	vec2 d = pb - pa;
+	if(dot(d, d) < 0.000001) {
+		d = vec2(1.0, 0.0);
+	}
+	d = normalize(d);
+	vec2 n = vec2(-d.y, d.x) * Side * LineWidth * 0.5;
+
+	gl_Position = a + vec4((Offset.xy + n) / halfSize * abs(a.w), 0.0, 0.0);
+	color = Color;
+}
+`)
+
+var glslFrag = []byte(`
+#version 120
+
+varying vec4 color;
+
+void main()
+{
+	gl_FragColor = color;
+}
+`)
+
+// point is a single end point of a line: a world space position and an offset
+// from it's projected position on screen, in pixels.
+type point struct {
+	pos    gmath.Vec3
+	offset gmath.Vec2
+}
+
+// line is a single line added to a drawer.
+type line struct {
+	a, b  point
+	color gfx.Color
+
+	// The time at which the line expires, or the zero time if it is only
+	// drawn until the next flush.
+	expires time.Time
+
+	// Whether or not the line has been drawn, lines are kept until they are
+	// drawn at least once regardless of their lifetime.
+	drawn bool
+}
+
+// state is the state shared by a drawer and those returned by it's For
+// method.
+type state struct {
+	access sync.Mutex
+	lines  []line
+
+	// Whether or not the mesh must be rebuilt from the lines.
+	dirty bool
+
+	// The width of lines, in pixels.
+	width float64
+
+	// The current time, used for lifetimes.
+	now func() time.Time
+
+	shader *gfx.Shader
+	mesh   *gfx.Mesh
+	obj    *gfx.Object
+}
+
+// Drawer accumulates debug shapes and draws them as a single object. It
+// implements the gfx.Drawable interface.
+//
+// Drawers are safe for use by multiple goroutines concurrently.
+type Drawer struct {
+	*state
+
+	// The lifetime of the shapes added through this drawer, or zero if they
+	// are only drawn until the end of the frame.
+	life time.Duration
+}
+
+// For returns a drawer that shares the shapes of this one, but whose shapes
+// are drawn for the given number of seconds instead of until the end of the
+// frame. For instance to show where a bullet hit for two seconds:
+//  dbg.For(2).Sphere(hit, 0.1, debugdraw.Red)
+//
+// A shape is always drawn for at least one frame, regardless of how short
+// it's lifetime is.
+func (d *Drawer) For(seconds float64) *Drawer {
+	return &Drawer{
+		state: d.state,
+		life:  time.Duration(seconds * float64(time.Second)),
+	}
+}
+
+// add adds lines between each pair of the given points.
+func (d *Drawer) add(c gfx.Color, points ...point) {
+	var expires time.Time
+	if d.life > 0 {
+		expires = d.now().Add(d.life)
+	}
+	d.access.Lock()
+	for i := 0; i+1 < len(points); i += 2 {
+		d.lines = append(d.lines, line{
+			a:       points[i],
+			b:       points[i+1],
+			color:   c,
+			expires: expires,
+		})
+	}
+	d.dirty = true
+	d.access.Unlock()
+}
+
+// SetLineWidth sets the width of lines in pixels, the default is 1.5.
+func (d *Drawer) SetLineWidth(pixels float64) {
+	d.access.Lock()
+	d.width = pixels
+	d.access.Unlock()
+}
+
+// SetDepthTest sets whether or not shapes are hidden behind the objects drawn
+// before them (the default), or always drawn on top.
+func (d *Drawer) SetDepthTest(enabled bool) {
+	d.obj.Lock()
+	d.obj.State.DepthTest = enabled
+	d.obj.Unlock()
+}
+
+// Len returns the number of lines that will be drawn. Each shape is made up
+// of one or more lines.
+func (d *Drawer) Len() int {
+	d.access.Lock()
+	defer d.access.Unlock()
+	return len(d.lines)
+}
+
+// Flush ends the frame: shapes without a lifetime, and shapes whose lifetime
+// has passed, are removed. It should be called once each frame, after the
+// drawer has been drawn.
+func (d *Drawer) Flush() {
+	now := d.now()
+	d.access.Lock()
+	keep := d.lines[:0]
+	for _, l := range d.lines {
+		if !l.expires.IsZero() && (now.Before(l.expires) || !l.drawn) {
+			keep = append(keep, l)
+		}
+	}
+	if len(keep) != len(d.lines) {
+		d.dirty = true
+	}
+	for i := len(keep); i < len(d.lines); i++ {
+		d.lines[i] = line{}
+	}
+	d.lines = keep
+	d.access.Unlock()
+}
+
+// build rebuilds the mesh from the lines, each of which is a quad made up of
+// two triangles.
+//
+// The drawer's lock and the mesh's write lock must be held for this method to
+// operate safely.
+func (d *Drawer) build() {
+	m := d.mesh
+	n := 4 * len(d.lines)
+	var (
+		others  = make([]gfx.Vec3, 0, n)
+		offsets = make([]gfx.Vec4, 0, n)
+		sides   = make([]float32, 0, n)
+	)
+	m.Vertices = m.Vertices[:0]
+	m.Colors = m.Colors[:0]
+	m.Bary = m.Bary[:0]
+	m.Indices = m.Indices[:0]
+	m.AABB = gmath.Rect3{}
+	for i, l := range d.lines {
+		// The vertices at the other end point are on the opposite side, as
+		// the direction of the line is reversed for them.
+		for _, v := range [4]struct {
+			p, other point
+			side     float32
+		}{
+			{l.a, l.b, 1},
+			{l.a, l.b, -1},
+			{l.b, l.a, -1},
+			{l.b, l.a, 1},
+		} {
+			m.Vertices = append(m.Vertices, gfx.ConvertVec3(v.p.pos))
+			m.Colors = append(m.Colors, l.color)
+			m.Bary = append(m.Bary, gfx.Vec3{})
+			others = append(others, gfx.ConvertVec3(v.other.pos))
+			offsets = append(offsets, gfx.Vec4{
+				float32(v.p.offset.X), float32(v.p.offset.Y),
+				float32(v.other.offset.X), float32(v.other.offset.Y),
+			})
+			sides = append(sides, v.side)
+		}
+		base := uint32(4 * i)
+		m.Indices = append(m.Indices,
+			base, base+1, base+3,
+			base, base+3, base+2,
+		)
+	}
+	m.Attribs = map[string]gfx.VertexAttrib{
+		"Other":  {Data: others, Changed: true},
+		"Offset": {Data: offsets, Changed: true},
+		"Side":   {Data: sides, Changed: true},
+	}
+	m.IndicesChanged = true
+	m.VerticesChanged = true
+	m.ColorsChanged = true
+	m.BaryChanged = true
+	if len(m.Vertices) > 0 {
+		m.CalculateBounds()
+	}
+}
+
+// DrawTo implements the gfx.Drawable interface. The shapes are drawn using
+// the given camera, which must not be nil.
+//
+// The width of lines and the size of text are relative to the given bounds
+// (or the bounds of the canvas, if empty).
+func (d *Drawer) DrawTo(c gfx.Canvas, bounds image.Rectangle, cam *gfx.Camera) {
+	if cam == nil {
+		return
+	}
+	d.access.Lock()
+	defer d.access.Unlock()
+	if len(d.lines) == 0 {
+		return
+	}
+	if d.dirty {
+		d.mesh.Lock()
+		d.build()
+		d.mesh.Unlock()
+		d.dirty = false
+	}
+	for i := range d.lines {
+		d.lines[i].drawn = true
+	}
+
+	view := bounds
+	if view.Empty() {
+		view = c.Bounds()
+	}
+	d.shader.Lock()
+	d.shader.Inputs["Viewport"] = gfx.Vec3{float32(view.Dx()), float32(view.Dy()), 0}
+	d.shader.Inputs["LineWidth"] = float32(d.width)
+	d.shader.Unlock()
+	c.Draw(bounds, d.obj, cam)
+}
+
+// New returns a new drawer, with no shapes.
+func New() *Drawer {
+	shader := gfx.NewShader("debugdraw")
+	shader.GLSLVert = glslVert
+	shader.GLSLFrag = glslFrag
+
+	mesh := new(gfx.Mesh)
+	mesh.Dynamic = true
+
+	obj := gfx.NewObject()
+	obj.State.AlphaMode = gfx.AlphaBlend
+	obj.State.DepthWrite = false
+	obj.State.FaceCulling = gfx.NoFaceCulling
+	obj.Shaders = []*gfx.Shader{shader}
+	obj.Meshes = []*gfx.Mesh{mesh}
+	obj.Textures = [][]*gfx.Texture{nil}
+
+	return &Drawer{
+		state: &state{
+			width:  1.5,
+			now:    time.Now,
+			shader: shader,
+			mesh:   mesh,
+			obj:    obj,
+		},
+	}
+}
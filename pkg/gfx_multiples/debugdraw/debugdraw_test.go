@@ -0,0 +1,156 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debugdraw
+
+import (
+	"azul3d.org/v1/gfx"
+	"azul3d.org/v1/gfx/glsl"
+	gmath "azul3d.org/v1/math"
+	"image"
+	"math"
+	"testing"
+	"time"
+)
+
+func newCamera() *gfx.Camera {
+	cam := gfx.NewCamera()
+	cam.SetPersp(image.Rect(0, 0, 800, 600), 75, 0.5, 500)
+	cam.SetPos(gmath.Vec3{0, -20, 10})
+	return cam
+}
+
+func TestShapes(t *testing.T) {
+	d := New()
+	origin := gmath.Vec3{}
+	one := gmath.Vec3{1, 1, 1}
+	for _, tst := range []struct {
+		name  string
+		draw  func()
+		lines int
+	}{
+		{"Line", func() { d.Line(origin, one, Red) }, 1},
+		{"Box", func() { d.Box(gmath.Rect3{origin, one}, Red) }, 12},
+		{"Sphere", func() { d.Sphere(origin, 1, Red) }, 3 * circleSegments},
+		{"Frustum", func() { d.Frustum(newCamera(), Red) }, 12},
+		{"Arrow", func() { d.Arrow(origin, one, Red) }, 5},
+		{"Grid", func() { d.Grid(origin, 1, 4, Red) }, 10},
+		{"Text3D", func() { d.Text3D(origin, "TL", 12, Red) }, 2 + 2},
+	} {
+		tst.draw()
+		if got := d.Len(); got != tst.lines {
+			t.Errorf("%s: got %d lines want %d", tst.name, got, tst.lines)
+		}
+		d.Flush()
+	}
+}
+
+func TestText3D(t *testing.T) {
+	d := New()
+	pos := gmath.Vec3{1, 2, 3}
+	d.Text3D(pos, "A-1\nok?", 12, White)
+	if d.Len() == 0 {
+		t.Fatal("no lines drawn")
+	}
+
+	// Text is centered on the position.
+	min := gmath.Vec2{math.Inf(1), math.Inf(1)}
+	max := gmath.Vec2{math.Inf(-1), math.Inf(-1)}
+	for _, l := range d.lines {
+		for _, p := range []point{l.a, l.b} {
+			if p.pos != pos {
+				t.Fatalf("got position %v want %v", p.pos, pos)
+			}
+			min.X, min.Y = math.Min(min.X, p.offset.X), math.Min(min.Y, p.offset.Y)
+			max.X, max.Y = math.Max(max.X, p.offset.X), math.Max(max.Y, p.offset.Y)
+		}
+	}
+	if !min.Equals(gmath.Vec2{-max.X, -max.Y}) {
+		t.Fatalf("text not centered, offsets range from %v to %v", min, max)
+	}
+	if h := max.Y - min.Y; math.Abs(h-(12+12*lineAdvance/glyphHeight)) > 1e-9 {
+		t.Fatalf("got text height %v", h)
+	}
+}
+
+func TestLifetime(t *testing.T) {
+	now := time.Unix(0, 0)
+	d := New()
+	d.now = func() time.Time { return now }
+
+	d.Line(gmath.Vec3{}, gmath.Vec3{1, 0, 0}, Red)
+	d.For(2).Line(gmath.Vec3{}, gmath.Vec3{0, 1, 0}, Green)
+	d.For(0.001).Line(gmath.Vec3{}, gmath.Vec3{0, 0, 1}, Blue)
+
+	// Nothing has been drawn yet: only the line without a lifetime is
+	// removed.
+	now = now.Add(time.Second)
+	d.Flush()
+	if d.Len() != 2 {
+		t.Fatalf("got %d lines want 2", d.Len())
+	}
+
+	r := gfx.Nil()
+	d.DrawTo(r, r.Bounds(), newCamera())
+	d.Flush()
+	if d.Len() != 1 {
+		t.Fatalf("got %d lines want 1", d.Len())
+	}
+
+	now = now.Add(time.Second)
+	d.Flush()
+	if d.Len() != 0 {
+		t.Fatalf("got %d lines want 0", d.Len())
+	}
+}
+
+func TestDrawTo(t *testing.T) {
+	d := New()
+	r := gfx.Nil()
+	cam := newCamera()
+
+	// Nothing to draw.
+	d.DrawTo(r, r.Bounds(), cam)
+	r.Render()
+	if s := r.Stats(); s.DrawCalls != 0 {
+		t.Fatalf("got %d draw calls want 0", s.DrawCalls)
+	}
+
+	d.Box(gmath.Rect3{gmath.Vec3{-1, -1, -1}, gmath.Vec3{1, 1, 1}}, Red)
+	d.Text3D(gmath.Vec3{}, "box", 12, White)
+	d.DrawTo(r, r.Bounds(), cam)
+	r.Render()
+	if s := r.Stats(); s.DrawCalls != 1 {
+		t.Fatalf("got %d draw calls want 1", s.DrawCalls)
+	}
+
+	m := d.mesh
+	n := d.Len()
+	if len(m.Vertices) != 4*n || len(m.Indices) != 6*n {
+		t.Fatalf("got %d vertices and %d indices for %d lines", len(m.Vertices), len(m.Indices), n)
+	}
+	if !m.CanDraw() || !d.obj.CanDraw() {
+		t.Fatal("mesh cannot be drawn")
+	}
+
+	// The shader accepts the mesh's attributes and the drawer's inputs.
+	refl, err := glsl.ReflectShader(d.shader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, attrib := range m.Attribs {
+		if _, ok := refl.Attributes[name]; !ok {
+			t.Fatalf("attribute %q is not declared", name)
+		}
+		if attrib.Len() != len(m.Vertices) {
+			t.Fatalf("attribute %q has %d elements want %d", name, attrib.Len(), len(m.Vertices))
+		}
+	}
+	builtin := func(name string) bool {
+		return name == "MVP"
+	}
+	if err := refl.Validate(d.shader.Inputs, builtin); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package debugdraw implements immediate-mode drawing of debug shapes.
+//
+// Shapes (lines, boxes, spheres, frustums, arrows, grids and text) are added
+// to a Drawer from anywhere in the program, and are accumulated into a single
+// dynamic mesh which is drawn as one object:
+//
+//  dbg := debugdraw.New()
+//
+//  // Each frame:
+//  dbg.Line(a, b, debugdraw.Red)
+//  dbg.Box(player.Bounds(), debugdraw.Green)
+//  dbg.Text3D(player.Pos(), "player", 14, debugdraw.White)
+//  dbg.For(2).Sphere(hit, 0.25, debugdraw.Yellow) // Keep for two seconds.
+//
+//  dbg.DrawTo(r, r.Bounds(), camera)
+//  r.Render()
+//  dbg.Flush()
+//
+// Shapes are drawn until the end of the frame (see Drawer.Flush), or for a
+// lifetime in seconds when added through Drawer.For.
+//
+// Lines are drawn with a constant width in pixels, and text is drawn facing
+// the screen with a constant size in pixels, using a built-in stroke font.
+// Both are expanded into triangles by the vertex shader, such that the mesh
+// does not depend on the camera.
+package debugdraw
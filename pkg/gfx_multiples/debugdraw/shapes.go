@@ -0,0 +1,150 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debugdraw
+
+import (
+	"azul3d.org/v1/gfx"
+	gmath "azul3d.org/v1/math"
+	"math"
+)
+
+// circleSegments is the number of lines that circles are made of.
+const circleSegments = 32
+
+// at returns the point at the given world position, with no offset.
+func at(p gmath.Vec3) point {
+	return point{pos: p}
+}
+
+// Line draws a line between the two given points.
+func (d *Drawer) Line(a, b gmath.Vec3, c gfx.Color) {
+	d.add(c, at(a), at(b))
+}
+
+// Box draws the edges of the given axis-aligned box.
+func (d *Drawer) Box(b gmath.Rect3, c gfx.Color) {
+	min, max := b.Min, b.Max
+	corners := [8]gmath.Vec3{
+		{min.X, min.Y, min.Z},
+		{max.X, min.Y, min.Z},
+		{max.X, max.Y, min.Z},
+		{min.X, max.Y, min.Z},
+		{min.X, min.Y, max.Z},
+		{max.X, min.Y, max.Z},
+		{max.X, max.Y, max.Z},
+		{min.X, max.Y, max.Z},
+	}
+	d.add(c, edges(corners)...)
+}
+
+// edges returns the points of the twelve edges of the box with the given
+// corners: the first four are the bottom (or near) face and the last four are
+// the top (or far) face, each in the same winding order.
+func edges(corners [8]gmath.Vec3) []point {
+	points := make([]point, 0, 24)
+	for i := 0; i < 4; i++ {
+		j := (i + 1) % 4
+		points = append(points,
+			at(corners[i]), at(corners[j]),
+			at(corners[i+4]), at(corners[j+4]),
+			at(corners[i]), at(corners[i+4]),
+		)
+	}
+	return points
+}
+
+// circle returns the points of the lines of a circle with the given center
+// and radius, in the plane of the two given (normalized, perpendicular) axes.
+func circle(center gmath.Vec3, radius float64, u, v gmath.Vec3) []point {
+	points := make([]point, 0, 2*circleSegments)
+	pos := func(i int) gmath.Vec3 {
+		a := 2 * math.Pi * float64(i) / circleSegments
+		p := u.MulScalar(math.Cos(a) * radius).Add(v.MulScalar(math.Sin(a) * radius))
+		return center.Add(p)
+	}
+	for i := 0; i < circleSegments; i++ {
+		points = append(points, at(pos(i)), at(pos(i+1)))
+	}
+	return points
+}
+
+// Sphere draws a sphere with the given center and radius, as three circles
+// around the X, Y and Z axes.
+func (d *Drawer) Sphere(center gmath.Vec3, radius float64, c gfx.Color) {
+	var (
+		x = gmath.Vec3{1, 0, 0}
+		y = gmath.Vec3{0, 1, 0}
+		z = gmath.Vec3{0, 0, 1}
+	)
+	points := circle(center, radius, x, y)
+	points = append(points, circle(center, radius, x, z)...)
+	points = append(points, circle(center, radius, y, z)...)
+	d.add(c, points...)
+}
+
+// Frustum draws the edges of the viewing frustum of the given camera, e.g. to
+// see what a shadow-casting light or a second camera can see.
+//
+// This method properly read-locks the camera.
+func (d *Drawer) Frustum(cam *gfx.Camera, c gfx.Color) {
+	cam.RLock()
+	corners, ok := cam.FrustumCorners()
+	cam.RUnlock()
+	if !ok {
+		return
+	}
+	d.add(c, edges(corners)...)
+}
+
+// perpendicular returns two normalized vectors perpendicular to the given
+// normalized direction and to each other.
+func perpendicular(dir gmath.Vec3) (u, v gmath.Vec3) {
+	axis := gmath.Vec3{0, 0, 1}
+	if math.Abs(dir.Z) > 0.9 {
+		axis = gmath.Vec3{1, 0, 0}
+	}
+	u, _ = dir.Cross(axis).Normalized()
+	v = dir.Cross(u)
+	return
+}
+
+// Arrow draws an arrow pointing from one point to another, whose head is a
+// fourth of it's length.
+func (d *Drawer) Arrow(from, to gmath.Vec3, c gfx.Color) {
+	dir, ok := to.Sub(from).Normalized()
+	if !ok {
+		return
+	}
+	length := to.Sub(from).Length()
+	base := to.Sub(dir.MulScalar(length / 4))
+	u, v := perpendicular(dir)
+	u, v = u.MulScalar(length/10), v.MulScalar(length/10)
+	d.add(c,
+		at(from), at(to),
+		at(to), at(base.Add(u)),
+		at(to), at(base.Sub(u)),
+		at(to), at(base.Add(v)),
+		at(to), at(base.Sub(v)),
+	)
+}
+
+// Grid draws a square grid of cells on the XY (ground) plane, centered at the
+// given point, with the given number of cells of the given size along each
+// side.
+func (d *Drawer) Grid(center gmath.Vec3, cellSize float64, cells int, c gfx.Color) {
+	if cells <= 0 {
+		return
+	}
+	half := cellSize * float64(cells) / 2
+	points := make([]point, 0, 4*(cells+1))
+	for i := 0; i <= cells; i++ {
+		o := -half + cellSize*float64(i)
+		points = append(points,
+			at(center.Add(gmath.Vec3{o, -half, 0})), at(center.Add(gmath.Vec3{o, half, 0})),
+			at(center.Add(gmath.Vec3{-half, o, 0})), at(center.Add(gmath.Vec3{half, o, 0})),
+		)
+	}
+	d.add(c, points...)
+}
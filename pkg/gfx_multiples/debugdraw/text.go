@@ -0,0 +1,139 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package debugdraw
+
+import (
+	"azul3d.org/v1/gfx"
+	gmath "azul3d.org/v1/math"
+	"strings"
+	"unicode"
+)
+
+// The metrics of the stroke font, in grid units: glyphs are four units wide
+// and six tall.
+const (
+	glyphHeight  = 6
+	glyphAdvance = 6
+	lineAdvance  = 10
+)
+
+// font is the built-in stroke font. Each glyph is a list of polylines, whose
+// points are pairs of digits: the X and Y grid coordinates from the
+// bottom-left of the glyph.
+var font = map[rune][]string{
+	'A':  {"00 04 26 44 40", "03 43"},
+	'B':  {"00 06 36 45 44 33 03", "33 42 41 30 00"},
+	'C':  {"46 16 05 01 10 40"},
+	'D':  {"00 06 26 44 42 20 00"},
+	'E':  {"46 06 00 40", "03 33"},
+	'F':  {"46 06 00", "03 33"},
+	'G':  {"45 36 16 05 01 10 30 41 43 23"},
+	'H':  {"00 06", "40 46", "03 43"},
+	'I':  {"16 36", "10 30", "20 26"},
+	'J':  {"46 41 30 10 01"},
+	'K':  {"00 06", "46 02", "13 40"},
+	'L':  {"06 00 40"},
+	'M':  {"00 06 23 46 40"},
+	'N':  {"00 06 40 46"},
+	'O':  {"10 01 05 16 36 45 41 30 10"},
+	'P':  {"00 06 36 45 44 33 03"},
+	'Q':  {"10 01 05 16 36 45 41 30 10", "22 40"},
+	'R':  {"00 06 36 45 44 33 03", "23 40"},
+	'S':  {"45 36 16 05 04 13 33 42 41 30 10 01"},
+	'T':  {"06 46", "20 26"},
+	'U':  {"06 01 10 30 41 46"},
+	'V':  {"06 20 46"},
+	'W':  {"06 10 23 30 46"},
+	'X':  {"00 46", "06 40"},
+	'Y':  {"06 23 46", "23 20"},
+	'Z':  {"06 46 00 40"},
+	'0':  {"10 01 05 16 36 45 41 30 10", "41 05"},
+	'1':  {"15 26 20", "10 30"},
+	'2':  {"05 16 36 45 44 00 40"},
+	'3':  {"05 16 36 45 44 33 13", "33 42 41 30 10 01"},
+	'4':  {"30 36 02 42"},
+	'5':  {"46 06 04 34 43 41 30 10 01"},
+	'6':  {"45 36 16 05 01 10 30 41 42 33 03"},
+	'7':  {"06 46 20"},
+	'8':  {"13 04 05 16 36 45 44 33 13 02 01 10 30 41 42 33"},
+	'9':  {"01 10 30 41 45 36 16 05 04 13 43"},
+	'.':  {"10 20 21 11 10"},
+	',':  {"21 20 11"},
+	':':  {"11 21", "15 25"},
+	';':  {"15 25", "21 20 11"},
+	'-':  {"03 43"},
+	'+':  {"03 43", "21 25"},
+	'=':  {"02 42", "04 44"},
+	'*':  {"03 43", "11 35", "15 31"},
+	'/':  {"00 46"},
+	'\\': {"06 40"},
+	'(':  {"36 14 12 30"},
+	')':  {"16 34 32 10"},
+	'[':  {"36 16 10 30"},
+	']':  {"16 36 30 10"},
+	'<':  {"45 03 41"},
+	'>':  {"05 43 01"},
+	'_':  {"00 40"},
+	'!':  {"26 22", "20 21"},
+	'?':  {"05 16 36 45 44 23 22", "20 21"},
+	'\'': {"26 24"},
+	'"':  {"16 14", "36 34"},
+	'#':  {"16 10", "36 30", "04 44", "02 42"},
+	'%':  {"00 46", "06 16 15 05 06", "31 41 40 30 31"},
+	' ':  {},
+}
+
+// unknownGlyph is drawn for runes that are not in the font.
+var unknownGlyph = []string{"00 06 46 40 00"}
+
+// glyph returns the line segments of the given rune, as pairs of points in
+// grid units.
+func glyph(r rune) []gmath.Vec2 {
+	polylines, ok := font[unicode.ToUpper(r)]
+	if !ok {
+		polylines = unknownGlyph
+	}
+	var segments []gmath.Vec2
+	for _, pl := range polylines {
+		var prev *gmath.Vec2
+		for _, xy := range strings.Fields(pl) {
+			p := gmath.Vec2{float64(xy[0] - '0'), float64(xy[1] - '0')}
+			if prev != nil {
+				segments = append(segments, *prev, p)
+			}
+			prev = &p
+		}
+	}
+	return segments
+}
+
+// Text3D draws the given text facing the screen, centered on the given world
+// position. The size is the height of capital letters in pixels, such that the
+// text is the same size regardless of it's distance from the camera.
+//
+// Multiple lines are separated by newlines. Letters are drawn as capitals, and
+// characters not in the built-in font are drawn as boxes.
+func (d *Drawer) Text3D(pos gmath.Vec3, text string, size float64, c gfx.Color) {
+	scale := size / glyphHeight
+	lines := strings.Split(text, "\n")
+	top := float64(len(lines)*lineAdvance-(lineAdvance-glyphHeight)) / 2
+
+	var points []point
+	for i, ln := range lines {
+		runes := []rune(ln)
+		left := -float64(len(runes)*glyphAdvance-(glyphAdvance-4)) / 2
+		bottom := top - glyphHeight - float64(i*lineAdvance)
+		for j, r := range runes {
+			origin := gmath.Vec2{left + float64(j*glyphAdvance), bottom}
+			for _, p := range glyph(r) {
+				points = append(points, point{
+					pos:    pos,
+					offset: origin.Add(p).MulScalar(scale),
+				})
+			}
+		}
+	}
+	d.add(c, points...)
+}
@@ -0,0 +1,121 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gfx
+
+import "azul3d.org/v1/math"
+
+// Plane represents a plane in 3D space, as the set of points p for which:
+//  Normal.Dot(p) + D == 0
+type Plane struct {
+	// The normal vector of the plane.
+	Normal math.Vec3
+
+	// The signed distance of the plane from the origin, along the negated
+	// normal (if the normal is normalized).
+	D float64
+}
+
+// Dist returns the signed distance of the point from the plane, positive if
+// the point is on the side of the plane that it's normal points towards. The
+// distance is in units of the plane's normal length.
+func (p Plane) Dist(v math.Vec3) float64 {
+	return p.Normal.Dot(v) + p.D
+}
+
+// Normalized returns this plane with a normalized normal vector, such that
+// Dist returns true distances. If the normal vector is of zero length then
+// ok=false is returned.
+func (p Plane) Normalized() (n Plane, ok bool) {
+	l := p.Normal.Length()
+	if l == 0 {
+		return p, false
+	}
+	return Plane{p.Normal.MulScalar(1 / l), p.D / l}, true
+}
+
+// Indices of each plane in a Frustum.
+const (
+	FrustumLeft = iota
+	FrustumRight
+	FrustumBottom
+	FrustumTop
+	FrustumNear
+	FrustumFar
+)
+
+// Frustum represents a viewing frustum as six planes, each with it's normal
+// pointing towards the inside of the frustum. Planes are indexed by the
+// FrustumLeft, FrustumRight, etc constants.
+type Frustum [6]Plane
+
+// FrustumFromMat4 extracts the frustum planes of the given (row-vector)
+// view-projection matrix, whose clip space is in the standard OpenGL
+// -1 to +1 range on all axes. The returned planes are in the space that the
+// matrix transforms from (e.g. world space, for a camera's world to clip space
+// matrix), and are normalized.
+func FrustumFromMat4(m math.Mat4) Frustum {
+	col := func(i int) math.Vec4 {
+		return math.Vec4{m[0][i], m[1][i], m[2][i], m[3][i]}
+	}
+	plane := func(v math.Vec4) Plane {
+		p := Plane{math.Vec3{v.X, v.Y, v.Z}, v.W}
+		p, _ = p.Normalized()
+		return p
+	}
+	x, y, z, w := col(0), col(1), col(2), col(3)
+	return Frustum{
+		FrustumLeft:   plane(w.Add(x)),
+		FrustumRight:  plane(w.Sub(x)),
+		FrustumBottom: plane(w.Add(y)),
+		FrustumTop:    plane(w.Sub(y)),
+		FrustumNear:   plane(w.Add(z)),
+		FrustumFar:    plane(w.Sub(z)),
+	}
+}
+
+// ContainsPoint tells if the given point is inside the frustum.
+func (f *Frustum) ContainsPoint(p math.Vec3) bool {
+	for _, plane := range f {
+		if plane.Dist(p) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// OverlapsRect3 tells if the given axis-aligned box is (at least partially)
+// inside the frustum. The test is conservative: boxes near the corners of the
+// frustum may be reported as overlapping when they are not, but boxes that
+// overlap are never reported as not overlapping.
+func (f *Frustum) OverlapsRect3(r math.Rect3) bool {
+	for _, plane := range f {
+		// The corner of the box furthest along the plane's normal.
+		p := r.Min
+		if plane.Normal.X >= 0 {
+			p.X = r.Max.X
+		}
+		if plane.Normal.Y >= 0 {
+			p.Y = r.Max.Y
+		}
+		if plane.Normal.Z >= 0 {
+			p.Z = r.Max.Z
+		}
+		if plane.Dist(p) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// OverlapsSphere tells if the given sphere is (at least partially) inside the
+// frustum. Like OverlapsRect3 the test is conservative.
+func (f *Frustum) OverlapsSphere(s math.Sphere) bool {
+	for _, plane := range f {
+		if plane.Dist(s.Center) < -s.Radius {
+			return false
+		}
+	}
+	return true
+}
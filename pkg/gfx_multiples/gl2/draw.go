@@ -10,6 +10,7 @@ import (
 	"azul3d.org/v1/native/gl"
 	"fmt"
 	"image"
+	"reflect"
 )
 
 var (
@@ -84,6 +85,10 @@ func (n nativeObject) needRebuild(o *gfx.Object, c *gfx.Camera) bool {
 	if o.Transform.Mat4() != n.Transform {
 		return true
 	}
+	if c == nil {
+		// Drawn without a camera, see rebuild.
+		return true
+	}
 	if c.Object.Transform.Mat4() != n.CameraTransform {
 		return true
 	}
@@ -234,11 +239,12 @@ func (r *Renderer) Draw(rect image.Rectangle, o *gfx.Object, c *gfx.Camera) {
 	}
 
 	// Check if the now-loaded shaders might have errors.
-	for _, shader := range o.Shaders {
-		shader.RLock()
-		shaderCantDraw := !shader.CanDraw()
-		shader.RUnlock()
-		if shaderCantDraw {
+	for i, shader := range o.Shaders {
+		var textures int
+		if i < len(o.Textures) {
+			textures = len(o.Textures[i])
+		}
+		if !r.canDraw(shader, textures) {
 			// Can't draw.
 			unlock()
 			return
@@ -315,6 +321,114 @@ func (r *Renderer) findUniformLocation(native *nativeShader, name string) int32
 	return location
 }
 
+// inputsChanged tells if the names (or Go types) of the inputs of the loaded
+// shader, or the number of textures it is drawn with, have changed since they
+// were last validated (see validateInputs).
+//
+// The shader's read lock must be held for this function to operate safely.
+func inputsChanged(s *gfx.Shader, textures int) bool {
+	ns, ok := s.NativeShader.(*nativeShader)
+	if !ok || ns.reflection == nil {
+		return false
+	}
+	if ns.inputTypes == nil || textures != ns.inputTextures || len(s.Inputs) != len(ns.inputTypes) {
+		return true
+	}
+	for name, v := range s.Inputs {
+		if t, ok := ns.inputTypes[name]; !ok || t != reflect.TypeOf(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateInputs validates the inputs of the loaded shader against it's
+// uniforms, replacing the input errors of the native shader (which prevent it
+// from being drawn, see InputErrors). The number of textures the shader is
+// drawn with is used to determine which texture uniforms are provided by the
+// renderer.
+//
+// The names and types of the inputs are recorded, such that they are only
+// validated again once they change (see inputsChanged).
+//
+// The shader's write lock must be held for this method to operate safely.
+func (r *Renderer) validateInputs(s *gfx.Shader, textures int) {
+	ns, ok := s.NativeShader.(*nativeShader)
+	if !ok || ns.reflection == nil {
+		return
+	}
+	ns.inputTypes = make(map[string]reflect.Type, len(s.Inputs))
+	for name, v := range s.Inputs {
+		ns.inputTypes[name] = reflect.TypeOf(v)
+	}
+	ns.inputTextures = textures
+	ns.inputErrors = nil
+	err := ns.reflection.Validate(s.Inputs, func(name string) bool {
+		switch name {
+		case "Model", "View", "Projection", "MVP", "BinaryAlpha":
+			return true
+		}
+		for i := 0; i < textures; i++ {
+			if name == textureName(i) {
+				return true
+			}
+		}
+		return false
+	})
+	if err != nil {
+		ns.inputErrors = []byte(s.Name + " | Input errors:\n" + err.Error() + "\n")
+	}
+}
+
+// inputsValid tells if the last validation of the loaded shader's inputs
+// found no errors (see validateInputs).
+//
+// The shader's read lock must be held for this function to operate safely.
+func inputsValid(s *gfx.Shader) bool {
+	ns, ok := s.NativeShader.(*nativeShader)
+	return !ok || len(ns.inputErrors) == 0
+}
+
+// canDraw tells if the loaded shader can be drawn with the given number of
+// textures: it must be valid for drawing (see gfx.Shader.CanDraw) and it's
+// inputs must be valid. The inputs are only validated again if they have
+// changed since the last time (see inputsChanged), such that a shader whose
+// inputs are fixed is drawn again.
+//
+// This method properly locks the shader.
+func (r *Renderer) canDraw(s *gfx.Shader, textures int) bool {
+	s.RLock()
+	ok := s.CanDraw()
+	validate := ok && inputsChanged(s, textures)
+	ok = ok && inputsValid(s)
+	s.RUnlock()
+	if validate {
+		s.Lock()
+		if s.CanDraw() {
+			r.validateInputs(s, textures)
+		}
+		ok = s.CanDraw() && inputsValid(s)
+		s.Unlock()
+	}
+	return ok
+}
+
+// InputErrors returns the errors found by the last validation of the given
+// loaded shader's inputs against the uniforms declared by it's sources, or nil
+// if there were none. A shader whose inputs have errors is not drawn until
+// they are fixed.
+//
+// This method properly read-locks the shader.
+func (r *Renderer) InputErrors(s *gfx.Shader) []byte {
+	s.RLock()
+	defer s.RUnlock()
+	ns, ok := s.NativeShader.(*nativeShader)
+	if !ok {
+		return nil
+	}
+	return ns.inputErrors
+}
+
 type texSlot int32
 
 func (r *Renderer) updateUniform(native *nativeShader, name string, value interface{}) {
@@ -531,6 +645,28 @@ func (r *Renderer) drawMesh(ns *nativeShader, m *gfx.Mesh) {
 		}
 	}
 
+	if native.normals != 0 {
+		// Use normals data.
+		location, ok = r.findAttribLocation(ns, "Normal")
+		if ok {
+			r.render.BindBuffer(gl.ARRAY_BUFFER, native.normals)
+			r.render.EnableVertexAttribArray(location)
+			defer r.render.DisableVertexAttribArray(location)
+			r.render.VertexAttribPointer(location, 3, gl.FLOAT, gl.GLBool(false), 0, nil)
+		}
+	}
+
+	// Use each custom attribute's data.
+	for name, attrib := range native.attribs {
+		location, ok = r.findAttribLocation(ns, name)
+		if ok {
+			r.render.BindBuffer(gl.ARRAY_BUFFER, attrib.vbo)
+			r.render.EnableVertexAttribArray(location)
+			defer r.render.DisableVertexAttribArray(location)
+			r.render.VertexAttribPointer(location, attrib.size, attrib.glType, gl.GLBool(false), 0, nil)
+		}
+	}
+
 	// Use each texture coordinate set data.
 	for index, texCoords := range native.texCoords {
 		name := texCoordName(index)
@@ -547,10 +683,13 @@ func (r *Renderer) drawMesh(ns *nativeShader, m *gfx.Mesh) {
 		// Draw indexed mesh.
 		r.render.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, native.indices)
 		r.render.DrawElements(gl.TRIANGLES, native.indicesCount, gl.UNSIGNED_INT, nil)
+		r.frame.Triangles += int(native.indicesCount / 3)
 	} else {
 		// Draw regular mesh.
 		r.render.DrawArrays(gl.TRIANGLES, 0, native.verticesCount)
+		r.frame.Triangles += int(native.verticesCount / 3)
 	}
+	r.frame.DrawCalls++
 
 	// Unbind buffer to avoid carrying OpenGL state.
 	r.render.BindBuffer(gl.ARRAY_BUFFER, 0)
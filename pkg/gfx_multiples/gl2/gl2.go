@@ -124,6 +124,17 @@ type Renderer struct {
 
 	// Channel to wait for a Render() call to finish.
 	renderComplete chan struct{}
+
+	// Statistics of the frame being rendered, only accessed by the render
+	// loop.
+	frame gfx.Stats
+
+	// Statistics counted by the loader (i.e. bytes uploaded and native
+	// resources loaded), and statistics of the last frame.
+	stats struct {
+		sync.Mutex
+		loader, last gfx.Stats
+	}
 }
 
 // Implements gfx.Renderer interface.
@@ -191,6 +202,8 @@ func (r *Renderer) queryYield() int {
 			nativeObj := query.o.NativeObject.(nativeObject)
 			nativeObj.sampleCount = int(result)
 			query.o.NativeObject = nativeObj
+			r.frame.OcclusionQueries++
+			r.frame.SamplesPassed += int(result)
 
 			// Remove from pending slice.
 			r.pending.queries = append(r.pending.queries[:queryIndex], r.pending.queries[queryIndex+1:]...)
@@ -265,6 +278,9 @@ func (r *Renderer) Render() {
 		// Wait for occlusion query results to come in.
 		r.queryWait()
 
+		// Store the statistics of this frame.
+		r.endFrame()
+
 		// Tick the clock.
 		r.clock.Tick()
 
@@ -275,6 +291,32 @@ func (r *Renderer) Render() {
 	<-r.renderComplete
 }
 
+// Implements gfx.Renderer interface.
+func (r *Renderer) Stats() gfx.Stats {
+	r.stats.Lock()
+	defer r.stats.Unlock()
+	return r.stats.last
+}
+
+// endFrame stores the statistics of the frame being rendered as those of the
+// last frame, and begins counting a new frame.
+//
+// Must only be called on the render loop.
+func (r *Renderer) endFrame() {
+	r.stats.Lock()
+	s := r.frame
+	s.MeshBytes = r.stats.loader.MeshBytes
+	s.TextureBytes = r.stats.loader.TextureBytes
+	s.Meshes = r.stats.loader.Meshes
+	s.Textures = r.stats.loader.Textures
+	s.Shaders = r.stats.loader.Shaders
+	r.stats.loader.MeshBytes = 0
+	r.stats.loader.TextureBytes = 0
+	r.stats.last = s
+	r.stats.Unlock()
+	r.frame = gfx.Stats{}
+}
+
 // Implements gfx.Renderer interface.
 func (r *Renderer) Precision() gfx.Precision {
 	return r.precision
@@ -6,6 +6,7 @@ package gl2
 
 import (
 	"azul3d.org/v1/gfx"
+	"azul3d.org/v1/gfx/glsl"
 	"testing"
 )
 
@@ -13,3 +14,43 @@ func TestRendererInterface(t *testing.T) {
 	var r *Renderer
 	_ = gfx.Renderer(r)
 }
+
+func TestInputErrors(t *testing.T) {
+	vert := []byte("uniform mat4 MVP;\nattribute vec3 Vertex;\nvoid main() { gl_Position = MVP * vec4(Vertex, 1.0); }\n")
+	frag := []byte("uniform sampler2D Texture0;\nuniform float Scale;\nvoid main() { gl_FragColor = Scale * texture2D(Texture0, vec2(0.0)); }\n")
+	reflection, err := glsl.Reflect(vert, frag)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A loaded shader, whose Scale input is only set after the first draw.
+	s := gfx.NewShader("test")
+	s.GLSLVert = vert
+	s.GLSLFrag = frag
+	s.Loaded = true
+	s.NativeShader = &nativeShader{reflection: reflection}
+
+	r := &Renderer{}
+	if r.canDraw(s, 1) || len(r.InputErrors(s)) == 0 {
+		t.Fatal("drawn without an input for Scale")
+	}
+	if len(s.Error) > 0 {
+		t.Fatal("input errors in the error log")
+	}
+
+	// Fixing the inputs makes it drawable again.
+	s.Inputs["Scale"] = float32(2)
+	if !r.canDraw(s, 1) || r.InputErrors(s) != nil {
+		t.Fatalf("not drawn after fixing the inputs: %s", r.InputErrors(s))
+	}
+
+	// Changing the type of an input validates them again.
+	s.Inputs["Scale"] = "2"
+	if r.canDraw(s, 1) {
+		t.Fatal("drawn with a string input for Scale")
+	}
+	s.Inputs["Scale"] = float32(2)
+	if !r.canDraw(s, 1) {
+		t.Fatal("not drawn after fixing the type of Scale")
+	}
+}
@@ -18,11 +18,53 @@ type nativeMesh struct {
 	vertices                    uint32
 	colors                      uint32
 	bary                        uint32
+	normals                     uint32
 	texCoords                   []uint32
+	attribs                     map[string]nativeAttrib
 	verticesCount, indicesCount uint32
 	r                           *Renderer
 }
 
+// nativeAttrib stores the VBO ID and data layout of a single custom vertex
+// attribute.
+type nativeAttrib struct {
+	vbo uint32
+
+	// The number of components per-vertex (1-4) and OpenGL data type of each
+	// component (e.g. gl.FLOAT).
+	size   int32
+	glType int32
+}
+
+// attribData returns the data layout, element size, element count, and
+// pointer to the first element of the given vertex attribute's data slice.
+// If the data slice is empty or of an unsupported type, ok=false is returned.
+func attribData(a gfx.VertexAttrib) (layout nativeAttrib, elemSize uintptr, length int, data unsafe.Pointer, ok bool) {
+	switch d := a.Data.(type) {
+	case []float32:
+		if len(d) > 0 {
+			return nativeAttrib{size: 1, glType: gl.FLOAT}, unsafe.Sizeof(d[0]), len(d), unsafe.Pointer(&d[0]), true
+		}
+	case []gfx.TexCoord:
+		if len(d) > 0 {
+			return nativeAttrib{size: 2, glType: gl.FLOAT}, unsafe.Sizeof(d[0]), len(d), unsafe.Pointer(&d[0]), true
+		}
+	case []gfx.Vec3:
+		if len(d) > 0 {
+			return nativeAttrib{size: 3, glType: gl.FLOAT}, unsafe.Sizeof(d[0]), len(d), unsafe.Pointer(&d[0]), true
+		}
+	case []gfx.Vec4:
+		if len(d) > 0 {
+			return nativeAttrib{size: 4, glType: gl.FLOAT}, unsafe.Sizeof(d[0]), len(d), unsafe.Pointer(&d[0]), true
+		}
+	case []int32:
+		if len(d) > 0 {
+			return nativeAttrib{size: 1, glType: gl.INT}, unsafe.Sizeof(d[0]), len(d), unsafe.Pointer(&d[0]), true
+		}
+	}
+	return nativeAttrib{}, 0, 0, nil, false
+}
+
 func finalizeMesh(n *nativeMesh) {
 	n.r.meshesToFree.Lock()
 	n.r.meshesToFree.slice = append(n.r.meshesToFree.slice, n)
@@ -31,6 +73,8 @@ func finalizeMesh(n *nativeMesh) {
 
 // Implements gfx.Destroyable interface.
 func (n *nativeMesh) Destroy() {
+	// The finalizer must not free the mesh a second time.
+	runtime.SetFinalizer(n, nil)
 	finalizeMesh(n)
 }
 
@@ -53,6 +97,10 @@ func (r *Renderer) updateVBO(usageHint int32, dataSize uintptr, dataLength int,
 		usageHint,
 	)
 	r.loader.Execute()
+
+	r.stats.Lock()
+	r.stats.loader.MeshBytes += int64(dataSize) * int64(dataLength)
+	r.stats.Unlock()
 }
 
 func (r *Renderer) deleteVBO(vboId *uint32) {
@@ -76,6 +124,12 @@ func (r *Renderer) freeMeshes() {
 		r.loader.DeleteBuffers(1, &native.vertices)
 		r.loader.DeleteBuffers(1, &native.colors)
 		r.loader.DeleteBuffers(1, &native.bary)
+		r.loader.DeleteBuffers(1, &native.normals)
+
+		// Delete custom attribute buffers.
+		for _, attrib := range native.attribs {
+			r.loader.DeleteBuffers(1, &attrib.vbo)
+		}
 
 		// Delete texture coords buffers.
 		if len(native.texCoords) > 0 {
@@ -87,6 +141,10 @@ func (r *Renderer) freeMeshes() {
 		r.loader.Execute()
 	}
 
+	r.stats.Lock()
+	r.stats.loader.Meshes -= len(r.meshesToFree.slice)
+	r.stats.Unlock()
+
 	// Slice to zero, and unlock.
 	r.meshesToFree.slice = r.meshesToFree.slice[:0]
 	r.meshesToFree.Unlock()
@@ -213,6 +271,72 @@ func (r *Renderer) LoadMesh(m *gfx.Mesh, done chan *gfx.Mesh) {
 			m.BaryChanged = false
 		}
 
+		// Update Normals VBO.
+		if !m.Loaded || m.NormalsChanged {
+			if len(m.Normals) == 0 {
+				// Delete normals VBO.
+				r.deleteVBO(&native.normals)
+			} else {
+				if native.normals == 0 {
+					// Create normals VBO.
+					native.normals = r.createVBO()
+				}
+				// Update normals VBO.
+				r.updateVBO(
+					usageHint,
+					unsafe.Sizeof(m.Normals[0]),
+					len(m.Normals),
+					unsafe.Pointer(&m.Normals[0]),
+					native.normals,
+				)
+			}
+			m.NormalsChanged = false
+		}
+
+		// Any custom attributes that were removed should have their VBO's
+		// deleted. A nil map on a loaded mesh means the data was cleared (see
+		// ClearData) and not that every attribute was removed.
+		if m.Attribs != nil || !m.Loaded {
+			for name, na := range native.attribs {
+				if _, ok := m.Attribs[name]; !ok {
+					r.deleteVBO(&na.vbo)
+					delete(native.attribs, name)
+				}
+			}
+		}
+
+		// Any custom attributes that were added should have VBO's created,
+		// and any that were changed need to have their VBO's updated.
+		for name, attrib := range m.Attribs {
+			layout, elemSize, length, data, ok := attribData(attrib)
+			na, exists := native.attribs[name]
+			if !ok {
+				// The data is now empty or of an unsupported type, so the
+				// attribute is ignored and it's old VBO must not be drawn.
+				if exists {
+					r.deleteVBO(&na.vbo)
+					delete(native.attribs, name)
+				}
+				continue
+			}
+			if exists && m.Loaded && !attrib.Changed {
+				continue
+			}
+			if !exists {
+				if native.attribs == nil {
+					native.attribs = make(map[string]nativeAttrib)
+				}
+				na.vbo = r.createVBO()
+			}
+			na.size = layout.size
+			na.glType = layout.glType
+			r.updateVBO(usageHint, elemSize, length, data, na.vbo)
+			native.attribs[name] = na
+
+			attrib.Changed = false
+			m.Attribs[name] = attrib
+		}
+
 		// Any texture coordinate sets that were removed should have their
 		// VBO's deleted.
 		deletedMax := len(m.TexCoords)
@@ -273,6 +397,10 @@ func (r *Renderer) LoadMesh(m *gfx.Mesh, done chan *gfx.Mesh) {
 
 			// Attach a finalizer to the mesh that will later free it.
 			runtime.SetFinalizer(native, finalizeMesh)
+
+			r.stats.Lock()
+			r.stats.loader.Meshes++
+			r.stats.Unlock()
 		}
 
 		// Set the mesh to loaded, clear any data slices if they are not wanted.
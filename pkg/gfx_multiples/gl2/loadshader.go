@@ -6,7 +6,9 @@ package gl2
 
 import (
 	"azul3d.org/v1/gfx"
+	"azul3d.org/v1/gfx/glsl"
 	"azul3d.org/v1/native/gl"
+	"reflect"
 	"runtime"
 	"strings"
 )
@@ -17,6 +19,17 @@ type nativeShader struct {
 	program, vertex, fragment   uint32
 	attribLookup, uniformLookup map[string]int32
 	r                           *Renderer
+
+	// Reflection of the shader's sources, used to validate it's inputs. May
+	// be nil if the sources could not be parsed.
+	reflection *glsl.Reflection
+
+	// The Go types of the inputs, by name, the number of textures that the
+	// inputs were last validated with, and the errors found then (see
+	// validateInputs). Protected by the lock of the gfx.Shader.
+	inputTypes    map[string]reflect.Type
+	inputTextures int
+	inputErrors   []byte
 }
 
 func finalizeShader(n *nativeShader) {
@@ -27,6 +40,8 @@ func finalizeShader(n *nativeShader) {
 
 // Implements gfx.Destroyable interface.
 func (n *nativeShader) Destroy() {
+	// The finalizer must not free the shader a second time.
+	runtime.SetFinalizer(n, nil)
 	finalizeShader(n)
 }
 
@@ -50,6 +65,10 @@ func (r *Renderer) freeShaders() {
 		r.loader.Execute()
 	}
 
+	r.stats.Lock()
+	r.stats.loader.Shaders -= len(r.shadersToFree.slice)
+	r.stats.Unlock()
+
 	// Slice to zero, and unlock.
 	r.shadersToFree.slice = r.shadersToFree.slice[:0]
 	r.shadersToFree.Unlock()
@@ -179,12 +198,22 @@ func (r *Renderer) LoadShader(s *gfx.Shader, done chan *gfx.Shader) {
 
 		// Mark the shader as loaded if there were no errors.
 		if len(s.Error) == 0 {
+			// Reflect the shader sources (before they are cleared) for input
+			// validation. The driver accepted the sources, so a parse error
+			// only means our parser doesn't understand them, in which case
+			// inputs are simply not validated.
+			native.reflection, _ = glsl.Reflect(s.GLSLVert, s.GLSLFrag)
+
 			s.Loaded = true
 			s.NativeShader = native
 			s.ClearData()
 
 			// Attach a finalizer to the shader that will later free it.
 			runtime.SetFinalizer(native, finalizeShader)
+
+			r.stats.Lock()
+			r.stats.loader.Shaders++
+			r.stats.Unlock()
 		}
 
 		// Flush and execute OpenGL commands.
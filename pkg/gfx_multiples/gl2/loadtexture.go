@@ -20,6 +20,8 @@ type nativeTexture struct {
 }
 
 func (n *nativeTexture) Destroy() {
+	// The finalizer must not free the texture a second time.
+	runtime.SetFinalizer(n, nil)
 	finalizeTexture(n)
 }
 
@@ -189,6 +191,10 @@ func (r *Renderer) freeTextures() {
 		r.loader.Execute()
 	}
 
+	r.stats.Lock()
+	r.stats.loader.Textures -= len(r.texturesToFree.slice)
+	r.stats.Unlock()
+
 	// Slice to zero, and unlock.
 	r.texturesToFree.slice = r.texturesToFree.slice[:0]
 	r.texturesToFree.Unlock()
@@ -253,9 +259,11 @@ func (r *Renderer) LoadTexture(t *gfx.Texture, done chan *gfx.Texture) {
 		// Determine appropriate internal image format.
 		targetFormat := convertTexFormat(t.Format)
 		internalFormat := gl.RGBA
+		storedFormat := gfx.RGBA
 		for _, format := range r.compressedTextureFormats {
 			if format == targetFormat {
 				internalFormat = format
+				storedFormat = t.Format
 				break
 			}
 		}
@@ -297,6 +305,11 @@ func (r *Renderer) LoadTexture(t *gfx.Texture, done chan *gfx.Texture) {
 		// Attach a finalizer to the texture that will later free it.
 		runtime.SetFinalizer(native, finalizeTexture)
 
+		r.stats.Lock()
+		r.stats.loader.Textures++
+		r.stats.loader.TextureBytes += gfx.TextureBytes(storedFormat, bounds.Dx(), bounds.Dy(), t.MinFilter.Mipmapped())
+		r.stats.Unlock()
+
 		// Unlock, signal completion, and return.
 		t.Unlock()
 		select {
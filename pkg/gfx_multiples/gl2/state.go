@@ -168,6 +168,8 @@ func (r *Renderer) stateScissor(rect image.Rectangle) {
 	}
 
 	if r.last.scissor != rect {
+		r.frame.StateChanges++
+
 		// Store the new scissor rectangle.
 		r.last.scissor = rect
 		x, y, width, height := convertRect(rect, bounds)
@@ -178,6 +180,7 @@ func (r *Renderer) stateScissor(rect image.Rectangle) {
 func (r *Renderer) stateColorWrite(cr, g, b, a bool) {
 	cw := [4]bool{cr, g, b, a}
 	if r.last.colorWrite != cw {
+		r.frame.StateChanges++
 		r.last.colorWrite = cw
 		r.render.ColorMask(
 			gl.GLBool(cr),
@@ -190,6 +193,7 @@ func (r *Renderer) stateColorWrite(cr, g, b, a bool) {
 
 func (r *Renderer) stateDithering(enabled bool) {
 	if r.last.dithering != enabled {
+		r.frame.StateChanges++
 		r.last.dithering = enabled
 		if enabled {
 			r.render.Enable(gl.DITHER)
@@ -201,6 +205,7 @@ func (r *Renderer) stateDithering(enabled bool) {
 
 func (r *Renderer) stateStencilTest(stencilTest bool) {
 	if r.last.stencilTest != stencilTest {
+		r.frame.StateChanges++
 		r.last.stencilTest = stencilTest
 		if stencilTest {
 			r.render.Enable(gl.STENCIL_TEST)
@@ -212,6 +217,7 @@ func (r *Renderer) stateStencilTest(stencilTest bool) {
 
 func (r *Renderer) stateStencilOp(front, back gfx.StencilState) {
 	if r.last.stencilOpFront != front || r.last.stencilOpBack != back {
+		r.frame.StateChanges++
 		r.last.stencilOpFront = front
 		r.last.stencilOpBack = back
 		if front == back {
@@ -241,6 +247,7 @@ func (r *Renderer) stateStencilOp(front, back gfx.StencilState) {
 
 func (r *Renderer) stateStencilFunc(front, back gfx.StencilState) {
 	if r.last.stencilFuncFront != front || r.last.stencilFuncBack != back {
+		r.frame.StateChanges++
 		r.last.stencilFuncFront = front
 		r.last.stencilFuncBack = back
 		if front == back {
@@ -270,6 +277,7 @@ func (r *Renderer) stateStencilFunc(front, back gfx.StencilState) {
 
 func (r *Renderer) stateStencilMask(front, back uint) {
 	if r.last.stencilMaskFront != front || r.last.stencilMaskBack != back {
+		r.frame.StateChanges++
 		r.last.stencilMaskFront = front
 		r.last.stencilMaskBack = back
 		if front == back {
@@ -284,6 +292,7 @@ func (r *Renderer) stateStencilMask(front, back uint) {
 
 func (r *Renderer) stateDepthFunc(df gfx.Cmp) {
 	if r.last.depthFunc != df {
+		r.frame.StateChanges++
 		r.last.depthFunc = df
 		r.render.DepthFunc(convertCmp(df))
 	}
@@ -291,6 +300,7 @@ func (r *Renderer) stateDepthFunc(df gfx.Cmp) {
 
 func (r *Renderer) stateDepthTest(enabled bool) {
 	if r.last.depthTest != enabled {
+		r.frame.StateChanges++
 		r.last.depthTest = enabled
 		if enabled {
 			r.render.Enable(gl.DEPTH_TEST)
@@ -302,6 +312,7 @@ func (r *Renderer) stateDepthTest(enabled bool) {
 
 func (r *Renderer) stateDepthWrite(enabled bool) {
 	if r.last.depthWrite != enabled {
+		r.frame.StateChanges++
 		r.last.depthWrite = enabled
 		if enabled {
 			r.render.DepthMask(gl.GLBool(true))
@@ -313,6 +324,7 @@ func (r *Renderer) stateDepthWrite(enabled bool) {
 
 func (r *Renderer) stateFaceCulling(m gfx.FaceCullMode) {
 	if r.last.faceCulling != m {
+		r.frame.StateChanges++
 		r.last.faceCulling = m
 		switch m {
 		case gfx.BackFaceCulling:
@@ -329,6 +341,7 @@ func (r *Renderer) stateFaceCulling(m gfx.FaceCullMode) {
 
 func (r *Renderer) stateProgram(p uint32) {
 	if r.last.program != p {
+		r.frame.StateChanges++
 		r.last.program = p
 		r.render.UseProgram(p)
 	}
@@ -336,6 +349,7 @@ func (r *Renderer) stateProgram(p uint32) {
 
 func (r *Renderer) stateBlend(blend bool) {
 	if r.last.blend != blend {
+		r.frame.StateChanges++
 		r.last.blend = blend
 		if blend {
 			r.render.Enable(gl.BLEND)
@@ -347,6 +361,7 @@ func (r *Renderer) stateBlend(blend bool) {
 
 func (r *Renderer) stateBlendColor(c gfx.Color) {
 	if r.last.blendColor != c {
+		r.frame.StateChanges++
 		r.last.blendColor = c
 		r.render.BlendColor(c.R, c.G, c.B, c.A)
 	}
@@ -354,6 +369,7 @@ func (r *Renderer) stateBlendColor(c gfx.Color) {
 
 func (r *Renderer) stateBlendFuncSeparate(s gfx.BlendState) {
 	if r.last.blendFuncSeparate != s {
+		r.frame.StateChanges++
 		r.last.blendFuncSeparate = s
 		r.render.BlendFuncSeparate(
 			convertBlendOp(s.SrcRGB),
@@ -366,6 +382,7 @@ func (r *Renderer) stateBlendFuncSeparate(s gfx.BlendState) {
 
 func (r *Renderer) stateBlendEquationSeparate(s gfx.BlendState) {
 	if r.last.blendEquationSeparate != s {
+		r.frame.StateChanges++
 		r.last.blendEquationSeparate = s
 		r.render.BlendEquationSeparate(
 			convertBlendEq(s.RGBEq),
@@ -376,6 +393,7 @@ func (r *Renderer) stateBlendEquationSeparate(s gfx.BlendState) {
 
 func (r *Renderer) stateAlphaToCoverage(alphaToCoverage bool) {
 	if r.last.alphaToCoverage != alphaToCoverage {
+		r.frame.StateChanges++
 		r.last.alphaToCoverage = alphaToCoverage
 		if r.gpuInfo.AlphaToCoverage {
 			if alphaToCoverage {
@@ -389,6 +407,7 @@ func (r *Renderer) stateAlphaToCoverage(alphaToCoverage bool) {
 
 func (r *Renderer) stateClearColor(color gfx.Color) {
 	if r.last.clearColor != color {
+		r.frame.StateChanges++
 		r.last.clearColor = color
 		r.render.ClearColor(color.R, color.G, color.B, color.A)
 	}
@@ -396,6 +415,7 @@ func (r *Renderer) stateClearColor(color gfx.Color) {
 
 func (r *Renderer) stateClearDepth(depth float64) {
 	if r.last.clearDepth != depth {
+		r.frame.StateChanges++
 		r.last.clearDepth = depth
 		r.render.ClearDepth(depth)
 	}
@@ -403,6 +423,7 @@ func (r *Renderer) stateClearDepth(depth float64) {
 
 func (r *Renderer) stateClearStencil(stencil int) {
 	if r.last.clearStencil != stencil {
+		r.frame.StateChanges++
 		r.last.clearStencil = stencil
 		r.render.ClearStencil(int32(stencil))
 	}
@@ -0,0 +1,16 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package glsl implements reflection of GLSL shader sources.
+//
+// It parses the global uniform and attribute declarations of a shader's GLSL
+// sources, such that the inputs of a gfx.Shader may be validated against
+// them before drawing: catching misspelled input names, inputs of the wrong
+// type and uniforms that are never given a value (which would otherwise
+// silently render incorrectly).
+//
+// The parser only understands declarations, it does not validate the GLSL
+// source itself (that is left to the graphics driver) and it does not expand
+// preprocessor macros.
+package glsl
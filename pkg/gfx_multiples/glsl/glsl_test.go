@@ -0,0 +1,201 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glsl
+
+import (
+	"azul3d.org/v1/gfx"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testVert = []byte(`
+#version 120
+#define MAX_LIGHTS \
+	4
+
+// uniform float Commented;
+/* uniform float
+   AlsoCommented; */
+attribute vec3 Vertex;
+attribute vec4 Color;
+attribute vec2 TexCoord0;
+
+uniform mat4 MVP;
+uniform highp float Scale, Offsets[3];
+uniform vec3 Lights[MAX_LIGHTS];
+uniform bool BinaryAlpha;
+
+varying vec2 tc0;
+
+struct Light {
+	vec3 pos;
+};
+
+float helper(float x) {
+	uniform float NotGlobal;
+	return x * Scale;
+}
+
+void main() {
+	tc0 = TexCoord0;
+	gl_Position = MVP * vec4(Vertex * Scale, 1.0);
+}
+`)
+
+var testFrag = []byte(`
+#version 120
+precision mediump float;
+
+varying vec2 tc0;
+
+uniform sampler2D Texture0;
+uniform float Scale;
+uniform mat4 Grading;
+const float Gamma = pow(2.0, 1.1);
+
+void main() {
+	gl_FragColor = texture2D(Texture0, tc0) * Scale;
+}
+`)
+
+func TestParse(t *testing.T) {
+	d, err := Parse(testVert)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantUniforms := []Variable{
+		{"MVP", Mat4, 0},
+		{"Scale", Float, 0},
+		{"Offsets", Float, 3},
+		{"Lights", Vec3, -1},
+		{"BinaryAlpha", Bool, 0},
+	}
+	if !reflect.DeepEqual(d.Uniforms, wantUniforms) {
+		t.Errorf("uniforms:\ngot  %v\nwant %v", d.Uniforms, wantUniforms)
+	}
+	wantInputs := []Variable{
+		{"Vertex", Vec3, 0},
+		{"Color", Vec4, 0},
+		{"TexCoord0", Vec2, 0},
+	}
+	if !reflect.DeepEqual(d.Inputs, wantInputs) {
+		t.Errorf("inputs:\ngot  %v\nwant %v", d.Inputs, wantInputs)
+	}
+}
+
+func TestParseGLSL3(t *testing.T) {
+	d, err := Parse([]byte(`
+		#version 330
+		layout(location = 0) in vec3 Vertex;
+		in vec3 Normal;
+		out vec3 normal;
+		uniform Block {
+			mat4 Hidden;
+		} block;
+		uniform int Mode = 2;
+	`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Inputs) != 2 || d.Inputs[0].Name != "Vertex" || d.Inputs[1] != (Variable{"Normal", Vec3, 0}) {
+		t.Errorf("got inputs %v", d.Inputs)
+	}
+	if len(d.Uniforms) != 1 || d.Uniforms[0] != (Variable{"Mode", Int, 0}) {
+		t.Errorf("got uniforms %v", d.Uniforms)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, src := range []string{
+		"void main() {",
+		"}",
+		"/* unterminated",
+	} {
+		if _, err := Parse([]byte(src)); err == nil {
+			t.Errorf("%q: expected error", src)
+		}
+	}
+}
+
+func TestReflect(t *testing.T) {
+	r, err := Reflect(testVert, testFrag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Uniforms) != 7 {
+		t.Errorf("got %d uniforms, want 7: %v", len(r.Uniforms), r.Uniforms)
+	}
+	if r.Uniforms["Texture0"].Type != Sampler2D {
+		t.Errorf("Texture0: got %v", r.Uniforms["Texture0"])
+	}
+	if len(r.Attributes) != 3 {
+		t.Errorf("got %d attributes, want 3", len(r.Attributes))
+	}
+
+	_, err = Reflect(testVert, []byte("uniform vec2 Scale;"))
+	if err == nil {
+		t.Fatal("expected conflicting uniform error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	r, err := Reflect(testVert, testFrag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	builtin := func(name string) bool {
+		return name == "MVP" || name == "BinaryAlpha"
+	}
+
+	inputs := map[string]interface{}{
+		"Scale":   float32(1),
+		"Offsets": []float32{1, 2, 3},
+		"Lights":  []gfx.Vec3{{1, 2, 3}},
+		"Grading": gfx.Mat4{},
+	}
+	if err := r.Validate(inputs, builtin); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	inputs = map[string]interface{}{
+		"Scael":    float32(1),
+		"Offsets":  []float32{1, 2, 3, 4},
+		"Lights":   gfx.Vec3{1, 2, 3},
+		"Grading":  gfx.Mat4{},
+		"Texture0": float32(0),
+	}
+	err = r.Validate(inputs, builtin)
+	errs, ok := err.(Errors)
+	if !ok {
+		t.Fatalf("got %v, want Errors", err)
+	}
+	want := []string{
+		`input "Lights" of type gfx.Vec3`,
+		`input "Offsets" of type []float32`,
+		`input "Scael" is not a uniform`,
+		`uniform "float Scale" has no input`,
+		`input "Texture0" is a sampler2D uniform`,
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d:\n%v", len(errs), len(want), err)
+	}
+	for i, w := range want {
+		if !strings.HasPrefix(errs[i].Error(), w) {
+			t.Errorf("error %d: got %q, want prefix %q", i, errs[i], w)
+		}
+	}
+
+	// Without builtins the renderer provided uniforms are missing.
+	err = r.Validate(map[string]interface{}{
+		"Scale":   float32(1),
+		"Offsets": []float32{1},
+		"Lights":  []gfx.Vec3{{1, 2, 3}},
+		"Grading": gfx.Mat4{},
+	}, nil)
+	if errs, _ := err.(Errors); len(errs) != 2 {
+		t.Errorf("got %v, want two errors", err)
+	}
+}
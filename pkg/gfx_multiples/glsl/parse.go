@@ -0,0 +1,231 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glsl
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+)
+
+// Declarations holds the global variable declarations of a single GLSL
+// shader source.
+type Declarations struct {
+	// The uniform variables, in order of declaration.
+	Uniforms []Variable
+
+	// The input variables (declared using either the attribute or in
+	// storage qualifier), in order of declaration.
+	Inputs []Variable
+}
+
+// tokenize splits the source into tokens, dropping comments, whitespace and
+// preprocessor directives.
+func tokenize(src []byte) ([]string, error) {
+	var (
+		tokens    []string
+		lineStart = true
+	)
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			lineStart = true
+			i++
+			continue
+
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f' || c == '\v':
+			i++
+			continue
+
+		case c == '#' && lineStart:
+			// Preprocessor directive, skip to the end of the line (taking
+			// line continuations into account).
+			for i < len(src) && src[i] != '\n' {
+				if src[i] == '\\' && i+1 < len(src) && src[i+1] == '\n' {
+					i++
+				}
+				i++
+			}
+			continue
+
+		case c == '/' && i+1 < len(src) && src[i+1] == '/':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			continue
+
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := -1
+			for j := i + 2; j+1 < len(src); j++ {
+				if src[j] == '*' && src[j+1] == '/' {
+					end = j + 2
+					break
+				}
+			}
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+			i = end
+			continue
+		}
+		lineStart = false
+
+		if isIdent(rune(c)) {
+			start := i
+			for i < len(src) && isIdent(rune(src[i])) {
+				i++
+			}
+			tokens = append(tokens, string(src[start:i]))
+			continue
+		}
+		tokens = append(tokens, string(c))
+		i++
+	}
+	return tokens, nil
+}
+
+func isIdent(r rune) bool {
+	return r == '_' || r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// Qualifiers which may appear before the type in a declaration, but which do
+// not matter to us.
+var ignoredQualifiers = map[string]bool{
+	"const":         true,
+	"invariant":     true,
+	"flat":          true,
+	"smooth":        true,
+	"noperspective": true,
+	"centroid":      true,
+	"lowp":          true,
+	"mediump":       true,
+	"highp":         true,
+}
+
+// Parse parses the global uniform and input declarations of the given GLSL
+// source.
+func Parse(src []byte) (*Declarations, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	d := new(Declarations)
+	var (
+		stmt  []string
+		depth int
+	)
+	for _, tok := range tokens {
+		switch tok {
+		case "{":
+			// Function bodies, structures and interface blocks are not
+			// (global) declarations we care about.
+			depth++
+			stmt = nil
+			continue
+		case "}":
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unexpected '}'")
+			}
+			stmt = nil
+			continue
+		}
+		if depth > 0 {
+			continue
+		}
+		if tok == ";" {
+			if err := d.declare(stmt); err != nil {
+				return nil, err
+			}
+			stmt = nil
+			continue
+		}
+		stmt = append(stmt, tok)
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("missing '}'")
+	}
+	return d, nil
+}
+
+// declare adds the variables of the given global statement (without it's
+// trailing semicolon) to the declarations, if it is a uniform or input
+// declaration.
+func (d *Declarations) declare(stmt []string) error {
+	var storage string
+	i := 0
+qualifiers:
+	for i < len(stmt) {
+		tok := stmt[i]
+		switch {
+		case tok == "layout":
+			// Skip the layout qualifier's parenthesized list.
+			for i < len(stmt) && stmt[i] != ")" {
+				i++
+			}
+			i++
+			continue
+		case tok == "uniform" || tok == "attribute" || tok == "in":
+			storage = tok
+			i++
+			continue
+		case ignoredQualifiers[tok]:
+			i++
+			continue
+		}
+		break qualifiers
+	}
+	if storage == "" || i >= len(stmt) {
+		// Not a declaration we care about (e.g. a precision statement or a
+		// varying).
+		return nil
+	}
+
+	typ := typesByName[stmt[i]]
+	i++
+
+	var vars []Variable
+	for i < len(stmt) {
+		v := Variable{Name: stmt[i], Type: typ}
+		if !isIdent(rune(v.Name[0])) {
+			return fmt.Errorf("expected variable name, found %q", v.Name)
+		}
+		i++
+		if i < len(stmt) && stmt[i] == "[" {
+			v.ArraySize = -1
+			if i+2 < len(stmt) && stmt[i+2] == "]" {
+				if n, err := strconv.Atoi(stmt[i+1]); err == nil {
+					v.ArraySize = n
+				}
+			}
+			for i < len(stmt) && stmt[i] != "]" {
+				i++
+			}
+			i++
+		}
+		vars = append(vars, v)
+
+		// Skip any initializer, up to the next declarator.
+		parens := 0
+		for i < len(stmt) {
+			tok := stmt[i]
+			i++
+			if tok == "(" {
+				parens++
+			} else if tok == ")" {
+				parens--
+			} else if tok == "," && parens == 0 {
+				break
+			}
+		}
+	}
+	if storage == "uniform" {
+		d.Uniforms = append(d.Uniforms, vars...)
+	} else {
+		d.Inputs = append(d.Inputs, vars...)
+	}
+	return nil
+}
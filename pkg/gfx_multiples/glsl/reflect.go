@@ -0,0 +1,153 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glsl
+
+import (
+	"azul3d.org/v1/gfx"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Reflection describes the uniforms and attributes of a shader program.
+type Reflection struct {
+	// The uniforms of the program (declared by either the vertex or fragment
+	// shader), by name.
+	Uniforms map[string]Variable
+
+	// The vertex attributes of the program, by name.
+	Attributes map[string]Variable
+}
+
+// Reflect parses the given vertex and fragment shader sources and returns a
+// reflection of the program they form.
+//
+// An error is returned if either source cannot be parsed or if both shaders
+// declare a uniform of the same name but with a different type.
+func Reflect(vert, frag []byte) (*Reflection, error) {
+	v, err := Parse(vert)
+	if err != nil {
+		return nil, fmt.Errorf("vertex shader: %v", err)
+	}
+	f, err := Parse(frag)
+	if err != nil {
+		return nil, fmt.Errorf("fragment shader: %v", err)
+	}
+	r := &Reflection{
+		Uniforms:   make(map[string]Variable, len(v.Uniforms)+len(f.Uniforms)),
+		Attributes: make(map[string]Variable, len(v.Inputs)),
+	}
+	for _, u := range append(v.Uniforms, f.Uniforms...) {
+		if prev, ok := r.Uniforms[u.Name]; ok && prev != u {
+			return nil, fmt.Errorf("uniform %q declared as both %q and %q", u.Name, prev, u)
+		}
+		r.Uniforms[u.Name] = u
+	}
+	for _, a := range v.Inputs {
+		r.Attributes[a.Name] = a
+	}
+	return r, nil
+}
+
+// ReflectShader is short-hand for:
+//  Reflect(s.GLSLVert, s.GLSLFrag)
+//
+// The shader's read lock must be held for this method to operate safely.
+func ReflectShader(s *gfx.Shader) (*Reflection, error) {
+	return Reflect(s.GLSLVert, s.GLSLFrag)
+}
+
+// Errors is a list of validation errors.
+type Errors []error
+
+// Error implements the error interface, it returns each error on it's own
+// line.
+func (e Errors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// accepts tells if a shader input value of the given Go type can be assigned
+// to the uniform (in the way that gfx renderers assign them).
+func accepts(u Variable, value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return u.Type == Bool && u.ArraySize == 0
+	case float32:
+		return u.Type == Float && u.ArraySize == 0
+	case []float32:
+		return u.Type == Float && fits(u, len(v))
+	case gfx.Vec3:
+		return u.Type == Vec3 && u.ArraySize == 0
+	case []gfx.Vec3:
+		return u.Type == Vec3 && fits(u, len(v))
+	case gfx.Mat4:
+		return u.Type == Mat4 && u.ArraySize == 0
+	case []gfx.Mat4:
+		return u.Type == Mat4 && fits(u, len(v))
+	}
+	return false
+}
+
+// fits tells if a slice of n elements fits within the uniform.
+func fits(u Variable, n int) bool {
+	if u.ArraySize == 0 {
+		return n == 1
+	}
+	return u.ArraySize < 0 || n <= u.ArraySize
+}
+
+// Validate validates the given shader inputs against the program's uniforms,
+// it reports:
+//  Inputs whose name is not a declared uniform (e.g. a typo).
+//  Inputs whose Go type cannot be assigned to the uniform's type.
+//  Uniforms (except samplers) that are not given a value by any input.
+//
+// Uniforms for which builtin returns true are provided by the renderer (e.g.
+// the MVP matrix) and are not required to be inputs. The builtin function may
+// be nil.
+//
+// Uniforms of unknown types (e.g. structures) are never reported. If there are
+// no errors then nil is returned, otherwise the returned error is of type
+// Errors and is sorted by uniform name.
+func (r *Reflection) Validate(inputs map[string]interface{}, builtin func(name string) bool) error {
+	var (
+		errs  Errors
+		names []string
+	)
+	for name := range inputs {
+		names = append(names, name)
+	}
+	for name := range r.Uniforms {
+		if _, ok := inputs[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		u, declared := r.Uniforms[name]
+		value, isInput := inputs[name]
+		switch {
+		case !declared:
+			errs = append(errs, fmt.Errorf("input %q is not a uniform of the shader", name))
+		case u.Type == Unknown:
+			continue
+		case isInput && u.Type.Sampler():
+			errs = append(errs, fmt.Errorf("input %q is a %s uniform, which is assigned by the renderer", name, u.Type))
+		case isInput && !accepts(u, value):
+			errs = append(errs, fmt.Errorf("input %q of type %T cannot be assigned to uniform %q", name, value, u))
+		case !isInput && !u.Type.Sampler() && (builtin == nil || !builtin(name)):
+			errs = append(errs, fmt.Errorf("uniform %q has no input", u))
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package glsl
+
+import "fmt"
+
+// Type specifies a single GLSL data type.
+type Type uint8
+
+const (
+	// Unknown is any type not understood by this package (e.g. a structure).
+	Unknown Type = iota
+
+	// The GLSL data types, named after their GLSL counterparts.
+	Bool
+	BVec2
+	BVec3
+	BVec4
+	Int
+	IVec2
+	IVec3
+	IVec4
+	Float
+	Vec2
+	Vec3
+	Vec4
+	Mat2
+	Mat3
+	Mat4
+	Sampler1D
+	Sampler2D
+	Sampler3D
+	SamplerCube
+	Sampler2DShadow
+)
+
+var typeNames = [...]string{
+	Unknown:         "unknown",
+	Bool:            "bool",
+	BVec2:           "bvec2",
+	BVec3:           "bvec3",
+	BVec4:           "bvec4",
+	Int:             "int",
+	IVec2:           "ivec2",
+	IVec3:           "ivec3",
+	IVec4:           "ivec4",
+	Float:           "float",
+	Vec2:            "vec2",
+	Vec3:            "vec3",
+	Vec4:            "vec4",
+	Mat2:            "mat2",
+	Mat3:            "mat3",
+	Mat4:            "mat4",
+	Sampler1D:       "sampler1D",
+	Sampler2D:       "sampler2D",
+	Sampler3D:       "sampler3D",
+	SamplerCube:     "samplerCube",
+	Sampler2DShadow: "sampler2DShadow",
+}
+
+var typesByName map[string]Type
+
+func init() {
+	typesByName = make(map[string]Type, len(typeNames))
+	for t, name := range typeNames {
+		if Type(t) != Unknown {
+			typesByName[name] = Type(t)
+		}
+	}
+}
+
+// String returns the GLSL name of this type.
+//
+// For example: Vec3 -> "vec3", Sampler2D -> "sampler2D".
+func (t Type) String() string {
+	if int(t) < len(typeNames) {
+		return typeNames[t]
+	}
+	return fmt.Sprintf("Type(%d)", t)
+}
+
+// Sampler tells if this type is a sampler type.
+func (t Type) Sampler() bool {
+	return t >= Sampler1D && t <= Sampler2DShadow
+}
+
+// Variable describes a single declared uniform or attribute variable.
+type Variable struct {
+	// The name of the variable.
+	Name string
+
+	// The data type of the variable.
+	Type Type
+
+	// The number of elements, if the variable is an array. Zero if the
+	// variable is not an array, or -1 if the size is not known (e.g. it's a
+	// preprocessor macro).
+	ArraySize int
+}
+
+// String returns a string representation of this variable in GLSL syntax.
+//
+// For example:
+//  "vec3 LightPos"
+//  "float Weights[4]"
+func (v Variable) String() string {
+	switch {
+	case v.ArraySize > 0:
+		return fmt.Sprintf("%s %s[%d]", v.Type, v.Name, v.ArraySize)
+	case v.ArraySize < 0:
+		return fmt.Sprintf("%s %s[]", v.Type, v.Name)
+	}
+	return fmt.Sprintf("%s %s", v.Type, v.Name)
+}
@@ -0,0 +1,30 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package material implements lights and materials for shading objects.
+//
+// A Material describes the surface of an object using either the Blinn-Phong
+// or a physically based (metallic/roughness) lighting model. The GLSL shader
+// sources for a material are generated automatically, along with the shader
+// inputs describing the material and the lights affecting the object:
+//
+//  m := material.NewPBR(math.Vec3{0.8, 0.1, 0.1}, 0, 0.4)
+//  shader := m.Shader(4) // Up to four lights.
+//  m.SetInputs(shader.Inputs, 4, material.Env{
+//      Eye:     camPos,
+//      Ambient: math.Vec3{0.05, 0.05, 0.05},
+//      Lights:  lights,
+//  })
+//
+// Meshes shaded by a material must have normals (see gfx.Mesh.Normals), and
+// texture coordinates if the material is textured.
+//
+// Lighting is computed in world space; the object's Model matrix is used to
+// transform normals, which is only correct for uniform scaling.
+//
+// The Forward type implements forward rendering on top of materials: for each
+// object drawn it picks the lights most relevant to the object (based on their
+// intensity, range and cone), and updates the object's shader and inputs
+// accordingly.
+package material
@@ -0,0 +1,143 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package material
+
+import (
+	"azul3d.org/v1/gfx"
+	gmath "azul3d.org/v1/math"
+	"image"
+	"sync"
+)
+
+// objectShader is the shader of an object drawn by a forward renderer.
+type objectShader struct {
+	key    shaderKey
+	shader *gfx.Shader
+}
+
+// Forward is a forward rendering helper. For each object drawn, it selects the
+// lights most relevant to the object (see Select) and updates the shader of
+// the object to shade it with it's material under those lights.
+//
+// Since shader inputs are stored per-shader, each object is given it's own
+// shader. Objects whose material changes lighting model or texturing are
+// given a new shader.
+//
+// It is safe to use from multiple goroutines concurrently.
+type Forward struct {
+	access sync.Mutex
+
+	// The maximum number of lights affecting a single object.
+	maxLights int
+
+	// The lights of the scene, and the ambient light color.
+	lights  []Light
+	ambient gmath.Vec3
+
+	// The shader of each object.
+	shaders map[*gfx.Object]*objectShader
+}
+
+// SetLights sets the lights and the linear RGB ambient light color of the
+// scene.
+func (f *Forward) SetLights(lights []Light, ambient gmath.Vec3) {
+	f.access.Lock()
+	f.lights = append(f.lights[:0], lights...)
+	f.ambient = ambient
+	f.access.Unlock()
+}
+
+// Lights returns the lights of the scene.
+func (f *Forward) Lights() []Light {
+	f.access.Lock()
+	lights := make([]Light, len(f.lights))
+	copy(lights, f.lights)
+	f.access.Unlock()
+	return lights
+}
+
+// MaxLights returns the maximum number of lights affecting a single object.
+func (f *Forward) MaxLights() int {
+	return f.maxLights
+}
+
+// Prepare prepares the given object to be drawn with the given material as
+// seen by the given camera: the lights most relevant to the object are
+// selected and each mesh of the object is assigned a shader (and the
+// material's texture, if any) shading it accordingly. Any previous shaders and
+// textures of the object are replaced.
+//
+// The object and camera are locked while this method operates.
+func (f *Forward) Prepare(o *gfx.Object, m *Material, c *gfx.Camera) {
+	// Find the viewer's position.
+	var eye gmath.Vec3
+	if c != nil {
+		c.RLock()
+		eye = c.Transform.Mat4().Translation()
+		c.RUnlock()
+	}
+
+	bounds := o.Bounds()
+	k := m.key(f.maxLights)
+
+	f.access.Lock()
+	env := Env{
+		Eye:     eye,
+		Ambient: f.ambient,
+		Lights:  Select(f.lights, bounds, f.maxLights),
+	}
+	os := f.shaders[o]
+	if os == nil || os.key != k {
+		os = &objectShader{
+			key:    k,
+			shader: m.Shader(f.maxLights),
+		}
+		f.shaders[o] = os
+	}
+	f.access.Unlock()
+
+	os.shader.Lock()
+	m.SetInputs(os.shader.Inputs, f.maxLights, env)
+	os.shader.Unlock()
+
+	o.Lock()
+	o.Shaders = o.Shaders[:0]
+	for len(o.Shaders) < len(o.Meshes) {
+		o.Shaders = append(o.Shaders, os.shader)
+	}
+	o.Textures = o.Textures[:0]
+	for len(o.Textures) < len(o.Meshes) {
+		if m.Texture != nil {
+			o.Textures = append(o.Textures, []*gfx.Texture{m.Texture})
+		} else {
+			o.Textures = append(o.Textures, nil)
+		}
+	}
+	o.Unlock()
+}
+
+// Draw prepares the object (see Prepare) and then draws it onto the given
+// rectangle of the canvas, as seen by the camera.
+func (f *Forward) Draw(canvas gfx.Canvas, r image.Rectangle, o *gfx.Object, m *Material, c *gfx.Camera) {
+	f.Prepare(o, m, c)
+	canvas.Draw(r, o, c)
+}
+
+// Forget forgets the shader of the given object, such that it may be garbage
+// collected. It should be called once an object is no longer drawn.
+func (f *Forward) Forget(o *gfx.Object) {
+	f.access.Lock()
+	delete(f.shaders, o)
+	f.access.Unlock()
+}
+
+// NewForward returns a new forward rendering helper, shading objects with at
+// most maxLights lights each.
+func NewForward(maxLights int) *Forward {
+	return &Forward{
+		maxLights: maxLights,
+		shaders:   make(map[*gfx.Object]*objectShader),
+	}
+}
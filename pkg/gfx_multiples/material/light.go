@@ -0,0 +1,175 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package material
+
+import (
+	gmath "azul3d.org/v1/math"
+	"math"
+	"sort"
+)
+
+// LightType is the type of a light.
+type LightType uint8
+
+const (
+	// DirectionalLight is a light infinitely far away (e.g. the sun), lighting
+	// everything from the same direction.
+	DirectionalLight LightType = iota
+
+	// PointLight is a light emitting in all directions from a position (e.g.
+	// a light bulb).
+	PointLight
+
+	// SpotLight is a light emitting in a cone from a position (e.g. a
+	// flashlight).
+	SpotLight
+)
+
+// Light is a single light source.
+type Light struct {
+	// The type of light.
+	Type LightType
+
+	// The linear RGB color of the light, and the intensity it is multiplied
+	// by.
+	Color     gmath.Vec3
+	Intensity float64
+
+	// The world position of point and spot lights.
+	Pos gmath.Vec3
+
+	// The normalized world direction that directional and spot lights point
+	// in (i.e. the direction light travels in).
+	Dir gmath.Vec3
+
+	// The distance at which the light of point and spot lights falls off to
+	// zero.
+	Range float64
+
+	// The angles, in degrees, between the direction of a spot light and the
+	// edges of it's inner cone (full intensity) and outer cone (no
+	// intensity).
+	InnerCone, OuterCone float64
+}
+
+// radiance returns the light's color multiplied by it's intensity.
+func (l Light) radiance() gmath.Vec3 {
+	return l.Color.MulScalar(l.Intensity)
+}
+
+// Relevance returns an estimate of how much the light contributes to the
+// lighting of an object with the given world-space bounding box, with zero
+// meaning that the light does not reach the object at all.
+//
+// Directional lights are relevant to everything. Point and spot lights are
+// relevant in proportion to their attenuation at the closest point of the
+// bounding sphere of the box, and spot lights must additionally reach the
+// sphere with their outer cone.
+func (l Light) Relevance(bounds gmath.Rect3) float64 {
+	c := l.radiance()
+	score := 0.2126*c.X + 0.7152*c.Y + 0.0722*c.Z
+	if l.Type == DirectionalLight || score <= 0 {
+		return score
+	}
+
+	center := bounds.Min.Add(bounds.Max).MulScalar(0.5)
+	radius := bounds.Max.Sub(center).Length()
+	toCenter := center.Sub(l.Pos)
+	dist := toCenter.Length()
+	d := math.Max(dist-radius, 0)
+	if d >= l.Range {
+		return 0
+	}
+	x := 1 - d/l.Range
+	score *= x * x
+
+	if l.Type == SpotLight && dist > radius {
+		// The angle from the spot's axis to the center of the sphere, less
+		// the angular radius of the sphere.
+		dir, _ := l.Dir.Normalized()
+		cos := math.Max(-1, math.Min(1, toCenter.Dot(dir)/dist))
+		angle := math.Acos(cos) - math.Asin(radius/dist)
+		if angle > gmath.Radians(l.OuterCone) {
+			return 0
+		}
+	}
+	return score
+}
+
+// byRelevance sorts lights by their relevance, most relevant first.
+type byRelevance struct {
+	lights []Light
+	scores []float64
+}
+
+func (b byRelevance) Len() int           { return len(b.lights) }
+func (b byRelevance) Less(i, j int) bool { return b.scores[i] > b.scores[j] }
+func (b byRelevance) Swap(i, j int) {
+	b.lights[i], b.lights[j] = b.lights[j], b.lights[i]
+	b.scores[i], b.scores[j] = b.scores[j], b.scores[i]
+}
+
+// Select returns the (at most) n lights most relevant to an object with the
+// given world-space bounding box (see Light.Relevance), most relevant first.
+// Lights that do not reach the object are never returned. Lights that are
+// equally relevant keep their order.
+func Select(lights []Light, bounds gmath.Rect3, n int) []Light {
+	b := byRelevance{
+		lights: make([]Light, 0, len(lights)),
+		scores: make([]float64, 0, len(lights)),
+	}
+	for _, l := range lights {
+		if s := l.Relevance(bounds); s > 0 {
+			b.lights = append(b.lights, l)
+			b.scores = append(b.scores, s)
+		}
+	}
+	sort.Stable(b)
+	if len(b.lights) > n {
+		b.lights = b.lights[:n]
+	}
+	return b.lights
+}
+
+// NewDirectional returns a new directional light shining in the given
+// direction.
+func NewDirectional(dir, color gmath.Vec3, intensity float64) Light {
+	dir, _ = dir.Normalized()
+	return Light{
+		Type:      DirectionalLight,
+		Color:     color,
+		Intensity: intensity,
+		Dir:       dir,
+	}
+}
+
+// NewPoint returns a new point light at the given position, whose light falls
+// off to zero at the given range.
+func NewPoint(pos, color gmath.Vec3, intensity, rng float64) Light {
+	return Light{
+		Type:      PointLight,
+		Color:     color,
+		Intensity: intensity,
+		Pos:       pos,
+		Range:     rng,
+	}
+}
+
+// NewSpot returns a new spot light at the given position shining in the given
+// direction, whose light falls off to zero at the given range. The inner and
+// outer cone angles are in degrees.
+func NewSpot(pos, dir, color gmath.Vec3, intensity, rng, inner, outer float64) Light {
+	dir, _ = dir.Normalized()
+	return Light{
+		Type:      SpotLight,
+		Color:     color,
+		Intensity: intensity,
+		Pos:       pos,
+		Dir:       dir,
+		Range:     rng,
+		InnerCone: inner,
+		OuterCone: outer,
+	}
+}
@@ -0,0 +1,362 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package material
+
+import (
+	"azul3d.org/v1/gfx"
+	gmath "azul3d.org/v1/math"
+	"bytes"
+	"fmt"
+	"math"
+)
+
+// Model is a lighting model.
+type Model uint8
+
+const (
+	// Phong is the Blinn-Phong lighting model: lambertian diffuse lighting
+	// and a specular highlight of a given color and shininess.
+	Phong Model = iota
+
+	// PBR is a physically based lighting model using the metallic/roughness
+	// workflow (a Cook-Torrance BRDF with the GGX distribution).
+	PBR
+)
+
+// String returns a string representation of the lighting model.
+func (m Model) String() string {
+	switch m {
+	case Phong:
+		return "Phong"
+	case PBR:
+		return "PBR"
+	}
+	return fmt.Sprintf("Model(%d)", m)
+}
+
+// Material describes the surface of an object.
+type Material struct {
+	// The lighting model of the material.
+	Model Model
+
+	// The linear RGB base color of the surface, and it's opacity.
+	Albedo gmath.Vec3
+	Alpha  float64
+
+	// The linear RGB color emitted by the surface, regardless of lighting.
+	Emissive gmath.Vec3
+
+	// The color and shininess (exponent) of specular highlights, used by the
+	// Phong model only.
+	Specular  gmath.Vec3
+	Shininess float64
+
+	// The metalness and roughness of the surface in the range [0, 1], used by
+	// the PBR model only.
+	Metallic, Roughness float64
+
+	// An optional texture whose color is multiplied with the albedo, using
+	// the first set of texture coordinates of the mesh.
+	Texture *gfx.Texture
+}
+
+// Env is the environment in which an object is shaded.
+type Env struct {
+	// The world position of the viewer (i.e. the camera).
+	Eye gmath.Vec3
+
+	// The linear RGB ambient light color, multiplied by the albedo.
+	Ambient gmath.Vec3
+
+	// The lights affecting the object.
+	Lights []Light
+}
+
+// key returns the shader cache key of the material for the given number of
+// lights: materials with the same key share the same shader sources.
+func (m *Material) key(maxLights int) shaderKey {
+	return shaderKey{m.Model, m.Texture != nil, maxLights}
+}
+
+// shaderKey identifies the sources generated for a material.
+type shaderKey struct {
+	model     Model
+	textured  bool
+	maxLights int
+}
+
+// name returns the name of shaders with this key.
+func (k shaderKey) name() string {
+	name := fmt.Sprintf("material.%s%d", k.model, k.maxLights)
+	if k.textured {
+		name += "Textured"
+	}
+	return name
+}
+
+// GLSL generates and returns the GLSL vertex and fragment shader sources for
+// this material, supporting up to maxLights lights.
+//
+// The sources depend only on the lighting model, the number of lights, and
+// whether or not the material has a texture (all other properties are given
+// as inputs, see SetInputs).
+func (m *Material) GLSL(maxLights int) (vert, frag []byte) {
+	k := m.key(maxLights)
+	return k.vert(), k.frag()
+}
+
+func (k shaderKey) vert() []byte {
+	var b bytes.Buffer
+	b.WriteString("#version 120\n\n")
+	b.WriteString("attribute vec3 Vertex;\n")
+	b.WriteString("attribute vec3 Normal;\n")
+	if k.textured {
+		b.WriteString("attribute vec2 TexCoord0;\n")
+	}
+	b.WriteString("\nuniform mat4 MVP;\n")
+	b.WriteString("uniform mat4 Model;\n\n")
+	b.WriteString("varying vec3 worldPos;\n")
+	b.WriteString("varying vec3 worldNormal;\n")
+	if k.textured {
+		b.WriteString("varying vec2 tc0;\n")
+	}
+	b.WriteString("\nvoid main()\n{\n")
+	b.WriteString("\tworldPos = (Model * vec4(Vertex, 1.0)).xyz;\n")
+	b.WriteString("\tworldNormal = (Model * vec4(Normal, 0.0)).xyz;\n")
+	if k.textured {
+		b.WriteString("\ttc0 = TexCoord0;\n")
+	}
+	b.WriteString("\tgl_Position = MVP * vec4(Vertex, 1.0);\n")
+	b.WriteString("}\n")
+	return b.Bytes()
+}
+
+// glslLight computes the direction towards, and attenuation of, light i at
+// the fragment.
+const glslLight = `
+vec3 lightDir(int i, out float atten)
+{
+	atten = 1.0;
+	if(LightType[i] < 0.5) {
+		return -LightDir[i];
+	}
+	vec3 d = LightPos[i] - worldPos;
+	float dist = length(d);
+	vec3 l = d / max(dist, 0.0001);
+	float x = clamp(1.0 - dist / LightRange[i], 0.0, 1.0);
+	atten = x * x;
+	if(LightType[i] > 1.5) {
+		atten *= smoothstep(LightCosOuter[i], LightCosInner[i], dot(-l, LightDir[i]));
+	}
+	return l;
+}
+`
+
+const glslPhong = `
+	vec3 h = normalize(l + v);
+	float spec = 0.0;
+	if(ndl > 0.0) {
+		spec = pow(max(dot(n, h), 0.0), Shininess);
+	}
+	color += radiance * (albedo * ndl + Specular * spec);
+`
+
+const glslPBR = `
+	vec3 h = normalize(l + v);
+	float a = Roughness * Roughness;
+	float a2 = a * a;
+	float ndh = max(dot(n, h), 0.0);
+	float ndv = max(dot(n, v), 0.0001);
+	float q = ndh * ndh * (a2 - 1.0) + 1.0;
+	float D = a2 / (PI * q * q);
+	float k = (Roughness + 1.0) * (Roughness + 1.0) / 8.0;
+	float G = (ndv / (ndv * (1.0 - k) + k)) * (ndl / (ndl * (1.0 - k) + k));
+	vec3 F = f0 + (1.0 - f0) * pow(1.0 - max(dot(h, v), 0.0), 5.0);
+	vec3 spec = D * G * F / max(4.0 * ndv * ndl, 0.0001);
+	vec3 kd = (1.0 - F) * (1.0 - Metallic);
+	color += (kd * albedo / PI + spec) * radiance * ndl;
+`
+
+func (k shaderKey) frag() []byte {
+	var b bytes.Buffer
+	b.WriteString("#version 120\n\n")
+	if k.model == PBR {
+		b.WriteString("const float PI = 3.14159265;\n\n")
+	}
+	b.WriteString("varying vec3 worldPos;\n")
+	b.WriteString("varying vec3 worldNormal;\n")
+	if k.textured {
+		b.WriteString("varying vec2 tc0;\n\n")
+		b.WriteString("uniform sampler2D Texture0;\n")
+	}
+	b.WriteString("\nuniform vec3 Eye;\n")
+	b.WriteString("uniform vec3 Ambient;\n")
+	b.WriteString("uniform vec3 Albedo;\n")
+	b.WriteString("uniform float Alpha;\n")
+	b.WriteString("uniform vec3 Emissive;\n")
+	switch k.model {
+	case Phong:
+		b.WriteString("uniform vec3 Specular;\n")
+		b.WriteString("uniform float Shininess;\n")
+	case PBR:
+		b.WriteString("uniform float Metallic;\n")
+		b.WriteString("uniform float Roughness;\n")
+	}
+	if k.maxLights > 0 {
+		n := k.maxLights
+		b.WriteString("\nuniform float LightCount;\n")
+		fmt.Fprintf(&b, "uniform float LightType[%d];\n", n)
+		fmt.Fprintf(&b, "uniform vec3 LightPos[%d];\n", n)
+		fmt.Fprintf(&b, "uniform vec3 LightDir[%d];\n", n)
+		fmt.Fprintf(&b, "uniform vec3 LightColor[%d];\n", n)
+		fmt.Fprintf(&b, "uniform float LightRange[%d];\n", n)
+		fmt.Fprintf(&b, "uniform float LightCosInner[%d];\n", n)
+		fmt.Fprintf(&b, "uniform float LightCosOuter[%d];\n", n)
+		b.WriteString(glslLight)
+	}
+
+	b.WriteString("\nvoid main()\n{\n")
+	b.WriteString("\tvec3 n = normalize(worldNormal);\n")
+	b.WriteString("\tvec3 v = normalize(Eye - worldPos);\n")
+	b.WriteString("\tvec3 albedo = Albedo;\n")
+	b.WriteString("\tfloat alpha = Alpha;\n")
+	if k.textured {
+		b.WriteString("\tvec4 t = texture2D(Texture0, tc0);\n")
+		b.WriteString("\talbedo *= t.rgb;\n")
+		b.WriteString("\talpha *= t.a;\n")
+	}
+	if k.model == PBR {
+		b.WriteString("\tvec3 f0 = mix(vec3(0.04), albedo, Metallic);\n")
+	}
+	b.WriteString("\tvec3 color = Ambient * albedo + Emissive;\n")
+	if k.maxLights > 0 {
+		fmt.Fprintf(&b, "\tfor(int i = 0; i < %d; i++) {\n", k.maxLights)
+		b.WriteString("\t\tif(float(i) >= LightCount) {\n\t\t\tbreak;\n\t\t}\n")
+		b.WriteString("\t\tfloat atten;\n")
+		b.WriteString("\t\tvec3 l = lightDir(i, atten);\n")
+		b.WriteString("\t\tfloat ndl = max(dot(n, l), 0.0);\n")
+		b.WriteString("\t\tvec3 radiance = LightColor[i] * atten;\n")
+		body := glslPhong
+		if k.model == PBR {
+			body = glslPBR
+		}
+		b.WriteString(indent(body))
+		b.WriteString("\t}\n")
+	}
+	b.WriteString("\tgl_FragColor = vec4(color, alpha);\n")
+	b.WriteString("}\n")
+	return b.Bytes()
+}
+
+// indent indents each non-empty line of the GLSL body by one more tab.
+func indent(s string) string {
+	var b bytes.Buffer
+	for _, line := range bytes.Split([]byte(s), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		b.WriteByte('\t')
+		b.Write(line)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Shader returns a new shader with the generated sources of this material
+// (see GLSL), supporting up to maxLights lights. The shader's inputs are not
+// set (see SetInputs).
+func (m *Material) Shader(maxLights int) *gfx.Shader {
+	k := m.key(maxLights)
+	s := gfx.NewShader(k.name())
+	s.GLSLVert = k.vert()
+	s.GLSLFrag = k.frag()
+	return s
+}
+
+func vec3(v gmath.Vec3) gfx.Vec3 {
+	return gfx.Vec3{float32(v.X), float32(v.Y), float32(v.Z)}
+}
+
+// SetInputs sets the shader inputs describing this material and the given
+// environment in the inputs map of a shader generated for this material with
+// the same number of lights (see Shader). Only the first maxLights lights of
+// the environment are used.
+func (m *Material) SetInputs(inputs map[string]interface{}, maxLights int, env Env) {
+	inputs["Eye"] = vec3(env.Eye)
+	inputs["Ambient"] = vec3(env.Ambient)
+	inputs["Albedo"] = vec3(m.Albedo)
+	inputs["Alpha"] = float32(m.Alpha)
+	inputs["Emissive"] = vec3(m.Emissive)
+	switch m.Model {
+	case Phong:
+		inputs["Specular"] = vec3(m.Specular)
+		inputs["Shininess"] = float32(m.Shininess)
+	case PBR:
+		inputs["Metallic"] = float32(m.Metallic)
+		// Perfectly smooth surfaces have an infinitely small highlight.
+		inputs["Roughness"] = float32(math.Min(math.Max(m.Roughness, 0.03), 1))
+	}
+	if maxLights == 0 {
+		return
+	}
+
+	lights := env.Lights
+	if len(lights) > maxLights {
+		lights = lights[:maxLights]
+	}
+	var (
+		types    = make([]float32, maxLights)
+		pos      = make([]gfx.Vec3, maxLights)
+		dir      = make([]gfx.Vec3, maxLights)
+		color    = make([]gfx.Vec3, maxLights)
+		ranges   = make([]float32, maxLights)
+		cosInner = make([]float32, maxLights)
+		cosOuter = make([]float32, maxLights)
+	)
+	for i, l := range lights {
+		types[i] = float32(l.Type)
+		pos[i] = vec3(l.Pos)
+		d, _ := l.Dir.Normalized()
+		dir[i] = vec3(d)
+		color[i] = vec3(l.radiance())
+		ranges[i] = float32(l.Range)
+		if l.Type == SpotLight {
+			cosInner[i] = float32(math.Cos(gmath.Radians(l.InnerCone)))
+			cosOuter[i] = float32(math.Cos(gmath.Radians(l.OuterCone)))
+		}
+	}
+	inputs["LightCount"] = float32(len(lights))
+	inputs["LightType"] = types
+	inputs["LightPos"] = pos
+	inputs["LightDir"] = dir
+	inputs["LightColor"] = color
+	inputs["LightRange"] = ranges
+	inputs["LightCosInner"] = cosInner
+	inputs["LightCosOuter"] = cosOuter
+}
+
+// NewPhong returns a new opaque Blinn-Phong material with the given albedo,
+// a white specular color and a shininess of 32.
+func NewPhong(albedo gmath.Vec3) *Material {
+	return &Material{
+		Model:     Phong,
+		Albedo:    albedo,
+		Alpha:     1,
+		Specular:  gmath.Vec3One,
+		Shininess: 32,
+	}
+}
+
+// NewPBR returns a new opaque physically based material with the given
+// albedo, metalness and roughness.
+func NewPBR(albedo gmath.Vec3, metallic, roughness float64) *Material {
+	return &Material{
+		Model:     PBR,
+		Albedo:    albedo,
+		Alpha:     1,
+		Metallic:  metallic,
+		Roughness: roughness,
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package material
+
+import (
+	"azul3d.org/v1/gfx"
+	"azul3d.org/v1/gfx/glsl"
+	gmath "azul3d.org/v1/math"
+	"image"
+	"testing"
+)
+
+func builtin(name string) bool {
+	return name == "MVP" || name == "Model"
+}
+
+func testEnv() Env {
+	return Env{
+		Eye:     gmath.Vec3{0, -10, 0},
+		Ambient: gmath.Vec3{0.1, 0.1, 0.1},
+		Lights: []Light{
+			NewDirectional(gmath.Vec3{0, 0, -1}, gmath.Vec3One, 1),
+			NewPoint(gmath.Vec3{0, 0, 5}, gmath.Vec3{1, 0.5, 0}, 2, 20),
+			NewSpot(gmath.Vec3{5, 0, 5}, gmath.Vec3{-1, 0, -1}, gmath.Vec3One, 3, 30, 20, 30),
+		},
+	}
+}
+
+func TestShaderInputs(t *testing.T) {
+	tex := &gfx.Texture{Bounds: image.Rect(0, 0, 1, 1)}
+	for _, m := range []*Material{
+		NewPhong(gmath.Vec3One),
+		NewPBR(gmath.Vec3One, 1, 0.5),
+	} {
+		for _, texture := range []*gfx.Texture{nil, tex} {
+			m.Texture = texture
+			for _, maxLights := range []int{0, 2, 4} {
+				s := m.Shader(maxLights)
+				r, err := glsl.ReflectShader(s)
+				if err != nil {
+					t.Fatalf("%s: %v", s.Name, err)
+				}
+				m.SetInputs(s.Inputs, maxLights, testEnv())
+				if err := r.Validate(s.Inputs, builtin); err != nil {
+					t.Errorf("%s: %v", s.Name, err)
+				}
+
+				attribs := 2
+				if texture != nil {
+					attribs++
+				}
+				if n := len(r.Attributes); n != attribs {
+					t.Errorf("%s: got %d attributes, want %d", s.Name, n, attribs)
+				}
+			}
+		}
+	}
+}
+
+func TestSetInputs(t *testing.T) {
+	m := NewPBR(gmath.Vec3One, 0, 0)
+	inputs := make(map[string]interface{})
+	m.SetInputs(inputs, 2, testEnv())
+	if v := inputs["LightCount"].(float32); v != 2 {
+		t.Fatalf("got LightCount %v, want 2", v)
+	}
+	if v := inputs["Roughness"].(float32); v <= 0 {
+		t.Fatalf("got Roughness %v, want > 0", v)
+	}
+	if v := inputs["LightType"].([]float32); len(v) != 2 || v[1] != float32(PointLight) {
+		t.Fatalf("got LightType %v", v)
+	}
+
+	// Arrays are padded to the maximum number of lights.
+	m.SetInputs(inputs, 4, Env{})
+	if v := inputs["LightColor"].([]gfx.Vec3); len(v) != 4 {
+		t.Fatalf("got %d light colors, want 4", len(v))
+	}
+}
+
+func TestSelect(t *testing.T) {
+	bounds := gmath.Rect3{
+		Min: gmath.Vec3{-1, -1, -1},
+		Max: gmath.Vec3{1, 1, 1},
+	}
+	white := gmath.Vec3One
+	var (
+		sun     = NewDirectional(gmath.Vec3{0, 0, -1}, white, 0.5)
+		near    = NewPoint(gmath.Vec3{0, 0, 2}, white, 1, 10)
+		far     = NewPoint(gmath.Vec3{0, 0, 8}, white, 1, 10)
+		outside = NewPoint(gmath.Vec3{0, 0, 20}, white, 100, 10)
+		dark    = NewPoint(gmath.Vec3{0, 0, 0}, white, 0, 10)
+		spotAt  = NewSpot(gmath.Vec3{0, 0, 5}, gmath.Vec3{0, 0, -1}, white, 2, 10, 10, 20)
+		spotOff = NewSpot(gmath.Vec3{0, 0, 5}, gmath.Vec3{0, 0, 1}, white, 2, 10, 10, 20)
+	)
+	lights := []Light{sun, far, outside, near, dark, spotOff, spotAt}
+
+	got := Select(lights, bounds, 8)
+	want := []Light{near, spotAt, sun, far}
+	if len(got) != len(want) {
+		t.Fatalf("got %d lights, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("light %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	if got := Select(lights, bounds, 1); len(got) != 1 || got[0] != near {
+		t.Fatalf("got %+v, want the near light", got)
+	}
+}
+
+func TestForward(t *testing.T) {
+	f := NewForward(4)
+	f.SetLights(testEnv().Lights, gmath.Vec3{0.1, 0.1, 0.1})
+
+	cam := gfx.NewCamera()
+	cam.SetPersp(image.Rect(0, 0, 64, 64), 75, 0.1, 100)
+
+	newObject := func() *gfx.Object {
+		o := gfx.NewObject()
+		o.Meshes = []*gfx.Mesh{{
+			Vertices: []gfx.Vec3{{-1, 0, -1}, {1, 0, -1}, {0, 0, 1}},
+		}, {
+			Vertices: []gfx.Vec3{{-1, 1, -1}, {1, 1, -1}, {0, 1, 1}},
+		}}
+		return o
+	}
+	a, b := newObject(), newObject()
+	m := NewPhong(gmath.Vec3One)
+
+	r := gfx.Nil()
+	f.Draw(r, r.Bounds(), a, m, cam)
+	f.Prepare(b, m, cam)
+	for _, o := range []*gfx.Object{a, b} {
+		if len(o.Shaders) != 2 || len(o.Textures) != 2 {
+			t.Fatalf("got %d shaders and %d texture sets, want 2", len(o.Shaders), len(o.Textures))
+		}
+	}
+	if a.Shaders[0] != a.Shaders[1] {
+		t.Fatal("meshes of an object have different shaders")
+	}
+	if a.Shaders[0] == b.Shaders[0] {
+		t.Fatal("objects share a shader")
+	}
+	if n := a.Shaders[0].Inputs["LightCount"].(float32); n != 3 {
+		t.Fatalf("got LightCount %v, want 3", n)
+	}
+
+	// The shader is kept until the material needs different sources.
+	s := a.Shaders[0]
+	f.Prepare(a, m, cam)
+	if a.Shaders[0] != s {
+		t.Fatal("shader was not reused")
+	}
+	m.Texture = &gfx.Texture{Bounds: image.Rect(0, 0, 1, 1)}
+	f.Prepare(a, m, cam)
+	if a.Shaders[0] == s {
+		t.Fatal("shader was not replaced")
+	}
+	if len(a.Textures[1]) != 1 || a.Textures[1][0] != m.Texture {
+		t.Fatal("texture not assigned")
+	}
+}
@@ -35,6 +35,66 @@ type TexCoordSet struct {
 	Changed bool
 }
 
+// VertexAttrib represents a single custom per-vertex attribute of a mesh (e.g.
+// tangent vectors or bone weights for skinning), it is accessible by shaders
+// as an attribute variable of the same name as it's key in the mesh's Attribs
+// map.
+type VertexAttrib struct {
+	// The slice of per-vertex data for the attribute, it must hold exactly
+	// one element per vertex of the mesh and must be one of the following
+	// data types (or else the attribute will be ignored):
+	//  []float32      (GLSL float)
+	//  []gfx.TexCoord (GLSL vec2)
+	//  []gfx.Vec3     (GLSL vec3)
+	//  []gfx.Vec4     (GLSL vec4)
+	//  []int32        (GLSL float, converted from the integer values)
+	Data interface{}
+
+	// Weather or not the data slice has changed since the last time the mesh
+	// was loaded. If set to true the renderer should take note and re-upload
+	// the data slice to the graphics hardware.
+	Changed bool
+}
+
+// Len returns the number of elements in the data slice of this attribute, or
+// -1 if the data slice is not one of the supported data types.
+func (a VertexAttrib) Len() int {
+	switch d := a.Data.(type) {
+	case []float32:
+		return len(d)
+	case []TexCoord:
+		return len(d)
+	case []Vec3:
+		return len(d)
+	case []Vec4:
+		return len(d)
+	case []int32:
+		return len(d)
+	}
+	return -1
+}
+
+// Copy returns a new copy of this attribute and it's data slice. Explicitly
+// not copied over is the changed status.
+func (a VertexAttrib) Copy() VertexAttrib {
+	var cpy interface{}
+	switch d := a.Data.(type) {
+	case []float32:
+		cpy = append([]float32(nil), d...)
+	case []TexCoord:
+		cpy = append([]TexCoord(nil), d...)
+	case []Vec3:
+		cpy = append([]Vec3(nil), d...)
+	case []Vec4:
+		cpy = append([]Vec4(nil), d...)
+	case []int32:
+		cpy = append([]int32(nil), d...)
+	default:
+		cpy = a.Data
+	}
+	return VertexAttrib{Data: cpy}
+}
+
 // NativeMesh represents the native object of a mesh, typically only renderers
 // create these.
 type NativeMesh Destroyable
@@ -113,12 +173,24 @@ type Mesh struct {
 	// multiple sets which directly relate to multiple textures on a
 	// object.
 	TexCoords []TexCoordSet
+
+	// The slice of vertex normals for the mesh.
+	Normals []Vec3
+
+	// Whether or not the vertex normals have changed since the last time the
+	// mesh was loaded. If set to true the renderer should take note and
+	// re-upload the data slice to the graphics hardware.
+	NormalsChanged bool
+
+	// A map of custom per-vertex attributes for the mesh, keyed by the name
+	// of the attribute variable used by shaders. It may be nil.
+	Attribs map[string]VertexAttrib
 }
 
 // Copy returns a new copy of this Mesh. Depending on how large the mesh is
 // this may be an expensive operation. Explicitly not copied over is the native
 // mesh, the OnLoad slice, and the loaded and changed statuses (Loaded,
-// IndicesChanged, VerticesChanged, etc).
+// IndicesChanged, VerticesChanged, etc). Custom attributes are deep copied.
 //
 // The mesh's read lock must be held for this method to operate safely.
 func (m *Mesh) Copy() *Mesh {
@@ -138,6 +210,9 @@ func (m *Mesh) Copy() *Mesh {
 		make([]Vec3, len(m.Bary)),
 		false, // BaryChanged -- not copied.
 		make([]TexCoordSet, len(m.TexCoords)),
+		make([]Vec3, len(m.Normals)),
+		false, // NormalsChanged -- not copied.
+		nil,   // Attribs -- copied below.
 	}
 
 	copy(cpy.Indices, m.Indices)
@@ -151,6 +226,13 @@ func (m *Mesh) Copy() *Mesh {
 		copy(setCpy.Slice, set.Slice)
 		cpy.TexCoords[index] = setCpy
 	}
+	copy(cpy.Normals, m.Normals)
+	if m.Attribs != nil {
+		cpy.Attribs = make(map[string]VertexAttrib, len(m.Attribs))
+		for name, attrib := range m.Attribs {
+			cpy.Attribs[name] = attrib.Copy()
+		}
+	}
 	return cpy
 }
 
@@ -160,6 +242,8 @@ func (m *Mesh) Copy() *Mesh {
 //  len(m.Colors) != len(m.Vertices)
 //  len(m.Bary) != len(m.Vertices)
 //  Any m.TexCoord whose len(texCoordSet.Slice) != len(m.Vertices)
+//  len(m.Normals) > 0 && len(m.Normals) != len(m.Vertices)
+//  Any m.Attribs whose attrib.Len() != len(m.Vertices)
 func (m *Mesh) CanDraw() bool {
 	if len(m.Vertices) == 0 {
 		return false
@@ -175,6 +259,14 @@ func (m *Mesh) CanDraw() bool {
 			return false
 		}
 	}
+	if len(m.Normals) > 0 && len(m.Normals) != len(m.Vertices) {
+		return false
+	}
+	for _, attrib := range m.Attribs {
+		if attrib.Len() != len(m.Vertices) {
+			return false
+		}
+	}
 	return true
 }
 
@@ -234,7 +326,7 @@ func (m *Mesh) CalculateBounds() {
 //
 // The mesh's read lock must be held for this method to operate safely.
 func (m *Mesh) HasChanged() bool {
-	if m.IndicesChanged || m.VerticesChanged || m.ColorsChanged || m.BaryChanged {
+	if m.IndicesChanged || m.VerticesChanged || m.ColorsChanged || m.BaryChanged || m.NormalsChanged {
 		return true
 	}
 	for _, texCoordSet := range m.TexCoords {
@@ -242,6 +334,11 @@ func (m *Mesh) HasChanged() bool {
 			return true
 		}
 	}
+	for _, attrib := range m.Attribs {
+		if attrib.Changed {
+			return true
+		}
+	}
 	return false
 }
 
@@ -256,5 +353,7 @@ func (m *Mesh) ClearData() {
 		m.Colors = nil
 		m.Bary = nil
 		m.TexCoords = nil
+		m.Normals = nil
+		m.Attribs = nil
 	}
 }
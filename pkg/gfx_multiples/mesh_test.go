@@ -0,0 +1,65 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gfx
+
+import "testing"
+
+func testMesh() *Mesh {
+	return &Mesh{
+		Vertices: []Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}},
+		Colors:   []Color{{1, 1, 1, 1}, {1, 1, 1, 1}, {1, 1, 1, 1}},
+		Bary:     []Vec3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+		Normals:  []Vec3{{0, 0, 1}, {0, 0, 1}, {0, 0, 1}},
+		Attribs: map[string]VertexAttrib{
+			"Weight": {Data: []float32{0.5, 1, 0.25}},
+		},
+	}
+}
+
+func TestMeshAttribs(t *testing.T) {
+	m := testMesh()
+	if !m.CanDraw() {
+		t.Fatal("expected CanDraw")
+	}
+
+	m.Attribs["Bad"] = VertexAttrib{Data: []float32{1}}
+	if m.CanDraw() {
+		t.Fatal("expected !CanDraw with short attribute")
+	}
+	m.Attribs["Bad"] = VertexAttrib{Data: []string{"a", "b", "c"}}
+	if m.CanDraw() {
+		t.Fatal("expected !CanDraw with unsupported attribute")
+	}
+	delete(m.Attribs, "Bad")
+
+	if m.HasChanged() {
+		t.Fatal("expected !HasChanged")
+	}
+	w := m.Attribs["Weight"]
+	w.Changed = true
+	m.Attribs["Weight"] = w
+	if !m.HasChanged() {
+		t.Fatal("expected HasChanged")
+	}
+}
+
+func TestMeshCopyAttribs(t *testing.T) {
+	m := testMesh()
+	cpy := m.Copy()
+	if len(cpy.Normals) != 3 || cpy.Normals[0] != m.Normals[0] {
+		t.Fatal("normals not copied")
+	}
+
+	// The attribute data must be a deep copy.
+	cpy.Attribs["Weight"].Data.([]float32)[0] = 42
+	if m.Attribs["Weight"].Data.([]float32)[0] == 42 {
+		t.Fatal("attribute data not deep copied")
+	}
+
+	m.ClearData()
+	if m.Normals != nil || m.Attribs != nil {
+		t.Fatal("data not cleared")
+	}
+}
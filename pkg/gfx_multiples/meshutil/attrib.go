@@ -0,0 +1,94 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package meshutil
+
+import "azul3d.org/v1/gfx"
+
+// attribEqual tells if elements i and j of the attribute's data are equal.
+func attribEqual(a gfx.VertexAttrib, i, j int) bool {
+	switch d := a.Data.(type) {
+	case []float32:
+		return d[i] == d[j]
+	case []gfx.TexCoord:
+		return d[i] == d[j]
+	case []gfx.Vec3:
+		return d[i] == d[j]
+	case []gfx.Vec4:
+		return d[i] == d[j]
+	case []int32:
+		return d[i] == d[j]
+	}
+	return true
+}
+
+// attribSelect returns a new attribute whose data holds the elements of the
+// given attribute's data at each of the given indices.
+func attribSelect(a gfx.VertexAttrib, indices []int) gfx.VertexAttrib {
+	switch d := a.Data.(type) {
+	case []float32:
+		s := make([]float32, len(indices))
+		for i, k := range indices {
+			s[i] = d[k]
+		}
+		return gfx.VertexAttrib{Data: s, Changed: true}
+	case []gfx.TexCoord:
+		s := make([]gfx.TexCoord, len(indices))
+		for i, k := range indices {
+			s[i] = d[k]
+		}
+		return gfx.VertexAttrib{Data: s, Changed: true}
+	case []gfx.Vec3:
+		s := make([]gfx.Vec3, len(indices))
+		for i, k := range indices {
+			s[i] = d[k]
+		}
+		return gfx.VertexAttrib{Data: s, Changed: true}
+	case []gfx.Vec4:
+		s := make([]gfx.Vec4, len(indices))
+		for i, k := range indices {
+			s[i] = d[k]
+		}
+		return gfx.VertexAttrib{Data: s, Changed: true}
+	case []int32:
+		s := make([]int32, len(indices))
+		for i, k := range indices {
+			s[i] = d[k]
+		}
+		return gfx.VertexAttrib{Data: s, Changed: true}
+	}
+	return a
+}
+
+// attribLerp linearly interpolates element i of the attribute's data towards
+// element j by t, storing the result in element i. Integer data is not
+// interpolated but instead takes the value of the nearest element.
+func attribLerp(a gfx.VertexAttrib, i, j int, t float32) {
+	switch d := a.Data.(type) {
+	case []float32:
+		d[i] += (d[j] - d[i]) * t
+	case []gfx.TexCoord:
+		d[i].U += (d[j].U - d[i].U) * t
+		d[i].V += (d[j].V - d[i].V) * t
+	case []gfx.Vec3:
+		d[i] = lerpVec3(d[i], d[j], t)
+	case []gfx.Vec4:
+		d[i].X += (d[j].X - d[i].X) * t
+		d[i].Y += (d[j].Y - d[i].Y) * t
+		d[i].Z += (d[j].Z - d[i].Z) * t
+		d[i].W += (d[j].W - d[i].W) * t
+	case []int32:
+		if t > 0.5 {
+			d[i] = d[j]
+		}
+	}
+}
+
+func lerpVec3(a, b gfx.Vec3, t float32) gfx.Vec3 {
+	return gfx.Vec3{
+		a.X + (b.X-a.X)*t,
+		a.Y + (b.Y-a.Y)*t,
+		a.Z + (b.Z-a.Z)*t,
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package meshutil
+
+import "math"
+
+// DefaultCacheSize is the post-transform vertex cache size that
+// OptimizeVertexCache optimizes for. Most graphics hardware has a cache of at
+// least this many vertices.
+const DefaultCacheSize = 32
+
+// Scoring constants as described by Tom Forsyth's "Linear-Speed Vertex Cache
+// Optimisation".
+const (
+	cacheDecayPower   = 1.5
+	lastTriScore      = 0.75
+	valenceBoostScale = 2.0
+	valenceBoostPower = 0.5
+)
+
+// vertexScore returns the score of a vertex given it's position in the
+// simulated cache (or -1 if not in the cache) and the number of triangles that
+// still need to be drawn using it.
+func vertexScore(cachePos, remaining, cacheSize int) float64 {
+	if remaining == 0 {
+		// No triangles need this vertex anymore.
+		return -1
+	}
+	var score float64
+	if cachePos >= 0 {
+		if cachePos < 3 {
+			// The vertex was used by the last triangle, it's given a fixed
+			// score so that the last triangle isn't simply repeated.
+			score = lastTriScore
+		} else {
+			scaler := 1.0 / float64(cacheSize-3)
+			score = 1.0 - float64(cachePos-3)*scaler
+			score = math.Pow(score, cacheDecayPower)
+		}
+	}
+
+	// Boost vertices with few remaining triangles, so that lone triangles
+	// are not left behind.
+	score += valenceBoostScale * math.Pow(float64(remaining), -valenceBoostPower)
+	return score
+}
+
+// OptimizeVertexCache reorders the triangles of the given triangle list index
+// buffer in order to improve the hit rate of the post-transform vertex cache
+// found on graphics hardware, and returns the new index buffer. The winding
+// order of each triangle is preserved.
+//
+// The vertexCount parameter is the number of vertices referenced by the
+// indices (i.e. len(m.Vertices)).
+//
+// The algorithm used is Tom Forsyth's "Linear-Speed Vertex Cache
+// Optimisation" which is not specific to any single cache size, a simulated
+// cache of DefaultCacheSize vertices is used for scoring.
+func OptimizeVertexCache(indices []uint32, vertexCount int) []uint32 {
+	const cacheSize = DefaultCacheSize
+	numTris := len(indices) / 3
+	if numTris == 0 {
+		return nil
+	}
+
+	// Build vertex to triangle adjacency.
+	remaining := make([]int, vertexCount)
+	for _, idx := range indices[:numTris*3] {
+		remaining[idx]++
+	}
+	offsets := make([]int, vertexCount+1)
+	for v := 0; v < vertexCount; v++ {
+		offsets[v+1] = offsets[v] + remaining[v]
+	}
+	fill := make([]int, vertexCount)
+	adjacency := make([]int, offsets[vertexCount])
+	for t := 0; t < numTris; t++ {
+		for k := 0; k < 3; k++ {
+			v := indices[t*3+k]
+			adjacency[offsets[v]+fill[v]] = t
+			fill[v]++
+		}
+	}
+
+	// Initial vertex and triangle scores.
+	cachePos := make([]int, vertexCount)
+	vScore := make([]float64, vertexCount)
+	for v := range cachePos {
+		cachePos[v] = -1
+		vScore[v] = vertexScore(-1, remaining[v], cacheSize)
+	}
+	triScore := make([]float64, numTris)
+	triAdded := make([]bool, numTris)
+	for t := 0; t < numTris; t++ {
+		for k := 0; k < 3; k++ {
+			triScore[t] += vScore[indices[t*3+k]]
+		}
+	}
+
+	// removeTri removes the triangle from the adjacency list of the vertex.
+	removeTri := func(v uint32, t int) {
+		adj := adjacency[offsets[v] : offsets[v]+remaining[v]]
+		for i, at := range adj {
+			if at == t {
+				adj[i] = adj[len(adj)-1]
+				break
+			}
+		}
+		remaining[v]--
+	}
+
+	var (
+		out       = make([]uint32, 0, numTris*3)
+		cache     = make([]uint32, 0, cacheSize+3)
+		nextCache = make([]uint32, 0, cacheSize+3)
+		bestTri   = -1
+		scanPos   = 0
+	)
+	for len(out) < numTris*3 {
+		if bestTri == -1 {
+			// No candidate from the cache, find the best scoring triangle
+			// remaining. Since triangles are never re-added, a linear scan
+			// from the last position is enough to find an unadded one.
+			bestScore := -1.0
+			for t := scanPos; t < numTris; t++ {
+				if !triAdded[t] && triScore[t] > bestScore {
+					bestScore = triScore[t]
+					bestTri = t
+				}
+			}
+			for scanPos < numTris && triAdded[scanPos] {
+				scanPos++
+			}
+		}
+		t := bestTri
+		triAdded[t] = true
+		tri := indices[t*3 : t*3+3]
+		out = append(out, tri...)
+
+		// Move the triangle's vertices to the front of the cache.
+		nextCache = append(nextCache[:0], tri...)
+		for _, v := range tri {
+			removeTri(v, t)
+		}
+		for _, v := range cache {
+			if v != tri[0] && v != tri[1] && v != tri[2] {
+				nextCache = append(nextCache, v)
+			}
+		}
+		cache, nextCache = nextCache, cache
+
+		// Vertices pushed out of the cache are no longer in it.
+		for _, v := range nextCache {
+			cachePos[v] = -1
+		}
+		if len(cache) > cacheSize {
+			for _, v := range cache[cacheSize:] {
+				cachePos[v] = -1
+				vScore[v] = vertexScore(-1, remaining[v], cacheSize)
+				for _, at := range adjacency[offsets[v] : offsets[v]+remaining[v]] {
+					triScore[at] = vScore[indices[at*3]] + vScore[indices[at*3+1]] + vScore[indices[at*3+2]]
+				}
+			}
+			cache = cache[:cacheSize]
+		}
+
+		// Update the scores of the vertices in the cache, and the triangles
+		// using them, picking the best scoring triangle as the next one.
+		for i, v := range cache {
+			cachePos[v] = i
+		}
+		for _, v := range cache {
+			vScore[v] = vertexScore(cachePos[v], remaining[v], cacheSize)
+		}
+		bestTri = -1
+		bestScore := -1.0
+		for _, v := range cache {
+			for _, at := range adjacency[offsets[v] : offsets[v]+remaining[v]] {
+				s := vScore[indices[at*3]] + vScore[indices[at*3+1]] + vScore[indices[at*3+2]]
+				triScore[at] = s
+				if s > bestScore {
+					bestScore = s
+					bestTri = at
+				}
+			}
+		}
+	}
+	return out
+}
+
+// ACMR returns the average cache miss ratio (i.e. the average number of
+// vertices transformed per triangle) of the given triangle list index buffer,
+// when drawn by graphics hardware with a FIFO post-transform vertex cache of
+// the given size.
+//
+// It ranges from 3.0 (worst, every vertex is a cache miss) to about 0.5 (best,
+// for large regular meshes).
+func ACMR(indices []uint32, cacheSize int) float64 {
+	numTris := len(indices) / 3
+	if numTris == 0 {
+		return 0
+	}
+	var (
+		fifo   = make([]uint32, 0, cacheSize)
+		misses int
+	)
+	for _, v := range indices[:numTris*3] {
+		hit := false
+		for _, c := range fifo {
+			if c == v {
+				hit = true
+				break
+			}
+		}
+		if hit {
+			continue
+		}
+		misses++
+		if len(fifo) == cacheSize {
+			fifo = append(fifo[:0], fifo[1:]...)
+		}
+		fifo = append(fifo, v)
+	}
+	return float64(misses) / float64(numTris)
+}
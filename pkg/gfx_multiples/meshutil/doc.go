@@ -0,0 +1,42 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package meshutil implements various processing utilities for gfx meshes.
+//
+// It provides utilities for calculating per-vertex normals and tangents,
+// welding duplicate vertices into an indexed mesh, optimizing index buffers
+// for the post-transform vertex cache of graphics hardware, converting between
+// triangle lists, strips and fans, and simplifying meshes (e.g. to produce
+// level-of-detail chains).
+//
+// Unless explicitly specified otherwise, functions in this package do not lock
+// the meshes they operate on: the caller is responsible for holding the
+// mesh's read lock (for functions that only inspect the mesh) or write lock
+// (for functions that modify the mesh).
+package meshutil
+
+import "azul3d.org/v1/gfx"
+
+// eachTriangle invokes fn with the three vertex indices of each triangle in
+// the mesh, regardless of whether or not the mesh is indexed. Trailing
+// vertices or indices not forming a complete triangle are ignored.
+func eachTriangle(m *gfx.Mesh, fn func(a, b, c uint32)) {
+	if len(m.Indices) > 0 {
+		for i := 0; i+2 < len(m.Indices); i += 3 {
+			fn(m.Indices[i], m.Indices[i+1], m.Indices[i+2])
+		}
+		return
+	}
+	for i := 0; i+2 < len(m.Vertices); i += 3 {
+		fn(uint32(i), uint32(i+1), uint32(i+2))
+	}
+}
+
+// numTriangles returns the number of triangles in the mesh.
+func numTriangles(m *gfx.Mesh) int {
+	if len(m.Indices) > 0 {
+		return len(m.Indices) / 3
+	}
+	return len(m.Vertices) / 3
+}
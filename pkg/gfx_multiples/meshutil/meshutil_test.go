@@ -0,0 +1,361 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package meshutil
+
+import (
+	"azul3d.org/v1/gfx"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+// grid returns an indexed mesh of a flat grid on the XY plane with n*n quads
+// (each made of two triangles) spanning 0-1 on both axis, with texture
+// coordinates matching the X and Y position.
+func grid(n int) *gfx.Mesh {
+	m := new(gfx.Mesh)
+	tc := gfx.TexCoordSet{}
+	for y := 0; y <= n; y++ {
+		for x := 0; x <= n; x++ {
+			fx, fy := float32(x)/float32(n), float32(y)/float32(n)
+			m.Vertices = append(m.Vertices, gfx.Vec3{fx, fy, 0})
+			m.Colors = append(m.Colors, gfx.Color{fx, fy, 1, 1})
+			tc.Slice = append(tc.Slice, gfx.TexCoord{fx, fy})
+		}
+	}
+	m.TexCoords = []gfx.TexCoordSet{tc}
+	row := uint32(n + 1)
+	for y := uint32(0); y < uint32(n); y++ {
+		for x := uint32(0); x < uint32(n); x++ {
+			i := y*row + x
+			m.Indices = append(m.Indices,
+				i, i+1, i+row+1,
+				i, i+row+1, i+row,
+			)
+		}
+	}
+	return m
+}
+
+// sphere returns a non-indexed mesh (i.e. triangle soup) of a UV sphere with
+// the given radius, number of rings and segments.
+func sphere(radius float64, rings, segments int) *gfx.Mesh {
+	point := func(r, s int) gfx.Vec3 {
+		theta := math.Pi * float64(r) / float64(rings)
+		phi := 2 * math.Pi * float64(s%segments) / float64(segments)
+		return gfx.Vec3{
+			float32(radius * math.Sin(theta) * math.Cos(phi)),
+			float32(radius * math.Sin(theta) * math.Sin(phi)),
+			float32(radius * math.Cos(theta)),
+		}
+	}
+	m := new(gfx.Mesh)
+	for r := 0; r < rings; r++ {
+		for s := 0; s < segments; s++ {
+			a, b := point(r, s), point(r, s+1)
+			c, d := point(r+1, s), point(r+1, s+1)
+			if r != 0 {
+				m.Vertices = append(m.Vertices, a, c, b)
+			}
+			if r != rings-1 {
+				m.Vertices = append(m.Vertices, b, c, d)
+			}
+		}
+	}
+	return m
+}
+
+// triangles returns the sorted list of triangles (as positions) of the mesh,
+// each rotated such that their smallest vertex is first (i.e. winding order is
+// kept).
+func triangles(m *gfx.Mesh) []string {
+	var tris []string
+	eachTriangle(m, func(a, b, c uint32) {
+		tris = append(tris, triString(m.Vertices[a], m.Vertices[b], m.Vertices[c]))
+	})
+	sort.Strings(tris)
+	return tris
+}
+
+func triString(a, b, c gfx.Vec3) string {
+	less := func(a, b gfx.Vec3) bool {
+		if a.X != b.X {
+			return a.X < b.X
+		}
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.Z < b.Z
+	}
+	for less(b, a) || less(c, a) {
+		a, b, c = b, c, a
+	}
+	return fmtVec(a) + fmtVec(b) + fmtVec(c)
+}
+
+func fmtVec(v gfx.Vec3) string {
+	return "(" + ftoa(v.X) + "," + ftoa(v.Y) + "," + ftoa(v.Z) + ")"
+}
+
+func ftoa(f float32) string {
+	// Quantize, such that welded positions compare equal.
+	q := math.Floor(float64(f)*1e4 + 0.5)
+	if q == 0 {
+		q = 0 // Negative zero.
+	}
+	return strconv.FormatFloat(q, 'f', 0, 64)
+}
+
+func equalTriangles(t *testing.T, want, got []string) {
+	if len(want) != len(got) {
+		t.Fatalf("got %d triangles, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if want[i] != got[i] {
+			t.Fatalf("triangle %d differs", i)
+		}
+	}
+}
+
+func near(a, b gfx.Vec3) bool {
+	const eps = 1e-4
+	return math.Abs(float64(a.X-b.X)) < eps &&
+		math.Abs(float64(a.Y-b.Y)) < eps &&
+		math.Abs(float64(a.Z-b.Z)) < eps
+}
+
+func TestNormals(t *testing.T) {
+	m := grid(4)
+	for i, n := range Normals(m) {
+		if !near(n, gfx.Vec3{0, 0, 1}) {
+			t.Fatalf("vertex %d: got normal %v, want up", i, n)
+		}
+	}
+
+	// Smooth sphere normals should point outward from the center.
+	s := sphere(2, 8, 16)
+	Weld(s, 1e-5)
+	for i, n := range Normals(s) {
+		v := s.Vertices[i].Vec3()
+		dir, _ := v.Normalized()
+		if d := dir.Dot(n.Vec3()); d < 0.95 {
+			t.Fatalf("vertex %d: normal %v not outward (dot %v)", i, n, d)
+		}
+	}
+}
+
+func TestTangents(t *testing.T) {
+	m := grid(4)
+	normals := Normals(m)
+	tangents, bitangents, err := Tangents(m, normals, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range tangents {
+		if !near(tangents[i], gfx.Vec3{1, 0, 0}) {
+			t.Fatalf("vertex %d: got tangent %v", i, tangents[i])
+		}
+		if !near(bitangents[i], gfx.Vec3{0, 1, 0}) {
+			t.Fatalf("vertex %d: got bitangent %v", i, bitangents[i])
+		}
+	}
+
+	if _, _, err := Tangents(m, normals, 1); err != ErrNoTexCoords {
+		t.Fatal("expected ErrNoTexCoords, got", err)
+	}
+}
+
+func TestWeld(t *testing.T) {
+	const rings, segments = 8, 16
+	m := sphere(1, rings, segments)
+	want := triangles(m)
+	Weld(m, 1e-5)
+
+	// Two poles plus every ring in between.
+	if n := len(m.Vertices); n != 2+(rings-1)*segments {
+		t.Fatalf("got %d vertices, want %d", n, 2+(rings-1)*segments)
+	}
+	if !m.IndicesChanged || !m.VerticesChanged {
+		t.Fatal("expected changed flags to be set")
+	}
+	equalTriangles(t, want, triangles(m))
+}
+
+func TestWeldAttributes(t *testing.T) {
+	m := &gfx.Mesh{
+		Vertices: []gfx.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 0, 0}, {0, 1, 0}, {1, 1, 0}},
+		Colors: []gfx.Color{
+			{1, 0, 0, 1}, {1, 0, 0, 1}, {1, 0, 0, 1},
+			{0, 1, 0, 1}, {1, 0, 0, 1}, {1, 0, 0, 1},
+		},
+	}
+	Weld(m, 0)
+
+	// The first vertex differs in color and should not be welded.
+	if len(m.Vertices) != 5 {
+		t.Fatalf("got %d vertices, want 5", len(m.Vertices))
+	}
+	if len(m.Colors) != len(m.Vertices) {
+		t.Fatal("colors not compacted")
+	}
+}
+
+func TestWeldMismatched(t *testing.T) {
+	m := &gfx.Mesh{
+		Vertices:  []gfx.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 0, 0}, {0, 1, 0}, {1, 1, 0}},
+		Colors:    []gfx.Color{{1, 0, 0, 1}},
+		Normals:   make([]gfx.Vec3, 6),
+		TexCoords: []gfx.TexCoordSet{{Slice: make([]gfx.TexCoord, 3)}, {Slice: make([]gfx.TexCoord, 6)}},
+		Attribs: map[string]gfx.VertexAttrib{
+			"Short": {Data: []float32{1, 2}},
+			"Whole": {Data: make([]float32, 6)},
+		},
+	}
+	Weld(m, 0)
+
+	// Slices that could be welded are compacted, the others are cleared.
+	n := len(m.Vertices)
+	if n != 4 {
+		t.Fatalf("got %d vertices, want 4", n)
+	}
+	if m.Colors != nil || !m.ColorsChanged || len(m.Normals) != n {
+		t.Fatal("colors not cleared or normals not compacted")
+	}
+	if len(m.TexCoords) != 2 || m.TexCoords[0].Slice != nil || !m.TexCoords[0].Changed || len(m.TexCoords[1].Slice) != n {
+		t.Fatal("wrong texture coordinate sets")
+	}
+	if _, ok := m.Attribs["Short"]; ok || m.Attribs["Whole"].Len() != n {
+		t.Fatal("wrong custom attributes")
+	}
+}
+
+func TestOptimizeVertexCache(t *testing.T) {
+	m := grid(32)
+
+	// Shuffle the triangles to destroy any locality.
+	numTris := len(m.Indices) / 3
+	for i := numTris - 1; i > 0; i-- {
+		j := rand.Intn(i + 1)
+		for k := 0; k < 3; k++ {
+			m.Indices[i*3+k], m.Indices[j*3+k] = m.Indices[j*3+k], m.Indices[i*3+k]
+		}
+	}
+	want := triangles(m)
+	before := ACMR(m.Indices, DefaultCacheSize)
+
+	m.Indices = OptimizeVertexCache(m.Indices, len(m.Vertices))
+	after := ACMR(m.Indices, DefaultCacheSize)
+	if after >= before || after > 1.0 {
+		t.Fatalf("ACMR before %v after %v", before, after)
+	}
+	equalTriangles(t, want, triangles(m))
+}
+
+func TestStrips(t *testing.T) {
+	m := grid(8)
+	want := triangles(m)
+
+	strip := ListToStrip(m.Indices)
+	if len(strip) >= len(m.Indices) {
+		t.Fatalf("strip (%d) not smaller than list (%d)", len(strip), len(m.Indices))
+	}
+	m.Indices = StripToList(strip)
+	equalTriangles(t, want, triangles(m))
+
+	// Odd triangles of a strip are flipped.
+	list := StripToList([]uint32{0, 1, 2, 3})
+	if len(list) != 6 || list[3] != 2 || list[4] != 1 || list[5] != 3 {
+		t.Fatal("got", list)
+	}
+}
+
+func TestFanToList(t *testing.T) {
+	list := FanToList([]uint32{0, 1, 2, 3, 4})
+	want := []uint32{0, 1, 2, 0, 2, 3, 0, 3, 4}
+	if len(list) != len(want) {
+		t.Fatal("got", list)
+	}
+	for i := range want {
+		if list[i] != want[i] {
+			t.Fatal("got", list)
+		}
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	m := grid(16)
+	s := Simplify(m, 64)
+	if n := len(s.Indices) / 3; n > 64 || n == 0 {
+		t.Fatalf("got %d triangles, want 1-64", n)
+	}
+
+	// A flat grid must stay flat, and it's boundary must be kept.
+	for _, v := range s.Vertices {
+		if v.Z != 0 {
+			t.Fatal("vertex left plane", v)
+		}
+	}
+	b := s.Bounds()
+	if !near(gfx.ConvertVec3(b.Min), gfx.Vec3{0, 0, 0}) || !near(gfx.ConvertVec3(b.Max), gfx.Vec3{1, 1, 0}) {
+		t.Fatal("boundary not preserved", b)
+	}
+	if len(s.Colors) != len(s.Vertices) || len(s.TexCoords[0].Slice) != len(s.Vertices) {
+		t.Fatal("attributes not kept")
+	}
+
+	// No triangle may have been flipped.
+	for i, n := range Normals(s) {
+		if n.Z < 0.99 {
+			t.Fatalf("vertex %d: flipped normal %v", i, n)
+		}
+	}
+}
+
+func TestSimplifySphere(t *testing.T) {
+	const radius = 2
+	m := sphere(radius, 24, 48)
+	s := Simplify(m, numTriangles(m)/8)
+	if numTriangles(s) > numTriangles(m)/8 {
+		t.Fatalf("got %d triangles", numTriangles(s))
+	}
+	for _, v := range s.Vertices {
+		if d := v.Vec3().Length(); math.Abs(d-radius) > 0.1 {
+			t.Fatalf("vertex %v is %v from center, want %v", v, d, radius)
+		}
+	}
+}
+
+func TestLODChain(t *testing.T) {
+	m := sphere(1, 16, 32)
+	chain := LODChain(m, 0.5, 0.25, 0.125)
+	if len(chain) != 3 {
+		t.Fatal("got", len(chain), "levels")
+	}
+	prev := numTriangles(m)
+	for i, lod := range chain {
+		n := numTriangles(lod)
+		if n >= prev || n == 0 {
+			t.Fatalf("level %d: %d triangles, previous %d", i, n, prev)
+		}
+		prev = n
+	}
+}
+
+func BenchmarkSimplify(b *testing.B) {
+	m := sphere(1, 32, 64)
+	target := numTriangles(m) / 4
+	for i := 0; i < b.N; i++ {
+		Simplify(m, target)
+	}
+}
+
+func BenchmarkOptimizeVertexCache(b *testing.B) {
+	m := grid(64)
+	for i := 0; i < b.N; i++ {
+		OptimizeVertexCache(m.Indices, len(m.Vertices))
+	}
+}
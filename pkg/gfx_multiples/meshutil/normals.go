@@ -0,0 +1,121 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package meshutil
+
+import (
+	"azul3d.org/v1/gfx"
+	"azul3d.org/v1/math"
+	"errors"
+)
+
+// ErrNoTexCoords is returned by Tangents when the requested texture
+// coordinate set does not exist or has too few coordinates.
+var ErrNoTexCoords = errors.New("meshutil: texture coordinate set not available")
+
+// Normals calculates and returns per-vertex normals for the given mesh. Each
+// vertex normal is the normalized sum of the (area-weighted) normals of every
+// triangle that references the vertex.
+//
+// Since vertices of non-indexed meshes are only ever referenced by a single
+// triangle, this effectively produces flat (faceted) normals for them. For
+// smooth normals the mesh should first be welded (see Weld) so that triangles
+// share vertices.
+//
+// Vertices not referenced by any triangle are given a zero normal.
+func Normals(m *gfx.Mesh) []gfx.Vec3 {
+	acc := make([]math.Vec3, len(m.Vertices))
+	eachTriangle(m, func(a, b, c uint32) {
+		va := m.Vertices[a].Vec3()
+		vb := m.Vertices[b].Vec3()
+		vc := m.Vertices[c].Vec3()
+
+		// The length of the cross product is twice the area of the triangle,
+		// so larger triangles contribute more to the final normal.
+		n := vb.Sub(va).Cross(vc.Sub(va))
+		acc[a] = acc[a].Add(n)
+		acc[b] = acc[b].Add(n)
+		acc[c] = acc[c].Add(n)
+	})
+
+	normals := make([]gfx.Vec3, len(acc))
+	for i, n := range acc {
+		if n, ok := n.Normalized(); ok {
+			normals[i] = gfx.ConvertVec3(n)
+		}
+	}
+	return normals
+}
+
+// Tangents calculates and returns per-vertex tangent and bitangent vectors
+// for the given mesh, such that they point in the direction of increasing U
+// and V texture coordinates (of the given texture coordinate set),
+// respectively.
+//
+// The normals slice (e.g. as returned by Normals) is used to orthogonalize the
+// tangents, the returned tangent, bitangent and normal of each vertex form an
+// orthonormal basis (i.e. tangent space) whose handedness matches the
+// texture coordinates.
+//
+// If the texture coordinate set does not exist, or holds less coordinates than
+// the mesh has vertices, then ErrNoTexCoords is returned.
+func Tangents(m *gfx.Mesh, normals []gfx.Vec3, set int) (tangents, bitangents []gfx.Vec3, err error) {
+	if set < 0 || set >= len(m.TexCoords) || len(m.TexCoords[set].Slice) < len(m.Vertices) {
+		return nil, nil, ErrNoTexCoords
+	}
+	tc := m.TexCoords[set].Slice
+
+	tan := make([]math.Vec3, len(m.Vertices))
+	bitan := make([]math.Vec3, len(m.Vertices))
+	eachTriangle(m, func(a, b, c uint32) {
+		va := m.Vertices[a].Vec3()
+		e1 := m.Vertices[b].Vec3().Sub(va)
+		e2 := m.Vertices[c].Vec3().Sub(va)
+
+		du1 := float64(tc[b].U - tc[a].U)
+		dv1 := float64(tc[b].V - tc[a].V)
+		du2 := float64(tc[c].U - tc[a].U)
+		dv2 := float64(tc[c].V - tc[a].V)
+		det := du1*dv2 - du2*dv1
+		if det == 0 {
+			// Degenerate texture mapping, no sensible direction.
+			return
+		}
+		r := 1.0 / det
+		t := e1.MulScalar(dv2).Sub(e2.MulScalar(dv1)).MulScalar(r)
+		bt := e2.MulScalar(du1).Sub(e1.MulScalar(du2)).MulScalar(r)
+		for _, i := range [3]uint32{a, b, c} {
+			tan[i] = tan[i].Add(t)
+			bitan[i] = bitan[i].Add(bt)
+		}
+	})
+
+	tangents = make([]gfx.Vec3, len(m.Vertices))
+	bitangents = make([]gfx.Vec3, len(m.Vertices))
+	for i := range tan {
+		var n math.Vec3
+		if i < len(normals) {
+			n = normals[i].Vec3()
+		}
+
+		// Gram-Schmidt orthogonalize the tangent against the normal.
+		t, ok := tan[i].Sub(n.MulScalar(n.Dot(tan[i]))).Normalized()
+		if !ok {
+			continue
+		}
+
+		// Derive the bitangent from the normal and tangent, keeping the
+		// handedness of the texture coordinates.
+		bt := n.Cross(t)
+		if bt.Dot(bitan[i]) < 0 {
+			bt = bt.MulScalar(-1)
+		}
+		if _, ok := n.Normalized(); !ok {
+			bt, _ = bitan[i].Normalized()
+		}
+		tangents[i] = gfx.ConvertVec3(t)
+		bitangents[i] = gfx.ConvertVec3(bt)
+	}
+	return tangents, bitangents, nil
+}
@@ -0,0 +1,413 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package meshutil
+
+import (
+	"azul3d.org/v1/gfx"
+	"azul3d.org/v1/math"
+	"container/heap"
+)
+
+// boundaryWeight is the weight of the quadrics used to preserve the
+// boundaries (open edges) of meshes during simplification.
+const boundaryWeight = 1000.0
+
+// quadric is a symmetric 4x4 matrix representing the sum of squared distances
+// to a set of planes, stored as it's upper triangle:
+//  a2 ab ac ad
+//     b2 bc bd
+//        c2 cd
+//           d2
+type quadric [10]float64
+
+// planeQuadric returns the quadric for the plane with normal n (a, b, c) and
+// offset d, such that a*x + b*y + c*z + d = 0, scaled by the given weight.
+func planeQuadric(n math.Vec3, d, w float64) quadric {
+	a, b, c := n.X, n.Y, n.Z
+	return quadric{
+		w * a * a, w * a * b, w * a * c, w * a * d,
+		w * b * b, w * b * c, w * b * d,
+		w * c * c, w * c * d,
+		w * d * d,
+	}
+}
+
+func (q quadric) add(o quadric) quadric {
+	for i := range q {
+		q[i] += o[i]
+	}
+	return q
+}
+
+// eval returns the error of the point v with respect to the quadric.
+func (q quadric) eval(v math.Vec3) float64 {
+	x, y, z := v.X, v.Y, v.Z
+	return q[0]*x*x + 2*q[1]*x*y + 2*q[2]*x*z + 2*q[3]*x +
+		q[4]*y*y + 2*q[5]*y*z + 2*q[6]*y +
+		q[7]*z*z + 2*q[8]*z +
+		q[9]
+}
+
+// collapse is a candidate edge collapse, of vertex b into vertex a.
+type collapse struct {
+	cost   float64
+	a, b   uint32
+	t      float64 // Interpolation factor from a to b.
+	stamps [2]int
+}
+
+type collapseHeap []collapse
+
+func (h collapseHeap) Len() int            { return len(h) }
+func (h collapseHeap) Less(i, j int) bool  { return h[i].cost < h[j].cost }
+func (h collapseHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *collapseHeap) Push(x interface{}) { *h = append(*h, x.(collapse)) }
+func (h *collapseHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// simplifier holds the state of a single mesh simplification.
+type simplifier struct {
+	pos       []math.Vec3
+	q         []quadric
+	stamp     []int
+	faces     [][3]uint32
+	alive     []bool
+	vertFaces [][]int
+	heap      collapseHeap
+}
+
+// faceNormal returns the (unnormalized) normal of the face, optionally
+// substituting the position of vertex v with p.
+func (s *simplifier) faceNormal(f [3]uint32, v uint32, p math.Vec3) math.Vec3 {
+	var pts [3]math.Vec3
+	for i, idx := range f {
+		if idx == v {
+			pts[i] = p
+		} else {
+			pts[i] = s.pos[idx]
+		}
+	}
+	return pts[1].Sub(pts[0]).Cross(pts[2].Sub(pts[0]))
+}
+
+// flips tells if moving vertex v to p would flip (or collapse) any face
+// around it, except for faces containing the other vertex.
+func (s *simplifier) flips(v, other uint32, p math.Vec3) bool {
+	for _, fi := range s.vertFaces[v] {
+		if !s.alive[fi] {
+			continue
+		}
+		f := s.faces[fi]
+		if f[0] == other || f[1] == other || f[2] == other {
+			// Removed by the collapse.
+			continue
+		}
+		before := s.faceNormal(f, v, s.pos[v])
+		after := s.faceNormal(f, v, p)
+		if before.Dot(after) <= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// push computes the best collapse of the edge between a and b and pushes it
+// onto the heap.
+func (s *simplifier) push(a, b uint32) {
+	q := s.q[a].add(s.q[b])
+	best := collapse{a: a, b: b, stamps: [2]int{s.stamp[a], s.stamp[b]}}
+	for i, t := range [3]float64{0.5, 0, 1} {
+		cost := q.eval(s.pos[a].Lerp(s.pos[b], t))
+		if i == 0 || cost < best.cost {
+			best.cost = cost
+			best.t = t
+		}
+	}
+	heap.Push(&s.heap, best)
+}
+
+// Simplify returns a simplified copy of the mesh with at most the given number
+// of triangles, using edge collapses guided by quadric error metrics (as
+// described in "Surface Simplification Using Quadric Error Metrics" by
+// Garland and Heckbert).
+//
+// The returned mesh is always indexed. Boundary edges of the mesh are
+// preserved as much as possible, and collapses that would flip the winding of
+// a triangle are avoided (as such the mesh may not be simplified all the way
+// down to the target). Per-vertex colors, barycentric and texture coordinates,
+// normals and custom attributes are linearly interpolated along collapsed
+// edges.
+//
+// The mesh's read lock must be held for this function to operate safely.
+func Simplify(m *gfx.Mesh, target int) *gfx.Mesh {
+	m = m.Copy()
+	if len(m.Indices) == 0 {
+		Weld(m, 0)
+	}
+	if target < 0 {
+		target = 0
+	}
+
+	n := len(m.Vertices)
+	s := &simplifier{
+		pos:       make([]math.Vec3, n),
+		q:         make([]quadric, n),
+		stamp:     make([]int, n),
+		vertFaces: make([][]int, n),
+	}
+	for i, v := range m.Vertices {
+		s.pos[i] = v.Vec3()
+	}
+
+	// Build faces, skipping degenerate ones, and accumulate the quadric of
+	// each face's plane into it's vertices.
+	edgeCount := make(map[[2]uint32]int)
+	edgeFace := make(map[[2]uint32]int)
+	eachTriangle(m, func(a, b, c uint32) {
+		if a == b || b == c || a == c {
+			return
+		}
+		f := [3]uint32{a, b, c}
+		fi := len(s.faces)
+		s.faces = append(s.faces, f)
+		s.alive = append(s.alive, true)
+
+		normal, ok := s.faceNormal(f, a, s.pos[a]).Normalized()
+		var fq quadric
+		if ok {
+			fq = planeQuadric(normal, -normal.Dot(s.pos[a]), 1)
+		}
+		for k, v := range f {
+			s.vertFaces[v] = append(s.vertFaces[v], fi)
+			s.q[v] = s.q[v].add(fq)
+
+			key := [2]uint32{v, f[(k+1)%3]}
+			if key[0] > key[1] {
+				key[0], key[1] = key[1], key[0]
+			}
+			edgeCount[key]++
+			edgeFace[key] = fi
+		}
+	})
+
+	// Boundary edges (used by only a single face) are constrained by a plane
+	// perpendicular to the face through the edge.
+	for key, count := range edgeCount {
+		if count != 1 {
+			continue
+		}
+		f := s.faces[edgeFace[key]]
+		fn := s.faceNormal(f, f[0], s.pos[f[0]])
+		e := s.pos[key[1]].Sub(s.pos[key[0]])
+		normal, ok := e.Cross(fn).Normalized()
+		if !ok {
+			continue
+		}
+		bq := planeQuadric(normal, -normal.Dot(s.pos[key[0]]), boundaryWeight)
+		s.q[key[0]] = s.q[key[0]].add(bq)
+		s.q[key[1]] = s.q[key[1]].add(bq)
+	}
+
+	for key := range edgeCount {
+		s.push(key[0], key[1])
+	}
+
+	var (
+		aliveFaces = len(s.faces)
+		colors     = len(m.Colors) == n
+		bary       = len(m.Bary) == n
+		normals    = len(m.Normals) == n
+	)
+	for aliveFaces > target && s.heap.Len() > 0 {
+		c := heap.Pop(&s.heap).(collapse)
+		a, b := c.a, c.b
+		if s.stamp[a] != c.stamps[0] || s.stamp[b] != c.stamps[1] {
+			// Stale, one of the vertices has changed since.
+			continue
+		}
+		p := s.pos[a].Lerp(s.pos[b], c.t)
+		if s.flips(a, b, p) || s.flips(b, a, p) {
+			continue
+		}
+
+		// Collapse b into a.
+		s.pos[a] = p
+		s.q[a] = s.q[a].add(s.q[b])
+		s.stamp[a]++
+		s.stamp[b]++
+		t := float32(c.t)
+		m.Vertices[a] = gfx.ConvertVec3(p)
+		if colors {
+			ca, cb := m.Colors[a], m.Colors[b]
+			m.Colors[a] = gfx.Color{
+				ca.R + (cb.R-ca.R)*t,
+				ca.G + (cb.G-ca.G)*t,
+				ca.B + (cb.B-ca.B)*t,
+				ca.A + (cb.A-ca.A)*t,
+			}
+		}
+		if bary {
+			m.Bary[a] = lerpVec3(m.Bary[a], m.Bary[b], t)
+		}
+		if normals {
+			nv := lerpVec3(m.Normals[a], m.Normals[b], t).Vec3()
+			if nv, ok := nv.Normalized(); ok {
+				m.Normals[a] = gfx.ConvertVec3(nv)
+			}
+		}
+		for _, attrib := range m.Attribs {
+			if attrib.Len() == n {
+				attribLerp(attrib, int(a), int(b), t)
+			}
+		}
+		for _, set := range m.TexCoords {
+			if len(set.Slice) == n {
+				ta, tb := set.Slice[a], set.Slice[b]
+				set.Slice[a] = gfx.TexCoord{
+					ta.U + (tb.U-ta.U)*t,
+					ta.V + (tb.V-ta.V)*t,
+				}
+			}
+		}
+
+		for _, fi := range s.vertFaces[b] {
+			if !s.alive[fi] {
+				continue
+			}
+			f := &s.faces[fi]
+			if f[0] == a || f[1] == a || f[2] == a {
+				// The face contained the collapsed edge, it is now
+				// degenerate.
+				s.alive[fi] = false
+				aliveFaces--
+				continue
+			}
+			for k := range f {
+				if f[k] == b {
+					f[k] = a
+				}
+			}
+			s.vertFaces[a] = append(s.vertFaces[a], fi)
+		}
+		s.vertFaces[b] = nil
+
+		// Push the new edges around a.
+		seen := make(map[uint32]bool)
+		alive := s.vertFaces[a][:0]
+		for _, fi := range s.vertFaces[a] {
+			if !s.alive[fi] {
+				continue
+			}
+			alive = append(alive, fi)
+			for _, v := range s.faces[fi] {
+				if v != a && !seen[v] {
+					seen[v] = true
+					s.push(a, v)
+				}
+			}
+		}
+		s.vertFaces[a] = alive
+	}
+
+	// Build the compacted output mesh.
+	remap := make([]int, n)
+	for i := range remap {
+		remap[i] = -1
+	}
+	out := &gfx.Mesh{
+		KeepDataOnLoad:  m.KeepDataOnLoad,
+		Dynamic:         m.Dynamic,
+		IndicesChanged:  true,
+		VerticesChanged: true,
+	}
+	var used []int
+	for fi, f := range s.faces {
+		if !s.alive[fi] {
+			continue
+		}
+		for _, v := range f {
+			if remap[v] == -1 {
+				remap[v] = len(used)
+				used = append(used, int(v))
+			}
+			out.Indices = append(out.Indices, uint32(remap[v]))
+		}
+	}
+	out.Vertices = make([]gfx.Vec3, len(used))
+	for i, v := range used {
+		out.Vertices[i] = m.Vertices[v]
+	}
+	if colors {
+		out.Colors = make([]gfx.Color, len(used))
+		for i, v := range used {
+			out.Colors[i] = m.Colors[v]
+		}
+		out.ColorsChanged = true
+	}
+	if bary {
+		out.Bary = make([]gfx.Vec3, len(used))
+		for i, v := range used {
+			out.Bary[i] = m.Bary[v]
+		}
+		out.BaryChanged = true
+	}
+	for _, set := range m.TexCoords {
+		if len(set.Slice) != n {
+			continue
+		}
+		tc := make([]gfx.TexCoord, len(used))
+		for i, v := range used {
+			tc[i] = set.Slice[v]
+		}
+		out.TexCoords = append(out.TexCoords, gfx.TexCoordSet{Slice: tc, Changed: true})
+	}
+	if normals {
+		out.Normals = make([]gfx.Vec3, len(used))
+		for i, v := range used {
+			out.Normals[i] = m.Normals[v]
+		}
+		out.NormalsChanged = true
+	}
+	for name, attrib := range m.Attribs {
+		if attrib.Len() != n {
+			continue
+		}
+		if out.Attribs == nil {
+			out.Attribs = make(map[string]gfx.VertexAttrib, len(m.Attribs))
+		}
+		out.Attribs[name] = attribSelect(attrib, used)
+	}
+	out.CalculateBounds()
+	return out
+}
+
+// LODChain returns a level-of-detail chain for the given mesh. Each returned
+// mesh is a simplified version of the given mesh (see Simplify) with the
+// given ratio of it's triangles, for example:
+//  lods := LODChain(m, 0.5, 0.25, 0.125)
+// would produce three meshes with half, one quarter and one eighth of the
+// triangles of the original mesh respectively.
+//
+// Each level is simplified from the previous (more detailed) level, so ratios
+// should be given in decreasing order.
+//
+// The mesh's read lock must be held for this function to operate safely.
+func LODChain(m *gfx.Mesh, ratios ...float64) []*gfx.Mesh {
+	var (
+		tris  = numTriangles(m)
+		chain = make([]*gfx.Mesh, 0, len(ratios))
+		prev  = m
+	)
+	for _, ratio := range ratios {
+		lod := Simplify(prev, int(float64(tris)*ratio))
+		chain = append(chain, lod)
+		prev = lod
+	}
+	return chain
+}
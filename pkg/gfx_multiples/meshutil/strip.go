@@ -0,0 +1,145 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package meshutil
+
+// StripToList converts the given triangle strip indices into triangle list
+// indices, such that they may be used as m.Indices of a mesh.
+//
+// Winding order is preserved (every odd triangle of the strip has it's
+// winding flipped, as graphics hardware does). Degenerate triangles, such as
+// the ones used to join multiple strips together, are removed.
+func StripToList(strip []uint32) []uint32 {
+	if len(strip) < 3 {
+		return nil
+	}
+	list := make([]uint32, 0, (len(strip)-2)*3)
+	for i := 0; i+2 < len(strip); i++ {
+		a, b, c := strip[i], strip[i+1], strip[i+2]
+		if a == b || b == c || a == c {
+			continue
+		}
+		if i%2 == 1 {
+			a, b = b, a
+		}
+		list = append(list, a, b, c)
+	}
+	return list
+}
+
+// FanToList converts the given triangle fan indices into triangle list
+// indices, such that they may be used as m.Indices of a mesh.
+//
+// The first index is the center of the fan, winding order is preserved.
+func FanToList(fan []uint32) []uint32 {
+	if len(fan) < 3 {
+		return nil
+	}
+	list := make([]uint32, 0, (len(fan)-2)*3)
+	for i := 1; i+1 < len(fan); i++ {
+		a, b, c := fan[0], fan[i], fan[i+1]
+		if a == b || b == c || a == c {
+			continue
+		}
+		list = append(list, a, b, c)
+	}
+	return list
+}
+
+// edge is a directed edge between two vertices.
+type edge struct {
+	a, b uint32
+}
+
+// ListToStrip converts the given triangle list indices into a single triangle
+// strip, joining separate strips together using degenerate triangles.
+//
+// Triangles are greedily chained together through shared edges in such a way
+// that the winding order of each triangle is preserved, hence:
+//  StripToList(ListToStrip(list))
+// yields the same triangles as list (although in a different order, and
+// with their vertices possibly rotated).
+func ListToStrip(list []uint32) []uint32 {
+	numTris := len(list) / 3
+	if numTris == 0 {
+		return nil
+	}
+
+	// Map each directed edge to the triangles that contain it.
+	edges := make(map[edge][]int, numTris*3)
+	for t := 0; t < numTris; t++ {
+		a, b, c := list[t*3], list[t*3+1], list[t*3+2]
+		edges[edge{a, b}] = append(edges[edge{a, b}], t)
+		edges[edge{b, c}] = append(edges[edge{b, c}], t)
+		edges[edge{c, a}] = append(edges[edge{c, a}], t)
+	}
+	used := make([]bool, numTris)
+
+	// next finds an unused triangle containing the directed edge a->b and
+	// returns it's third vertex.
+	next := func(a, b uint32) (t int, v uint32, ok bool) {
+		for _, t := range edges[edge{a, b}] {
+			if used[t] {
+				continue
+			}
+			tri := list[t*3 : t*3+3]
+			for _, v := range tri {
+				if v != a && v != b {
+					return t, v, true
+				}
+			}
+		}
+		return 0, 0, false
+	}
+
+	var out []uint32
+	for start := 0; start < numTris; start++ {
+		if used[start] {
+			continue
+		}
+		used[start] = true
+		tri := list[start*3 : start*3+3]
+
+		// Choose the rotation of the starting triangle that allows the strip
+		// to continue, if any.
+		s := []uint32{tri[0], tri[1], tri[2]}
+		for r := 0; r < 3; r++ {
+			a, b, c := tri[r], tri[(r+1)%3], tri[(r+2)%3]
+			// The second triangle of a strip has flipped winding, so it must
+			// contain the directed edge c->b.
+			if _, _, ok := next(c, b); ok {
+				s = []uint32{a, b, c}
+				break
+			}
+		}
+
+		// Extend the strip as far as possible.
+		for {
+			n := len(s)
+			a, b := s[n-2], s[n-1]
+			if n%2 == 1 {
+				// The next triangle is odd, it's winding is flipped.
+				a, b = b, a
+			}
+			t, v, ok := next(a, b)
+			if !ok {
+				break
+			}
+			used[t] = true
+			s = append(s, v)
+		}
+
+		// Join the strip onto the output using degenerate triangles, keeping
+		// the strip starting at an even triangle so it's winding is kept.
+		if len(out) > 0 {
+			last := out[len(out)-1]
+			if len(out)%2 == 1 {
+				out = append(out, last)
+			}
+			out = append(out, last, s[0])
+		}
+		out = append(out, s...)
+	}
+	return out
+}
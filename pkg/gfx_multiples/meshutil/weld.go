@@ -0,0 +1,199 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package meshutil
+
+import (
+	"azul3d.org/v1/gfx"
+	"math"
+)
+
+// cell is a single cell of the uniform grid used to find nearby vertices.
+type cell struct {
+	x, y, z int64
+}
+
+// Weld merges duplicate vertices of the mesh into single vertices, rebuilding
+// m.Indices such that the mesh becomes (or remains) an indexed mesh.
+//
+// Two vertices are considered duplicates when their positions are within
+// epsilon distance of each other on every axis and every other per-vertex
+// attribute (colors, barycentric and texture coordinates, normals and custom
+// attributes) is exactly equal. An epsilon of zero requires positions to be
+// exactly equal as well.
+//
+// Per-vertex data slices whose length does not match the number of vertices
+// cannot be welded along with them; when any vertices are welded such slices
+// are cleared (and custom attributes removed), like Simplify drops them.
+//
+// Data slices that are modified are marked as changed (e.g. IndicesChanged and
+// VerticesChanged are set to true).
+//
+// The mesh's write lock must be held for this function to operate safely.
+func Weld(m *gfx.Mesh, epsilon float32) {
+	n := len(m.Vertices)
+	if n == 0 {
+		return
+	}
+	hasColors := len(m.Colors) == n
+	hasBary := len(m.Bary) == n
+	hasNormals := len(m.Normals) == n
+
+	same := func(a, b int) bool {
+		va, vb := m.Vertices[a], m.Vertices[b]
+		if abs32(va.X-vb.X) > epsilon || abs32(va.Y-vb.Y) > epsilon || abs32(va.Z-vb.Z) > epsilon {
+			return false
+		}
+		if hasColors && m.Colors[a] != m.Colors[b] {
+			return false
+		}
+		if hasBary && m.Bary[a] != m.Bary[b] {
+			return false
+		}
+		if hasNormals && m.Normals[a] != m.Normals[b] {
+			return false
+		}
+		for _, set := range m.TexCoords {
+			if len(set.Slice) == n && set.Slice[a] != set.Slice[b] {
+				return false
+			}
+		}
+		for _, attrib := range m.Attribs {
+			if attrib.Len() == n && !attribEqual(attrib, a, b) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// Vertices are bucketed into a uniform grid whose cells are the size of
+	// epsilon, such that only the neighboring cells need to be searched.
+	size := float64(epsilon)
+	if size == 0 {
+		size = 1
+	}
+	cellOf := func(v gfx.Vec3) cell {
+		return cell{
+			int64(math.Floor(float64(v.X) / size)),
+			int64(math.Floor(float64(v.Y) / size)),
+			int64(math.Floor(float64(v.Z) / size)),
+		}
+	}
+
+	var (
+		grid  = make(map[cell][]uint32, n)
+		remap = make([]uint32, n)
+		keep  = make([]int, 0, n)
+	)
+	for i, v := range m.Vertices {
+		c := cellOf(v)
+		found := -1
+	search:
+		for dx := int64(-1); dx <= 1; dx++ {
+			for dy := int64(-1); dy <= 1; dy++ {
+				for dz := int64(-1); dz <= 1; dz++ {
+					for _, k := range grid[cell{c.x + dx, c.y + dy, c.z + dz}] {
+						if same(keep[k], i) {
+							found = int(k)
+							break search
+						}
+					}
+				}
+			}
+		}
+		if found == -1 {
+			found = len(keep)
+			keep = append(keep, i)
+			grid[c] = append(grid[c], uint32(found))
+		}
+		remap[i] = uint32(found)
+	}
+
+	// Rebuild the index buffer.
+	var indices []uint32
+	if len(m.Indices) > 0 {
+		indices = make([]uint32, len(m.Indices))
+		for i, idx := range m.Indices {
+			indices[i] = remap[idx]
+		}
+	} else {
+		indices = remap
+	}
+	m.Indices = indices
+	m.IndicesChanged = true
+
+	if len(keep) == n {
+		// Nothing was welded.
+		return
+	}
+
+	// Compact each of the data slices.
+	vertices := make([]gfx.Vec3, len(keep))
+	for i, k := range keep {
+		vertices[i] = m.Vertices[k]
+	}
+	m.Vertices = vertices
+	m.VerticesChanged = true
+	if hasColors {
+		colors := make([]gfx.Color, len(keep))
+		for i, k := range keep {
+			colors[i] = m.Colors[k]
+		}
+		m.Colors = colors
+		m.ColorsChanged = true
+	} else if m.Colors != nil {
+		m.Colors = nil
+		m.ColorsChanged = true
+	}
+	if hasBary {
+		bary := make([]gfx.Vec3, len(keep))
+		for i, k := range keep {
+			bary[i] = m.Bary[k]
+		}
+		m.Bary = bary
+		m.BaryChanged = true
+	} else if m.Bary != nil {
+		m.Bary = nil
+		m.BaryChanged = true
+	}
+	for s, set := range m.TexCoords {
+		if len(set.Slice) != n {
+			// Keep the set, such that the sets after it keep their index.
+			if set.Slice != nil {
+				m.TexCoords[s] = gfx.TexCoordSet{Changed: true}
+			}
+			continue
+		}
+		tc := make([]gfx.TexCoord, len(keep))
+		for i, k := range keep {
+			tc[i] = set.Slice[k]
+		}
+		m.TexCoords[s] = gfx.TexCoordSet{Slice: tc, Changed: true}
+	}
+	if hasNormals {
+		normals := make([]gfx.Vec3, len(keep))
+		for i, k := range keep {
+			normals[i] = m.Normals[k]
+		}
+		m.Normals = normals
+		m.NormalsChanged = true
+	} else if m.Normals != nil {
+		m.Normals = nil
+		m.NormalsChanged = true
+	}
+	for name, attrib := range m.Attribs {
+		if attrib.Len() == n {
+			m.Attribs[name] = attribSelect(attrib, keep)
+		} else {
+			delete(m.Attribs, name)
+		}
+	}
+}
+
+func abs32(v float32) float32 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -17,20 +17,159 @@ func (n nilNativeObject) SampleCount() int {
 	return 0
 }
 
-type nilNativeTexture struct{}
+type nilNativeTexture struct {
+	stats     *nilStats
+	destroyed bool
+}
 
-func (n nilNativeTexture) Destroy() {}
-func (n nilNativeTexture) Download(r image.Rectangle, complete chan image.Image) {
+func (n *nilNativeTexture) Destroy() {
+	n.stats.destroy(&n.stats.frame.Textures, &n.destroyed)
+}
+func (n *nilNativeTexture) Download(r image.Rectangle, complete chan image.Image) {
 	complete <- nil
 }
 
-type nilNativeMesh struct{}
+type nilNativeMesh struct {
+	stats     *nilStats
+	destroyed bool
+
+	// The number of triangles of the mesh when it was loaded.
+	triangles int
+}
+
+func (n *nilNativeMesh) Destroy() {
+	n.stats.destroy(&n.stats.frame.Meshes, &n.destroyed)
+}
+
+type nilNativeShader struct {
+	stats     *nilStats
+	destroyed bool
+}
+
+func (n *nilNativeShader) Destroy() {
+	n.stats.destroy(&n.stats.frame.Shaders, &n.destroyed)
+}
+
+// nilStats counts the work performed by a nil renderer and the canvases
+// returned by it's RenderToTexture method.
+type nilStats struct {
+	sync.Mutex
+
+	// Statistics of the current and the last frame.
+	frame, last Stats
+
+	// The state and shader of the last object drawn, used to count state
+	// changes.
+	stateSet bool
+	state    State
+	shader   *Shader
+}
+
+// destroy decrements the given count of loaded native resources, unless the
+// native resource was already destroyed.
+func (s *nilStats) destroy(count *int, destroyed *bool) {
+	s.Lock()
+	if !*destroyed {
+		*destroyed = true
+		*count--
+	}
+	s.Unlock()
+}
+
+// load increments the given count of loaded native resources, and the given
+// count of uploaded bytes (if non-nil) by n.
+func (s *nilStats) load(count *int, bytes *int64, n int64) {
+	s.Lock()
+	*count++
+	if bytes != nil {
+		*bytes += n
+	}
+	s.Unlock()
+}
+
+// draw counts the state changes and draw calls of drawing the given object,
+// which draws the given number of triangles.
+//
+// The object's lock must be held for this method to operate safely.
+func (s *nilStats) draw(o *Object, triangles int) {
+	s.Lock()
+	defer s.Unlock()
+	if !s.stateSet || s.state != o.State {
+		s.stateSet = true
+		s.state = o.State
+		s.frame.StateChanges++
+	}
+	for i, m := range o.Meshes {
+		if m == nil {
+			continue
+		}
+		if i < len(o.Shaders) && o.Shaders[i] != s.shader {
+			s.shader = o.Shaders[i]
+			s.frame.StateChanges++
+		}
+		s.frame.DrawCalls++
+	}
+	s.frame.Triangles += triangles
+}
 
-func (n nilNativeMesh) Destroy() {}
+// render ends the current frame.
+func (s *nilStats) render() {
+	s.Lock()
+	s.last = s.frame
+	s.frame = Stats{
+		Meshes:   s.last.Meshes,
+		Textures: s.last.Textures,
+		Shaders:  s.last.Shaders,
+	}
+	s.stateSet = false
+	s.shader = nil
+	s.Unlock()
+}
 
-type nilNativeShader struct{}
+// triangles returns the number of triangles of the given mesh, counting from
+// it's data if it has any, or else from the last time it was loaded.
+//
+// The mesh's read lock must be held for this method to operate safely.
+func triangles(m *Mesh) int {
+	switch {
+	case len(m.Indices) > 0:
+		return len(m.Indices) / 3
+	case len(m.Vertices) > 0:
+		return len(m.Vertices) / 3
+	}
+	if n, ok := m.NativeMesh.(*nilNativeMesh); ok {
+		return n.triangles
+	}
+	return 0
+}
 
-func (n nilNativeShader) Destroy() {}
+// meshBytes returns the number of bytes needed to upload the data of the
+// given mesh.
+//
+// The mesh's read lock must be held for this method to operate safely.
+func meshBytes(m *Mesh) int64 {
+	n := int64(len(m.Indices)) * 4
+	n += int64(len(m.Vertices)+len(m.Bary)+len(m.Normals)) * 12
+	n += int64(len(m.Colors)) * 16
+	for _, set := range m.TexCoords {
+		n += int64(len(set.Slice)) * 8
+	}
+	for _, a := range m.Attribs {
+		switch d := a.Data.(type) {
+		case []float32:
+			n += int64(len(d)) * 4
+		case []int32:
+			n += int64(len(d)) * 4
+		case []TexCoord:
+			n += int64(len(d)) * 8
+		case []Vec3:
+			n += int64(len(d)) * 12
+		case []Vec4:
+			n += int64(len(d)) * 16
+		}
+	}
+	return n
+}
 
 type nilRenderer struct {
 	// The MSAA state.
@@ -41,6 +180,9 @@ type nilRenderer struct {
 
 	// The graphics clock.
 	clock *clock.Clock
+
+	// The statistics, shared with canvases returned by RenderToTexture.
+	stats *nilStats
 }
 
 func (n *nilRenderer) Clock() *clock.Clock {
@@ -69,6 +211,11 @@ func (n *nilRenderer) GPUInfo() GPUInfo {
 		OcclusionQuery:  false,
 	}
 }
+func (n *nilRenderer) Stats() Stats {
+	n.stats.Lock()
+	defer n.stats.Unlock()
+	return n.stats.last
+}
 func (n *nilRenderer) Download(r image.Rectangle, complete chan image.Image) {
 	complete <- nil
 }
@@ -89,19 +236,39 @@ func (n *nilRenderer) ClearStencil(r image.Rectangle, stencil int) {}
 func (n *nilRenderer) Draw(r image.Rectangle, o *Object, c *Camera) {
 	o.Lock()
 	o.NativeObject = nilNativeObject{}
+	var tris int
+	for _, m := range o.Meshes {
+		if m != nil {
+			m.RLock()
+			tris += triangles(m)
+			m.RUnlock()
+		}
+	}
+	n.stats.draw(o, tris)
 	o.Unlock()
 }
 func (n *nilRenderer) QueryWait() {}
 func (n *nilRenderer) Render() {
+	n.stats.render()
 	n.clock.Tick()
 }
 
 func (n *nilRenderer) LoadMesh(m *Mesh, done chan *Mesh) {
 	m.Lock()
+	old, _ := m.NativeMesh.(*nilNativeMesh)
+	bytes := meshBytes(m)
+	native := &nilNativeMesh{
+		stats:     n.stats,
+		triangles: triangles(m),
+	}
 	m.Loaded = true
 	m.ClearData()
-	m.NativeMesh = nilNativeMesh{}
+	m.NativeMesh = native
 	m.Unlock()
+	if old != nil {
+		old.Destroy()
+	}
+	n.stats.load(&n.stats.frame.Meshes, &n.stats.frame.MeshBytes, bytes)
 	select {
 	case done <- m:
 	default:
@@ -109,10 +276,20 @@ func (n *nilRenderer) LoadMesh(m *Mesh, done chan *Mesh) {
 }
 func (n *nilRenderer) LoadTexture(t *Texture, done chan *Texture) {
 	t.Lock()
+	old, _ := t.NativeTexture.(*nilNativeTexture)
+	var bytes int64
+	if t.Source != nil {
+		b := t.Source.Bounds()
+		bytes = TextureBytes(t.Format, b.Dx(), b.Dy(), t.MinFilter.Mipmapped())
+	}
 	t.Loaded = true
 	t.ClearData()
-	t.NativeTexture = nilNativeTexture{}
+	t.NativeTexture = &nilNativeTexture{stats: n.stats}
 	t.Unlock()
+	if old != nil {
+		old.Destroy()
+	}
+	n.stats.load(&n.stats.frame.Textures, &n.stats.frame.TextureBytes, bytes)
 	select {
 	case done <- t:
 	default:
@@ -120,10 +297,15 @@ func (n *nilRenderer) LoadTexture(t *Texture, done chan *Texture) {
 }
 func (n *nilRenderer) LoadShader(s *Shader, done chan *Shader) {
 	s.Lock()
+	old, _ := s.NativeShader.(*nilNativeShader)
 	s.Loaded = true
 	s.ClearData()
-	s.NativeShader = nilNativeShader{}
+	s.NativeShader = &nilNativeShader{stats: n.stats}
 	s.Unlock()
+	if old != nil {
+		old.Destroy()
+	}
+	n.stats.load(&n.stats.frame.Shaders, nil, 0)
 	select {
 	case done <- s:
 	default:
@@ -131,13 +313,23 @@ func (n *nilRenderer) LoadShader(s *Shader, done chan *Shader) {
 }
 
 func (n *nilRenderer) RenderToTexture(t *Texture) Canvas {
-	return new(nilRenderer)
+	return newNil(n.stats)
 }
 
-// Nil returns a renderer that does not actually render anything.
-func Nil() Renderer {
+func newNil(stats *nilStats) *nilRenderer {
 	r := new(nilRenderer)
 	r.msaa.enabled = true
 	r.clock = clock.New()
+	r.stats = stats
 	return r
 }
+
+// Nil returns a renderer that does not actually render anything.
+//
+// The renderer counts the work it would perform (see Renderer.Stats), such
+// that budgets can be asserted in tests: each mesh of an object drawn is one
+// draw call, and a change of the object state or shader between objects
+// drawn is one state change.
+func Nil() Renderer {
+	return newNil(new(nilStats))
+}
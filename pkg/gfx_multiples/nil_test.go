@@ -5,6 +5,7 @@
 package gfx
 
 import (
+	"image"
 	"image/color"
 	"testing"
 )
@@ -41,3 +42,94 @@ func TestNilRenderer(t *testing.T) {
 		r.Render()
 	}
 }
+
+func TestNilStats(t *testing.T) {
+	r := Nil()
+	tex := &Texture{Source: image.NewRGBA(image.Rect(0, 0, 8, 8))}
+	shader := NewShader("a")
+	other := NewShader("b")
+
+	// Two objects with two meshes, one indexed, of one triangle each.
+	newObject := func() *Object {
+		o := NewObject()
+		o.Shaders = []*Shader{shader, shader}
+		o.Meshes = []*Mesh{
+			{Vertices: []Vec3{{}, {}, {}}},
+			{Indices: []uint32{0, 1, 2}, Vertices: []Vec3{{}, {}, {}, {}}},
+		}
+		return o
+	}
+	a, b := newObject(), newObject()
+	b.Shaders = []*Shader{shader, other}
+	b.DepthWrite = !b.DepthWrite
+
+	r.LoadTexture(tex, nil)
+	r.LoadShader(shader, nil)
+	for _, o := range []*Object{a, b} {
+		for _, m := range o.Meshes {
+			r.LoadMesh(m, nil)
+		}
+	}
+	r.Draw(r.Bounds(), a, nil)
+	r.Draw(r.Bounds(), b, nil)
+	r.Render()
+
+	want := Stats{
+		DrawCalls: 4,
+		// a's state and shader, then b's state and other shader.
+		StateChanges: 4,
+		Triangles:    4,
+		MeshBytes:    2 * (3*12 + 3*4 + 4*12),
+		TextureBytes: 8 * 8 * 4,
+		Meshes:       4,
+		Textures:     1,
+		Shaders:      1,
+	}
+	if s := r.Stats(); s != want {
+		t.Fatalf("got stats\n%+v\nwant\n%+v", s, want)
+	}
+
+	// Counters start over each frame, and drawing onto a texture counts
+	// towards the renderer's frame.
+	r.RenderToTexture(tex).Draw(image.Rect(0, 0, 8, 8), a, nil)
+	tex.NativeTexture.Destroy()
+	a.Meshes[0].NativeMesh.Destroy()
+	a.Meshes[0].NativeMesh.Destroy()
+	r.Render()
+	want = Stats{
+		DrawCalls:    2,
+		StateChanges: 2,
+		Triangles:    2,
+		Meshes:       3,
+		Shaders:      1,
+	}
+	if s := r.Stats(); s != want {
+		t.Fatalf("got stats\n%+v\nwant\n%+v", s, want)
+	}
+}
+
+func TestTextureBytes(t *testing.T) {
+	tests := []struct {
+		f         TexFormat
+		w, h      int
+		mipmapped bool
+		want      int64
+	}{
+		{RGBA, 8, 8, false, 8 * 8 * 4},
+		{RGB, 8, 8, false, 8 * 8 * 3},
+		{DXT1, 8, 8, false, 4 * 8},
+		{DXT1RGBA, 6, 5, false, 4 * 8},
+		{DXT5, 8, 8, false, 4 * 16},
+
+		// 8x4, 4x2, 2x1 and 1x1 levels.
+		{RGBA, 8, 4, true, (32 + 8 + 2 + 1) * 4},
+
+		// Levels smaller than a block still take up a whole block.
+		{DXT3, 8, 8, true, (4 + 1 + 1 + 1) * 16},
+	}
+	for _, tst := range tests {
+		if got := TextureBytes(tst.f, tst.w, tst.h, tst.mipmapped); got != tst.want {
+			t.Errorf("%v %dx%d (mipmapped=%v): got %d bytes want %d", tst.f, tst.w, tst.h, tst.mipmapped, got, tst.want)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package picking
+
+import (
+	"azul3d.org/v1/gfx"
+	gmath "azul3d.org/v1/math"
+	"image"
+	"image/color"
+	"math"
+	"sync"
+)
+
+var glslVert = []byte(`
+#version 120
+
+attribute vec3 Vertex;
+
+uniform mat4 MVP;
+
+void main()
+{
+	gl_Position = MVP * vec4(Vertex, 1.0);
+}
+`)
+
+// glslFrag outputs the color of the object's ID texture, which is a single
+// texel.
+var glslFrag = []byte(`
+#version 120
+
+uniform sampler2D Texture0;
+
+void main()
+{
+	gl_FragColor = texture2D(Texture0, vec2(0.5, 0.5));
+}
+`)
+
+// maxID is the largest ID of an object, IDs are stored in the red, green and
+// blue channels of the texture. Zero is the ID of the background.
+const maxID = 1<<24 - 1
+
+// encodeID returns the color an object with the given ID is rendered with.
+func encodeID(id uint32) color.RGBA {
+	return color.RGBA{
+		R: uint8(id >> 16),
+		G: uint8(id >> 8),
+		B: uint8(id),
+		A: 0xff,
+	}
+}
+
+// decodeID returns the ID of the object rendered with the given color, or zero
+// if the color is the background.
+func decodeID(c color.Color) uint32 {
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	if rgba.A == 0 {
+		return 0
+	}
+	return uint32(rgba.R)<<16 | uint32(rgba.G)<<8 | uint32(rgba.B)
+}
+
+// proxy is the object drawn in place of an object, with it's ID texture.
+type proxy struct {
+	*gfx.Object
+	id   uint32
+	tex  *gfx.Texture
+	used bool
+}
+
+// Buffer is an ID buffer: each object is rendered into it's texture with a
+// color which uniquely identifies it, such that the object under a pixel is
+// found by downloading the pixel.
+//
+// IDs are kept for as long as an object is drawn each time the buffer is, and
+// are reused afterwards.
+type Buffer struct {
+	// The texture that the IDs are rendered into. It's bounds are the size of
+	// the buffer, which is typically the size of the window (or smaller, for
+	// less precise but faster picking).
+	Texture *gfx.Texture
+
+	access   sync.Mutex
+	shader   *gfx.Shader
+	canvas   gfx.Canvas
+	renderer gfx.Renderer
+	proxies  map[*gfx.Object]*proxy
+	objects  map[uint32]*gfx.Object
+	next     uint32
+}
+
+// newID returns an unused ID.
+//
+// The buffer's lock must be held for this method to operate safely.
+func (b *Buffer) newID() uint32 {
+	for {
+		b.next++
+		if b.next > maxID {
+			b.next = 1
+		}
+		if _, used := b.objects[b.next]; !used {
+			return b.next
+		}
+	}
+}
+
+// update updates the proxy of the given object.
+func (p *proxy) update(o *gfx.Object, s *gfx.Shader) {
+	o.RLock()
+	p.Lock()
+	p.Transform = o.Transform
+	p.Meshes = o.Meshes
+	if len(p.Shaders) != len(o.Meshes) {
+		p.Shaders = p.Shaders[:0]
+		p.Textures = p.Textures[:0]
+		for len(p.Shaders) < len(o.Meshes) {
+			p.Shaders = append(p.Shaders, s)
+			p.Textures = append(p.Textures, []*gfx.Texture{p.tex})
+		}
+	}
+	p.FaceCulling = o.FaceCulling
+	p.DepthTest = o.DepthTest
+	p.Unlock()
+	o.RUnlock()
+}
+
+// Draw draws the IDs of the given objects onto the given canvas, which should
+// render to the buffer's texture (see Render), as seen by the given camera.
+// The canvas is cleared first.
+//
+// The canvas should not use multi-sample anti-aliasing (see
+// gfx.Canvas.SetMSAA), as it would blend the IDs of adjacent objects.
+func (b *Buffer) Draw(c gfx.Canvas, cam *gfx.Camera, objects []*gfx.Object) {
+	b.access.Lock()
+	defer b.access.Unlock()
+
+	var all image.Rectangle
+	c.Clear(all, gfx.Color{})
+	c.ClearDepth(all, 1)
+
+	for _, p := range b.proxies {
+		p.used = false
+	}
+	for _, o := range objects {
+		p := b.proxies[o]
+		if p == nil {
+			id := b.newID()
+			p = &proxy{Object: gfx.NewObject(), id: id, tex: idTexture(id)}
+			b.proxies[o] = p
+			b.objects[p.id] = o
+		}
+		if p.used {
+			// Listed twice.
+			continue
+		}
+		p.used = true
+		p.update(o, b.shader)
+		c.Draw(all, p.Object, cam)
+	}
+
+	// Forget about the objects that were not drawn, such that they may be
+	// garbage collected and their IDs reused.
+	for o, p := range b.proxies {
+		if !p.used {
+			delete(b.proxies, o)
+			delete(b.objects, p.id)
+		}
+	}
+}
+
+// idTexture returns a new texture of a single texel, whose color is the given
+// ID.
+func idTexture(id uint32) *gfx.Texture {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	img.SetRGBA(0, 0, encodeID(id))
+	return &gfx.Texture{
+		KeepDataOnLoad: true,
+		Bounds:         img.Bounds(),
+		Source:         img,
+		Format:         gfx.RGBA,
+		MinFilter:      gfx.Nearest,
+		MagFilter:      gfx.Nearest,
+	}
+}
+
+// Render renders the IDs of the given objects into the buffer's texture
+// using the given renderer (see Draw). Multi-sample anti-aliasing is disabled
+// for the texture's canvas.
+func (b *Buffer) Render(r gfx.Renderer, cam *gfx.Camera, objects []*gfx.Object) {
+	b.access.Lock()
+	if b.canvas == nil || b.renderer != r {
+		b.canvas = r.RenderToTexture(b.Texture)
+		b.canvas.SetMSAA(false)
+		b.renderer = r
+	}
+	c := b.canvas
+	b.access.Unlock()
+
+	b.Draw(c, cam, objects)
+	c.Render()
+}
+
+// texel returns the texel of the buffer under the given point in window
+// coordinates of the given viewing rectangle, and false if it is outside of
+// the rectangle.
+func (b *Buffer) texel(view image.Rectangle, p gmath.Vec2) (image.Point, bool) {
+	if view.Empty() {
+		return image.Point{}, false
+	}
+	tb := b.Texture.Bounds
+	fx := (p.X - float64(view.Min.X)) / float64(view.Dx())
+	fy := (p.Y - float64(view.Min.Y)) / float64(view.Dy())
+	if fx < 0 || fx >= 1 || fy < 0 || fy >= 1 {
+		return image.Point{}, false
+	}
+	return image.Point{
+		X: tb.Min.X + int(math.Floor(fx*float64(tb.Dx()))),
+		Y: tb.Min.Y + int(math.Floor(fy*float64(tb.Dy()))),
+	}, true
+}
+
+// lookup returns the object whose ID is at the given texel of the given
+// downloaded image, or nil if there is none. Images whose bounds do not
+// contain the texel are assumed to begin at it.
+func (b *Buffer) lookup(img image.Image, pt image.Point) *gfx.Object {
+	if img == nil || img.Bounds().Empty() {
+		return nil
+	}
+	if !pt.In(img.Bounds()) {
+		pt = img.Bounds().Min
+	}
+	id := decodeID(img.At(pt.X, pt.Y))
+	b.access.Lock()
+	defer b.access.Unlock()
+	return b.objects[id]
+}
+
+// hit returns the hit of the given camera's ray through the given point
+// against the given picked object.
+func hit(cam *gfx.Camera, view image.Rectangle, p gmath.Vec2, o *gfx.Object) Hit {
+	cam.RLock()
+	r, ok := cam.Ray(view, p)
+	cam.RUnlock()
+	if ok {
+		if h, ok := Object(r, o); ok {
+			return h
+		}
+	}
+	return Hit{Object: o, Mesh: -1, Triangle: -1}
+}
+
+// Pick returns the object under the given point in window coordinates of the
+// given viewing rectangle, as of the last time the buffer was rendered (see
+// Render), by downloading the texel under it. It blocks until the download
+// completes.
+//
+// The point and triangle of the hit are found by ray picking against the
+// object (see Object) with the given camera, which should be the one the
+// buffer was rendered with.
+func (b *Buffer) Pick(cam *gfx.Camera, view image.Rectangle, p gmath.Vec2) (h Hit, ok bool) {
+	b.access.Lock()
+	c := b.canvas
+	b.access.Unlock()
+	if c == nil {
+		return h, false
+	}
+	pt, ok := b.texel(view, p)
+	if !ok {
+		return h, false
+	}
+
+	complete := make(chan image.Image, 1)
+	c.Download(image.Rect(pt.X, pt.Y, pt.X+1, pt.Y+1), complete)
+	o := b.lookup(<-complete, pt)
+	if o == nil {
+		return h, false
+	}
+	return hit(cam, view, p, o), true
+}
+
+// NewBuffer returns a new ID buffer of the given size in pixels.
+func NewBuffer(width, height int) *Buffer {
+	shader := gfx.NewShader("picking")
+	shader.GLSLVert = glslVert
+	shader.GLSLFrag = glslFrag
+	return &Buffer{
+		Texture: &gfx.Texture{
+			Bounds:    image.Rect(0, 0, width, height),
+			Format:    gfx.RGBA,
+			MinFilter: gfx.Nearest,
+			MagFilter: gfx.Nearest,
+		},
+		shader:  shader,
+		proxies: make(map[*gfx.Object]*proxy),
+		objects: make(map[uint32]*gfx.Object),
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package picking implements identifying the objects under a point on the
+// screen (e.g. the mouse cursor).
+//
+// Two methods of picking are provided. Ray picking is performed on the CPU:
+// a ray from the camera through the point (see gfx.Camera.Ray) is tested
+// against the bounds and then the triangles of each object's meshes:
+//
+//  hit, ok := picking.Pixel(camera, r.Bounds(), mouse, objects)
+//  if ok {
+//      fmt.Println(hit.Object, hit.Point, hit.Triangle)
+//  }
+//
+// Ray picking requires the mesh data of the objects, such that meshes must
+// keep their data after being loaded (see gfx.Mesh.KeepDataOnLoad), meshes
+// without data are tested using their bounds only.
+//
+// ID-buffer picking is performed on the GPU: each object is rendered with a
+// unique color into an offscreen texture (see Buffer), and the color of the
+// pixel under the point is downloaded to find the object. It is exact for any
+// geometry the renderer can draw (e.g. skinned meshes, or meshes whose data
+// was not kept), but requires rendering the objects once more and waiting for
+// the download:
+//
+//  ids := picking.NewBuffer(r.Bounds().Dx(), r.Bounds().Dy())
+//
+//  // When the mouse is clicked:
+//  ids.Render(r, camera, objects)
+//  hit, ok := ids.Pick(camera, r.Bounds(), mouse)
+//
+// Both methods return a Hit, whose Point and Triangle are found by ray
+// picking against the object.
+package picking
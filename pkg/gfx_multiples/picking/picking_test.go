@@ -0,0 +1,196 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package picking
+
+import (
+	"azul3d.org/v1/gfx"
+	"azul3d.org/v1/gfx/glsl"
+	gmath "azul3d.org/v1/math"
+	"image"
+	"image/draw"
+	"testing"
+)
+
+// recorder is a canvas that records the objects drawn onto it.
+type recorder struct {
+	gfx.Canvas
+	drawn []*gfx.Object
+}
+
+func (r *recorder) Clear(rect image.Rectangle, bg gfx.Color)       {}
+func (r *recorder) ClearDepth(rect image.Rectangle, depth float64) {}
+func (r *recorder) Draw(rect image.Rectangle, o *gfx.Object, c *gfx.Camera) {
+	r.drawn = append(r.drawn, o)
+}
+
+// newWall returns a new object with a single mesh: a 2x2 square in the XZ
+// plane (facing the camera, which looks down +Y) made of two triangles, at the
+// given position.
+func newWall(pos gmath.Vec3) *gfx.Object {
+	o := gfx.NewObject()
+	o.Meshes = []*gfx.Mesh{{
+		Vertices: []gfx.Vec3{
+			{-1, 0, -1}, {1, 0, -1}, {1, 0, 1},
+			{-1, 0, -1}, {1, 0, 1}, {-1, 0, 1},
+		},
+	}}
+	o.SetPos(pos)
+	return o
+}
+
+func newCamera() *gfx.Camera {
+	cam := gfx.NewCamera()
+	cam.SetPersp(image.Rect(0, 0, 800, 600), 75, 0.5, 500)
+	return cam
+}
+
+var (
+	view   = image.Rect(0, 0, 800, 600)
+	center = gmath.Vec2{400, 300}
+)
+
+func TestObject(t *testing.T) {
+	o := newWall(gmath.Vec3{0, 10, 0})
+	forward := gmath.Vec3{0, 1, 0}
+
+	// The lower-right triangle.
+	h, ok := Object(gfx.Ray{Origin: gmath.Vec3{0.5, 0, -0.5}, Dir: forward}, o)
+	if !ok || h.Mesh != 0 || h.Triangle != 0 {
+		t.Fatalf("got hit %+v, %v", h, ok)
+	}
+	if !h.Point.Equals(gmath.Vec3{0.5, 10, -0.5}) || !gmath.Equal(h.Dist, 10) {
+		t.Fatalf("got point %v at distance %v", h.Point, h.Dist)
+	}
+
+	// The upper-left triangle, of the scaled object.
+	o.SetScale(gmath.Vec3{2, 2, 2})
+	h, ok = Object(gfx.Ray{Origin: gmath.Vec3{-1.5, 0, 1.5}, Dir: forward}, o)
+	if !ok || h.Triangle != 1 || !gmath.Equal(h.Dist, 10) {
+		t.Fatalf("got hit %+v, %v", h, ok)
+	}
+
+	// Misses.
+	if _, ok := Object(gfx.Ray{Origin: gmath.Vec3{2.5, 0, 0}, Dir: forward}, o); ok {
+		t.Fatal("hit outside of the object")
+	}
+	if _, ok := Object(gfx.Ray{Origin: gmath.Vec3{}, Dir: forward.MulScalar(-1)}, o); ok {
+		t.Fatal("hit behind the ray")
+	}
+
+	// Without mesh data only the bounds are hit.
+	m := o.Meshes[0]
+	m.CalculateBounds()
+	m.Vertices = nil
+	h, ok = Object(gfx.Ray{Origin: gmath.Vec3{0, 0, 0}, Dir: forward}, o)
+	if !ok || h.Triangle != -1 || !gmath.Equal(h.Dist, 10) {
+		t.Fatalf("got hit %+v, %v", h, ok)
+	}
+}
+
+func TestNearest(t *testing.T) {
+	near := newWall(gmath.Vec3{0, 5, 0})
+	far := newWall(gmath.Vec3{0, 20, 0})
+	aside := newWall(gmath.Vec3{10, 10, 0})
+	objects := []*gfx.Object{far, aside, near}
+
+	hits := All(gfx.Ray{Dir: gmath.Vec3{0, 1, 0}}, objects)
+	if len(hits) != 2 || hits[0].Object != near || hits[1].Object != far {
+		t.Fatalf("got hits %+v", hits)
+	}
+
+	h, ok := Pixel(newCamera(), view, center, objects)
+	if !ok || h.Object != near {
+		t.Fatalf("got hit %+v, %v", h, ok)
+	}
+	if _, ok := Pixel(newCamera(), view, gmath.Vec2{0, 0}, objects); ok {
+		t.Fatal("hit in the corner of the view")
+	}
+}
+
+func TestID(t *testing.T) {
+	for _, id := range []uint32{0, 1, 255, 256, 65536, maxID} {
+		if got := decodeID(encodeID(id)); got != id {
+			t.Fatalf("ID %d decoded as %d", id, got)
+		}
+	}
+	if decodeID(image.Transparent) != 0 {
+		t.Fatal("background decoded as an object")
+	}
+}
+
+func TestBuffer(t *testing.T) {
+	a := newWall(gmath.Vec3{0, 10, 0})
+	b := newWall(gmath.Vec3{10, 10, 0})
+	buf := NewBuffer(400, 300)
+	cam := newCamera()
+
+	c := &recorder{}
+	buf.Draw(c, cam, []*gfx.Object{a, b, a})
+	if len(c.drawn) != 2 {
+		t.Fatalf("got %d objects drawn want 2", len(c.drawn))
+	}
+	idA, idB := buf.proxies[a].id, buf.proxies[b].id
+	if idA == 0 || idA == idB {
+		t.Fatalf("got IDs %d and %d", idA, idB)
+	}
+	for i, want := range []uint32{idA, idB} {
+		p := c.drawn[i]
+		if len(p.Shaders) != 1 || len(p.Textures) != 1 || p.Meshes[0] != a.Meshes[0] && p.Meshes[0] != b.Meshes[0] {
+			t.Fatal("proxy has the wrong meshes, shaders or textures")
+		}
+		if got := decodeID(p.Textures[0][0].Source.At(0, 0)); got != want {
+			t.Fatalf("proxy drawn with ID %d want %d", got, want)
+		}
+	}
+
+	// The pixel at the center of the view is the center of the buffer.
+	pt, ok := buf.texel(view, center)
+	if !ok || pt != (image.Point{200, 150}) {
+		t.Fatalf("got texel %v, %v", pt, ok)
+	}
+	if _, ok := buf.texel(view, gmath.Vec2{800, 0}); ok {
+		t.Fatal("got texel outside of the view")
+	}
+
+	// Look up the object in a downloaded image.
+	img := image.NewRGBA(image.Rect(pt.X, pt.Y, pt.X+1, pt.Y+1))
+	draw.Draw(img, img.Bounds(), &image.Uniform{encodeID(idA)}, image.ZP, draw.Src)
+	o := buf.lookup(img, pt)
+	if o != a {
+		t.Fatal("object not found by it's ID")
+	}
+	h := hit(cam, view, center, o)
+	if h.Object != a || h.Mesh != 0 || !h.Point.Equals(gmath.Vec3{0, 10, 0}) {
+		t.Fatalf("got hit %+v", h)
+	}
+
+	// Objects not drawn are forgotten.
+	buf.Draw(c, cam, []*gfx.Object{b})
+	if buf.lookup(img, pt) != nil || buf.proxies[b].id != idB {
+		t.Fatal("IDs not updated")
+	}
+}
+
+func TestBufferNil(t *testing.T) {
+	r := gfx.Nil()
+	buf := NewBuffer(400, 300)
+	if _, ok := buf.Pick(newCamera(), view, center); ok {
+		t.Fatal("picked before rendering")
+	}
+	buf.Render(r, newCamera(), []*gfx.Object{newWall(gmath.Vec3{0, 10, 0})})
+	if _, ok := buf.Pick(newCamera(), view, center); ok {
+		t.Fatal("picked without a download")
+	}
+}
+
+func TestShader(t *testing.T) {
+	r, err := glsl.Reflect(glslVert, glslFrag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r.Uniforms["Texture0"]; !ok {
+		t.Fatal("Texture0 is not declared")
+	}
+}
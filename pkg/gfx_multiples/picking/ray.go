@@ -0,0 +1,212 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package picking
+
+import (
+	"azul3d.org/v1/gfx"
+	gmath "azul3d.org/v1/math"
+	"image"
+	"math"
+	"sort"
+)
+
+// Hit describes where a ray hit an object.
+type Hit struct {
+	// The object that was hit.
+	Object *gfx.Object
+
+	// The world space point at which the object was hit, and it's distance
+	// from the origin of the ray (in units of the ray's direction).
+	Point gmath.Vec3
+	Dist  float64
+
+	// The index of the mesh of the object that was hit, and the index of the
+	// triangle of that mesh (i.e. it's first vertex or index is at
+	// 3*Triangle).
+	//
+	// Triangle is -1 if only the bounds of the mesh were hit, because the
+	// mesh's data was not kept after loading. Mesh is also -1 if the object
+	// was picked by a Buffer but could not be hit by a ray, in which case
+	// Point and Dist are not meaningful.
+	Mesh, Triangle int
+}
+
+// triangle returns the distance along the ray (whose direction need not be
+// normalized) at which it intersects the triangle a, b, c from either side,
+// using the Möller–Trumbore algorithm.
+func triangle(origin, dir, a, b, c gmath.Vec3) (t float64, ok bool) {
+	e1 := b.Sub(a)
+	e2 := c.Sub(a)
+	p := dir.Cross(e2)
+	det := e1.Dot(p)
+	if math.Abs(det) < 1e-12 {
+		// Parallel to (or a degenerate) triangle.
+		return 0, false
+	}
+	inv := 1 / det
+	s := origin.Sub(a)
+	u := s.Dot(p) * inv
+	if u < 0 || u > 1 {
+		return 0, false
+	}
+	q := s.Cross(e1)
+	v := dir.Dot(q) * inv
+	if v < 0 || u+v > 1 {
+		return 0, false
+	}
+	t = e2.Dot(q) * inv
+	return t, t >= 0
+}
+
+// meshBounds returns the bounds of the given mesh, and false if it has
+// neither bounds nor vertices.
+//
+// The mesh's read lock must be held for this function to operate safely.
+func meshBounds(m *gfx.Mesh) (b gmath.Rect3, ok bool) {
+	if len(m.Vertices) == 0 {
+		return m.AABB, m.AABB != gmath.Rect3Zero
+	}
+	if m.AABB != gmath.Rect3Zero {
+		return m.AABB, true
+	}
+	b.Min = m.Vertices[0].Vec3()
+	b.Max = b.Min
+	for _, v32 := range m.Vertices[1:] {
+		v := v32.Vec3()
+		b.Min = b.Min.Min(v)
+		b.Max = b.Max.Max(v)
+	}
+	return b, true
+}
+
+// pickMesh returns the nearest hit of the given local space ray against the
+// given mesh.
+//
+// The mesh's read lock must be held for this function to operate safely.
+func pickMesh(r gfx.Ray, m *gfx.Mesh) (t float64, tri int, ok bool) {
+	bounds, ok := meshBounds(m)
+	if !ok {
+		return 0, 0, false
+	}
+	t, ok = r.IntersectRect3(bounds)
+	if !ok {
+		return 0, 0, false
+	}
+	if len(m.Vertices) == 0 {
+		return t, -1, true
+	}
+
+	ok = false
+	test := func(i int, a, b, c uint32) {
+		n := uint32(len(m.Vertices))
+		if a >= n || b >= n || c >= n {
+			return
+		}
+		d, hit := triangle(r.Origin, r.Dir, m.Vertices[a].Vec3(), m.Vertices[b].Vec3(), m.Vertices[c].Vec3())
+		if hit && (!ok || d < t) {
+			t, tri, ok = d, i, true
+		}
+	}
+	if len(m.Indices) > 0 {
+		for i := 0; i+2 < len(m.Indices); i += 3 {
+			test(i/3, m.Indices[i], m.Indices[i+1], m.Indices[i+2])
+		}
+	} else {
+		for i := 0; i+2 < len(m.Vertices); i += 3 {
+			test(i/3, uint32(i), uint32(i+1), uint32(i+2))
+		}
+	}
+	return t, tri, ok
+}
+
+// Object returns the nearest hit of the given world space ray against the
+// triangles (or bounds, see Hit.Triangle) of the meshes of the given object.
+// Triangles are hit from either side, regardless of the object's face culling
+// mode.
+//
+// This function properly read-locks the object and it's meshes.
+func Object(r gfx.Ray, o *gfx.Object) (h Hit, ok bool) {
+	o.RLock()
+	defer o.RUnlock()
+
+	// Transform the ray into the local space of the object, without
+	// normalizing it's direction such that distances along it are the same
+	// in both spaces.
+	local := r
+	if o.Transform != nil {
+		inv, invertible := o.Transform.Convert(gfx.LocalToWorld).Inverse()
+		if !invertible {
+			return h, false
+		}
+		local.Origin = r.Origin.TransformMat4(inv)
+		local.Dir = r.Origin.Add(r.Dir).TransformMat4(inv).Sub(local.Origin)
+	}
+
+	for i, m := range o.Meshes {
+		if m == nil {
+			continue
+		}
+		m.RLock()
+		t, tri, hit := pickMesh(local, m)
+		m.RUnlock()
+		if hit && (!ok || t < h.Dist) {
+			h = Hit{
+				Object:   o,
+				Point:    r.At(t),
+				Dist:     t,
+				Mesh:     i,
+				Triangle: tri,
+			}
+			ok = true
+		}
+	}
+	return h, ok
+}
+
+// All returns every hit of the given world space ray against the given
+// objects (at most one per object, see Object), sorted nearest first.
+func All(r gfx.Ray, objects []*gfx.Object) []Hit {
+	var hits []Hit
+	for _, o := range objects {
+		if h, ok := Object(r, o); ok {
+			hits = append(hits, h)
+		}
+	}
+	sort.Stable(byDist(hits))
+	return hits
+}
+
+// byDist sorts hits nearest first.
+type byDist []Hit
+
+func (s byDist) Len() int           { return len(s) }
+func (s byDist) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byDist) Less(i, j int) bool { return s[i].Dist < s[j].Dist }
+
+// Nearest returns the nearest hit of the given world space ray against the
+// given objects (see Object).
+func Nearest(r gfx.Ray, objects []*gfx.Object) (h Hit, ok bool) {
+	for _, o := range objects {
+		if oh, hit := Object(r, o); hit && (!ok || oh.Dist < h.Dist) {
+			h, ok = oh, true
+		}
+	}
+	return h, ok
+}
+
+// Pixel returns the nearest of the given objects under the given point in
+// window coordinates of the given viewing rectangle (see gfx.Camera.Ray), as
+// seen by the given camera.
+//
+// This function properly read-locks the camera.
+func Pixel(cam *gfx.Camera, view image.Rectangle, p gmath.Vec2, objects []*gfx.Object) (h Hit, ok bool) {
+	cam.RLock()
+	r, ok := cam.Ray(view, p)
+	cam.RUnlock()
+	if !ok {
+		return h, false
+	}
+	return Nearest(r, objects)
+}
@@ -0,0 +1,260 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gfx
+
+import (
+	gmath "azul3d.org/v1/math"
+	"math"
+)
+
+// BlendMode describes how a pose is blended on top of another.
+type BlendMode uint8
+
+const (
+	// BlendOverride blends towards the layer pose by the weight, such that a
+	// weight of one fully replaces the base pose.
+	BlendOverride BlendMode = iota
+
+	// BlendAdditive treats the layer pose as a difference (see Pose.Sub) and
+	// adds it to the base pose scaled by the weight.
+	BlendAdditive
+)
+
+// Pose is a decomposed transformation: a position, quaternion rotation, scale
+// and shear. Unlike a matrix, poses can be meaningfully interpolated and
+// blended together, which makes them suitable for animation.
+type Pose struct {
+	Pos, Scale, Shear gmath.Vec3
+	Rot               gmath.Quat
+}
+
+// PoseIdentity is the pose with no translation, rotation or shear and a
+// uniform scale of one.
+var PoseIdentity = Pose{
+	Scale: gmath.Vec3One,
+	Rot:   gmath.Quat{W: 1},
+}
+
+// Mat4 composes and returns the transformation matrix of this pose, built in
+// the same manner as a Transform's local matrix.
+func (p Pose) Mat4() gmath.Mat4 {
+	hpr := p.Rot.Hpr(gmath.CoordSysZUpRight)
+	m := gmath.Mat3Compose(p.Scale, p.Shear, hpr, gmath.CoordSysZUpRight)
+	return gmath.Mat4Identity.SetUpperMat3(m).SetTranslation(p.Pos)
+}
+
+// Equals tells if the two poses are equal. Two rotations are considered equal
+// if they describe the same orientation (i.e. q and -q are equal).
+func (p Pose) Equals(other Pose) bool {
+	if !p.Pos.Equals(other.Pos) || !p.Scale.Equals(other.Scale) || !p.Shear.Equals(other.Shear) {
+		return false
+	}
+	if p.Rot.Equals(other.Rot) {
+		return true
+	}
+	return p.Rot.Equals(quatNeg(other.Rot))
+}
+
+// Lerp interpolates between the two poses by the amount t, where t=0 returns
+// p and t=1 returns b. Position, scale and shear are linearly interpolated
+// and rotation is spherically interpolated (see Slerp).
+func (p Pose) Lerp(b Pose, t float64) Pose {
+	return Pose{
+		Pos:   lerpVec3(p.Pos, b.Pos, t),
+		Scale: lerpVec3(p.Scale, b.Scale, t),
+		Shear: lerpVec3(p.Shear, b.Shear, t),
+		Rot:   Slerp(p.Rot, b.Rot, t),
+	}
+}
+
+// Sub returns the difference between this pose and the reference one, such
+// that ref.Add(p.Sub(ref), 1) is equal to p. The difference is suitable for
+// additive blending:
+//
+//  // Difference between a 'nod' animation frame and it's rest pose.
+//  delta := nodFrame.Sub(rest)
+//
+//  // Nod while walking.
+//  p := walkFrame.Add(delta, 1)
+//
+func (p Pose) Sub(ref Pose) Pose {
+	return Pose{
+		Pos: p.Pos.Sub(ref.Pos),
+		Scale: gmath.Vec3{
+			safeDiv(p.Scale.X, ref.Scale.X),
+			safeDiv(p.Scale.Y, ref.Scale.Y),
+			safeDiv(p.Scale.Z, ref.Scale.Z),
+		},
+		Shear: p.Shear.Sub(ref.Shear),
+		Rot:   quatCompose(p.Rot, quatConj(ref.Rot)),
+	}
+}
+
+// Add adds the difference pose (see Sub) to this one, scaled by the weight.
+// A weight of zero returns p unchanged, a weight of one applies the full
+// difference.
+func (p Pose) Add(delta Pose, weight float64) Pose {
+	s := lerpVec3(gmath.Vec3One, delta.Scale, weight)
+	return Pose{
+		Pos:   p.Pos.Add(delta.Pos.MulScalar(weight)),
+		Scale: gmath.Vec3{p.Scale.X * s.X, p.Scale.Y * s.Y, p.Scale.Z * s.Z},
+		Shear: p.Shear.Add(delta.Shear.MulScalar(weight)),
+		Rot:   quatCompose(Slerp(PoseIdentity.Rot, delta.Rot, weight), p.Rot),
+	}
+}
+
+// Blend blends the layer pose on top of this one using the given mode and
+// weight (where zero returns p unchanged and one applies the full layer).
+// Layers are typically applied in order:
+//
+//  p := base
+//  p = p.Blend(aim, 0.5, gfx.BlendOverride)
+//  p = p.Blend(breathe, 1, gfx.BlendAdditive)
+//
+func (p Pose) Blend(layer Pose, weight float64, mode BlendMode) Pose {
+	if mode == BlendAdditive {
+		return p.Add(layer, weight)
+	}
+	return p.Lerp(layer, weight)
+}
+
+// PoseFromMat4 decomposes the given affine transformation matrix into a pose
+// such that the returned pose's Mat4 is equal to m. Any projective part of m
+// is ignored. If the upper 3x3 matrix is singular (i.e. some axis has a scale
+// of zero) then ok=false is returned.
+//
+// Negative scaling (a reflection) is always decomposed as a negative Z scale.
+func PoseFromMat4(m gmath.Mat4) (p Pose, ok bool) {
+	// The upper 3x3 matrix is the scale-shear matrix (see math.Mat3Compose)
+	// multiplied by an orthonormal rotation matrix. For the Z-up right-handed
+	// coordinate system the scale-shear matrix is:
+	//
+	//  sx      shx*sx  0
+	//  0       sy      0
+	//  shy*sz  shz*sz  sz
+	//
+	// The rows are thus separated using Gram-Schmidt orthogonalization, in
+	// the order Y, X, Z.
+	rows := [3]gmath.Vec3{
+		{m[0][0], m[0][1], m[0][2]},
+		{m[1][0], m[1][1], m[1][2]},
+		{m[2][0], m[2][1], m[2][2]},
+	}
+	p.Pos = m.Translation()
+
+	const epsilon = 1e-12
+
+	// Y axis: scale only.
+	p.Scale.Y = rows[1].Length()
+	if p.Scale.Y < epsilon {
+		return PoseIdentity, false
+	}
+	r1 := rows[1].DivScalar(p.Scale.Y)
+
+	// X axis: XY shear and scale.
+	d := rows[0].Dot(r1)
+	u0 := rows[0].Sub(r1.MulScalar(d))
+	p.Scale.X = u0.Length()
+	if p.Scale.X < epsilon {
+		return PoseIdentity, false
+	}
+	p.Shear.X = d / p.Scale.X
+	r0 := u0.DivScalar(p.Scale.X)
+
+	// Z axis: XZ and YZ shear and scale.
+	d0, d1 := rows[2].Dot(r0), rows[2].Dot(r1)
+	u2 := rows[2].Sub(r0.MulScalar(d0)).Sub(r1.MulScalar(d1))
+	p.Scale.Z = u2.Length()
+	if p.Scale.Z < epsilon {
+		return PoseIdentity, false
+	}
+	r2 := u2.DivScalar(p.Scale.Z)
+
+	// A left-handed basis holds a reflection, which we move into the scale so
+	// that the rotation is proper.
+	if r0.Cross(r1).Dot(r2) < 0 {
+		p.Scale.Z = -p.Scale.Z
+		r2 = r2.MulScalar(-1)
+	}
+	p.Shear.Y = d0 / p.Scale.Z
+	p.Shear.Z = d1 / p.Scale.Z
+
+	p.Rot = gmath.QuatFromMat3(gmath.Mat3{
+		{r0.X, r0.Y, r0.Z},
+		{r1.X, r1.Y, r1.Z},
+		{r2.X, r2.Y, r2.Z},
+	})
+	return p, true
+}
+
+// Slerp spherically interpolates between the two quaternion rotations by the
+// amount t, always taking the shortest path. The returned quaternion is of
+// unit length.
+func Slerp(a, b gmath.Quat, t float64) gmath.Quat {
+	a = quatNormalize(a)
+	b = quatNormalize(b)
+	cos := quatDot(a, b)
+	if cos < 0 {
+		// Take the shortest path.
+		b = quatNeg(b)
+		cos = -cos
+	}
+
+	var wa, wb float64
+	if cos > 0.9995 {
+		// The rotations are very close; linear interpolation avoids the
+		// division by a (near) zero sine below.
+		wa, wb = 1-t, t
+	} else {
+		theta := math.Acos(cos)
+		sin := math.Sin(theta)
+		wa = math.Sin((1-t)*theta) / sin
+		wb = math.Sin(t*theta) / sin
+	}
+	return quatNormalize(gmath.Quat{
+		W: wa*a.W + wb*b.W,
+		X: wa*a.X + wb*b.X,
+		Y: wa*a.Y + wb*b.Y,
+		Z: wa*a.Z + wb*b.Z,
+	})
+}
+
+// quatCompose returns the rotation a followed by the rotation b.
+func quatCompose(a, b gmath.Quat) gmath.Quat {
+	ma := a.ExtractToMat4().UpperMat3()
+	mb := b.ExtractToMat4().UpperMat3()
+	return quatNormalize(gmath.QuatFromMat3(ma.Mul(mb)))
+}
+
+func quatDot(a, b gmath.Quat) float64 {
+	return a.W*b.W + a.X*b.X + a.Y*b.Y + a.Z*b.Z
+}
+
+func quatNeg(q gmath.Quat) gmath.Quat {
+	return gmath.Quat{W: -q.W, X: -q.X, Y: -q.Y, Z: -q.Z}
+}
+
+func quatConj(q gmath.Quat) gmath.Quat {
+	return gmath.Quat{W: q.W, X: -q.X, Y: -q.Y, Z: -q.Z}
+}
+
+func quatNormalize(q gmath.Quat) gmath.Quat {
+	l := math.Sqrt(quatDot(q, q))
+	if l == 0 {
+		return gmath.Quat{W: 1}
+	}
+	return gmath.Quat{W: q.W / l, X: q.X / l, Y: q.Y / l, Z: q.Z / l}
+}
+
+func lerpVec3(a, b gmath.Vec3, t float64) gmath.Vec3 {
+	return a.Add(b.Sub(a).MulScalar(t))
+}
+
+func safeDiv(a, b float64) float64 {
+	if b == 0 {
+		return 1
+	}
+	return a / b
+}
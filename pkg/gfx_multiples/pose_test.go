@@ -0,0 +1,196 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gfx
+
+import (
+	gmath "azul3d.org/v1/math"
+	"math"
+	"testing"
+)
+
+func mat4Near(a, b gmath.Mat4, epsilon float64) bool {
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if math.Abs(a[i][j]-b[i][j]) > epsilon {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// quatAngle returns the angle in radians between the two rotations.
+func quatAngle(a, b gmath.Quat) float64 {
+	d := math.Abs(quatDot(quatNormalize(a), quatNormalize(b)))
+	if d > 1 {
+		d = 1
+	}
+	return 2 * math.Acos(d)
+}
+
+func testPose(hpr, pos, scale, shear gmath.Vec3) Pose {
+	return Pose{
+		Pos:   pos,
+		Scale: scale,
+		Shear: shear,
+		Rot:   gmath.QuatFromHpr(hpr.Radians(), gmath.CoordSysZUpRight),
+	}
+}
+
+var testPoses = []Pose{
+	PoseIdentity,
+	testPose(gmath.Vec3{30, 0, 0}, gmath.Vec3{1, 2, 3}, gmath.Vec3One, gmath.Vec3Zero),
+	testPose(gmath.Vec3{45, -20, 10}, gmath.Vec3{-4, 0, 9}, gmath.Vec3{2, 0.5, 3}, gmath.Vec3Zero),
+	testPose(gmath.Vec3{-120, 60, 170}, gmath.Vec3{0, 1, 0}, gmath.Vec3{1, 2, 1}, gmath.Vec3{0.3, -0.2, 0.5}),
+	testPose(gmath.Vec3{10, 20, 30}, gmath.Vec3Zero, gmath.Vec3{1, 1, -2}, gmath.Vec3{0, 0.4, 0}),
+}
+
+func TestPoseFromMat4(t *testing.T) {
+	for i, p := range testPoses {
+		m := p.Mat4()
+		got, ok := PoseFromMat4(m)
+		if !ok {
+			t.Fatalf("pose %d: PoseFromMat4 not ok", i)
+		}
+		if !mat4Near(got.Mat4(), m, 1e-9) {
+			t.Errorf("pose %d: recomposed matrix\n%v\nwant\n%v", i, got.Mat4(), m)
+		}
+		if !vecNear(got.Pos, p.Pos, 1e-9) || !vecNear(got.Scale, p.Scale, 1e-9) || !vecNear(got.Shear, p.Shear, 1e-9) {
+			t.Errorf("pose %d: got %+v want %+v", i, got, p)
+		}
+		if a := quatAngle(got.Rot, p.Rot); a > 1e-6 {
+			t.Errorf("pose %d: rotation off by %v radians", i, a)
+		}
+	}
+
+	// Singular matrices cannot be decomposed.
+	singular := PoseIdentity
+	singular.Scale.Y = 0
+	if _, ok := PoseFromMat4(singular.Mat4()); ok {
+		t.Fatal("PoseFromMat4 of singular matrix is ok")
+	}
+}
+
+func TestPoseFromMat4Shear(t *testing.T) {
+	// The layout of the scale-shear matrix that PoseFromMat4 relies on.
+	scale := gmath.Vec3{2, 3, 4}
+	shear := gmath.Vec3{0.5, -0.25, 0.75}
+	want := gmath.Mat3{
+		{2, 1, 0},
+		{0, 3, 0},
+		{-1, 3, 4},
+	}
+	m := gmath.Mat3Compose(scale, shear, gmath.Vec3Zero, gmath.CoordSysZUpRight)
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			if math.Abs(m[i][j]-want[i][j]) > 1e-12 {
+				t.Fatalf("scale-shear matrix\n%v\nwant\n%v", m, want)
+			}
+		}
+	}
+
+	p, ok := PoseFromMat4(gmath.Mat4Identity.SetUpperMat3(m))
+	if !ok || !vecNear(p.Scale, scale, 1e-9) || !vecNear(p.Shear, shear, 1e-9) {
+		t.Fatalf("got %+v, %v", p, ok)
+	}
+	if a := quatAngle(p.Rot, PoseIdentity.Rot); a > 1e-6 {
+		t.Fatalf("rotation off by %v radians", a)
+	}
+}
+
+func TestPoseLerp(t *testing.T) {
+	a, b := testPoses[1], testPoses[2]
+	if got := a.Lerp(b, 0); !mat4Near(got.Mat4(), a.Mat4(), 1e-9) {
+		t.Errorf("Lerp(0) = %+v, want %+v", got, a)
+	}
+	if got := a.Lerp(b, 1); !mat4Near(got.Mat4(), b.Mat4(), 1e-9) {
+		t.Errorf("Lerp(1) = %+v, want %+v", got, b)
+	}
+
+	mid := a.Lerp(b, 0.5)
+	if !vecNear(mid.Pos, gmath.Vec3{-1.5, 1, 6}, 1e-9) {
+		t.Errorf("Lerp(0.5) position %v", mid.Pos)
+	}
+	total := quatAngle(a.Rot, b.Rot)
+	if d := quatAngle(a.Rot, mid.Rot) - total/2; math.Abs(d) > 1e-9 {
+		t.Errorf("Lerp(0.5) rotation is %v radians from halfway", d)
+	}
+}
+
+func TestSlerpShortestPath(t *testing.T) {
+	a := gmath.QuatFromHpr(gmath.Vec3{10, 0, 0}.Radians(), gmath.CoordSysZUpRight)
+	b := gmath.QuatFromHpr(gmath.Vec3{50, 0, 0}.Radians(), gmath.CoordSysZUpRight)
+
+	// The negated quaternion describes the same rotation, the interpolation
+	// must not go the long way around.
+	for _, bb := range []gmath.Quat{b, quatNeg(b)} {
+		mid := Slerp(a, bb, 0.5)
+		want := gmath.QuatFromHpr(gmath.Vec3{30, 0, 0}.Radians(), gmath.CoordSysZUpRight)
+		if d := quatAngle(mid, want); d > 1e-9 {
+			t.Errorf("Slerp(%v, %v, 0.5) off by %v radians", a, bb, d)
+		}
+	}
+}
+
+func TestPoseBlend(t *testing.T) {
+	rest, frame, base := testPoses[1], testPoses[3], testPoses[2]
+	delta := frame.Sub(rest)
+
+	// Adding the difference back onto the reference gives the original pose.
+	if got := rest.Add(delta, 1); !mat4Near(got.Mat4(), frame.Mat4(), 1e-9) {
+		t.Errorf("rest.Add(delta, 1) = %+v, want %+v", got, frame)
+	}
+
+	// Zero weights leave the base untouched.
+	for _, mode := range []BlendMode{BlendOverride, BlendAdditive} {
+		if got := base.Blend(delta, 0, mode); !mat4Near(got.Mat4(), base.Mat4(), 1e-9) {
+			t.Errorf("mode %d: Blend(0) = %+v, want %+v", mode, got, base)
+		}
+	}
+
+	// A full override replaces the base.
+	if got := base.Blend(frame, 1, BlendOverride); !mat4Near(got.Mat4(), frame.Mat4(), 1e-9) {
+		t.Errorf("override Blend(1) = %+v, want %+v", got, frame)
+	}
+
+	// Half of an additive layer moves halfway.
+	half := base.Blend(delta, 0.5, BlendAdditive)
+	full := base.Blend(delta, 1, BlendAdditive)
+	if !vecNear(half.Pos, base.Pos.Add(full.Pos).DivScalar(2), 1e-9) {
+		t.Errorf("additive Blend(0.5) position %v", half.Pos)
+	}
+	if d := quatAngle(base.Rot, half.Rot) - quatAngle(base.Rot, full.Rot)/2; math.Abs(d) > 1e-9 {
+		t.Errorf("additive Blend(0.5) rotation is %v radians from halfway", d)
+	}
+}
+
+func TestTransformPose(t *testing.T) {
+	// SetQuat on a transform using euler rotation must not panic.
+	tf := NewTransform()
+	tf.SetQuat(testPoses[2].Rot)
+	if !tf.IsQuat() {
+		t.Fatal("IsQuat after SetQuat is false")
+	}
+
+	for i, p := range testPoses {
+		tf := NewTransform()
+		tf.SetPose(p)
+		if !mat4Near(tf.LocalMat4(), p.Mat4(), 1e-9) {
+			t.Errorf("pose %d: LocalMat4\n%v\nwant\n%v", i, tf.LocalMat4(), p.Mat4())
+		}
+		if got := tf.Pose(); !got.Equals(p) {
+			t.Errorf("pose %d: Pose() = %+v, want %+v", i, got, p)
+		}
+	}
+
+	// Euler rotation is converted.
+	tf = NewTransform()
+	tf.SetRot(gmath.Vec3{20, 0, 40})
+	tf.SetPos(gmath.Vec3{1, 2, 3})
+	p := tf.Pose()
+	if !mat4Near(p.Mat4(), tf.LocalMat4(), 1e-9) {
+		t.Errorf("euler Pose().Mat4()\n%v\nwant\n%v", p.Mat4(), tf.LocalMat4())
+	}
+}
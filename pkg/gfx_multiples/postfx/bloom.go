@@ -0,0 +1,120 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postfx
+
+import (
+	"azul3d.org/v1/gfx"
+	"image"
+	"math"
+)
+
+const glslBright = `
+#version 120
+
+varying vec2 tc0;
+
+uniform sampler2D Texture0;
+uniform float Threshold;
+
+void main()
+{
+	vec4 c = texture2D(Texture0, tc0);
+	float l = dot(c.rgb, vec3(0.2126, 0.7152, 0.0722));
+	float k = max(l - Threshold, 0.0) / max(l, 0.0001);
+	gl_FragColor = vec4(c.rgb * k, 1.0);
+}
+`
+
+const glslBloom = `
+#version 120
+
+varying vec2 tc0;
+
+uniform sampler2D Texture0;
+uniform sampler2D Texture1;
+uniform float Intensity;
+
+void main()
+{
+	vec4 base = texture2D(Texture0, tc0);
+	vec4 bloom = texture2D(Texture1, tc0);
+	gl_FragColor = vec4(base.rgb + bloom.rgb * Intensity, base.a);
+}
+`
+
+// Bloom is an effect that makes bright areas of the image bleed light into
+// their surroundings. It extracts the bright parts of the image, blurs them
+// (at a lower resolution) and adds them back onto the image.
+type Bloom struct {
+	// The luminance above which pixels contribute to the bloom.
+	Threshold float64
+
+	// The intensity of the bloom added to the image.
+	Intensity float64
+
+	// The blur applied to the bright parts of the image, it's scale is the
+	// resolution of the bloom (see Pass.Scale).
+	Blur *Blur
+
+	bright, composite *gfx.Shader
+}
+
+// Passes implements the Effect interface.
+func (b *Bloom) Passes() []*Pass {
+	passes := []*Pass{{
+		Name:    "BloomBright",
+		Shader:  b.bright,
+		Sources: []Source{Previous},
+		Scale:   b.Blur.Scale,
+		Update: func(s *gfx.Shader, src image.Rectangle) {
+			s.Inputs["Threshold"] = float32(b.Threshold)
+		},
+	}}
+	passes = append(passes, b.Blur.Passes()...)
+	return append(passes, &Pass{
+		Name:    "Bloom",
+		Shader:  b.composite,
+		Sources: []Source{Input, Previous},
+		Update: func(s *gfx.Shader, src image.Rectangle) {
+			s.Inputs["Intensity"] = float32(b.Intensity)
+		},
+	})
+}
+
+// Apply implements the Effect interface.
+func (b *Bloom) Apply(src *image.RGBA) *image.RGBA {
+	in := newFImage(src)
+	w, h := scaled(in.w, in.h, b.Blur.Scale)
+	bright := render(w, h, func(u, v float64) vec4 {
+		c := in.sample(u, v)
+		l := c.luma()
+		k := math.Max(l-b.Threshold, 0) / math.Max(l, 0.0001)
+		c = c.mul(k)
+		c[3] = 1
+		return c
+	})
+	bloom := b.Blur.apply(bright, w, h)
+	return render(in.w, in.h, func(u, v float64) vec4 {
+		c := in.sample(u, v)
+		a := c[3]
+		c = c.add(bloom.sample(u, v).mul(b.Intensity))
+		c[3] = a
+		return c
+	}).rgba()
+}
+
+// NewBloom returns a new bloom effect with a threshold of 0.8, an intensity of
+// one, and a blur of radius eight at half resolution.
+func NewBloom() *Bloom {
+	blur := NewBlur(8)
+	blur.Scale = 0.5
+	return &Bloom{
+		Threshold: 0.8,
+		Intensity: 1,
+		Blur:      blur,
+		bright:    newShader("BloomBright", glslBright),
+		composite: newShader("Bloom", glslBloom),
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postfx
+
+import (
+	"azul3d.org/v1/gfx"
+	"fmt"
+	"image"
+	"math"
+)
+
+// MaxBlurRadius is the maximum radius, in texels, of a gaussian blur.
+const MaxBlurRadius = 16
+
+var glslBlur = fmt.Sprintf(`
+#version 120
+
+varying vec2 tc0;
+
+uniform sampler2D Texture0;
+uniform vec3 Step;
+uniform float Radius;
+uniform float Weights[%d];
+
+void main()
+{
+	vec4 sum = texture2D(Texture0, tc0) * Weights[0];
+	for(int i = 1; i <= %d; i++) {
+		if(float(i) > Radius) {
+			break;
+		}
+		vec2 o = Step.xy * float(i);
+		sum += (texture2D(Texture0, tc0 + o) + texture2D(Texture0, tc0 - o)) * Weights[i];
+	}
+	gl_FragColor = sum;
+}
+`, MaxBlurRadius+1, MaxBlurRadius)
+
+// Blur is a separable gaussian blur effect, it blurs horizontally and then
+// vertically in two passes.
+type Blur struct {
+	// The radius of the blur in texels of the source image, clamped to
+	// MaxBlurRadius.
+	Radius int
+
+	// The standard deviation of the gaussian, or zero for one third of the
+	// radius.
+	Sigma float64
+
+	// The resolution at which the blur is performed, relative to the
+	// renderer's bounds (see Pass.Scale).
+	Scale float64
+
+	horizontal, vertical *gfx.Shader
+}
+
+// weights returns the normalized weights of the center and each following
+// tap of the blur.
+func (b *Blur) weights() []float64 {
+	r := b.radius()
+	sigma := b.Sigma
+	if sigma == 0 {
+		sigma = math.Max(float64(r)/3, 0.5)
+	}
+	w := make([]float64, r+1)
+	sum := 0.0
+	for i := range w {
+		w[i] = math.Exp(-float64(i*i) / (2 * sigma * sigma))
+		if i == 0 {
+			sum += w[i]
+		} else {
+			sum += 2 * w[i]
+		}
+	}
+	for i := range w {
+		w[i] /= sum
+	}
+	return w
+}
+
+func (b *Blur) radius() int {
+	if b.Radius < 0 {
+		return 0
+	}
+	if b.Radius > MaxBlurRadius {
+		return MaxBlurRadius
+	}
+	return b.Radius
+}
+
+// update returns the shader update function for the given direction.
+func (b *Blur) update(dx, dy float64) func(s *gfx.Shader, src image.Rectangle) {
+	return func(s *gfx.Shader, src image.Rectangle) {
+		w := b.weights()
+		w32 := make([]float32, len(w))
+		for i, v := range w {
+			w32[i] = float32(v)
+		}
+		s.Inputs["Step"] = gfx.Vec3{
+			X: float32(dx / float64(src.Dx())),
+			Y: float32(dy / float64(src.Dy())),
+		}
+		s.Inputs["Radius"] = float32(len(w) - 1)
+		s.Inputs["Weights"] = w32
+	}
+}
+
+// Passes implements the Effect interface.
+func (b *Blur) Passes() []*Pass {
+	return []*Pass{
+		{
+			Name:    "BlurHorizontal",
+			Shader:  b.horizontal,
+			Sources: []Source{Previous},
+			Scale:   b.Scale,
+			Update:  b.update(1, 0),
+		},
+		{
+			Name:    "BlurVertical",
+			Shader:  b.vertical,
+			Sources: []Source{Previous},
+			Scale:   b.Scale,
+			Update:  b.update(0, 1),
+		},
+	}
+}
+
+// blur applies one direction of the blur to the image, rendering into a w by
+// h image.
+func (b *Blur) blur(in *fimage, w, h int, dx, dy float64) *fimage {
+	weights := b.weights()
+	sx, sy := dx/float64(in.w), dy/float64(in.h)
+	return render(w, h, func(u, v float64) vec4 {
+		sum := in.sample(u, v).mul(weights[0])
+		for i := 1; i < len(weights); i++ {
+			ox, oy := sx*float64(i), sy*float64(i)
+			c := in.sample(u+ox, v+oy).add(in.sample(u-ox, v-oy))
+			sum = sum.add(c.mul(weights[i]))
+		}
+		return sum
+	})
+}
+
+// apply applies both passes of the blur, the output is w by h.
+func (b *Blur) apply(in *fimage, w, h int) *fimage {
+	return b.blur(b.blur(in, w, h, 1, 0), w, h, 0, 1)
+}
+
+// Apply implements the Effect interface. The source image's bounds are used as
+// the renderer's bounds for scaling purposes, and the result is at the blur's
+// scale.
+func (b *Blur) Apply(src *image.RGBA) *image.RGBA {
+	in := newFImage(src)
+	w, h := scaled(in.w, in.h, b.Scale)
+	return b.apply(in, w, h).rgba()
+}
+
+// NewBlur returns a new gaussian blur effect of the given radius.
+func NewBlur(radius int) *Blur {
+	return &Blur{
+		Radius:     radius,
+		horizontal: newShader("BlurHorizontal", glslBlur),
+		vertical:   newShader("BlurVertical", glslBlur),
+	}
+}
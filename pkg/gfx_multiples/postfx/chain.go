@@ -0,0 +1,223 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postfx
+
+import (
+	"azul3d.org/v1/gfx"
+	"azul3d.org/v1/gfx/rendergraph"
+	"fmt"
+	"image"
+)
+
+// Source identifies a texture that a pass reads from.
+type Source uint8
+
+const (
+	// Previous is the output of the previous pass of the effect, or the input
+	// of the effect for it's first pass.
+	Previous Source = iota
+
+	// Input is the input of the effect (i.e. the output of the previous
+	// effect in the chain).
+	Input
+)
+
+// Pass is a single full-screen pass of an effect.
+type Pass struct {
+	// The name of the pass, used for the shader and render graph pass.
+	Name string
+
+	// The shader used to draw the full-screen quad.
+	Shader *gfx.Shader
+
+	// The sources the pass reads, bound in order to the Texture0, Texture1,
+	// etc uniforms of the shader.
+	Sources []Source
+
+	// Additional textures (e.g. lookup tables) bound after the sources.
+	Textures []*gfx.Texture
+
+	// The resolution of the pass's output relative to the renderer's bounds
+	// (e.g. 0.5 for half-resolution). A value of zero is treated as one. The
+	// last pass of the last effect in a chain always renders at the
+	// resolution of the chain's output.
+	Scale float64
+
+	// Update, if not nil, is called before each draw of the pass to update
+	// the shader's inputs. It is given the bounds of the pass's first source
+	// texture. The shader's write lock is held while it is called.
+	Update func(s *gfx.Shader, src image.Rectangle)
+}
+
+// Effect is a post-processing effect.
+type Effect interface {
+	// Passes returns the passes of the effect, which are executed in order.
+	// It is called once when the effect is added to a chain, the parameters
+	// of the effect may still be changed afterwards as they are read by the
+	// passes's Update functions.
+	Passes() []*Pass
+
+	// Apply applies the effect to the given image on the CPU, and returns the
+	// result. This is the reference implementation of the effect, which
+	// produces the same result as rendering with the same parameters (within
+	// rounding and sampling precision).
+	Apply(src *image.RGBA) *image.RGBA
+}
+
+// TextureDesc returns the description of a texture suitable for use as the
+// input of a chain, at the given scale of the renderer's bounds. The texture
+// is stored as RGBA with linear filtering and clamped texture coordinates.
+func TextureDesc(scale float64) rendergraph.TextureDesc {
+	return rendergraph.TextureDesc{
+		Scale:     scale,
+		Format:    gfx.RGBA,
+		WrapU:     gfx.Clamp,
+		WrapV:     gfx.Clamp,
+		MinFilter: gfx.Linear,
+		MagFilter: gfx.Linear,
+	}
+}
+
+// copyEffect is a single pass copying it's input, used by empty chains.
+type copyEffect struct{}
+
+const glslCopy = `
+#version 120
+
+varying vec2 tc0;
+
+uniform sampler2D Texture0;
+
+void main()
+{
+	gl_FragColor = texture2D(Texture0, tc0);
+}
+`
+
+func (copyEffect) Passes() []*Pass {
+	return []*Pass{{
+		Name:    "Copy",
+		Shader:  newShader("Copy", glslCopy),
+		Sources: []Source{Previous},
+	}}
+}
+
+func (copyEffect) Apply(src *image.RGBA) *image.RGBA {
+	return newFImage(src).rgba()
+}
+
+// Chain is a sequence of effects, applied in order.
+type Chain struct {
+	// The effects of the chain. They must not be changed once the chain has
+	// been added to a graph.
+	Effects []Effect
+
+	// The full-screen quad mesh drawn by each pass, and the camera it is
+	// drawn with (whose transform and projection are the identity, as the
+	// quad's vertices are already in device coordinates).
+	quad *gfx.Mesh
+	cam  *gfx.Camera
+}
+
+// AddTo adds the passes of each effect in the chain to the given render
+// graph. The passes read from the in resource and the last one writes to the
+// out resource, or to the renderer if out is nil.
+//
+// If the chain has no effects, a single pass copying in to out is added.
+func (c *Chain) AddTo(g *rendergraph.Graph, in, out *rendergraph.Resource) {
+	effects := c.Effects
+	if len(effects) == 0 {
+		effects = []Effect{copyEffect{}}
+	}
+
+	var passes [][]*Pass
+	n := 0
+	for _, e := range effects {
+		p := e.Passes()
+		passes = append(passes, p)
+		n += len(p)
+	}
+
+	effectIn := in
+	for ei, ep := range passes {
+		prev := effectIn
+		for pi, p := range ep {
+			n--
+			var dst *rendergraph.Resource
+			if n == 0 {
+				dst = out
+			} else {
+				name := fmt.Sprintf("postfx %d.%d %s", ei, pi, p.Name)
+				dst = g.Transient(name, TextureDesc(p.Scale))
+			}
+			var srcs []*rendergraph.Resource
+			for _, s := range p.Sources {
+				if s == Input {
+					srcs = append(srcs, effectIn)
+				} else {
+					srcs = append(srcs, prev)
+				}
+			}
+			g.AddPass(&rendergraph.Pass{
+				Name:   "postfx " + p.Name,
+				Inputs: srcs,
+				Output: dst,
+				Run:    c.run(p, srcs),
+			})
+			prev = dst
+		}
+		effectIn = prev
+	}
+}
+
+// run returns the render graph function drawing the given pass.
+func (c *Chain) run(p *Pass, srcs []*rendergraph.Resource) func(gfx.Canvas) {
+	o := NewObject(c.quad, p.Shader)
+	return func(canvas gfx.Canvas) {
+		textures := make([]*gfx.Texture, 0, len(srcs)+len(p.Textures))
+		for _, s := range srcs {
+			textures = append(textures, s.Texture())
+		}
+		textures = append(textures, p.Textures...)
+
+		if p.Update != nil {
+			var b image.Rectangle
+			if len(textures) > 0 {
+				textures[0].RLock()
+				b = textures[0].Bounds
+				textures[0].RUnlock()
+			}
+			if b.Empty() {
+				b = canvas.Bounds()
+			}
+			p.Shader.Lock()
+			p.Update(p.Shader, b)
+			p.Shader.Unlock()
+		}
+
+		o.Lock()
+		o.Textures[0] = textures
+		o.Unlock()
+		canvas.Draw(image.Rectangle{}, o, c.cam)
+	}
+}
+
+// Apply applies each effect of the chain to the given image on the CPU, in
+// order, and returns the result (see Effect.Apply).
+func (c *Chain) Apply(src *image.RGBA) *image.RGBA {
+	for _, e := range c.Effects {
+		src = e.Apply(src)
+	}
+	return src
+}
+
+// NewChain returns a new chain of the given effects.
+func NewChain(effects ...Effect) *Chain {
+	return &Chain{
+		Effects: effects,
+		quad:    NewQuad(),
+		cam:     gfx.NewCamera(),
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package postfx implements full-screen post-processing effects.
+//
+// An effect is made up of one or more passes, each of which draws a
+// full-screen quad (see NewQuad) with a shader that samples the output of
+// earlier passes. Effects are applied in sequence by a Chain, which adds the
+// passes to a render graph (see the rendergraph package) such that all
+// intermediate textures are allocated and reused by the graph:
+//
+//  g := rendergraph.New()
+//  scene := g.Transient("scene", postfx.TextureDesc(1))
+//  g.AddPass(&rendergraph.Pass{
+//      Name:   "scene",
+//      Output: scene,
+//      Clear:  rendergraph.ClearColor | rendergraph.ClearDepth,
+//      Depth:  1,
+//      Run:    drawScene,
+//  })
+//  chain := postfx.NewChain(
+//      postfx.NewBloom(),
+//      postfx.NewToneMap(),
+//      postfx.NewFXAA(),
+//  )
+//  chain.AddTo(g, scene, nil) // Output to the renderer.
+//
+// The built-in effects are:
+//  ToneMap - exposure, tone mapping operator and gamma correction.
+//  FXAA    - fast approximate anti-aliasing.
+//  Blur    - separable gaussian blur.
+//  Bloom   - bright-pass, blur and additive composite.
+//  LUT     - color grading via a 3D lookup table.
+//
+// Each effect also has a CPU reference implementation (see Effect.Apply), which
+// performs the same operations as the shaders (including bilinear texture
+// sampling and the 8-bit storage of intermediate textures). Golden tests may
+// thus compare the image downloaded from a renderer against the reference.
+package postfx
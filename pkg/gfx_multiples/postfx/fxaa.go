@@ -0,0 +1,148 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postfx
+
+import (
+	"azul3d.org/v1/gfx"
+	"image"
+	"math"
+)
+
+const glslFXAA = `
+#version 120
+
+varying vec2 tc0;
+
+uniform sampler2D Texture0;
+uniform vec3 TexelSize;
+uniform float SpanMax;
+uniform float ReduceMul;
+uniform float ReduceMin;
+
+void main()
+{
+	vec2 texel = TexelSize.xy;
+	vec3 luma = vec3(0.299, 0.587, 0.114);
+	float lumaNW = dot(texture2D(Texture0, tc0 + vec2(-1.0, -1.0) * texel).rgb, luma);
+	float lumaNE = dot(texture2D(Texture0, tc0 + vec2(1.0, -1.0) * texel).rgb, luma);
+	float lumaSW = dot(texture2D(Texture0, tc0 + vec2(-1.0, 1.0) * texel).rgb, luma);
+	float lumaSE = dot(texture2D(Texture0, tc0 + vec2(1.0, 1.0) * texel).rgb, luma);
+	vec4 center = texture2D(Texture0, tc0);
+	float lumaM = dot(center.rgb, luma);
+
+	float lumaMin = min(lumaM, min(min(lumaNW, lumaNE), min(lumaSW, lumaSE)));
+	float lumaMax = max(lumaM, max(max(lumaNW, lumaNE), max(lumaSW, lumaSE)));
+
+	vec2 dir;
+	dir.x = -((lumaNW + lumaNE) - (lumaSW + lumaSE));
+	dir.y = ((lumaNW + lumaSW) - (lumaNE + lumaSE));
+
+	float dirReduce = max((lumaNW + lumaNE + lumaSW + lumaSE) * (0.25 * ReduceMul), ReduceMin);
+	float rcpDirMin = 1.0 / (min(abs(dir.x), abs(dir.y)) + dirReduce);
+	dir = clamp(dir * rcpDirMin, -SpanMax, SpanMax) * texel;
+
+	vec3 rgbA = 0.5 * (
+		texture2D(Texture0, tc0 + dir * (1.0 / 3.0 - 0.5)).rgb +
+		texture2D(Texture0, tc0 + dir * (2.0 / 3.0 - 0.5)).rgb);
+	vec3 rgbB = rgbA * 0.5 + 0.25 * (
+		texture2D(Texture0, tc0 + dir * -0.5).rgb +
+		texture2D(Texture0, tc0 + dir * 0.5).rgb);
+
+	float lumaB = dot(rgbB, luma);
+	if(lumaB < lumaMin || lumaB > lumaMax) {
+		gl_FragColor = vec4(rgbA, center.a);
+	} else {
+		gl_FragColor = vec4(rgbB, center.a);
+	}
+}
+`
+
+// FXAA is a fast approximate anti-aliasing effect, it blurs along the edges
+// found in the image based on it's luminance. It should be applied after tone
+// mapping.
+type FXAA struct {
+	// The maximum length, in texels, of the blur along edges.
+	SpanMax float64
+
+	// Parameters reducing the blur in dark areas of the image, where the
+	// reduction is:
+	//  max(lumaAverage * ReduceMul, ReduceMin)
+	ReduceMul, ReduceMin float64
+
+	shader *gfx.Shader
+}
+
+// Passes implements the Effect interface.
+func (f *FXAA) Passes() []*Pass {
+	return []*Pass{{
+		Name:    "FXAA",
+		Shader:  f.shader,
+		Sources: []Source{Previous},
+		Update: func(s *gfx.Shader, src image.Rectangle) {
+			s.Inputs["TexelSize"] = gfx.Vec3{
+				X: 1 / float32(src.Dx()),
+				Y: 1 / float32(src.Dy()),
+			}
+			s.Inputs["SpanMax"] = float32(f.SpanMax)
+			s.Inputs["ReduceMul"] = float32(f.ReduceMul)
+			s.Inputs["ReduceMin"] = float32(f.ReduceMin)
+		},
+	}}
+}
+
+// Apply implements the Effect interface.
+func (f *FXAA) Apply(src *image.RGBA) *image.RGBA {
+	in := newFImage(src)
+	tx, ty := 1/float64(in.w), 1/float64(in.h)
+	luma := func(c vec4) float64 {
+		return 0.299*c[0] + 0.587*c[1] + 0.114*c[2]
+	}
+	clamp := func(v float64) float64 {
+		return math.Min(math.Max(v, -f.SpanMax), f.SpanMax)
+	}
+	return render(in.w, in.h, func(u, v float64) vec4 {
+		lumaNW := luma(in.sample(u-tx, v-ty))
+		lumaNE := luma(in.sample(u+tx, v-ty))
+		lumaSW := luma(in.sample(u-tx, v+ty))
+		lumaSE := luma(in.sample(u+tx, v+ty))
+		center := in.sample(u, v)
+		lumaM := luma(center)
+
+		lumaMin := math.Min(lumaM, math.Min(math.Min(lumaNW, lumaNE), math.Min(lumaSW, lumaSE)))
+		lumaMax := math.Max(lumaM, math.Max(math.Max(lumaNW, lumaNE), math.Max(lumaSW, lumaSE)))
+
+		dx := -((lumaNW + lumaNE) - (lumaSW + lumaSE))
+		dy := (lumaNW + lumaSW) - (lumaNE + lumaSE)
+
+		dirReduce := math.Max((lumaNW+lumaNE+lumaSW+lumaSE)*(0.25*f.ReduceMul), f.ReduceMin)
+		rcpDirMin := 1 / (math.Min(math.Abs(dx), math.Abs(dy)) + dirReduce)
+		dx = clamp(dx*rcpDirMin) * tx
+		dy = clamp(dy*rcpDirMin) * ty
+
+		at := func(t float64) vec4 {
+			return in.sample(u+dx*t, v+dy*t)
+		}
+		rgbA := at(1.0/3 - 0.5).add(at(2.0/3 - 0.5)).mul(0.5)
+		rgbB := rgbA.mul(0.5).add(at(-0.5).add(at(0.5)).mul(0.25))
+
+		c := rgbB
+		if lumaB := luma(rgbB); lumaB < lumaMin || lumaB > lumaMax {
+			c = rgbA
+		}
+		c[3] = center[3]
+		return c
+	}).rgba()
+}
+
+// NewFXAA returns a new FXAA effect with a span of eight texels, and the
+// reduction parameters 1/8 and 1/128.
+func NewFXAA() *FXAA {
+	return &FXAA{
+		SpanMax:   8,
+		ReduceMul: 1.0 / 8,
+		ReduceMin: 1.0 / 128,
+		shader:    newShader("FXAA", glslFXAA),
+	}
+}
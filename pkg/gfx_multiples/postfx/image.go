@@ -0,0 +1,156 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postfx
+
+import (
+	"image"
+	"math"
+)
+
+// vec4 is a RGBA color, used by the CPU reference implementations in place
+// of the shader's vec4 type.
+type vec4 [4]float64
+
+func (a vec4) add(b vec4) vec4 {
+	return vec4{a[0] + b[0], a[1] + b[1], a[2] + b[2], a[3] + b[3]}
+}
+
+func (a vec4) mul(s float64) vec4 {
+	return vec4{a[0] * s, a[1] * s, a[2] * s, a[3] * s}
+}
+
+func (a vec4) mix(b vec4, t float64) vec4 {
+	return a.add(b.add(a.mul(-1)).mul(t))
+}
+
+// luma returns the Rec. 709 luminance of the color.
+func (a vec4) luma() float64 {
+	return 0.2126*a[0] + 0.7152*a[1] + 0.0722*a[2]
+}
+
+// fimage is a floating-point RGBA image. It stands in for a texture in the CPU
+// reference implementations: it is sampled with clamped texture coordinates
+// and bilinear filtering, just like the textures the shaders sample.
+//
+// Unlike textures, the texture coordinate (0, 0) is the top-left corner of an
+// fimage. All effects are symmetric in the vertical axis so the results are
+// identical.
+type fimage struct {
+	w, h int
+	pix  []vec4
+}
+
+// at returns the pixel at the given coordinates, clamped to the image.
+func (f *fimage) at(x, y int) vec4 {
+	if x < 0 {
+		x = 0
+	} else if x >= f.w {
+		x = f.w - 1
+	}
+	if y < 0 {
+		y = 0
+	} else if y >= f.h {
+		y = f.h - 1
+	}
+	return f.pix[y*f.w+x]
+}
+
+// sample samples the image at the texture coordinates (u, v) with bilinear
+// filtering.
+func (f *fimage) sample(u, v float64) vec4 {
+	x := u*float64(f.w) - 0.5
+	y := v*float64(f.h) - 0.5
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+	ix, iy := int(x0), int(y0)
+	top := f.at(ix, iy).mix(f.at(ix+1, iy), fx)
+	bottom := f.at(ix, iy+1).mix(f.at(ix+1, iy+1), fx)
+	return top.mix(bottom, fy)
+}
+
+// render returns a new w by h image whose pixels are the result of the given
+// fragment function, called with the texture coordinates of each pixel's
+// center. The result is stored with 8-bit precision, like a RGBA texture.
+func render(w, h int, frag func(u, v float64) vec4) *fimage {
+	f := &fimage{
+		w:   w,
+		h:   h,
+		pix: make([]vec4, w*h),
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			u := (float64(x) + 0.5) / float64(w)
+			v := (float64(y) + 0.5) / float64(h)
+			c := frag(u, v)
+			for i := range c {
+				c[i] = float64(quantize(c[i])) / 255
+			}
+			f.pix[y*w+x] = c
+		}
+	}
+	return f
+}
+
+// quantize clamps the value to the range [0, 1] and converts it to 8-bit.
+func quantize(v float64) uint8 {
+	if v <= 0 || v != v {
+		return 0
+	}
+	if v >= 1 {
+		return 255
+	}
+	return uint8(v*255 + 0.5)
+}
+
+// scaled returns the size of an image scaled relative to a w by h one, in the
+// same way that render graph textures are.
+func scaled(w, h int, scale float64) (int, int) {
+	if scale == 0 {
+		scale = 1
+	}
+	sw := int(float64(w)*scale + 0.5)
+	sh := int(float64(h)*scale + 0.5)
+	if sw < 1 {
+		sw = 1
+	}
+	if sh < 1 {
+		sh = 1
+	}
+	return sw, sh
+}
+
+// newFImage converts the given image.
+func newFImage(src *image.RGBA) *fimage {
+	b := src.Bounds()
+	f := &fimage{
+		w:   b.Dx(),
+		h:   b.Dy(),
+		pix: make([]vec4, b.Dx()*b.Dy()),
+	}
+	for y := 0; y < f.h; y++ {
+		for x := 0; x < f.w; x++ {
+			i := src.PixOffset(b.Min.X+x, b.Min.Y+y)
+			f.pix[y*f.w+x] = vec4{
+				float64(src.Pix[i]) / 255,
+				float64(src.Pix[i+1]) / 255,
+				float64(src.Pix[i+2]) / 255,
+				float64(src.Pix[i+3]) / 255,
+			}
+		}
+	}
+	return f
+}
+
+// rgba converts the image to a RGBA one.
+func (f *fimage) rgba() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, f.w, f.h))
+	for i, c := range f.pix {
+		img.Pix[i*4] = quantize(c[0])
+		img.Pix[i*4+1] = quantize(c[1])
+		img.Pix[i*4+2] = quantize(c[2])
+		img.Pix[i*4+3] = quantize(c[3])
+	}
+	return img
+}
@@ -0,0 +1,153 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postfx
+
+import (
+	"azul3d.org/v1/gfx"
+	"image"
+	"math"
+)
+
+const glslLUT = `
+#version 120
+
+varying vec2 tc0;
+
+uniform sampler2D Texture0;
+uniform sampler2D Texture1;
+uniform float Size;
+uniform float Mix;
+
+vec3 lookup(vec2 rg, float b)
+{
+	return texture2D(Texture1, vec2(rg.x + b / Size, rg.y)).rgb;
+}
+
+void main()
+{
+	vec4 c = texture2D(Texture0, tc0);
+	vec3 x = clamp(c.rgb, 0.0, 1.0) * (Size - 1.0);
+	vec2 rg = (x.rg + 0.5) / vec2(Size * Size, Size);
+	float b0 = floor(x.b);
+	float b1 = min(b0 + 1.0, Size - 1.0);
+	vec3 graded = mix(lookup(rg, b0), lookup(rg, b1), x.b - b0);
+	gl_FragColor = vec4(mix(c.rgb, graded, Mix), c.a);
+}
+`
+
+// NewLUTImage returns a new 3D lookup table image of the given size (i.e. the
+// number of entries along each of the red, green and blue axis), whose
+// entries are the result of the given function.
+//
+// The table is stored as a horizontal strip of size slices, one per blue
+// value, each of which is size by size pixels with red increasing to the
+// right and green increasing downwards. A common size is 16 (i.e. a 256x16
+// image).
+func NewLUTImage(size int, f func(r, g, b float64) (float64, float64, float64)) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size*size, size))
+	max := float64(size - 1)
+	for b := 0; b < size; b++ {
+		for g := 0; g < size; g++ {
+			for r := 0; r < size; r++ {
+				cr, cg, cb := f(float64(r)/max, float64(g)/max, float64(b)/max)
+				i := img.PixOffset(b*size+r, g)
+				img.Pix[i] = quantize(cr)
+				img.Pix[i+1] = quantize(cg)
+				img.Pix[i+2] = quantize(cb)
+				img.Pix[i+3] = 255
+			}
+		}
+	}
+	return img
+}
+
+// IdentityLUT returns a new 3D lookup table image of the given size which
+// maps each color onto itself (see NewLUTImage). It is the usual starting
+// point for color grading in an image editor.
+func IdentityLUT(size int) *image.RGBA {
+	return NewLUTImage(size, func(r, g, b float64) (float64, float64, float64) {
+		return r, g, b
+	})
+}
+
+// LUT is a color grading effect, which maps colors through a 3D lookup table
+// (see NewLUTImage) with trilinear interpolation.
+type LUT struct {
+	// The amount of grading, where zero leaves the image as-is and one fully
+	// applies the lookup table.
+	Mix float64
+
+	table   *image.RGBA
+	texture *gfx.Texture
+	shader  *gfx.Shader
+}
+
+// Table returns the lookup table image of this effect.
+func (l *LUT) Table() *image.RGBA {
+	return l.table
+}
+
+// size returns the number of entries along each axis of the table.
+func (l *LUT) size() int {
+	return l.table.Bounds().Dy()
+}
+
+// Passes implements the Effect interface.
+func (l *LUT) Passes() []*Pass {
+	return []*Pass{{
+		Name:     "LUT",
+		Shader:   l.shader,
+		Sources:  []Source{Previous},
+		Textures: []*gfx.Texture{l.texture},
+		Update: func(s *gfx.Shader, src image.Rectangle) {
+			s.Inputs["Size"] = float32(l.size())
+			s.Inputs["Mix"] = float32(l.Mix)
+		},
+	}}
+}
+
+// Apply implements the Effect interface.
+func (l *LUT) Apply(src *image.RGBA) *image.RGBA {
+	in := newFImage(src)
+	table := newFImage(l.table)
+	size := float64(l.size())
+	lookup := func(r, g, b float64) vec4 {
+		return table.sample((r+0.5)/(size*size)+b/size, (g+0.5)/size)
+	}
+	return render(in.w, in.h, func(u, v float64) vec4 {
+		c := in.sample(u, v)
+		var x [3]float64
+		for i := range x {
+			x[i] = math.Min(math.Max(c[i], 0), 1) * (size - 1)
+		}
+		b0 := math.Floor(x[2])
+		b1 := math.Min(b0+1, size-1)
+		graded := lookup(x[0], x[1], b0).mix(lookup(x[0], x[1], b1), x[2]-b0)
+		a := c[3]
+		c = c.mix(graded, l.Mix)
+		c[3] = a
+		return c
+	}).rgba()
+}
+
+// NewLUT returns a new color grading effect using the given lookup table image
+// (see NewLUTImage), which is fully applied.
+func NewLUT(table *image.RGBA) *LUT {
+	return &LUT{
+		Mix:   1,
+		table: table,
+		texture: &gfx.Texture{
+			Bounds:         table.Bounds(),
+			Source:         table,
+			KeepDataOnLoad: true,
+			Format:         gfx.RGBA,
+			WrapU:          gfx.Clamp,
+			WrapV:          gfx.Clamp,
+			MinFilter:      gfx.Linear,
+			MagFilter:      gfx.Linear,
+		},
+		shader: newShader("LUT", glslLUT),
+	}
+}
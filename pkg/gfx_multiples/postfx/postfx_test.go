@@ -0,0 +1,330 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postfx
+
+import (
+	"azul3d.org/v1/gfx"
+	"azul3d.org/v1/gfx/glsl"
+	"azul3d.org/v1/gfx/rendergraph"
+	"fmt"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func testEffects() []Effect {
+	return []Effect{
+		NewToneMap(),
+		NewFXAA(),
+		NewBlur(4),
+		NewBloom(),
+		NewLUT(IdentityLUT(16)),
+	}
+}
+
+func filled(w, h int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i] = c.R
+		img.Pix[i+1] = c.G
+		img.Pix[i+2] = c.B
+		img.Pix[i+3] = c.A
+	}
+	return img
+}
+
+// gradient returns an image with each channel varying over the image.
+func gradient(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, color.RGBA{
+				uint8(x * 255 / (w - 1)),
+				uint8(y * 255 / (h - 1)),
+				uint8((x + y) * 255 / (w + h - 2)),
+				255,
+			})
+		}
+	}
+	return img
+}
+
+// maxDiff returns the maximum difference between the channels of two equally
+// sized images.
+func maxDiff(a, b *image.RGBA) int {
+	max := 0
+	for i := range a.Pix {
+		d := int(a.Pix[i]) - int(b.Pix[i])
+		if d < 0 {
+			d = -d
+		}
+		if d > max {
+			max = d
+		}
+	}
+	return max
+}
+
+func TestShaderInputs(t *testing.T) {
+	for _, e := range append(testEffects(), copyEffect{}) {
+		for _, p := range e.Passes() {
+			r, err := glsl.Reflect(p.Shader.GLSLVert, p.Shader.GLSLFrag)
+			if err != nil {
+				t.Fatalf("%s: %v", p.Name, err)
+			}
+			if p.Update != nil {
+				p.Update(p.Shader, image.Rect(0, 0, 64, 32))
+			}
+			textures := len(p.Sources) + len(p.Textures)
+			err = r.Validate(p.Shader.Inputs, func(name string) bool {
+				for i := 0; i < textures; i++ {
+					if name == fmt.Sprintf("Texture%d", i) {
+						return true
+					}
+				}
+				return false
+			})
+			if err != nil {
+				t.Errorf("%s: %v", p.Name, err)
+			}
+			if n := len(r.Attributes); n != 2 {
+				t.Errorf("%s: got %d attributes, want 2", p.Name, n)
+			}
+		}
+	}
+}
+
+func TestChainGraph(t *testing.T) {
+	g := rendergraph.New()
+	in := g.Import("in", &gfx.Texture{Bounds: image.Rect(0, 0, 640, 480)})
+	chain := NewChain(testEffects()...)
+	chain.AddTo(g, in, nil)
+
+	passes := 0
+	for _, e := range chain.Effects {
+		passes += len(e.Passes())
+	}
+	order, err := g.Order()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != passes {
+		t.Fatalf("got %d passes, want %d", len(order), passes)
+	}
+	for frame := 0; frame < 2; frame++ {
+		if err := g.Execute(gfx.Nil()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Passes alternate between two full-resolution textures, and the bloom
+	// needs two half-resolution textures.
+	if n := g.Textures(); n != 4 {
+		t.Fatalf("got %d pooled textures, want 4", n)
+	}
+
+	// An empty chain copies.
+	g = rendergraph.New()
+	in = g.Import("in", &gfx.Texture{Bounds: image.Rect(0, 0, 640, 480)})
+	NewChain().AddTo(g, in, nil)
+	if order, _ := g.Order(); len(order) != 1 {
+		t.Fatalf("got %d passes for empty chain, want 1", len(order))
+	}
+}
+
+// camRenderer is a renderer whose canvases read the camera of each object
+// drawn, as a real renderer does.
+type camRenderer struct {
+	gfx.Renderer
+	draws *int
+}
+
+func (r camRenderer) Draw(rect image.Rectangle, o *gfx.Object, c *gfx.Camera) {
+	c.RLock()
+	_ = c.Object.Transform.Mat4()
+	_ = c.Projection
+	c.RUnlock()
+	*r.draws++
+	r.Renderer.Draw(rect, o, c)
+}
+
+func (r camRenderer) RenderToTexture(t *gfx.Texture) gfx.Canvas {
+	return camRenderer{r.Renderer.RenderToTexture(t).(gfx.Renderer), r.draws}
+}
+
+func TestChainCamera(t *testing.T) {
+	g := rendergraph.New()
+	in := g.Import("in", &gfx.Texture{Bounds: image.Rect(0, 0, 64, 32)})
+	chain := NewChain(NewToneMap(), NewFXAA())
+	chain.AddTo(g, in, nil)
+
+	var draws int
+	if err := g.Execute(camRenderer{gfx.Nil(), &draws}); err != nil {
+		t.Fatal(err)
+	}
+	if draws != 2 {
+		t.Fatalf("got %d draws, want 2", draws)
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	src := gradient(32, 24)
+
+	tm := NewToneMap()
+	tm.Operator = Clamp
+	tm.Gamma = 0
+	blur := NewBlur(0)
+	bloom := NewBloom()
+	bloom.Threshold = 1
+	for _, e := range []Effect{tm, blur, bloom, NewLUT(IdentityLUT(16)), copyEffect{}} {
+		if d := maxDiff(src, e.Apply(src)); d > 1 {
+			t.Errorf("%T: differs by %d", e, d)
+		}
+	}
+}
+
+func TestToneMap(t *testing.T) {
+	tm := NewToneMap()
+	tm.Operator = Reinhard
+	tm.Gamma = 0
+	out := tm.Apply(filled(2, 2, color.RGBA{255, 128, 0, 200}))
+	want := []uint8{128, 85, 0, 200}
+	for i, v := range want {
+		if d := int(out.Pix[i]) - int(v); d < -1 || d > 1 {
+			t.Fatalf("got %v want %v", out.Pix[:4], want)
+		}
+	}
+
+	// Filmic is monotonic.
+	tm.Operator = Filmic
+	tm.Gamma = 2.2
+	last := -1
+	for v := 0; v < 256; v += 5 {
+		out := tm.Apply(filled(1, 1, color.RGBA{uint8(v), 0, 0, 255}))
+		if int(out.Pix[0]) < last {
+			t.Fatalf("filmic is not monotonic at %d", v)
+		}
+		last = int(out.Pix[0])
+	}
+}
+
+func TestBlur(t *testing.T) {
+	// A constant image is unchanged.
+	gray := filled(16, 16, color.RGBA{100, 100, 100, 255})
+	if d := maxDiff(gray, NewBlur(6).Apply(gray)); d > 1 {
+		t.Fatalf("constant image differs by %d", d)
+	}
+
+	// A dot spreads out symmetrically.
+	dot := filled(17, 17, color.RGBA{0, 0, 0, 255})
+	dot.SetRGBA(8, 8, color.RGBA{255, 255, 255, 255})
+	out := NewBlur(3).Apply(dot)
+	c := out.RGBAAt(8, 8).R
+	if c == 0 || c == 255 {
+		t.Fatalf("center value %d", c)
+	}
+	for _, o := range [][2]int{{1, 0}, {0, 1}, {1, 1}} {
+		a := out.RGBAAt(8+o[0], 8+o[1]).R
+		b := out.RGBAAt(8-o[0], 8-o[1]).R
+		if a != b || a > c || a == 0 {
+			t.Fatalf("offset %v: got %d and %d (center %d)", o, a, b, c)
+		}
+	}
+	if v := out.RGBAAt(0, 0).R; v != 0 {
+		t.Fatalf("corner value %d", v)
+	}
+
+	// Weights are normalized.
+	b := NewBlur(MaxBlurRadius * 2)
+	w := b.weights()
+	if len(w) != MaxBlurRadius+1 {
+		t.Fatalf("got %d weights", len(w))
+	}
+	sum := w[0]
+	for _, v := range w[1:] {
+		sum += 2 * v
+	}
+	if sum < 0.999999 || sum > 1.000001 {
+		t.Fatalf("weights sum to %v", sum)
+	}
+}
+
+func TestBloom(t *testing.T) {
+	src := filled(32, 32, color.RGBA{20, 20, 20, 255})
+	for y := 14; y < 18; y++ {
+		for x := 14; x < 18; x++ {
+			src.SetRGBA(x, y, color.RGBA{255, 255, 255, 255})
+		}
+	}
+	out := NewBloom().Apply(src)
+
+	// Light bleeds into the surroundings, but not far away.
+	if v := out.RGBAAt(12, 15).R; v <= 20 {
+		t.Fatalf("near value %d", v)
+	}
+	if v := out.RGBAAt(0, 0).R; v != 20 {
+		t.Fatalf("far value %d", v)
+	}
+	if v := out.RGBAAt(15, 15).R; v != 255 {
+		t.Fatalf("center value %d", v)
+	}
+}
+
+func TestFXAA(t *testing.T) {
+	// A flat image is unchanged.
+	gray := filled(16, 16, color.RGBA{100, 150, 200, 255})
+	if d := maxDiff(gray, NewFXAA().Apply(gray)); d > 1 {
+		t.Fatalf("flat image differs by %d", d)
+	}
+
+	// A hard diagonal edge is smoothed.
+	img := filled(16, 16, color.RGBA{0, 0, 0, 255})
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			if x > y/2+4 {
+				img.SetRGBA(x, y, color.RGBA{255, 255, 255, 255})
+			}
+		}
+	}
+	out := NewFXAA().Apply(img)
+	smoothed := 0
+	for i := 0; i < len(out.Pix); i += 4 {
+		if v := out.Pix[i]; v > 0 && v < 255 {
+			smoothed++
+		}
+	}
+	if smoothed == 0 {
+		t.Fatal("edge not smoothed")
+	}
+	if d := maxDiff(img, out); d > 192 {
+		t.Fatalf("differs by %d", d)
+	}
+}
+
+func TestLUT(t *testing.T) {
+	invert := NewLUTImage(8, func(r, g, b float64) (float64, float64, float64) {
+		return 1 - r, 1 - g, 1 - b
+	})
+	if b := invert.Bounds(); b != image.Rect(0, 0, 64, 8) {
+		t.Fatalf("got bounds %v", b)
+	}
+	src := gradient(16, 16)
+	out := NewLUT(invert).Apply(src)
+	for i := 0; i < len(src.Pix); i += 4 {
+		for c := 0; c < 3; c++ {
+			if d := int(out.Pix[i+c]) + int(src.Pix[i+c]) - 255; d < -2 || d > 2 {
+				t.Fatalf("pixel %d: got %v for %v", i/4, out.Pix[i:i+4], src.Pix[i:i+4])
+			}
+		}
+	}
+
+	// Half mixed is half way.
+	lut := NewLUT(invert)
+	lut.Mix = 0.5
+	out = lut.Apply(filled(1, 1, color.RGBA{0, 0, 0, 255}))
+	if v := out.Pix[0]; v < 127 || v > 128 {
+		t.Fatalf("got %d", v)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postfx
+
+import (
+	"azul3d.org/v1/gfx"
+)
+
+// glslVert is the vertex shader shared by all passes, it passes the quad
+// through without any transformation.
+const glslVert = `
+#version 120
+
+attribute vec3 Vertex;
+attribute vec2 TexCoord0;
+
+varying vec2 tc0;
+
+void main()
+{
+	tc0 = TexCoord0;
+	gl_Position = vec4(Vertex, 1.0);
+}
+`
+
+// NewQuad returns a new mesh covering the entire screen: two triangles whose
+// vertices are already in normalized device coordinates, with texture
+// coordinates ranging from (0, 0) in the bottom-left to (1, 1) in the
+// top-right.
+func NewQuad() *gfx.Mesh {
+	m := new(gfx.Mesh)
+	m.Vertices = []gfx.Vec3{
+		{-1, -1, 0},
+		{1, -1, 0},
+		{1, 1, 0},
+
+		{-1, -1, 0},
+		{1, 1, 0},
+		{-1, 1, 0},
+	}
+	m.TexCoords = []gfx.TexCoordSet{
+		{
+			Slice: []gfx.TexCoord{
+				{0, 0},
+				{1, 0},
+				{1, 1},
+
+				{0, 0},
+				{1, 1},
+				{0, 1},
+			},
+		},
+	}
+	return m
+}
+
+// NewObject returns a new object that draws the given quad mesh (see NewQuad)
+// with the given shader and textures. Depth testing, depth writing and face
+// culling are disabled.
+func NewObject(quad *gfx.Mesh, s *gfx.Shader, textures ...*gfx.Texture) *gfx.Object {
+	o := gfx.NewObject()
+	o.State.DepthTest = false
+	o.State.DepthWrite = false
+	o.State.FaceCulling = gfx.NoFaceCulling
+	o.Shaders = []*gfx.Shader{s}
+	o.Meshes = []*gfx.Mesh{quad}
+	o.Textures = [][]*gfx.Texture{textures}
+	return o
+}
+
+// newShader returns a new shader with the given name and fragment shader
+// source, and the shared vertex shader.
+func newShader(name, frag string) *gfx.Shader {
+	s := gfx.NewShader("postfx " + name)
+	s.GLSLVert = []byte(glslVert)
+	s.GLSLFrag = []byte(frag)
+	return s
+}
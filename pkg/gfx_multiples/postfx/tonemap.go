@@ -0,0 +1,118 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package postfx
+
+import (
+	"azul3d.org/v1/gfx"
+	"image"
+	"math"
+)
+
+// Operator is a tone mapping operator, which maps high dynamic range colors
+// into the displayable [0, 1] range.
+type Operator uint8
+
+const (
+	// Reinhard is the simple Reinhard operator: c / (1 + c).
+	Reinhard Operator = iota
+
+	// Filmic is an approximation of the ACES filmic curve, with more contrast
+	// than Reinhard.
+	Filmic
+
+	// Clamp simply clamps colors to the [0, 1] range.
+	Clamp
+)
+
+const glslToneMap = `
+#version 120
+
+varying vec2 tc0;
+
+uniform sampler2D Texture0;
+uniform float Exposure;
+uniform float Operator;
+uniform float InvGamma;
+
+void main()
+{
+	vec4 c = texture2D(Texture0, tc0);
+	vec3 x = c.rgb * Exposure;
+	if(Operator < 0.5) {
+		x = x / (1.0 + x);
+	} else if(Operator < 1.5) {
+		x = (x * (2.51 * x + 0.03)) / (x * (2.43 * x + 0.59) + 0.14);
+	}
+	x = pow(clamp(x, 0.0, 1.0), vec3(InvGamma));
+	gl_FragColor = vec4(x, c.a);
+}
+`
+
+// ToneMap is an effect that applies exposure, a tone mapping operator and
+// gamma correction, in that order. The alpha channel is left as-is.
+type ToneMap struct {
+	// The exposure multiplied with colors before tone mapping.
+	Exposure float64
+
+	// The tone mapping operator.
+	Operator Operator
+
+	// The gamma to correct for, or zero for none.
+	Gamma float64
+
+	shader *gfx.Shader
+}
+
+func (t *ToneMap) invGamma() float64 {
+	if t.Gamma == 0 {
+		return 1
+	}
+	return 1 / t.Gamma
+}
+
+// Passes implements the Effect interface.
+func (t *ToneMap) Passes() []*Pass {
+	return []*Pass{{
+		Name:    "ToneMap",
+		Shader:  t.shader,
+		Sources: []Source{Previous},
+		Update: func(s *gfx.Shader, src image.Rectangle) {
+			s.Inputs["Exposure"] = float32(t.Exposure)
+			s.Inputs["Operator"] = float32(t.Operator)
+			s.Inputs["InvGamma"] = float32(t.invGamma())
+		},
+	}}
+}
+
+// Apply implements the Effect interface.
+func (t *ToneMap) Apply(src *image.RGBA) *image.RGBA {
+	in := newFImage(src)
+	invGamma := t.invGamma()
+	return render(in.w, in.h, func(u, v float64) vec4 {
+		c := in.sample(u, v)
+		for i := 0; i < 3; i++ {
+			x := c[i] * t.Exposure
+			switch t.Operator {
+			case Reinhard:
+				x = x / (1 + x)
+			case Filmic:
+				x = (x * (2.51*x + 0.03)) / (x*(2.43*x+0.59) + 0.14)
+			}
+			c[i] = math.Pow(math.Min(math.Max(x, 0), 1), invGamma)
+		}
+		return c
+	}).rgba()
+}
+
+// NewToneMap returns a new tone mapping effect with an exposure of one, the
+// Filmic operator and a gamma of 2.2.
+func NewToneMap() *ToneMap {
+	return &ToneMap{
+		Exposure: 1,
+		Operator: Filmic,
+		Gamma:    2.2,
+		shader:   newShader("ToneMap", glslToneMap),
+	}
+}
@@ -0,0 +1,303 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gfx
+
+import (
+	gmath "azul3d.org/v1/math"
+	"image"
+	"math"
+)
+
+// The layout of a SortKey, from the most significant bits to the least
+// significant ones.
+const (
+	keyStateBits   = 14
+	keyTextureBits = 14
+	keyShaderBits  = 15
+	keyDepthBits   = 16
+	keyLayerBits   = 4
+
+	keyStateShift       = 0
+	keyTextureShift     = keyStateShift + keyStateBits
+	keyShaderShift      = keyTextureShift + keyTextureBits
+	keyDepthShift       = keyShaderShift + keyShaderBits
+	keyTransparentShift = keyDepthShift + keyDepthBits
+	keyLayerShift       = keyTransparentShift + 1
+)
+
+// MaxLayer is the maximum layer of an object added to a Queue.
+const MaxLayer = 1<<keyLayerBits - 1
+
+// SortKey is a packed 64-bit key that determines the order in which a Queue
+// draws an object. From the most significant bits to the least significant
+// ones, it is made up of:
+//  4 bits  - The layer of the object.
+//  1 bit   - Whether or not the object is transparent (alpha blended).
+//  16 bits - The depth bucket of the object.
+//  15 bits - The ID of the object's shader.
+//  14 bits - The ID of the object's texture.
+//  14 bits - The ID of the object's graphics state.
+type SortKey uint64
+
+// Layer returns the layer of the object, see Queue.Add.
+func (k SortKey) Layer() int {
+	return int(k >> keyLayerShift)
+}
+
+// Transparent tells whether or not the object is alpha blended.
+func (k SortKey) Transparent() bool {
+	return (k>>keyTransparentShift)&1 == 1
+}
+
+// Depth returns the depth bucket of the object. Buckets are logarithmic,
+// such that nearby objects are bucketed more precisely than far away ones.
+// For transparent objects the bucket is inverted, such that they sort
+// back-to-front.
+func (k SortKey) Depth() int {
+	return int(k>>keyDepthShift) & (1<<keyDepthBits - 1)
+}
+
+// queueItem is a single object in a queue.
+type queueItem struct {
+	key   SortKey
+	layer int
+	o     *Object
+}
+
+// Queue is a render queue, it sorts objects for drawing using packed 64-bit
+// sort keys (see SortKey). Unlike ByDist and ByState (which lock and compare
+// objects for each comparison), each object is locked once to build it's key
+// and the keys are then radix sorted.
+//
+// Objects are drawn ordered by layer, and then opaque objects are drawn before
+// transparent (alpha blended) ones. Opaque objects are drawn front-to-back,
+// with objects at similar depths grouped by shader, texture and graphics state
+// in order to reduce state changes. Transparent objects are drawn
+// back-to-front.
+//
+// A queue is typically reused each frame:
+//  q.Reset()
+//  for _, o := range objects {
+//      q.Add(o, 0)
+//  }
+//  q.Sort(cam)
+//  q.Draw(canvas, rect, cam)
+//
+// The zero value of a queue is an empty queue ready to use. A queue must not
+// be used by multiple goroutines concurrently.
+type Queue struct {
+	items, tmp []queueItem
+
+	// The IDs assigned to shaders, textures and graphics states while
+	// sorting, in the order that the objects were added (such that the order
+	// of objects is stable between frames). They are cleared after each sort
+	// so as to not keep shaders and textures from being garbage collected.
+	shaders  map[*Shader]uint64
+	textures map[*Texture]uint64
+	states   map[State]uint64
+}
+
+// Reset removes all of the objects from the queue.
+func (q *Queue) Reset() {
+	// Don't keep the objects from being garbage collected.
+	for i := range q.items {
+		q.items[i].o = nil
+	}
+	for i := range q.tmp {
+		q.tmp[i].o = nil
+	}
+	q.items = q.items[:0]
+}
+
+// Add adds the given object to the queue, on the given layer. Lower layers
+// are drawn first (e.g. a skybox may be on layer zero and a HUD on layer
+// MaxLayer), the layer is clamped to the range [0, MaxLayer].
+func (q *Queue) Add(o *Object, layer int) {
+	if layer < 0 {
+		layer = 0
+	} else if layer > MaxLayer {
+		layer = MaxLayer
+	}
+	q.items = append(q.items, queueItem{layer: layer, o: o})
+}
+
+// Len returns the number of objects in the queue.
+func (q *Queue) Len() int {
+	return len(q.items)
+}
+
+// At returns the i'th object in the queue and it's sort key. Sort keys are
+// only valid once Sort has been called.
+func (q *Queue) At(i int) (*Object, SortKey) {
+	it := q.items[i]
+	return it.o, it.key
+}
+
+// newID returns the next ID of a map with n entries, whose IDs are of the
+// given number of bits. Once every ID is taken the last one is returned for
+// each new entry, such that they are grouped together.
+func newID(n, bits int) uint64 {
+	if n >= 1<<uint(bits)-1 {
+		return 1<<uint(bits) - 1
+	}
+	return uint64(n)
+}
+
+// shaderID returns the ID of the given shader.
+func (q *Queue) shaderID(s *Shader) uint64 {
+	if id, ok := q.shaders[s]; ok {
+		return id
+	}
+	id := newID(len(q.shaders), keyShaderBits)
+	q.shaders[s] = id
+	return id
+}
+
+// textureID returns the ID of the given texture.
+func (q *Queue) textureID(t *Texture) uint64 {
+	if id, ok := q.textures[t]; ok {
+		return id
+	}
+	id := newID(len(q.textures), keyTextureBits)
+	q.textures[t] = id
+	return id
+}
+
+// stateID returns the ID of the given graphics state.
+func (q *Queue) stateID(s State) uint64 {
+	if id, ok := q.states[s]; ok {
+		return id
+	}
+	id := newID(len(q.states), keyStateBits)
+	q.states[s] = id
+	return id
+}
+
+// clearIDs clears the IDs assigned while sorting, keeping the maps for reuse.
+func (q *Queue) clearIDs() {
+	for s := range q.shaders {
+		delete(q.shaders, s)
+	}
+	for t := range q.textures {
+		delete(q.textures, t)
+	}
+	for s := range q.states {
+		delete(q.states, s)
+	}
+}
+
+// worldPos returns the world space position of the given transform. It
+// avoids building the transformation matrix when the transform has no parent.
+func worldPos(t *Transform) gmath.Vec3 {
+	if t.Parent() == nil {
+		return t.Pos()
+	}
+	return t.Mat4().Translation()
+}
+
+// key builds the sort key of the given object, seen from the given eye
+// position.
+func (q *Queue) key(o *Object, layer int, eye gmath.Vec3) SortKey {
+	o.RLock()
+	pos := worldPos(o.Transform)
+	transparent := o.AlphaMode == AlphaBlend
+	state := o.State
+	var (
+		shader  *Shader
+		texture *Texture
+	)
+	if len(o.Shaders) > 0 {
+		shader = o.Shaders[0]
+	}
+	if len(o.Textures) > 0 && len(o.Textures[0]) > 0 {
+		texture = o.Textures[0][0]
+	}
+	o.RUnlock()
+
+	// The upper bits of a positive float are a logarithmic bucket of it.
+	dist := float32(pos.Sub(eye).Length())
+	depth := uint64(math.Float32bits(dist) >> (32 - keyDepthBits))
+
+	k := uint64(layer) << keyLayerShift
+	if transparent {
+		k |= 1 << keyTransparentShift
+		depth = 1<<keyDepthBits - 1 - depth
+	}
+	k |= depth << keyDepthShift
+	k |= q.shaderID(shader) << keyShaderShift
+	k |= q.textureID(texture) << keyTextureShift
+	k |= q.stateID(state) << keyStateShift
+	return SortKey(k)
+}
+
+// Sort builds the sort key of each object in the queue, seen from the given
+// camera, and then sorts the objects by their keys. If the camera is nil then
+// distances are from the origin.
+//
+// The objects (and the camera) are read-locked by this method.
+func (q *Queue) Sort(c *Camera) {
+	if q.shaders == nil {
+		q.shaders = make(map[*Shader]uint64)
+		q.textures = make(map[*Texture]uint64)
+		q.states = make(map[State]uint64)
+	}
+	var eye gmath.Vec3
+	if c != nil {
+		c.RLock()
+		eye = worldPos(c.Object.Transform)
+		c.RUnlock()
+	}
+	for i := range q.items {
+		it := &q.items[i]
+		it.key = q.key(it.o, it.layer, eye)
+	}
+	q.clearIDs()
+	if cap(q.tmp) < len(q.items) {
+		q.tmp = make([]queueItem, len(q.items))
+	}
+	q.items, q.tmp = radixSort(q.items, q.tmp[:len(q.items)])
+}
+
+// radixSort performs a stable least significant digit radix sort of the given
+// items by their keys, using tmp (which must be of the same length) as a
+// buffer. The sorted items, and the buffer, are returned.
+func radixSort(items, tmp []queueItem) (sorted, buf []queueItem) {
+	if len(items) < 2 {
+		return items, tmp
+	}
+	var offsets [256]int
+	for shift := uint(0); shift < 64; shift += 8 {
+		offsets = [256]int{}
+		for _, it := range items {
+			offsets[byte(it.key>>shift)]++
+		}
+
+		// Skip the digit if all of the keys share it (commonly the case for
+		// e.g. the layer).
+		if offsets[byte(items[0].key>>shift)] == len(items) {
+			continue
+		}
+
+		sum := 0
+		for i, n := range offsets {
+			offsets[i] = sum
+			sum += n
+		}
+		for _, it := range items {
+			d := byte(it.key >> shift)
+			tmp[offsets[d]] = it
+			offsets[d]++
+		}
+		items, tmp = tmp, items
+	}
+	return items, tmp
+}
+
+// Draw draws each object in the queue onto the given canvas, in sorted order.
+func (q *Queue) Draw(c Canvas, r image.Rectangle, cam *Camera) {
+	for _, it := range q.items {
+		c.Draw(r, it.o, cam)
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gfx
+
+import (
+	"azul3d.org/v1/math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+type byKey []queueItem
+
+func (b byKey) Len() int           { return len(b) }
+func (b byKey) Less(i, j int) bool { return b[i].key < b[j].key }
+func (b byKey) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+func TestRadixSort(t *testing.T) {
+	items := make([]queueItem, 1000)
+	for i := range items {
+		// Share the upper bits, such that some digits are skipped.
+		items[i].key = SortKey(rand.Int63n(1 << 40))
+		items[i].layer = i
+	}
+	want := make([]queueItem, len(items))
+	copy(want, items)
+	sort.Stable(byKey(want))
+
+	got, _ := radixSort(items, make([]queueItem, len(items)))
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("item %d: got %+v want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestQueue(t *testing.T) {
+	newObject := func(pos math.Vec3, s *Shader) *Object {
+		o := NewObject()
+		o.SetPos(pos)
+		o.Shaders = []*Shader{s}
+		o.Meshes = []*Mesh{new(Mesh)}
+		return o
+	}
+	a, b := NewShader("a"), NewShader("b")
+	var (
+		near      = newObject(math.Vec3{0, 1, 0}, a)
+		nearB     = newObject(math.Vec3{0, 1.001, 0}, b)
+		nearA     = newObject(math.Vec3{0, 1.002, 0}, a)
+		far       = newObject(math.Vec3{0, 100, 0}, a)
+		glassNear = newObject(math.Vec3{0, 2, 0}, a)
+		glassFar  = newObject(math.Vec3{0, 50, 0}, a)
+		hud       = newObject(math.Vec3{0, 500, 0}, a)
+	)
+	glassNear.AlphaMode = AlphaBlend
+	glassFar.AlphaMode = AlphaBlend
+
+	var q Queue
+	q.Add(hud, MaxLayer+1)
+	q.Add(glassNear, 0)
+	q.Add(far, 0)
+	q.Add(nearB, 0)
+	q.Add(glassFar, 0)
+	q.Add(near, 0)
+	q.Add(nearA, 0)
+	q.Sort(nil)
+
+	// Opaque objects front-to-back (with those at the same depth grouped by
+	// shader), transparent ones back-to-front, and then higher layers.
+	want := []*Object{near, nearA, nearB, far, glassFar, glassNear, hud}
+	if q.Len() != len(want) {
+		t.Fatalf("got %d objects want %d", q.Len(), len(want))
+	}
+	for i, w := range want {
+		if o, _ := q.At(i); o != w {
+			t.Fatalf("object %d is in the wrong order", i)
+		}
+	}
+	if _, k := q.At(6); k.Layer() != MaxLayer {
+		t.Fatalf("got layer %d", k.Layer())
+	}
+	if _, k := q.At(5); !k.Transparent() {
+		t.Fatal("blended object is not transparent")
+	}
+	_, k0 := q.At(0)
+	_, k3 := q.At(3)
+	if k0.Depth() >= k3.Depth() {
+		t.Fatal("depth buckets are not increasing")
+	}
+
+	// Drawing follows the sorted order.
+	r := Nil()
+	q.Draw(r, r.Bounds(), nil)
+	r.Render()
+	if s := r.Stats(); s.DrawCalls != len(want) {
+		t.Fatalf("got %d draw calls", s.DrawCalls)
+	}
+	q.Reset()
+	if q.Len() != 0 {
+		t.Fatal("queue not reset")
+	}
+}
+
+func TestQueueIDs(t *testing.T) {
+	// More textures than there are texture IDs.
+	const n = 1<<keyTextureBits + 10
+	shader := NewShader("a")
+	var q Queue
+	for i := 0; i < n; i++ {
+		o := NewObject()
+		o.Shaders = []*Shader{shader}
+		o.Textures = [][]*Texture{{new(Texture)}}
+		q.Add(o, 0)
+	}
+	q.Sort(nil)
+
+	// The texture IDs do not spill into the shader IDs, and are not reused
+	// by other textures once they run out.
+	zero := 0
+	for i := 0; i < q.Len(); i++ {
+		_, k := q.At(i)
+		if k>>keyShaderShift != 0 {
+			t.Fatalf("object %d: got key %x", i, k)
+		}
+		if k == 0 {
+			zero++
+		}
+	}
+	if zero != 1 {
+		t.Fatalf("got %d objects with the first texture ID", zero)
+	}
+	_, first := q.At(0)
+	_, last := q.At(n - 1)
+	if first == last || last != (1<<keyTextureBits-1)<<keyTextureShift {
+		t.Fatalf("got keys %x and %x", first, last)
+	}
+
+	// The shaders and textures are not kept after sorting.
+	if len(q.shaders) != 0 || len(q.textures) != 0 || len(q.states) != 0 {
+		t.Fatal("IDs kept after sorting")
+	}
+}
+
+func randomObjects(amount int) []*Object {
+	shaders := make([]*Shader, 8)
+	for i := range shaders {
+		shaders[i] = NewShader("")
+	}
+	randBool := func() bool {
+		return (rand.Int() % 2) == 0
+	}
+	objs := make([]*Object, amount)
+	for i := range objs {
+		o := NewObject()
+		o.Transform.SetPos(math.Vec3{
+			rand.Float64(),
+			rand.Float64(),
+			rand.Float64(),
+		})
+		o.Shaders = []*Shader{shaders[rand.Intn(len(shaders))]}
+		o.State = State{
+			WriteRed:    randBool(),
+			WriteGreen:  randBool(),
+			WriteBlue:   randBool(),
+			WriteAlpha:  randBool(),
+			Dithering:   randBool(),
+			DepthTest:   randBool(),
+			DepthWrite:  randBool(),
+			StencilTest: randBool(),
+		}
+		objs[i] = o
+	}
+	return objs
+}
+
+func sortQueue(amount int, b *testing.B) {
+	b.StopTimer()
+	objs := randomObjects(amount)
+	var q Queue
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		q.Reset()
+		for _, o := range objs {
+			q.Add(o, 0)
+		}
+		q.Sort(nil)
+	}
+}
+
+func BenchmarkQueueSort250(b *testing.B) {
+	sortQueue(250, b)
+}
+
+func BenchmarkQueueSort500(b *testing.B) {
+	sortQueue(500, b)
+}
+
+func BenchmarkQueueSort1k(b *testing.B) {
+	sortQueue(1000, b)
+}
+
+func BenchmarkQueueSort5k(b *testing.B) {
+	sortQueue(5000, b)
+}
+
+func BenchmarkQueueSort50k(b *testing.B) {
+	sortQueue(50000, b)
+}
+
+func BenchmarkDistSortStd50k(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		sortByDist(0, 50000, b, true)
+	}
+}
+
+func BenchmarkStateSort50k(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		sortByState(50000, b)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gfx
+
+import (
+	gmath "azul3d.org/v1/math"
+	"math"
+)
+
+// Ray represents a half-line in 3D space, starting at an origin point and
+// extending infinitely in a direction.
+type Ray struct {
+	// The origin point of the ray.
+	Origin gmath.Vec3
+
+	// The direction of the ray. Rays returned by this package always have a
+	// normalized direction.
+	Dir gmath.Vec3
+}
+
+// At returns the point along the ray at the given distance (in units of the
+// ray's direction) from it's origin.
+func (r Ray) At(t float64) gmath.Vec3 {
+	return r.Origin.Add(r.Dir.MulScalar(t))
+}
+
+// IntersectPlane returns the distance along the ray at which it intersects
+// the given plane. If the ray is parallel to the plane or the plane is behind
+// the ray then ok=false is returned.
+func (r Ray) IntersectPlane(p Plane) (t float64, ok bool) {
+	denom := p.Normal.Dot(r.Dir)
+	if denom == 0 {
+		return 0, false
+	}
+	t = -p.Dist(r.Origin) / denom
+	return t, t >= 0
+}
+
+// IntersectRect3 returns the distance along the ray at which it enters the
+// given axis-aligned box. If the ray origin is inside of the box then t=0 is
+// returned. If the ray does not intersect the box then ok=false is returned.
+func (r Ray) IntersectRect3(b gmath.Rect3) (t float64, ok bool) {
+	tMin, tMax := 0.0, math.Inf(1)
+	slab := func(origin, dir, min, max float64) bool {
+		if dir == 0 {
+			// Parallel to the slab, must be within it.
+			return origin >= min && origin <= max
+		}
+		inv := 1 / dir
+		t0, t1 := (min-origin)*inv, (max-origin)*inv
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		if t0 > tMin {
+			tMin = t0
+		}
+		if t1 < tMax {
+			tMax = t1
+		}
+		return tMin <= tMax
+	}
+	if !slab(r.Origin.X, r.Dir.X, b.Min.X, b.Max.X) ||
+		!slab(r.Origin.Y, r.Dir.Y, b.Min.Y, b.Max.Y) ||
+		!slab(r.Origin.Z, r.Dir.Z, b.Min.Z, b.Max.Z) {
+		return 0, false
+	}
+	return tMin, true
+}
+
+// IntersectSphere returns the distance along the ray at which it enters the
+// given sphere. If the ray origin is inside of the sphere then t=0 is
+// returned. If the ray does not intersect the sphere then ok=false is
+// returned.
+func (r Ray) IntersectSphere(s gmath.Sphere) (t float64, ok bool) {
+	oc := r.Origin.Sub(s.Center)
+	b := oc.Dot(r.Dir)
+	c := oc.Dot(oc) - s.Radius*s.Radius
+	if c <= 0 {
+		return 0, true
+	}
+	a := r.Dir.Dot(r.Dir)
+	disc := b*b - a*c
+	if b > 0 || disc < 0 {
+		return 0, false
+	}
+	return (-b - math.Sqrt(disc)) / a, true
+}
@@ -206,6 +206,11 @@ type Renderer interface {
 	// GPUInfo should return information about the graphics hardware.
 	GPUInfo() GPUInfo
 
+	// Stats should return statistics about the last frame rendered (i.e. the
+	// work performed before the last call to Render), such that per-frame
+	// budgets can be monitored.
+	Stats() Stats
+
 	// LoadMesh should begin loading the specified mesh asynchronously.
 	//
 	// Additionally, the renderer will set m.Loaded to true, and then invoke
@@ -0,0 +1,51 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package rendergraph implements a render graph for multi-pass rendering.
+//
+// Effects such as shadow mapping or post-processing render into textures that
+// later passes sample from. Rather than juggling RenderToTexture canvases and
+// clear operations by hand, each pass declares the textures it reads and the
+// one it writes (or the renderer's canvas itself) along with the buffers it
+// clears, and the graph takes care of the rest:
+//
+//  g := rendergraph.New()
+//  shadow := g.Transient("shadow", rendergraph.TextureDesc{
+//      Bounds: image.Rect(0, 0, 1024, 1024),
+//  })
+//  g.AddPass(&rendergraph.Pass{
+//      Name:   "scene",
+//      Inputs: []*rendergraph.Resource{shadow},
+//      Clear:  rendergraph.ClearColor | rendergraph.ClearDepth,
+//      Depth:  1,
+//      Run: func(c gfx.Canvas) {
+//          ground.Textures[1] = shadow.Texture()
+//          c.Draw(image.Rect(0, 0, 0, 0), ground, cam)
+//      },
+//  })
+//  g.AddPass(&rendergraph.Pass{
+//      Name:   "shadow",
+//      Output: shadow,
+//      Clear:  rendergraph.ClearDepth,
+//      Depth:  1,
+//      Run:    drawShadowCasters,
+//  })
+//
+//  // Each frame:
+//  if err := g.Execute(r); err != nil {
+//      log.Fatal(err)
+//  }
+//  r.Render()
+//
+// Passes are executed in the order implied by their inputs and outputs (the
+// order they are added in only matters for passes writing the same resource),
+// and passes whose results are never used are skipped.
+//
+// Transient textures are owned by the graph: a pool of textures is kept
+// between executions and a texture is shared by any number of transient
+// resources whose lifetimes do not overlap. Imported textures are owned by the
+// client and are used as-is.
+//
+// Any gfx.Renderer may execute a graph, including gfx.Nil for testing.
+package rendergraph
@@ -0,0 +1,380 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rendergraph
+
+import (
+	"azul3d.org/v1/gfx"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// pooled is a transient texture owned by the graph.
+type pooled struct {
+	desc TextureDesc
+	tex  *gfx.Texture
+
+	// Whether or not the texture is currently assigned to a resource, and
+	// whether or not it was used during the current execution.
+	inUse, used bool
+}
+
+// Graph is a set of passes and the resources they use. It is safe to use from
+// multiple goroutines concurrently, although executions are serialized.
+type Graph struct {
+	access    sync.Mutex
+	passes    []*Pass
+	resources []*Resource
+
+	// The compiled execution order, or nil if the graph must be recompiled.
+	order []*Pass
+
+	// The transient resources to acquire a texture for before, and release
+	// the texture of after, the pass at each position in the order.
+	acquire, release [][]*Resource
+
+	// The pool of transient textures, and the canvases rendering to textures.
+	pool     []*pooled
+	canvases map[*gfx.Texture]gfx.Canvas
+}
+
+// Transient creates and returns a new transient resource, whose texture is
+// allocated by the graph as described.
+func (g *Graph) Transient(name string, desc TextureDesc) *Resource {
+	g.access.Lock()
+	defer g.access.Unlock()
+	r := &Resource{
+		g:    g,
+		name: name,
+		desc: desc,
+	}
+	g.resources = append(g.resources, r)
+	g.order = nil
+	return r
+}
+
+// Import creates and returns a new resource for the given client-owned
+// texture. Passes writing to an imported resource are never skipped, as their
+// results are visible outside of the graph.
+func (g *Graph) Import(name string, t *gfx.Texture) *Resource {
+	if t == nil {
+		panic("rendergraph: imported texture is nil")
+	}
+	g.access.Lock()
+	defer g.access.Unlock()
+	r := &Resource{
+		g:        g,
+		name:     name,
+		imported: t,
+		tex:      t,
+	}
+	g.resources = append(g.resources, r)
+	g.order = nil
+	return r
+}
+
+// AddPass adds the given pass to the graph. All of the resources of the pass
+// must have been created by this graph.
+func (g *Graph) AddPass(p *Pass) {
+	g.access.Lock()
+	defer g.access.Unlock()
+	if p.Output != nil && p.Output.g != g {
+		panic("rendergraph: pass output is not in the graph")
+	}
+	for _, in := range p.Inputs {
+		if in.g != g {
+			panic("rendergraph: pass input is not in the graph")
+		}
+	}
+	g.passes = append(g.passes, p)
+	g.order = nil
+}
+
+// Order returns the passes of the graph in the order they will be executed,
+// omitting the ones that are skipped because their results are never used.
+//
+// An error is returned if the graph is invalid, i.e. if a pass reads it's own
+// output, if a transient resource is read but never written, or if the
+// passes depend on each other cyclically.
+func (g *Graph) Order() ([]*Pass, error) {
+	g.access.Lock()
+	defer g.access.Unlock()
+	if err := g.compile(); err != nil {
+		return nil, err
+	}
+	order := make([]*Pass, len(g.order))
+	copy(order, g.order)
+	return order, nil
+}
+
+// Textures returns the number of transient textures currently pooled by the
+// graph, i.e. the number allocated by the last execution.
+func (g *Graph) Textures() int {
+	g.access.Lock()
+	n := len(g.pool)
+	g.access.Unlock()
+	return n
+}
+
+// compile computes the execution order and transient resource lifetimes, if
+// needed.
+//
+// The graph's lock must be held for this method to operate safely.
+func (g *Graph) compile() error {
+	if g.order != nil {
+		return nil
+	}
+
+	// Find the writers and readers of each resource, by pass index.
+	writers := make(map[*Resource][]int)
+	readers := make(map[*Resource][]int)
+	for i, p := range g.passes {
+		for _, in := range p.Inputs {
+			if in == p.Output {
+				return fmt.Errorf("rendergraph: pass %q reads it's own output %q", p.Name, in.name)
+			}
+			readers[in] = append(readers[in], i)
+		}
+		if p.Output != nil {
+			writers[p.Output] = append(writers[p.Output], i)
+		}
+	}
+
+	// Build the dependency edges: writers of a resource execute in the order
+	// they were added, and readers execute after all of them.
+	n := len(g.passes)
+	deps := make([][]int, n)
+	for _, r := range g.resources {
+		w := writers[r]
+		if len(w) == 0 {
+			if r.Transient() && len(readers[r]) > 0 {
+				p := g.passes[readers[r][0]]
+				return fmt.Errorf("rendergraph: pass %q reads %q which is never written", p.Name, r.name)
+			}
+			continue
+		}
+		for i := 1; i < len(w); i++ {
+			deps[w[i]] = append(deps[w[i]], w[i-1])
+		}
+		for _, rd := range readers[r] {
+			deps[rd] = append(deps[rd], w[len(w)-1])
+		}
+	}
+
+	// Mark the passes whose results are used, starting from the root ones.
+	live := make([]bool, n)
+	var mark func(i int)
+	mark = func(i int) {
+		if live[i] {
+			return
+		}
+		live[i] = true
+		for _, d := range deps[i] {
+			mark(d)
+		}
+	}
+	for i, p := range g.passes {
+		if p.root() {
+			mark(i)
+		}
+	}
+
+	// Sort the live passes topologically, preferring the order they were
+	// added in among independent ones.
+	pending := make([]int, n)
+	dependents := make([][]int, n)
+	count := 0
+	for i := range g.passes {
+		if !live[i] {
+			continue
+		}
+		count++
+		for _, d := range deps[i] {
+			pending[i]++
+			dependents[d] = append(dependents[d], i)
+		}
+	}
+	done := make([]bool, n)
+	order := make([]*Pass, 0, count)
+	pos := make([]int, n)
+	for len(order) < count {
+		next := -1
+		for i := range g.passes {
+			if live[i] && !done[i] && pending[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			var names []string
+			for i, p := range g.passes {
+				if live[i] && !done[i] {
+					names = append(names, fmt.Sprintf("%q", p.Name))
+				}
+			}
+			return fmt.Errorf("rendergraph: cyclic dependency between passes %s", strings.Join(names, ", "))
+		}
+		done[next] = true
+		pos[next] = len(order)
+		order = append(order, g.passes[next])
+		for _, d := range dependents[next] {
+			pending[d]--
+		}
+	}
+
+	// Find the lifetime of each transient resource.
+	g.acquire = make([][]*Resource, len(order))
+	g.release = make([][]*Resource, len(order))
+	for _, r := range g.resources {
+		if !r.Transient() {
+			continue
+		}
+		r.first, r.last = -1, -1
+		use := func(i int) {
+			if !live[i] {
+				return
+			}
+			if r.first < 0 || pos[i] < r.first {
+				r.first = pos[i]
+			}
+			if pos[i] > r.last {
+				r.last = pos[i]
+			}
+		}
+		for _, i := range writers[r] {
+			use(i)
+		}
+		screen := false
+		for _, i := range readers[r] {
+			use(i)
+			if live[i] && g.passes[i].Output == nil {
+				screen = true
+			}
+		}
+		if screen && r.first >= 0 {
+			// Read by a pass rendering onto the renderer, which only renders
+			// once the caller invokes Render: keep the texture until the end
+			// such that later passes don't reuse it first.
+			r.last = len(order) - 1
+		}
+		if r.first >= 0 {
+			g.acquire[r.first] = append(g.acquire[r.first], r)
+			g.release[r.last] = append(g.release[r.last], r)
+		}
+	}
+	g.order = order
+	return nil
+}
+
+// Execute executes the passes of the graph in order using the given renderer.
+// Passes without an output render directly onto the renderer, and it is the
+// caller's responsibility to invoke r.Render afterwards (transient textures
+// they read are kept until Execute returns, rather than being reused by later
+// passes). Canvases rendering to textures are rendered by the graph after each
+// pass that writes to them.
+//
+// See the Order method for the errors that may be returned, in which case no
+// pass is executed.
+func (g *Graph) Execute(r gfx.Renderer) error {
+	g.access.Lock()
+	defer g.access.Unlock()
+	if err := g.compile(); err != nil {
+		return err
+	}
+	if g.canvases == nil {
+		g.canvases = make(map[*gfx.Texture]gfx.Canvas)
+	}
+	for _, pt := range g.pool {
+		pt.used = false
+	}
+
+	screen := r.Bounds()
+	for i, p := range g.order {
+		for _, res := range g.acquire[i] {
+			res.tex = g.acquireTexture(res.desc.resolve(screen))
+		}
+
+		// Find the canvas to render to.
+		var c gfx.Canvas = r
+		if p.Output != nil {
+			t := p.Output.tex
+			c = g.canvases[t]
+			if c == nil {
+				c = r.RenderToTexture(t)
+				g.canvases[t] = c
+			}
+		}
+
+		p.clear(c)
+		if p.Run != nil {
+			p.Run(c)
+		}
+		if c != gfx.Canvas(r) {
+			c.Render()
+		}
+
+		for _, res := range g.release[i] {
+			g.releaseTexture(res.tex)
+			res.tex = nil
+		}
+	}
+
+	// Drop the pooled textures that where not used by this execution (e.g.
+	// because the renderer was resized).
+	kept := g.pool[:0]
+	for _, pt := range g.pool {
+		if pt.used {
+			kept = append(kept, pt)
+		} else {
+			delete(g.canvases, pt.tex)
+		}
+	}
+	for i := len(kept); i < len(g.pool); i++ {
+		g.pool[i] = nil
+	}
+	g.pool = kept
+	return nil
+}
+
+// acquireTexture returns a free pooled texture matching the resolved
+// description, allocating a new one if needed.
+//
+// The graph's lock must be held for this method to operate safely.
+func (g *Graph) acquireTexture(desc TextureDesc) *gfx.Texture {
+	for _, pt := range g.pool {
+		if !pt.inUse && pt.desc == desc {
+			pt.inUse = true
+			pt.used = true
+			return pt.tex
+		}
+	}
+	pt := &pooled{
+		desc:  desc,
+		tex:   desc.newTexture(),
+		inUse: true,
+		used:  true,
+	}
+	g.pool = append(g.pool, pt)
+	return pt.tex
+}
+
+// releaseTexture returns the given texture to the pool.
+//
+// The graph's lock must be held for this method to operate safely.
+func (g *Graph) releaseTexture(t *gfx.Texture) {
+	for _, pt := range g.pool {
+		if pt.tex == t {
+			pt.inUse = false
+			return
+		}
+	}
+}
+
+// New returns a new, empty, render graph.
+func New() *Graph {
+	return &Graph{
+		canvases: make(map[*gfx.Texture]gfx.Canvas),
+	}
+}
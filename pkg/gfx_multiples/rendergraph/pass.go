@@ -0,0 +1,71 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rendergraph
+
+import (
+	"azul3d.org/v1/gfx"
+	"image"
+)
+
+// ClearOp is a bitmask of buffers to clear before a pass is run.
+type ClearOp uint8
+
+const (
+	// ClearColor clears the color buffer to the pass's Color.
+	ClearColor ClearOp = 1 << iota
+
+	// ClearDepth clears the depth buffer to the pass's Depth.
+	ClearDepth
+
+	// ClearStencil clears the stencil buffer to the pass's Stencil.
+	ClearStencil
+)
+
+// Pass is a single rendering pass of a graph. A pass must not be modified
+// once added to a graph.
+type Pass struct {
+	// The name of the pass, used in error messages.
+	Name string
+
+	// The resources whose textures this pass reads from (e.g. samples in a
+	// shader).
+	Inputs []*Resource
+
+	// The resource this pass renders to, or nil to render directly onto the
+	// renderer that executes the graph.
+	Output *Resource
+
+	// The buffers to clear before running the pass, and the values they are
+	// cleared to.
+	Clear   ClearOp
+	Color   gfx.Color
+	Depth   float64
+	Stencil int
+
+	// Run is called to perform the drawing operations of the pass onto the
+	// given canvas, which is the output of the pass. It may be nil for passes
+	// that only clear.
+	Run func(c gfx.Canvas)
+}
+
+// clear performs the clear operations of this pass on the given canvas.
+func (p *Pass) clear(c gfx.Canvas) {
+	var all image.Rectangle
+	if p.Clear&ClearColor != 0 {
+		c.Clear(all, p.Color)
+	}
+	if p.Clear&ClearDepth != 0 {
+		c.ClearDepth(all, p.Depth)
+	}
+	if p.Clear&ClearStencil != 0 {
+		c.ClearStencil(all, p.Stencil)
+	}
+}
+
+// root tells if the pass produces results visible outside of the graph (i.e.
+// it renders to the renderer or to an imported texture).
+func (p *Pass) root() bool {
+	return p.Output == nil || !p.Output.Transient()
+}
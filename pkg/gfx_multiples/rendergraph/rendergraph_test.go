@@ -0,0 +1,286 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rendergraph
+
+import (
+	"azul3d.org/v1/gfx"
+	"fmt"
+	"image"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recorder is a renderer that records the operations performed on it and on
+// the canvases it returns from RenderToTexture.
+type recorder struct {
+	gfx.Renderer
+	bounds image.Rectangle
+	log    []string
+	names  map[*gfx.Texture]string
+}
+
+func (r *recorder) name(t *gfx.Texture) string {
+	if n, ok := r.names[t]; ok {
+		return n
+	}
+	n := fmt.Sprintf("tex%d", len(r.names))
+	r.names[t] = n
+	return n
+}
+
+func (r *recorder) Bounds() image.Rectangle {
+	return r.bounds
+}
+
+func (r *recorder) Clear(rect image.Rectangle, bg gfx.Color) {
+	r.log = append(r.log, "clear screen")
+}
+
+func (r *recorder) RenderToTexture(t *gfx.Texture) gfx.Canvas {
+	r.log = append(r.log, "rtt "+r.name(t))
+	return &texCanvas{r.Renderer.RenderToTexture(t), r, r.name(t)}
+}
+
+type texCanvas struct {
+	gfx.Canvas
+	r    *recorder
+	name string
+}
+
+func (c *texCanvas) Clear(rect image.Rectangle, bg gfx.Color) {
+	c.r.log = append(c.r.log, "clear "+c.name)
+}
+
+func (c *texCanvas) ClearDepth(rect image.Rectangle, depth float64) {
+	c.r.log = append(c.r.log, "clearDepth "+c.name)
+}
+
+func (c *texCanvas) Render() {
+	c.r.log = append(c.r.log, "render "+c.name)
+}
+
+func newRecorder() *recorder {
+	return &recorder{
+		Renderer: gfx.Nil(),
+		bounds:   image.Rect(0, 0, 640, 480),
+		names:    make(map[*gfx.Texture]string),
+	}
+}
+
+func passNames(passes []*Pass) []string {
+	var names []string
+	for _, p := range passes {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+func TestOrder(t *testing.T) {
+	g := New()
+	shadow := g.Transient("shadow", TextureDesc{})
+	scene := g.Transient("scene", TextureDesc{})
+	unused := g.Transient("unused", TextureDesc{})
+	lut := g.Import("lut", &gfx.Texture{Bounds: image.Rect(0, 0, 16, 256)})
+
+	// Added out of order, the debug pass is unused and skipped.
+	g.AddPass(&Pass{Name: "post", Inputs: []*Resource{scene, lut}})
+	g.AddPass(&Pass{Name: "debug", Output: unused})
+	g.AddPass(&Pass{Name: "overlay", Output: scene})
+	g.AddPass(&Pass{Name: "scene", Inputs: []*Resource{shadow}, Output: scene})
+	g.AddPass(&Pass{Name: "shadow", Output: shadow})
+	g.AddPass(&Pass{Name: "lut", Output: lut})
+
+	order, err := g.Order()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := passNames(order)
+	want := []string{"overlay", "shadow", "scene", "lut", "post"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got order %v want %v", got, want)
+	}
+}
+
+func TestOrderErrors(t *testing.T) {
+	tests := []struct {
+		build func(g *Graph)
+		err   string
+	}{
+		{
+			build: func(g *Graph) {
+				a := g.Transient("a", TextureDesc{})
+				g.AddPass(&Pass{Name: "p", Inputs: []*Resource{a}, Output: a})
+			},
+			err: "reads it's own output",
+		},
+		{
+			build: func(g *Graph) {
+				a := g.Transient("a", TextureDesc{})
+				g.AddPass(&Pass{Name: "p", Inputs: []*Resource{a}})
+			},
+			err: "never written",
+		},
+		{
+			build: func(g *Graph) {
+				a := g.Transient("a", TextureDesc{})
+				b := g.Transient("b", TextureDesc{})
+				g.AddPass(&Pass{Name: "p1", Inputs: []*Resource{b}, Output: a})
+				g.AddPass(&Pass{Name: "p2", Inputs: []*Resource{a}, Output: b})
+				g.AddPass(&Pass{Name: "p3", Inputs: []*Resource{a}})
+			},
+			err: "cyclic",
+		},
+	}
+	for i, tst := range tests {
+		g := New()
+		tst.build(g)
+		_, err := g.Order()
+		if err == nil || !strings.Contains(err.Error(), tst.err) {
+			t.Errorf("test %d: got error %v, want %q", i, err, tst.err)
+		}
+		if err2 := g.Execute(gfx.Nil()); err2 == nil {
+			t.Errorf("test %d: Execute did not fail", i)
+		}
+	}
+}
+
+func TestTransientReuse(t *testing.T) {
+	g := New()
+	half := TextureDesc{Scale: 0.5}
+	a := g.Transient("a", half)
+	b := g.Transient("b", half)
+	c := g.Transient("c", half)
+	full := g.Transient("full", TextureDesc{})
+
+	seen := make(map[string]*gfx.Texture)
+	record := func(res ...*Resource) func(gfx.Canvas) {
+		return func(gfx.Canvas) {
+			for _, r := range res {
+				if r.Texture() == nil {
+					t.Fatalf("%s has no texture", r.Name())
+				}
+				seen[r.Name()] = r.Texture()
+			}
+		}
+	}
+	g.AddPass(&Pass{Name: "a", Output: a, Run: record(a)})
+	g.AddPass(&Pass{Name: "b", Inputs: []*Resource{a}, Output: b, Run: record(a, b)})
+	g.AddPass(&Pass{Name: "c", Inputs: []*Resource{b}, Output: c, Run: record(b, c)})
+	g.AddPass(&Pass{Name: "full", Inputs: []*Resource{c}, Output: full, Run: record(c, full)})
+	g.AddPass(&Pass{Name: "screen", Inputs: []*Resource{full}, Run: record(full)})
+
+	r := newRecorder()
+	for frame := 0; frame < 3; frame++ {
+		if err := g.Execute(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Two half-resolution textures (a and c share one) and a full one.
+	if n := g.Textures(); n != 3 {
+		t.Fatalf("got %d pooled textures, want 3", n)
+	}
+	if seen["a"] == seen["b"] || seen["b"] == seen["c"] {
+		t.Fatal("textures of overlapping resources are shared")
+	}
+	if seen["a"] != seen["c"] {
+		t.Fatal("texture of a is not reused by c")
+	}
+	if got := seen["a"].Bounds; got != image.Rect(0, 0, 320, 240) {
+		t.Fatalf("got half-resolution bounds %v", got)
+	}
+	if got := seen["full"].Bounds; got != image.Rect(0, 0, 640, 480) {
+		t.Fatalf("got full-resolution bounds %v", got)
+	}
+	if a.Texture() != nil {
+		t.Fatal("transient texture valid outside of execution")
+	}
+
+	// Canvases are created once and reused between executions.
+	rtt := 0
+	for _, op := range r.log {
+		if strings.HasPrefix(op, "rtt") {
+			rtt++
+		}
+	}
+	if rtt != 3 {
+		t.Fatalf("got %d RenderToTexture calls, want 3", rtt)
+	}
+
+	// Resizing the renderer reallocates the textures.
+	r.bounds = image.Rect(0, 0, 100, 100)
+	if err := g.Execute(r); err != nil {
+		t.Fatal(err)
+	}
+	if n := g.Textures(); n != 3 {
+		t.Fatalf("got %d pooled textures after resize, want 3", n)
+	}
+	if got := seen["full"].Bounds; got != image.Rect(0, 0, 100, 100) {
+		t.Fatalf("got bounds %v after resize", got)
+	}
+}
+
+func TestTransientScreen(t *testing.T) {
+	g := New()
+	a := g.Transient("a", TextureDesc{})
+	b := g.Transient("b", TextureDesc{})
+
+	seen := make(map[string]*gfx.Texture)
+	record := func(r *Resource) func(gfx.Canvas) {
+		return func(gfx.Canvas) {
+			seen[r.Name()] = r.Texture()
+		}
+	}
+	g.AddPass(&Pass{Name: "a", Output: a})
+	g.AddPass(&Pass{Name: "screen a", Inputs: []*Resource{a}, Run: record(a)})
+	g.AddPass(&Pass{Name: "b", Output: b})
+	g.AddPass(&Pass{Name: "screen b", Inputs: []*Resource{b}, Run: record(b)})
+
+	r := newRecorder()
+	if err := g.Execute(r); err != nil {
+		t.Fatal(err)
+	}
+
+	// The screen is only rendered after execution, so the texture of a may
+	// not be reused by b before then.
+	if seen["a"] == nil || seen["a"] == seen["b"] {
+		t.Fatal("texture read by a screen pass is reused")
+	}
+	if n := g.Textures(); n != 2 {
+		t.Fatalf("got %d pooled textures, want 2", n)
+	}
+}
+
+func TestClear(t *testing.T) {
+	g := New()
+	depth := g.Transient("depth", TextureDesc{})
+	g.AddPass(&Pass{
+		Name:   "screen",
+		Inputs: []*Resource{depth},
+		Clear:  ClearColor,
+	})
+	g.AddPass(&Pass{
+		Name:   "depth",
+		Output: depth,
+		Clear:  ClearColor | ClearDepth,
+		Depth:  1,
+	})
+
+	r := newRecorder()
+	if err := g.Execute(r); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"rtt tex0",
+		"clear tex0",
+		"clearDepth tex0",
+		"render tex0",
+		"clear screen",
+	}
+	if !reflect.DeepEqual(r.log, want) {
+		t.Fatalf("got operations %v want %v", r.log, want)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package rendergraph
+
+import (
+	"azul3d.org/v1/gfx"
+	"image"
+)
+
+// TextureDesc describes a transient texture to be allocated by the graph.
+type TextureDesc struct {
+	// The bounds of the texture. If empty, the bounds of the renderer the
+	// graph is executed with, multiplied by Scale, are used.
+	Bounds image.Rectangle
+
+	// Scale of the renderer's bounds used when Bounds is empty (e.g. 0.5 for
+	// a half-resolution texture). A value of zero is treated as one.
+	Scale float64
+
+	// The storage format of the texture.
+	Format gfx.TexFormat
+
+	// The U and V wrap modes of the texture.
+	WrapU, WrapV gfx.TexWrap
+
+	// The texture filtering used for minification and magnification of the
+	// texture.
+	MinFilter, MagFilter gfx.TexFilter
+}
+
+// resolve returns the description with absolute bounds, for execution with a
+// renderer whose bounds are the ones given.
+func (d TextureDesc) resolve(screen image.Rectangle) TextureDesc {
+	if d.Bounds.Empty() {
+		s := d.Scale
+		if s == 0 {
+			s = 1
+		}
+		w := int(float64(screen.Dx())*s + 0.5)
+		h := int(float64(screen.Dy())*s + 0.5)
+		if w < 1 {
+			w = 1
+		}
+		if h < 1 {
+			h = 1
+		}
+		d.Bounds = image.Rect(0, 0, w, h)
+	}
+	d.Scale = 0
+	return d
+}
+
+// newTexture returns a new texture matching the (resolved) description.
+func (d TextureDesc) newTexture() *gfx.Texture {
+	return &gfx.Texture{
+		Bounds:    d.Bounds,
+		Format:    d.Format,
+		WrapU:     d.WrapU,
+		WrapV:     d.WrapV,
+		MinFilter: d.MinFilter,
+		MagFilter: d.MagFilter,
+	}
+}
+
+// Resource is a texture that passes of a graph read from or write to. It is
+// either transient (allocated by the graph) or imported (owned by the
+// client).
+type Resource struct {
+	g    *Graph
+	name string
+	desc TextureDesc
+
+	// The imported texture, or nil if this resource is transient.
+	imported *gfx.Texture
+
+	// The texture currently backing this resource.
+	tex *gfx.Texture
+
+	// The first and last positions, in execution order, of the passes using
+	// this resource (transient resources only).
+	first, last int
+}
+
+// Name returns the name of this resource, as given to Graph.Transient or
+// Graph.Import.
+func (r *Resource) Name() string {
+	return r.name
+}
+
+// Transient tells if this resource is transient (i.e. it's texture is owned
+// by the graph) or imported.
+func (r *Resource) Transient() bool {
+	return r.imported == nil
+}
+
+// Texture returns the texture backing this resource. Imported resources
+// always return the imported texture.
+//
+// The texture of a transient resource may be shared with other transient
+// resources, as such it is only valid inside the Run function of a pass that
+// uses the resource; at all other times nil is returned.
+func (r *Resource) Texture() *gfx.Texture {
+	return r.tex
+}
@@ -0,0 +1,37 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package resource implements a manager for loading meshes, textures and
+// shaders.
+//
+// Instead of calling the Load methods of a renderer directly, resources are
+// loaded through a Manager by content key (e.g. a file path). The manager
+// decodes the source of each resource in a separate goroutine, shares it
+// between all loads of the same key, and uploads it to the graphics hardware
+// once decoded:
+//
+//  mgr := resource.NewManager(renderer)
+//  mgr.Budget = 256 << 20 // 256MB
+//
+//  tex := mgr.LoadTexture("grass.png", func() (*gfx.Texture, error) {
+//      return loadPNG("grass.png")
+//  })
+//  defer tex.Release()
+//
+//  // Each frame:
+//  mgr.Frame()
+//  if tex.State() == resource.Loaded {
+//      tex.Use()
+//      ... draw objects using tex.Texture() ...
+//  }
+//
+// Uploads are throttled per frame (see Manager.UploadsPerFrame), such that
+// streaming in many resources does not cause a hitch. When over the memory
+// budget the least recently used meshes and textures are evicted, and they
+// are loaded again (calling their source again if their data was not kept)
+// once used.
+//
+// Resources are reference counted: once all references to a resource are
+// released it is destroyed.
+package resource
@@ -0,0 +1,302 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package resource
+
+import (
+	"azul3d.org/v1/gfx"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Stats describes the resources of a manager.
+type Stats struct {
+	// The number of resources (that are not released) in each state.
+	Loaded, Pending, Evicted, Failed int
+
+	// The estimated number of bytes used on the graphics hardware by loaded
+	// (or uploading) resources.
+	Bytes int64
+
+	// The number of resources, and bytes, uploaded during the last frame.
+	Uploads       int
+	UploadedBytes int64
+
+	// The number of resources evicted during the last frame.
+	Evictions int
+}
+
+// Manager loads meshes, textures and shaders using a renderer. It decodes
+// their sources asynchronously, deduplicates them by content key, throttles
+// how many are uploaded per frame, and evicts the least recently used ones
+// when over a memory budget.
+//
+// The methods of a manager are safe to call from multiple goroutines
+// concurrently. The exported fields must be set before the manager is used.
+type Manager struct {
+	// The maximum number of resources uploaded per frame, or zero for no
+	// limit.
+	UploadsPerFrame int
+
+	// The maximum number of bytes uploaded per frame, or zero for no limit.
+	// At least one resource is uploaded per frame regardless, such that large
+	// resources are not starved.
+	UploadBytesPerFrame int64
+
+	// The memory budget in bytes, or zero for no limit. When over the budget
+	// the least recently used meshes and textures are evicted (shaders are
+	// never evicted). Resources used during the current frame are never
+	// evicted, so the budget may be exceeded temporarily.
+	Budget int64
+
+	access    sync.Mutex
+	r         gfx.Renderer
+	resources map[string]*Resource
+
+	// The decoded resources waiting to be uploaded, in order.
+	queue []*Resource
+
+	// The current frame number, and the bytes used by loaded resources.
+	frame uint64
+	bytes int64
+
+	// Statistics about the last frame.
+	last Stats
+
+	decoding sync.WaitGroup
+}
+
+// load returns the resource for the given key, creating it and decoding it's
+// source if needed.
+func (m *Manager) load(key string, kind Kind, init func(r *Resource)) *Resource {
+	m.access.Lock()
+	defer m.access.Unlock()
+	if r, ok := m.resources[key]; ok {
+		if r.kind != kind {
+			panic(fmt.Sprintf("resource: key %q is a %s, not a %s", key, r.kind, kind))
+		}
+		r.refs++
+		return r
+	}
+	r := &Resource{
+		m:        m,
+		key:      key,
+		kind:     kind,
+		refs:     1,
+		lastUsed: m.frame,
+		done:     make(chan struct{}),
+	}
+	init(r)
+	m.resources[key] = r
+	m.decode(r)
+	return r
+}
+
+// LoadMesh returns a reference to the mesh resource with the given content key
+// (e.g. the file path of the mesh, or a hash of it's data), which must be
+// released once no longer needed.
+//
+// If the key is not already loaded then the source function is called in a
+// separate goroutine to decode the mesh, which is then queued for upload (see
+// Frame). The source function is called again if the mesh is evicted and
+// then used again, unless the mesh keeps it's data on load.
+func (m *Manager) LoadMesh(key string, src func() (*gfx.Mesh, error)) *Resource {
+	return m.load(key, Mesh, func(r *Resource) {
+		r.meshSrc = src
+	})
+}
+
+// LoadTexture is like LoadMesh, except it loads a texture.
+func (m *Manager) LoadTexture(key string, src func() (*gfx.Texture, error)) *Resource {
+	return m.load(key, Texture, func(r *Resource) {
+		r.textureSrc = src
+	})
+}
+
+// LoadShader is like LoadMesh, except it loads a shader. Shaders are never
+// evicted.
+func (m *Manager) LoadShader(key string, src func() (*gfx.Shader, error)) *Resource {
+	return m.load(key, Shader, func(r *Resource) {
+		r.shaderSrc = src
+	})
+}
+
+// decode decodes the source of the given resource in a separate goroutine,
+// and then queues it for upload.
+//
+// The manager's lock must be held for this method to operate safely.
+func (m *Manager) decode(r *Resource) {
+	r.state = Decoding
+	m.decoding.Add(1)
+	go func() {
+		defer m.decoding.Done()
+		err := r.decode()
+
+		m.access.Lock()
+		defer m.access.Unlock()
+		if r.released {
+			return
+		}
+		if err != nil {
+			r.state = Failed
+			r.err = err
+			r.finish()
+			return
+		}
+		r.size = r.estimate()
+		r.state = Queued
+		m.queue = append(m.queue, r)
+	}()
+}
+
+// restore queues the given evicted resource for upload, decoding it's source
+// again if it's data was not kept.
+//
+// The manager's lock must be held for this method to operate safely.
+func (m *Manager) restore(r *Resource) {
+	if !r.hasData() {
+		m.decode(r)
+		return
+	}
+	r.state = Queued
+	m.queue = append(m.queue, r)
+}
+
+// uploaded is called once the upload of the given resource completes.
+func (m *Manager) uploaded(r *Resource, err error) {
+	m.access.Lock()
+	defer m.access.Unlock()
+	switch {
+	case r.released:
+		r.destroy()
+		return
+	case err != nil:
+		r.state = Failed
+		r.err = err
+		m.bytes -= r.size
+	default:
+		r.state = Loaded
+	}
+	r.finish()
+}
+
+// byLastUsed sorts resources by the frame they were last used in, least
+// recently used first.
+type byLastUsed []*Resource
+
+func (b byLastUsed) Len() int           { return len(b) }
+func (b byLastUsed) Less(i, j int) bool { return b[i].lastUsed < b[j].lastUsed }
+func (b byLastUsed) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+
+// evict evicts the least recently used meshes and textures, which were not
+// used during the current frame, until within the memory budget.
+//
+// The manager's lock must be held for this method to operate safely.
+func (m *Manager) evict() {
+	if m.Budget <= 0 || m.bytes <= m.Budget {
+		return
+	}
+	var candidates byLastUsed
+	for _, r := range m.resources {
+		if r.state == Loaded && r.kind != Shader && r.lastUsed < m.frame {
+			candidates = append(candidates, r)
+		}
+	}
+	sort.Sort(candidates)
+	for _, r := range candidates {
+		if m.bytes <= m.Budget {
+			break
+		}
+		r.destroy()
+		r.state = Evicted
+		m.bytes -= r.size
+		m.last.Evictions++
+	}
+}
+
+// Frame should be called once per frame (e.g. before rendering), it uploads
+// the queued resources (within the per-frame limits) and then evicts the least
+// recently used resources if over the memory budget.
+//
+// Resources used (see Resource.Use) since the last call to Frame are not
+// evicted.
+func (m *Manager) Frame() {
+	m.access.Lock()
+	m.last = Stats{}
+	var (
+		upload []*Resource
+		bytes  int64
+		i      int
+	)
+	for ; i < len(m.queue); i++ {
+		r := m.queue[i]
+		if r.released {
+			continue
+		}
+		if m.UploadsPerFrame > 0 && len(upload) >= m.UploadsPerFrame {
+			break
+		}
+		if m.UploadBytesPerFrame > 0 && len(upload) > 0 && bytes+r.size > m.UploadBytesPerFrame {
+			break
+		}
+		upload = append(upload, r)
+		bytes += r.size
+		r.state = Uploading
+		m.bytes += r.size
+	}
+	m.queue = append(m.queue[:0], m.queue[i:]...)
+	m.last.Uploads = len(upload)
+	m.last.UploadedBytes = bytes
+	m.evict()
+	m.frame++
+	m.access.Unlock()
+
+	// The renderer may block, so the lock is not held while uploading.
+	for _, r := range upload {
+		wait := r.upload(m.r)
+		go func(r *Resource) {
+			m.uploaded(r, wait())
+		}(r)
+	}
+}
+
+// Wait blocks until the sources of all of the resources being decoded are
+// decoded (but not necessarily uploaded), e.g. for a loading screen.
+func (m *Manager) Wait() {
+	m.decoding.Wait()
+}
+
+// Stats returns statistics about the resources of the manager, and about the
+// last frame.
+func (m *Manager) Stats() Stats {
+	m.access.Lock()
+	defer m.access.Unlock()
+	s := m.last
+	s.Bytes = m.bytes
+	for _, r := range m.resources {
+		switch r.state {
+		case Loaded:
+			s.Loaded++
+		case Evicted:
+			s.Evicted++
+		case Failed:
+			s.Failed++
+		default:
+			s.Pending++
+		}
+	}
+	return s
+}
+
+// NewManager returns a new resource manager using the given renderer, which
+// uploads at most 16 resources or 8MB per frame and has no memory budget.
+func NewManager(r gfx.Renderer) *Manager {
+	return &Manager{
+		UploadsPerFrame:     16,
+		UploadBytesPerFrame: 8 << 20,
+		r:                   r,
+		resources:           make(map[string]*Resource),
+	}
+}
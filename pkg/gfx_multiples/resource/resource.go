@@ -0,0 +1,438 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package resource
+
+import (
+	"azul3d.org/v1/gfx"
+	"fmt"
+)
+
+// Kind is the kind of graphics resource managed by a Resource.
+type Kind uint8
+
+const (
+	// Mesh is a *gfx.Mesh resource.
+	Mesh Kind = iota
+
+	// Texture is a *gfx.Texture resource.
+	Texture
+
+	// Shader is a *gfx.Shader resource.
+	Shader
+)
+
+// String returns a string representation of this kind.
+// e.g. Mesh -> "Mesh"
+func (k Kind) String() string {
+	switch k {
+	case Mesh:
+		return "Mesh"
+	case Texture:
+		return "Texture"
+	case Shader:
+		return "Shader"
+	}
+	return fmt.Sprintf("Kind(%d)", k)
+}
+
+// State is the loading state of a resource.
+type State uint8
+
+const (
+	// Decoding means the source of the resource is being decoded (i.e. it's
+	// source function is running).
+	Decoding State = iota
+
+	// Queued means the resource is decoded and waiting to be uploaded to the
+	// graphics hardware (see Manager.Frame).
+	Queued
+
+	// Uploading means the renderer is loading the resource.
+	Uploading
+
+	// Loaded means the resource is loaded and may be drawn.
+	Loaded
+
+	// Evicted means the resource was unloaded to stay within the memory
+	// budget, it is loaded again once used.
+	Evicted
+
+	// Failed means the source of the resource returned an error, or the
+	// shader failed to compile (see Resource.Err).
+	Failed
+)
+
+// String returns a string representation of this state.
+// e.g. Loaded -> "Loaded"
+func (s State) String() string {
+	switch s {
+	case Decoding:
+		return "Decoding"
+	case Queued:
+		return "Queued"
+	case Uploading:
+		return "Uploading"
+	case Loaded:
+		return "Loaded"
+	case Evicted:
+		return "Evicted"
+	case Failed:
+		return "Failed"
+	}
+	return fmt.Sprintf("State(%d)", s)
+}
+
+// Resource is a reference counted mesh, texture or shader managed by a
+// Manager. The same resource is returned for each load of the same key, until
+// all of the references to it are released.
+//
+// The methods of a resource are safe to call from multiple goroutines
+// concurrently.
+type Resource struct {
+	m    *Manager
+	key  string
+	kind Kind
+
+	// Exactly one of these is non-nil, depending on the kind. It is nil until
+	// the source is first decoded.
+	mesh    *gfx.Mesh
+	texture *gfx.Texture
+	shader  *gfx.Shader
+
+	// The source functions, of which one is non-nil depending on the kind.
+	meshSrc    func() (*gfx.Mesh, error)
+	textureSrc func() (*gfx.Texture, error)
+	shaderSrc  func() (*gfx.Shader, error)
+
+	// Each of the following fields is guarded by the manager's lock.
+
+	refs     int
+	state    State
+	err      error
+	size     int64
+	lastUsed uint64
+	released bool
+
+	// Closed once the resource is first loaded, or fails.
+	done chan struct{}
+}
+
+// Key returns the content key of the resource.
+func (r *Resource) Key() string {
+	return r.key
+}
+
+// Kind returns the kind of the resource.
+func (r *Resource) Kind() Kind {
+	return r.kind
+}
+
+// Mesh returns the mesh of this resource, or nil if it is not a mesh or it
+// has not yet been decoded. Once non-nil, the same mesh is always returned
+// (even after eviction) such that it may be stored in objects.
+func (r *Resource) Mesh() *gfx.Mesh {
+	r.m.access.Lock()
+	defer r.m.access.Unlock()
+	return r.mesh
+}
+
+// Texture returns the texture of this resource, or nil if it is not a
+// texture or it has not yet been decoded. Once non-nil, the same texture is
+// always returned (even after eviction) such that it may be stored in
+// objects.
+func (r *Resource) Texture() *gfx.Texture {
+	r.m.access.Lock()
+	defer r.m.access.Unlock()
+	return r.texture
+}
+
+// Shader returns the shader of this resource, or nil if it is not a shader or
+// it has not yet been decoded.
+func (r *Resource) Shader() *gfx.Shader {
+	r.m.access.Lock()
+	defer r.m.access.Unlock()
+	return r.shader
+}
+
+// State returns the current loading state of the resource.
+func (r *Resource) State() State {
+	r.m.access.Lock()
+	defer r.m.access.Unlock()
+	return r.state
+}
+
+// Err returns the error that caused the resource to fail to load, if any.
+func (r *Resource) Err() error {
+	r.m.access.Lock()
+	defer r.m.access.Unlock()
+	return r.err
+}
+
+// Size returns the estimated size in bytes of the resource on the graphics
+// hardware, or zero if it has not yet been decoded.
+func (r *Resource) Size() int64 {
+	r.m.access.Lock()
+	defer r.m.access.Unlock()
+	return r.size
+}
+
+// Done returns a channel which is closed once the resource is first loaded,
+// or fails to load.
+func (r *Resource) Done() <-chan struct{} {
+	return r.done
+}
+
+// Use marks the resource as used during the current frame, such that it is
+// not evicted before the next call to Manager.Frame. An evicted resource is
+// loaded again.
+func (r *Resource) Use() {
+	r.m.access.Lock()
+	defer r.m.access.Unlock()
+	r.lastUsed = r.m.frame
+	if r.state == Evicted && !r.released {
+		r.m.restore(r)
+	}
+}
+
+// Release releases a reference to the resource. Once all references are
+// released the resource is unloaded from the graphics hardware and a later
+// load of the same key starts over.
+func (r *Resource) Release() {
+	r.m.access.Lock()
+	defer r.m.access.Unlock()
+	if r.refs == 0 {
+		panic("resource: Release called too many times")
+	}
+	r.refs--
+	if r.refs > 0 {
+		return
+	}
+	r.released = true
+	delete(r.m.resources, r.key)
+	switch r.state {
+	case Loaded:
+		r.m.bytes -= r.size
+		r.destroy()
+	case Uploading:
+		// Destroyed once uploaded.
+		r.m.bytes -= r.size
+	}
+}
+
+// finish closes the done channel of the resource, if not already closed.
+func (r *Resource) finish() {
+	select {
+	case <-r.done:
+	default:
+		close(r.done)
+	}
+}
+
+// decode runs the source function of the resource. The first time, the
+// decoded object becomes the object of the resource. Later on (i.e. after
+// eviction), the data of the decoded object is restored into the existing
+// one, such that objects referencing it remain valid.
+//
+// The manager's lock must not be held.
+func (r *Resource) decode() error {
+	switch r.kind {
+	case Mesh:
+		m, err := r.meshSrc()
+		if err != nil {
+			return err
+		}
+		if r.mesh == nil {
+			r.m.access.Lock()
+			r.mesh = m
+			r.m.access.Unlock()
+			return nil
+		}
+		m.RLock()
+		r.mesh.Lock()
+		r.mesh.AABB = m.AABB
+		r.mesh.Indices = m.Indices
+		r.mesh.Vertices = m.Vertices
+		r.mesh.Colors = m.Colors
+		r.mesh.Bary = m.Bary
+		r.mesh.TexCoords = m.TexCoords
+		r.mesh.Normals = m.Normals
+		r.mesh.Attribs = m.Attribs
+		r.mesh.Unlock()
+		m.RUnlock()
+
+	case Texture:
+		t, err := r.textureSrc()
+		if err != nil {
+			return err
+		}
+		if r.texture == nil {
+			r.m.access.Lock()
+			r.texture = t
+			r.m.access.Unlock()
+			return nil
+		}
+		t.RLock()
+		r.texture.Lock()
+		r.texture.Bounds = t.Bounds
+		r.texture.Source = t.Source
+		r.texture.Format = t.Format
+		r.texture.WrapU = t.WrapU
+		r.texture.WrapV = t.WrapV
+		r.texture.BorderColor = t.BorderColor
+		r.texture.MinFilter = t.MinFilter
+		r.texture.MagFilter = t.MagFilter
+		r.texture.Unlock()
+		t.RUnlock()
+
+	case Shader:
+		s, err := r.shaderSrc()
+		if err != nil {
+			return err
+		}
+		r.m.access.Lock()
+		r.shader = s
+		r.m.access.Unlock()
+	}
+	return nil
+}
+
+// hasData tells if the resource still has it's data after being loaded, i.e.
+// if it can be loaded again without decoding it's source.
+func (r *Resource) hasData() bool {
+	switch r.kind {
+	case Mesh:
+		r.mesh.RLock()
+		defer r.mesh.RUnlock()
+		return len(r.mesh.Vertices) > 0
+	case Texture:
+		r.texture.RLock()
+		defer r.texture.RUnlock()
+		return r.texture.Source != nil
+	}
+	return false
+}
+
+// estimate estimates the size in bytes of the resource on the graphics
+// hardware, from it's data.
+func (r *Resource) estimate() int64 {
+	switch r.kind {
+	case Mesh:
+		m := r.mesh
+		m.RLock()
+		defer m.RUnlock()
+		n := int64(len(m.Indices)) * 4
+		n += int64(len(m.Vertices)+len(m.Bary)+len(m.Normals)) * 12
+		n += int64(len(m.Colors)) * 16
+		for _, set := range m.TexCoords {
+			n += int64(len(set.Slice)) * 8
+		}
+		for _, a := range m.Attribs {
+			n += attribSize(a)
+		}
+		return n
+
+	case Texture:
+		t := r.texture
+		t.RLock()
+		defer t.RUnlock()
+		pixels := int64(t.Bounds.Dx()) * int64(t.Bounds.Dy())
+		switch t.Format {
+		case gfx.RGB:
+			return pixels * 3
+		case gfx.DXT1, gfx.DXT1RGBA:
+			return pixels / 2
+		case gfx.DXT3, gfx.DXT5:
+			return pixels
+		}
+		return pixels * 4
+
+	case Shader:
+		s := r.shader
+		s.RLock()
+		defer s.RUnlock()
+		return int64(len(s.GLSLVert) + len(s.GLSLFrag))
+	}
+	return 0
+}
+
+// attribSize returns the size in bytes of the data of a vertex attribute.
+func attribSize(a gfx.VertexAttrib) int64 {
+	var elem int64
+	switch a.Data.(type) {
+	case []float32, []int32:
+		elem = 4
+	case []gfx.TexCoord:
+		elem = 8
+	case []gfx.Vec3:
+		elem = 12
+	case []gfx.Vec4:
+		elem = 16
+	}
+	return int64(a.Len()) * elem
+}
+
+// upload begins loading the resource using the given renderer, and returns a
+// function that blocks until the load completes and reports whether or not it
+// was successful.
+func (r *Resource) upload(rn gfx.Renderer) (wait func() error) {
+	switch r.kind {
+	case Mesh:
+		done := make(chan *gfx.Mesh, 1)
+		rn.LoadMesh(r.mesh, done)
+		return func() error {
+			<-done
+			return nil
+		}
+	case Texture:
+		done := make(chan *gfx.Texture, 1)
+		rn.LoadTexture(r.texture, done)
+		return func() error {
+			<-done
+			return nil
+		}
+	default:
+		done := make(chan *gfx.Shader, 1)
+		rn.LoadShader(r.shader, done)
+		return func() error {
+			s := <-done
+			s.RLock()
+			defer s.RUnlock()
+			if len(s.Error) > 0 {
+				return fmt.Errorf("resource: shader %q: %s", s.Name, s.Error)
+			}
+			return nil
+		}
+	}
+}
+
+// destroy destroys the native object of the resource.
+func (r *Resource) destroy() {
+	switch r.kind {
+	case Mesh:
+		r.mesh.Lock()
+		if r.mesh.NativeMesh != nil {
+			r.mesh.NativeMesh.Destroy()
+			r.mesh.NativeMesh = nil
+		}
+		r.mesh.Loaded = false
+		r.mesh.Unlock()
+	case Texture:
+		r.texture.Lock()
+		if r.texture.NativeTexture != nil {
+			r.texture.NativeTexture.Destroy()
+			r.texture.NativeTexture = nil
+		}
+		r.texture.Loaded = false
+		r.texture.Unlock()
+	case Shader:
+		r.shader.Lock()
+		if r.shader.NativeShader != nil {
+			r.shader.NativeShader.Destroy()
+			r.shader.NativeShader = nil
+		}
+		r.shader.Loaded = false
+		r.shader.Unlock()
+	}
+}
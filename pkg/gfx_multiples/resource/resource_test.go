@@ -0,0 +1,270 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package resource
+
+import (
+	"azul3d.org/v1/gfx"
+	"errors"
+	"fmt"
+	"image"
+	"sync"
+	"testing"
+)
+
+// counter counts the calls to source functions.
+type counter struct {
+	sync.Mutex
+	calls map[string]int
+}
+
+func (c *counter) count(key string) {
+	c.Lock()
+	c.calls[key]++
+	c.Unlock()
+}
+
+func (c *counter) get(key string) int {
+	c.Lock()
+	defer c.Unlock()
+	return c.calls[key]
+}
+
+func newCounter() *counter {
+	return &counter{calls: make(map[string]int)}
+}
+
+// texture returns a source function of a 16x16 RGBA texture (1024 bytes).
+func (c *counter) texture(key string) func() (*gfx.Texture, error) {
+	return func() (*gfx.Texture, error) {
+		c.count(key)
+		img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+		return &gfx.Texture{
+			Bounds: img.Bounds(),
+			Source: img,
+		}, nil
+	}
+}
+
+// load loads all of the queued resources and waits for them.
+func load(m *Manager, res ...*Resource) {
+	m.Wait()
+	m.Frame()
+	for _, r := range res {
+		<-r.Done()
+	}
+}
+
+func TestDedupe(t *testing.T) {
+	m := NewManager(gfx.Nil())
+	c := newCounter()
+	a := m.LoadTexture("a", c.texture("a"))
+	b := m.LoadTexture("a", c.texture("a"))
+	if a != b {
+		t.Fatal("same key loaded twice")
+	}
+	load(m, a)
+	if n := c.get("a"); n != 1 {
+		t.Fatalf("source called %d times", n)
+	}
+	if s := a.State(); s != Loaded {
+		t.Fatalf("got state %v", s)
+	}
+	tex := a.Texture()
+	if !tex.Loaded || tex.Source != nil {
+		t.Fatal("texture not loaded, or data not cleared")
+	}
+	if s := a.Size(); s != 16*16*4 {
+		t.Fatalf("got size %d", s)
+	}
+
+	// Resources are destroyed once all references are released.
+	a.Release()
+	if a.Texture().Loaded != true {
+		t.Fatal("texture destroyed with a reference left")
+	}
+	b.Release()
+	if a.Texture().Loaded {
+		t.Fatal("texture not destroyed")
+	}
+	if s := m.Stats(); s.Loaded != 0 || s.Bytes != 0 {
+		t.Fatalf("got stats %+v after release", s)
+	}
+	d := m.LoadTexture("a", c.texture("a"))
+	if d == a {
+		t.Fatal("released resource returned again")
+	}
+	d.Release()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for a key of a different kind")
+		}
+	}()
+	m.LoadTexture("x", c.texture("x"))
+	m.LoadMesh("x", nil)
+}
+
+func TestThrottle(t *testing.T) {
+	m := NewManager(gfx.Nil())
+	m.UploadsPerFrame = 3
+	c := newCounter()
+	var res []*Resource
+	for i := 0; i < 8; i++ {
+		key := fmt.Sprint(i)
+		res = append(res, m.LoadTexture(key, c.texture(key)))
+	}
+	m.Wait()
+
+	for _, want := range []int{3, 3, 2, 0} {
+		m.Frame()
+		if s := m.Stats(); s.Uploads != want {
+			t.Fatalf("got %d uploads, want %d", s.Uploads, want)
+		}
+	}
+	for _, r := range res {
+		<-r.Done()
+	}
+
+	// At least one upload per frame, even if over the byte limit.
+	m.UploadsPerFrame = 0
+	m.UploadBytesPerFrame = 1500
+	for i := 0; i < 3; i++ {
+		key := fmt.Sprint("b", i)
+		m.LoadTexture(key, c.texture(key))
+	}
+	m.Wait()
+	for _, want := range []int{1, 1, 1, 0} {
+		m.Frame()
+		if s := m.Stats(); s.Uploads != want {
+			t.Fatalf("got %d uploads, want %d", s.Uploads, want)
+		}
+	}
+}
+
+func TestEvict(t *testing.T) {
+	m := NewManager(gfx.Nil())
+	m.Budget = 2048
+	c := newCounter()
+	a := m.LoadTexture("a", c.texture("a"))
+	b := m.LoadTexture("b", c.texture("b"))
+	load(m, a, b)
+
+	// Over budget: the least recently used, unused texture is evicted.
+	a.Use()
+	m.Frame()
+	b.Use()
+	cr := m.LoadTexture("c", c.texture("c"))
+	load(m, cr)
+	cr.Use()
+	m.Frame()
+	if s := a.State(); s != Evicted {
+		t.Fatalf("got state %v for a", s)
+	}
+	if b.State() != Loaded || cr.State() != Loaded {
+		t.Fatal("used textures evicted")
+	}
+	if s := m.Stats(); s.Bytes != 2048 || s.Evicted != 1 {
+		t.Fatalf("got stats %+v", s)
+	}
+	if a.Texture().Loaded {
+		t.Fatal("evicted texture is loaded")
+	}
+
+	// Using an evicted texture decodes and loads it again, into the same
+	// texture.
+	tex := a.Texture()
+	a.Use()
+	m.Wait()
+	m.Frame()
+	if n := c.get("a"); n != 2 {
+		t.Fatalf("source called %d times", n)
+	}
+	if a.Texture() != tex {
+		t.Fatal("texture replaced")
+	}
+	for a.State() != Loaded {
+		m.Frame()
+	}
+}
+
+func TestDecodeAgain(t *testing.T) {
+	m := NewManager(gfx.Nil())
+	m.Budget = 1024
+	c := newCounter()
+	src := func() (*gfx.Texture, error) {
+		c.count("a")
+		img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+		tex := &gfx.Texture{
+			Bounds:    img.Bounds(),
+			Source:    img,
+			Format:    gfx.RGBA,
+			MinFilter: gfx.Nearest,
+			MagFilter: gfx.Nearest,
+		}
+		if c.get("a") > 1 {
+			// The source has changed since it was first decoded.
+			tex.Format = gfx.DXT1
+			tex.WrapU, tex.WrapV = gfx.Clamp, gfx.Mirror
+			tex.BorderColor = gfx.Color{1, 0, 0, 1}
+			tex.MinFilter, tex.MagFilter = gfx.LinearMipmapLinear, gfx.Linear
+		}
+		return tex, nil
+	}
+	a := m.LoadTexture("a", src)
+	load(m, a)
+	a.Use()
+	m.Frame()
+
+	// Evict it, by using another texture over budget.
+	b := m.LoadTexture("b", c.texture("b"))
+	load(m, b)
+	b.Use()
+	m.Frame()
+	if s := a.State(); s != Evicted {
+		t.Fatalf("got state %v for a", s)
+	}
+
+	// Decoding it again updates the format, wrap modes and filters too.
+	a.Use()
+	m.Wait()
+	m.Frame()
+	if n := c.get("a"); n != 2 {
+		t.Fatalf("source called %d times", n)
+	}
+	tex := a.Texture()
+	tex.RLock()
+	defer tex.RUnlock()
+	if tex.Format != gfx.DXT1 || tex.WrapU != gfx.Clamp || tex.WrapV != gfx.Mirror {
+		t.Fatalf("got format %v and wrap modes %v %v", tex.Format, tex.WrapU, tex.WrapV)
+	}
+	if tex.BorderColor != (gfx.Color{1, 0, 0, 1}) || tex.MinFilter != gfx.LinearMipmapLinear || tex.MagFilter != gfx.Linear {
+		t.Fatalf("got border color %v and filters %v %v", tex.BorderColor, tex.MinFilter, tex.MagFilter)
+	}
+}
+
+func TestFailure(t *testing.T) {
+	m := NewManager(gfx.Nil())
+	r := m.LoadMesh("bad", func() (*gfx.Mesh, error) {
+		return nil, errors.New("bad mesh")
+	})
+	<-r.Done()
+	if r.State() != Failed || r.Err() == nil {
+		t.Fatalf("got state %v and error %v", r.State(), r.Err())
+	}
+	if s := m.Stats(); s.Failed != 1 {
+		t.Fatalf("got stats %+v", s)
+	}
+
+	s := m.LoadShader("shader", func() (*gfx.Shader, error) {
+		s := gfx.NewShader("shader")
+		s.GLSLVert = []byte("void main() {}")
+		s.GLSLFrag = []byte("void main() {}")
+		return s, nil
+	})
+	load(m, s)
+	if s.State() != Loaded || !s.Shader().Loaded {
+		t.Fatalf("got state %v", s.State())
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package scenegraph implements a hierarchical scene graph.
+//
+// A scene graph is a tree of named nodes, each node owns graphics objects and
+// child nodes. Transformations are inherited through gfx.Transform parents:
+// the transform of a node is the parent of the transforms of it's children and
+// objects, such that moving a node moves everything below it:
+//
+//  root := scenegraph.New("level")
+//  car := root.New("car")
+//  car.Add(body)
+//  car.New("wheels").Add(frontLeft, frontRight, backLeft, backRight)
+//
+//  // Moves the body and the wheels.
+//  car.Transform().SetPos(math.Vec3{10, 0, 0})
+//
+// Nodes may be found by their path relative to another node, or by their tags:
+//
+//  wheels := root.Find("car/wheels")
+//  for _, n := range root.FindTagged("vehicle") {
+//      ...
+//  }
+//
+// Hidden nodes (see SetVisible) and all of their descendants are not drawn.
+//
+// The graph is traversed using depth-first or breadth-first visitors, which
+// is also how loaders (e.g. of tile maps or model formats) build their graphs:
+// one node per map layer or model node, with the objects of that layer or
+// node added to it.
+package scenegraph
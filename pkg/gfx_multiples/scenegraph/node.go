@@ -0,0 +1,351 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scenegraph
+
+import (
+	"azul3d.org/v1/gfx"
+	"image"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// access guards the fields of every node. A single lock is used such that
+// nodes may be moved between graphs without having to order locks.
+var access sync.RWMutex
+
+// Node is a single node of a scene graph. It is safe to use from multiple
+// goroutines concurrently.
+type Node struct {
+	name      string
+	parent    *Node
+	children  []*Node
+	objects   []*gfx.Object
+	tags      map[string]bool
+	hidden    bool
+	transform *gfx.Transform
+}
+
+// Name returns the name of this node.
+func (n *Node) Name() string {
+	access.RLock()
+	name := n.name
+	access.RUnlock()
+	return name
+}
+
+// SetName sets the name of this node. Names should not contain a slash, as
+// they could not be found by path otherwise (see Find).
+func (n *Node) SetName(name string) {
+	access.Lock()
+	n.name = name
+	access.Unlock()
+}
+
+// Transform returns the transform of this node. It's parent is the transform
+// of this node's parent, and it is the parent of the transforms of this
+// node's children and objects.
+//
+// The parent of the returned transform must not be changed, use SetParent
+// instead.
+func (n *Node) Transform() *gfx.Transform {
+	return n.transform
+}
+
+// Parent returns the parent of this node, or nil if it is a root node.
+func (n *Node) Parent() *Node {
+	access.RLock()
+	p := n.parent
+	access.RUnlock()
+	return p
+}
+
+// Root returns the root node of the graph this node is in, which is this node
+// itself if it has no parent.
+func (n *Node) Root() *Node {
+	access.RLock()
+	defer access.RUnlock()
+	for n.parent != nil {
+		n = n.parent
+	}
+	return n
+}
+
+// Children returns a new slice of the children of this node.
+func (n *Node) Children() []*Node {
+	access.RLock()
+	c := make([]*Node, len(n.children))
+	copy(c, n.children)
+	access.RUnlock()
+	return c
+}
+
+// setParent sets the parent of this node. The lock must be held.
+func (n *Node) setParent(p *Node) {
+	if n.parent == p {
+		return
+	}
+	for a := p; a != nil; a = a.parent {
+		if a == n {
+			panic("scenegraph: SetParent would create a cycle")
+		}
+	}
+	if n.parent != nil {
+		for i, c := range n.parent.children {
+			if c == n {
+				n.parent.children = append(n.parent.children[:i], n.parent.children[i+1:]...)
+				break
+			}
+		}
+	}
+	n.parent = p
+	if p != nil {
+		p.children = append(p.children, n)
+		n.transform.SetParent(p.transform)
+	} else {
+		n.transform.SetParent(nil)
+	}
+}
+
+// SetParent makes this node the last child of the given node, removing it
+// from it's current parent. If p is nil then this node is detached and
+// becomes a root node.
+//
+// The parent must not be this node itself or one of it's descendants, or else
+// a panic will occur.
+func (n *Node) SetParent(p *Node) {
+	access.Lock()
+	defer access.Unlock()
+	n.setParent(p)
+}
+
+// Detach detaches this node from it's parent, it is short-hand for:
+//  n.SetParent(nil)
+func (n *Node) Detach() {
+	n.SetParent(nil)
+}
+
+// New creates and returns a new node with the given name, as the last child of
+// this node.
+func (n *Node) New(name string) *Node {
+	c := New(name)
+	c.SetParent(n)
+	return c
+}
+
+// Add adds the given objects to this node. The parent of each object's
+// transform becomes the transform of this node (a transform is created for
+// objects that have none).
+//
+// An object should only be added to a single node, as it's transform can only
+// have one parent.
+func (n *Node) Add(objects ...*gfx.Object) {
+	for _, o := range objects {
+		o.Lock()
+		if o.Transform == nil {
+			o.Transform = gfx.NewTransform()
+		}
+		o.Transform.SetParent(n.transform)
+		o.Unlock()
+	}
+	access.Lock()
+	n.objects = append(n.objects, objects...)
+	access.Unlock()
+}
+
+// Remove removes the given object from this node, the parent of it's
+// transform is set to nil. False is returned if the object is not in this
+// node.
+func (n *Node) Remove(o *gfx.Object) bool {
+	access.Lock()
+	found := false
+	for i, other := range n.objects {
+		if other == o {
+			n.objects = append(n.objects[:i], n.objects[i+1:]...)
+			found = true
+			break
+		}
+	}
+	access.Unlock()
+	if !found {
+		return false
+	}
+	o.Lock()
+	if o.Transform != nil && o.Transform.Parent() == n.transform {
+		o.Transform.SetParent(nil)
+	}
+	o.Unlock()
+	return true
+}
+
+// Objects returns a new slice of the objects of this node (and not those of
+// it's children).
+func (n *Node) Objects() []*gfx.Object {
+	access.RLock()
+	o := make([]*gfx.Object, len(n.objects))
+	copy(o, n.objects)
+	access.RUnlock()
+	return o
+}
+
+// Tag adds the given tags to this node.
+func (n *Node) Tag(tags ...string) {
+	access.Lock()
+	if n.tags == nil {
+		n.tags = make(map[string]bool, len(tags))
+	}
+	for _, t := range tags {
+		n.tags[t] = true
+	}
+	access.Unlock()
+}
+
+// Untag removes the given tags from this node.
+func (n *Node) Untag(tags ...string) {
+	access.Lock()
+	for _, t := range tags {
+		delete(n.tags, t)
+	}
+	access.Unlock()
+}
+
+// HasTag tells if this node has the given tag.
+func (n *Node) HasTag(tag string) bool {
+	access.RLock()
+	has := n.tags[tag]
+	access.RUnlock()
+	return has
+}
+
+// Tags returns a sorted slice of the tags of this node.
+func (n *Node) Tags() []string {
+	access.RLock()
+	tags := make([]string, 0, len(n.tags))
+	for t := range n.tags {
+		tags = append(tags, t)
+	}
+	access.RUnlock()
+	sort.Strings(tags)
+	return tags
+}
+
+// SetVisible sets whether or not this node, and in effect all of it's
+// descendants, are visible. Nodes are visible by default.
+func (n *Node) SetVisible(visible bool) {
+	access.Lock()
+	n.hidden = !visible
+	access.Unlock()
+}
+
+// Visible tells if this node is visible, as set by SetVisible. Note that a
+// visible node is still not drawn if one of it's ancestors is hidden, see
+// Shown.
+func (n *Node) Visible() bool {
+	access.RLock()
+	v := !n.hidden
+	access.RUnlock()
+	return v
+}
+
+// Shown tells if this node is visible and so are all of it's ancestors, i.e.
+// whether or not it's objects are drawn when the root node is drawn.
+func (n *Node) Shown() bool {
+	access.RLock()
+	defer access.RUnlock()
+	for ; n != nil; n = n.parent {
+		if n.hidden {
+			return false
+		}
+	}
+	return true
+}
+
+// Path returns the path of this node relative to the root node, such that:
+//  n.Root().Find(n.Path()) == n
+//
+// As long as the names along the path are unique among their siblings.
+func (n *Node) Path() string {
+	access.RLock()
+	var names []string
+	for ; n.parent != nil; n = n.parent {
+		names = append(names, n.name)
+	}
+	access.RUnlock()
+	for i, j := 0, len(names)-1; i < j; i, j = i+1, j-1 {
+		names[i], names[j] = names[j], names[i]
+	}
+	return strings.Join(names, "/")
+}
+
+// Find finds the node at the given slash-separated path relative to this node,
+// for instance:
+//  n.Find("car/wheels")
+//
+// Returns the first child of n named "car", and then the first child of that
+// node named "wheels". The special name ".." refers to the parent of a node,
+// and empty names (e.g. of a trailing slash) are ignored.
+//
+// If there is no node at the given path, nil is returned.
+func (n *Node) Find(path string) *Node {
+	access.RLock()
+	defer access.RUnlock()
+	for _, name := range strings.Split(path, "/") {
+		switch name {
+		case "":
+			continue
+		case "..":
+			n = n.parent
+		default:
+			var found *Node
+			for _, c := range n.children {
+				if c.name == name {
+					found = c
+					break
+				}
+			}
+			n = found
+		}
+		if n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+// FindTagged returns all of the nodes at or below this one (in depth-first
+// order) that have the given tag.
+func (n *Node) FindTagged(tag string) []*Node {
+	var found []*Node
+	n.DepthFirst(func(n *Node) bool {
+		if n.HasTag(tag) {
+			found = append(found, n)
+		}
+		return true
+	})
+	return found
+}
+
+// Draw draws the objects of this node and all of it's descendants, in
+// depth-first order, to the given canvas. Hidden nodes and their descendants
+// are skipped, but the ancestors of this node are not considered.
+func (n *Node) Draw(c gfx.Canvas, r image.Rectangle, cam *gfx.Camera) {
+	n.DepthFirst(func(n *Node) bool {
+		if !n.Visible() {
+			return false
+		}
+		for _, o := range n.Objects() {
+			c.Draw(r, o, cam)
+		}
+		return true
+	})
+}
+
+// New creates and returns a new root node with the given name.
+func New(name string) *Node {
+	return &Node{
+		name:      name,
+		transform: gfx.NewTransform(),
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scenegraph
+
+import (
+	"azul3d.org/v1/gfx"
+	"azul3d.org/v1/math"
+	"reflect"
+	"testing"
+)
+
+// tree builds the graph:
+//  root
+//      a
+//          c
+//          d
+//      b
+//          e
+func tree() (root, a, b, c, d, e *Node) {
+	root = New("root")
+	a = root.New("a")
+	b = root.New("b")
+	c = a.New("c")
+	d = a.New("d")
+	e = b.New("e")
+	return
+}
+
+func names(nodes []*Node) []string {
+	var s []string
+	for _, n := range nodes {
+		s = append(s, n.Name())
+	}
+	return s
+}
+
+func TestTraversal(t *testing.T) {
+	root, a, _, _, _, _ := tree()
+	var dfs, bfs []*Node
+	root.DepthFirst(func(n *Node) bool {
+		dfs = append(dfs, n)
+		return true
+	})
+	root.BreadthFirst(func(n *Node) bool {
+		bfs = append(bfs, n)
+		return n != a
+	})
+	if got, want := names(dfs), []string{"root", "a", "c", "d", "b", "e"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("depth-first got %v want %v", got, want)
+	}
+	if got, want := names(bfs), []string{"root", "a", "b", "e"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("breadth-first got %v want %v", got, want)
+	}
+}
+
+func TestFind(t *testing.T) {
+	root, a, b, c, _, e := tree()
+	if root.Find("a/c") != c {
+		t.Fatal("a/c not found")
+	}
+	if c.Find("../../b/e/") != e {
+		t.Fatal("../../b/e/ not found")
+	}
+	if root.Find("a/e") != nil || root.Find("..") != nil {
+		t.Fatal("found a node that does not exist")
+	}
+	if p := e.Path(); p != "b/e" || root.Find(p) != e {
+		t.Fatalf("got path %q", p)
+	}
+
+	a.Tag("wheel", "round")
+	e.Tag("wheel")
+	b.Tag("wheel")
+	b.Untag("wheel")
+	if got := root.FindTagged("wheel"); !reflect.DeepEqual(got, []*Node{a, e}) {
+		t.Fatalf("got tagged nodes %v", names(got))
+	}
+	if got := a.Tags(); !reflect.DeepEqual(got, []string{"round", "wheel"}) {
+		t.Fatalf("got tags %v", got)
+	}
+}
+
+func TestReparent(t *testing.T) {
+	root, a, b, c, _, _ := tree()
+	c.SetParent(b)
+	if c.Parent() != b || c.Transform().Parent() != b.Transform() {
+		t.Fatal("node not reparented")
+	}
+	if got := names(a.Children()); !reflect.DeepEqual(got, []string{"d"}) {
+		t.Fatalf("old parent has children %v", got)
+	}
+	b.Detach()
+	if b.Root() != b || c.Root() != b || root.Find("b") != nil {
+		t.Fatal("node not detached")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic creating a cycle")
+		}
+	}()
+	b.SetParent(c)
+}
+
+func TestTransformInheritance(t *testing.T) {
+	root, a, _, c, _, _ := tree()
+	o := gfx.NewObject()
+	o.Transform.SetPos(math.Vec3{0, 0, 1})
+	c.Add(o)
+
+	root.Transform().SetPos(math.Vec3{1, 0, 0})
+	a.Transform().SetPos(math.Vec3{0, 1, 0})
+	want := math.Vec3{1, 1, 1}
+	if got := o.Transform.ConvertPos(math.Vec3{}, gfx.LocalToWorld); !got.Equals(want) {
+		t.Fatalf("got world position %v want %v", got, want)
+	}
+
+	if !c.Remove(o) || c.Remove(o) {
+		t.Fatal("object not removed once")
+	}
+	if o.Transform.Parent() != nil {
+		t.Fatal("removed object still has a parent transform")
+	}
+}
+
+func TestDrawVisible(t *testing.T) {
+	root, a, b, c, _, e := tree()
+	for _, n := range []*Node{root, a, b, c, e} {
+		o := gfx.NewObject()
+		o.Meshes = []*gfx.Mesh{new(gfx.Mesh)}
+		n.Add(o)
+	}
+	a.SetVisible(false)
+	if c.Visible() != true || c.Shown() != false || e.Shown() != true {
+		t.Fatal("wrong visibility")
+	}
+
+	r := gfx.Nil()
+	root.Draw(r, r.Bounds(), nil)
+	r.Render()
+	if s := r.Stats(); s.DrawCalls != 3 {
+		t.Fatalf("got %d draw calls want 3", s.DrawCalls)
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package scenegraph
+
+// Visitor is invoked for each node visited during a traversal of the graph. If
+// it returns false then the children of the node are not visited.
+//
+// The graph is not locked while the visitor is invoked, such that it may
+// modify the graph. Children added to a node that is being visited are not
+// guaranteed to be visited.
+type Visitor func(n *Node) bool
+
+// DepthFirst performs a depth-first (pre-order) traversal of the graph,
+// beginning at this node and visiting children in order.
+func (n *Node) DepthFirst(v Visitor) {
+	if !v(n) {
+		return
+	}
+	for _, c := range n.Children() {
+		c.DepthFirst(v)
+	}
+}
+
+// BreadthFirst performs a breadth-first traversal of the graph, beginning at
+// this node and visiting all of the nodes at each level before the next one.
+func (n *Node) BreadthFirst(v Visitor) {
+	queue := []*Node{n}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue[0] = nil
+		queue = queue[1:]
+		if v(n) {
+			queue = append(queue, n.Children()...)
+		}
+	}
+}
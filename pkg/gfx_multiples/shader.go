@@ -50,6 +50,12 @@ type Shader struct {
 	//  []gfx.Vec3
 	//  gfx.Mat4
 	//  []gfx.Mat4
+	//
+	// Renderers may validate the inputs against the uniforms declared by the
+	// shader sources before drawing (see the glsl package), in which case
+	// the shader is not drawn while it has unknown input names, mismatched
+	// types or uniforms without an input. Such errors are reported by the
+	// renderer, and are not part of the error log.
 	Inputs map[string]interface{}
 
 	// The error log from compiling the shader program, if any. Only set once
@@ -0,0 +1,132 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shadow
+
+import (
+	"azul3d.org/v1/gfx"
+	gmath "azul3d.org/v1/math"
+	"math"
+)
+
+// Splits returns the n+1 distances splitting the view range [near, far] into
+// n cascades. The lambda parameter blends between uniform splits (zero) and
+// logarithmic splits (one), the latter giving closer cascades more of the
+// shadow map resolution. The first and last distances are always near and
+// far.
+func Splits(near, far float64, n int, lambda float64) []float64 {
+	s := make([]float64, n+1)
+	for i := range s {
+		f := float64(i) / float64(n)
+		log := near * math.Pow(far/near, f)
+		uniform := near + (far-near)*f
+		s[i] = lambda*log + (1-lambda)*uniform
+	}
+	s[0], s[n] = near, far
+	return s
+}
+
+// Cascades is a set of cascaded shadow maps for a directional light: the view
+// frustum of a camera is split by distance into several parts, each of which
+// is covered by it's own shadow map.
+type Cascades struct {
+	// The shadow maps, from nearest to furthest.
+	Maps []*Map
+
+	// The split scheme, see Splits.
+	Lambda float64
+
+	// The maximum distance from the camera at which shadows are rendered, or
+	// zero for the camera's far clipping plane.
+	MaxDistance float64
+
+	// The split distances, the position and the view direction of the camera
+	// as computed by the last call to Fit.
+	splits       []float64
+	eye, forward gmath.Vec3
+}
+
+// Splits returns the split distances computed by the last call to Fit: the
+// view distance at which each cascade begins, followed by the distance at
+// which the last one ends.
+func (c *Cascades) Splits() []float64 {
+	s := make([]float64, len(c.splits))
+	copy(s, c.splits)
+	return s
+}
+
+// Fit splits the view frustum of the given camera and fits each shadow map to
+// it's part of the frustum (see Map.FitDirectional), for a directional light
+// shining in the given direction. If the camera's matrices are not
+// invertible then false is returned and the shadow maps are not modified.
+//
+// The camera's read lock must be held for this method to operate safely.
+func (c *Cascades) Fit(dir gmath.Vec3, cam *gfx.Camera) bool {
+	corners, ok := cam.FrustumCorners()
+	if !ok {
+		return false
+	}
+	center := func(c []gmath.Vec3) gmath.Vec3 {
+		return c[0].Add(c[1]).Add(c[2]).Add(c[3]).MulScalar(0.25)
+	}
+	nearCenter, farCenter := center(corners[:4]), center(corners[4:])
+	forward, ok := farCenter.Sub(nearCenter).Normalized()
+	if !ok {
+		return false
+	}
+	eye := cam.Transform.Mat4().Translation()
+	near := nearCenter.Sub(eye).Dot(forward)
+	far := farCenter.Sub(eye).Dot(forward)
+	end := far
+	if c.MaxDistance > 0 && c.MaxDistance < far {
+		end = c.MaxDistance
+	}
+
+	c.splits = Splits(near, end, len(c.Maps), c.Lambda)
+	c.eye, c.forward = eye, forward
+	lerp := func(a, b gmath.Vec3, t float64) gmath.Vec3 {
+		return a.Add(b.Sub(a).MulScalar(t))
+	}
+	points := make([]gmath.Vec3, 8)
+	for i, m := range c.Maps {
+		t0 := (c.splits[i] - near) / (far - near)
+		t1 := (c.splits[i+1] - near) / (far - near)
+		for j := 0; j < 4; j++ {
+			points[j] = lerp(corners[j], corners[j+4], t0)
+			points[j+4] = lerp(corners[j], corners[j+4], t1)
+		}
+		m.FitDirectional(dir, points)
+	}
+	return true
+}
+
+// Render renders each shadow map with the given casters (see Map.Render).
+func (c *Cascades) Render(r gfx.Renderer, casters []*gfx.Object) {
+	for _, m := range c.Maps {
+		m.Render(r, casters)
+	}
+}
+
+// Textures returns the texture of each shadow map, to be appended to the
+// textures of objects sampling the shadows (see GLSL).
+func (c *Cascades) Textures() []*gfx.Texture {
+	t := make([]*gfx.Texture, len(c.Maps))
+	for i, m := range c.Maps {
+		t[i] = m.Texture
+	}
+	return t
+}
+
+// NewCascades returns a new set of n cascaded shadow maps, each of the given
+// size in texels, using splits half way between uniform and logarithmic.
+func NewCascades(n, size int) *Cascades {
+	c := &Cascades{
+		Maps:   make([]*Map, n),
+		Lambda: 0.5,
+	}
+	for i := range c.Maps {
+		c.Maps[i] = NewMap(size)
+	}
+	return c
+}
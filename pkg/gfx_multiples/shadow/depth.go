@@ -0,0 +1,93 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shadow
+
+import (
+	"azul3d.org/v1/gfx"
+	"image/color"
+	"math"
+)
+
+var glslDepthVert = []byte(`
+#version 120
+
+attribute vec3 Vertex;
+
+uniform mat4 MVP;
+
+void main()
+{
+	gl_Position = MVP * vec4(Vertex, 1.0);
+}
+`)
+
+var glslDepthFrag = []byte(`
+#version 120
+
+void main()
+{
+	float d = min(gl_FragCoord.z, 0.99999);
+	vec4 enc = fract(vec4(1.0, 255.0, 65025.0, 16581375.0) * d);
+	enc -= enc.yzww * vec4(1.0 / 255.0, 1.0 / 255.0, 1.0 / 255.0, 0.0);
+	gl_FragColor = enc;
+}
+`)
+
+// glslUnpack is the GLSL function decoding a depth value written by the depth
+// shader, it is the inverse of PackDepth.
+const glslUnpack = `
+float shadowUnpack(vec4 c)
+{
+	return dot(c, vec4(1.0, 1.0 / 255.0, 1.0 / 65025.0, 1.0 / 16581375.0));
+}
+`
+
+// maxDepth is the largest depth value that can be packed.
+const maxDepth = 0.99999
+
+// PackDepth encodes the given depth value, in the range [0, 1], into the four
+// 8-bit channels of a color exactly as the depth shader does when rendering a
+// shadow map.
+//
+// Depth values of one (or more) cannot be represented, they are clamped to
+// just below one. A cleared (opaque white) shadow map unpacks to a depth
+// beyond one.
+func PackDepth(d float64) color.RGBA {
+	d = math.Max(0, math.Min(d, maxDepth))
+	var enc [4]float64
+	for i, s := range [4]float64{1, 255, 65025, 16581375} {
+		_, enc[i] = math.Modf(s * d)
+	}
+	for i := 0; i < 3; i++ {
+		enc[i] -= enc[i+1] / 255
+	}
+	q := func(v float64) uint8 {
+		return uint8(math.Max(0, math.Min(v*255+0.5, 255)))
+	}
+	return color.RGBA{q(enc[0]), q(enc[1]), q(enc[2]), q(enc[3])}
+}
+
+// UnpackDepth decodes a depth value from a color in a shadow map, it is the
+// inverse of PackDepth.
+func UnpackDepth(c color.RGBA) float64 {
+	return float64(c.R)/255 +
+		float64(c.G)/255/255 +
+		float64(c.B)/255/65025 +
+		float64(c.A)/255/16581375
+}
+
+// ClearColor is the color shadow maps are cleared to, which unpacks to a depth
+// beyond any rendered one (i.e. nothing casts a shadow).
+var ClearColor = gfx.Color{1, 1, 1, 1}
+
+// NewDepthShader returns a new shader which writes the window-space depth of
+// each fragment, packed into the four color channels (see PackDepth). It is
+// used to render shadow maps and requires only the vertices of meshes.
+func NewDepthShader() *gfx.Shader {
+	s := gfx.NewShader("shadow.Depth")
+	s.GLSLVert = glslDepthVert
+	s.GLSLFrag = glslDepthFrag
+	return s
+}
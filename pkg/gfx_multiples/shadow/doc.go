@@ -0,0 +1,40 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package shadow implements shadow mapping.
+//
+// A shadow map (see Map) is the depth of the shadow casters as seen by a
+// light's camera, rendered into a texture using RenderToTexture. Since
+// textures have no depth format, the depth is packed into the four 8-bit
+// color channels of the texture (see PackDepth).
+//
+// Directional lights (e.g. the sun) use cascaded shadow maps: the view
+// frustum of the camera is split by distance and each part is covered by it's
+// own shadow map, such that nearby shadows receive more resolution:
+//
+//  csm := shadow.NewCascades(4, 1024)
+//  csm.MaxDistance = 200
+//
+//  // Each frame:
+//  csm.Fit(sunDir, camera)
+//  csm.Render(renderer, casters)
+//  csm.SetInputs(shader.Inputs)
+//
+// Spot lights use a single shadow map with a perspective projection (see
+// Map.FitSpot).
+//
+// Shaders sample the shadow maps using the GLSL source returned by GLSL,
+// inserted into their fragment shader, with the shadow map textures appended
+// to the textures of the object:
+//
+//  frag := "... varying vec3 worldPos;" + shadow.GLSL(4, 1) + `
+//  void main()
+//  {
+//      float lit = shadow(worldPos);
+//      ...
+//  }`
+//
+// The sampling is mirrored in software by Map.Project and Lit, such that
+// shadow maps downloaded from a renderer can be inspected and tested.
+package shadow
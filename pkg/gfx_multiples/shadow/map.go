@@ -0,0 +1,275 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shadow
+
+import (
+	"azul3d.org/v1/gfx"
+	gmath "azul3d.org/v1/math"
+	"image"
+	"math"
+	"sync"
+)
+
+// clipToTexture transforms clip space coordinates in the range [-1, 1] into
+// shadow map texture coordinates and depth in the range [0, 1].
+var clipToTexture = gmath.Mat4{
+	{0.5, 0, 0, 0},
+	{0, 0.5, 0, 0},
+	{0, 0, 0.5, 0},
+	{0.5, 0.5, 0.5, 1},
+}
+
+// Map is a single shadow map: the depth of the scene as seen by a light's
+// camera, rendered into a texture.
+type Map struct {
+	access sync.Mutex
+
+	// The camera of the light, which the shadow casters are rendered with. It
+	// is positioned by the Fit methods.
+	Camera *gfx.Camera
+
+	// The texture the depth is rendered into (see PackDepth).
+	Texture *gfx.Texture
+
+	// The depth bias, in the range [0, 1], subtracted from the depth of a
+	// point before comparing it against the shadow map to avoid surfaces
+	// shadowing themselves ("shadow acne").
+	Bias float64
+
+	// The distance, towards the light, that shadow casters may be from the
+	// volume fitted by FitDirectional and still cast shadows into it.
+	Extrude float64
+
+	// The canvas rendering to the texture, and the renderer it belongs to.
+	canvas   gfx.Canvas
+	renderer gfx.Renderer
+
+	// The depth shader, and the depth-only object drawn for each object.
+	shader  *gfx.Shader
+	proxies map[*gfx.Object]*proxy
+}
+
+// proxy is a depth-only object drawn in place of a shadow caster.
+type proxy struct {
+	*gfx.Object
+	used bool
+}
+
+// Size returns the width (and height) of the shadow map in texels.
+func (m *Map) Size() int {
+	return m.Texture.Bounds.Dx()
+}
+
+// Matrix returns the matrix transforming world space coordinates into shadow
+// map coordinates: texture coordinates in the X and Y components and depth in
+// the Z component, each in the range [0, 1] inside of the light's view.
+//
+// A perspective divide is required when the light's camera has a perspective
+// projection (i.e. for spot lights).
+func (m *Map) Matrix() gmath.Mat4 {
+	m.Camera.RLock()
+	vp := m.Camera.ViewProjection()
+	m.Camera.RUnlock()
+	return vp.Mul(clipToTexture)
+}
+
+// Project returns the shadow map coordinates (see Matrix) of the given world
+// space point. If ok=false is returned then the point is outside of the
+// light's view.
+func (m *Map) Project(p gmath.Vec3) (c gmath.Vec3, ok bool) {
+	p4 := gmath.Vec4{p.X, p.Y, p.Z, 1}.Transform(m.Matrix())
+	if p4.W <= 0 {
+		return gmath.Vec3Zero, false
+	}
+	c = p4.Vec3().DivScalar(p4.W)
+	in := func(v float64) bool {
+		return v >= 0 && v <= 1
+	}
+	return c, in(c.X) && in(c.Y) && in(c.Z)
+}
+
+// basis returns the orientation of a camera looking in the given direction.
+func basis(dir gmath.Vec3) (right, forward, up gmath.Vec3) {
+	forward, ok := dir.Normalized()
+	if !ok {
+		forward = gmath.Vec3{0, 0, -1}
+	}
+	up = gmath.Vec3{0, 0, 1}
+	if math.Abs(forward.Z) > 0.99 {
+		up = gmath.Vec3{0, 1, 0}
+	}
+	right, _ = forward.Cross(up).Normalized()
+	up = right.Cross(forward)
+	return
+}
+
+// look orients the light's camera to look in the given direction from the
+// given position.
+func (m *Map) look(pos, dir gmath.Vec3) {
+	right, forward, up := basis(dir)
+	rot := gmath.Mat3{
+		{right.X, right.Y, right.Z},
+		{forward.X, forward.Y, forward.Z},
+		{up.X, up.Y, up.Z},
+	}
+	m.Camera.SetQuat(gmath.QuatFromMat3(rot))
+	m.Camera.SetPos(pos)
+}
+
+// FitDirectional positions and sets an orthographic projection on the light's
+// camera such that, looking in the direction of a directional light, it
+// encloses the bounding sphere of the given points (e.g. the corners of a
+// view frustum, see gfx.Camera.FrustumCorners). The volume is extended
+// towards the light by Extrude.
+//
+// The volume is snapped to whole texels of the shadow map, such that shadows
+// do not shimmer as the points move.
+func (m *Map) FitDirectional(dir gmath.Vec3, points []gmath.Vec3) {
+	var center gmath.Vec3
+	for _, p := range points {
+		center = center.Add(p)
+	}
+	center = center.DivScalar(math.Max(float64(len(points)), 1))
+	var radius float64
+	for _, p := range points {
+		radius = math.Max(radius, p.Sub(center).Length())
+	}
+	// Round the radius up, as it varies slightly with floating point error.
+	radius = math.Max(math.Ceil(radius*16)/16, 1.0/16)
+
+	// The camera sits at the origin, where the light space coordinates of the
+	// center are simple dot products.
+	right, forward, up := basis(dir)
+	texel := 2 * radius / float64(m.Size())
+	x := math.Floor(center.Dot(right)/texel) * texel
+	y := math.Floor(center.Dot(up)/texel) * texel
+	d := center.Dot(forward)
+
+	m.Camera.Lock()
+	m.look(gmath.Vec3Zero, dir)
+	proj := gmath.Mat4Ortho(x-radius, x+radius, y-radius, y+radius, d-radius-m.Extrude, d+radius)
+	m.Camera.Projection = gfx.ConvertMat4(proj)
+	m.Camera.Unlock()
+}
+
+// FitSpot positions and sets a perspective projection on the light's camera
+// such that it sees the cone of a spot light at the given position, shining
+// in the given direction with the given outer cone angle (in degrees), up to
+// the given range.
+func (m *Map) FitSpot(pos, dir gmath.Vec3, outerCone, rng float64) {
+	near := math.Max(rng/1000, 0.01)
+	fov := math.Min(2*outerCone+2, 170)
+
+	m.Camera.Lock()
+	m.look(pos, dir)
+	proj := gmath.Mat4Perspective(fov, 1, near, rng)
+	m.Camera.Projection = gfx.ConvertMat4(proj)
+	m.Camera.Unlock()
+}
+
+// casts tells if the given bounds could cast a shadow into the light's view.
+func (m *Map) casts(f *gfx.Frustum, b gmath.Rect3) bool {
+	return b == gmath.Rect3Zero || f.OverlapsRect3(b)
+}
+
+// update updates the proxy of the given object.
+func (p *proxy) update(o *gfx.Object, s *gfx.Shader) {
+	o.RLock()
+	p.Lock()
+	p.Transform = o.Transform
+	p.Meshes = o.Meshes
+	if len(p.Shaders) != len(o.Meshes) {
+		p.Shaders = p.Shaders[:0]
+		for len(p.Shaders) < len(o.Meshes) {
+			p.Shaders = append(p.Shaders, s)
+		}
+		p.Textures = make([][]*gfx.Texture, len(o.Meshes))
+	}
+	p.FaceCulling = o.FaceCulling
+	p.DepthTest = o.DepthTest
+	p.Unlock()
+	o.RUnlock()
+}
+
+// Draw draws the depth of the given shadow casters onto the given canvas,
+// which should render to the shadow map's texture (see Render). The canvas is
+// cleared first.
+//
+// Casters are drawn with a depth-only object in place of their shaders and
+// textures, honoring their face culling and depth test modes. Casters outside of the light's
+// view are skipped.
+func (m *Map) Draw(c gfx.Canvas, casters []*gfx.Object) {
+	m.access.Lock()
+	defer m.access.Unlock()
+
+	var all image.Rectangle
+	c.Clear(all, ClearColor)
+	c.ClearDepth(all, 1)
+
+	m.Camera.RLock()
+	f := m.Camera.Frustum()
+	m.Camera.RUnlock()
+	for _, p := range m.proxies {
+		p.used = false
+	}
+	for _, o := range casters {
+		if !m.casts(&f, o.Bounds()) {
+			continue
+		}
+		p := m.proxies[o]
+		if p == nil {
+			p = &proxy{Object: gfx.NewObject()}
+			m.proxies[o] = p
+		}
+		p.used = true
+		p.update(o, m.shader)
+		c.Draw(all, p.Object, m.Camera)
+	}
+
+	// Forget about the casters that were not drawn, such that they may be
+	// garbage collected.
+	for o, p := range m.proxies {
+		if !p.used {
+			delete(m.proxies, o)
+		}
+	}
+}
+
+// Render renders the depth of the given shadow casters into the shadow map's
+// texture using the given renderer (see Draw).
+func (m *Map) Render(r gfx.Renderer, casters []*gfx.Object) {
+	m.access.Lock()
+	if m.canvas == nil || m.renderer != r {
+		m.canvas = r.RenderToTexture(m.Texture)
+		m.renderer = r
+	}
+	c := m.canvas
+	m.access.Unlock()
+
+	m.Draw(c, casters)
+	c.Render()
+}
+
+// NewMap returns a new shadow map of the given size in texels, with a bias of
+// 0.002 and an extrusion of 100 units. Texture coordinates outside of the map
+// sample the clear color, such that nothing outside of it is in shadow.
+func NewMap(size int) *Map {
+	return &Map{
+		Camera: gfx.NewCamera(),
+		Texture: &gfx.Texture{
+			Bounds:      image.Rect(0, 0, size, size),
+			Format:      gfx.RGBA,
+			WrapU:       gfx.BorderColor,
+			WrapV:       gfx.BorderColor,
+			BorderColor: ClearColor,
+			MinFilter:   gfx.Nearest,
+			MagFilter:   gfx.Nearest,
+		},
+		Bias:    0.002,
+		Extrude: 100,
+		shader:  NewDepthShader(),
+		proxies: make(map[*gfx.Object]*proxy),
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shadow
+
+import (
+	"azul3d.org/v1/gfx"
+	gmath "azul3d.org/v1/math"
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+const glslPCF = `
+float shadowPCF(sampler2D tex, mat4 m, float bias, vec3 worldPos)
+{
+	vec4 p = m * vec4(worldPos, 1.0);
+	p.xyz /= p.w;
+	if(p.w <= 0.0 || p.z > 1.0) {
+		return 1.0;
+	}
+	float depth = p.z - bias;
+	float lit = 0.0;
+	for(int y = -1; y <= 1; y++) {
+		for(int x = -1; x <= 1; x++) {
+			vec2 tc = p.xy + vec2(float(x), float(y)) * ShadowTexel.xy;
+			if(depth <= shadowUnpack(texture2D(tex, tc))) {
+				lit += 1.0;
+			}
+		}
+	}
+	return lit / 9.0;
+}
+`
+
+// GLSL returns GLSL fragment shader source code declaring the uniforms and
+// functions used to sample n shadow maps (e.g. the maps of Cascades, or a
+// single Map), which are bound to consecutive textures starting at the given
+// index (e.g. Texture1 onwards if the object's first texture is it's color).
+//
+// The source declares the function:
+//  float shadow(vec3 worldPos)
+//
+// Which returns the fraction, between zero and one, of light reaching the
+// given world space position: zero if it is fully in shadow. It uses 3x3
+// percentage-closer filtering, and selects the cascade by the distance of the
+// point from the camera. Points outside of the shadow maps are lit.
+//
+// The source must be inserted into a fragment shader before it's main
+// function, and the inputs must be set using SetInputs.
+func GLSL(n, firstTexture int) string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "uniform mat4 ShadowMatrix[%d];\n", n)
+	fmt.Fprintf(&b, "uniform float ShadowSplit[%d];\n", n)
+	fmt.Fprintf(&b, "uniform float ShadowBias[%d];\n", n)
+	b.WriteString("uniform vec3 ShadowTexel;\n")
+	b.WriteString("uniform vec3 ShadowEye;\n")
+	b.WriteString("uniform vec3 ShadowForward;\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "uniform sampler2D Texture%d;\n", firstTexture+i)
+	}
+	b.WriteString(glslUnpack)
+	b.WriteString(glslPCF)
+	b.WriteString("\nfloat shadow(vec3 worldPos)\n{\n")
+	b.WriteString("\tfloat d = dot(worldPos - ShadowEye, ShadowForward);\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "\tif(d < ShadowSplit[%d]) {\n", i)
+		fmt.Fprintf(&b, "\t\treturn shadowPCF(Texture%d, ShadowMatrix[%d], ShadowBias[%d], worldPos);\n", firstTexture+i, i, i)
+		b.WriteString("\t}\n")
+	}
+	b.WriteString("\treturn 1.0;\n}\n")
+	return b.String()
+}
+
+// setInputs sets the inputs of the shader source returned by GLSL, for the
+// given maps, where ends is the view distance at which each map ends.
+func setInputs(inputs map[string]interface{}, maps []*Map, ends []float64, eye, forward gmath.Vec3) {
+	var (
+		matrices = make([]gfx.Mat4, len(maps))
+		splits   = make([]float32, len(maps))
+		bias     = make([]float32, len(maps))
+		texel    float32
+	)
+	for i, m := range maps {
+		matrices[i] = gfx.ConvertMat4(m.Matrix())
+		splits[i] = float32(ends[i])
+		bias[i] = float32(m.Bias)
+		texel = 1 / float32(m.Size())
+	}
+	inputs["ShadowMatrix"] = matrices
+	inputs["ShadowSplit"] = splits
+	inputs["ShadowBias"] = bias
+	inputs["ShadowTexel"] = gfx.Vec3{texel, texel, 0}
+	inputs["ShadowEye"] = gfx.Vec3{float32(eye.X), float32(eye.Y), float32(eye.Z)}
+	inputs["ShadowForward"] = gfx.Vec3{float32(forward.X), float32(forward.Y), float32(forward.Z)}
+}
+
+// SetInputs sets the inputs of the shader source returned by GLSL(1, ...) in
+// the given inputs map of a shader, for sampling this shadow map.
+func (m *Map) SetInputs(inputs map[string]interface{}) {
+	setInputs(inputs, []*Map{m}, []float64{math.MaxFloat32}, gmath.Vec3Zero, gmath.Vec3Zero)
+}
+
+// SetInputs sets the inputs of the shader source returned by GLSL(n, ...),
+// where n is the number of shadow maps, in the given inputs map of a shader.
+// Fit must have been called first.
+func (c *Cascades) SetInputs(inputs map[string]interface{}) {
+	setInputs(inputs, c.Maps, c.splits[1:], c.eye, c.forward)
+}
+
+// Lit is the software equivalent of the GLSL shadow sampling function: it
+// returns the fraction, between zero and one, of light reaching a point with
+// the given shadow map coordinates (see Map.Project) using 3x3
+// percentage-closer filtering of the given shadow map image.
+//
+// The image must be in texture order (i.e. it's first row is at the texture
+// coordinate V=0) as downloaded from the renderer. Texels outside of the
+// image are lit.
+func Lit(depth image.Image, c gmath.Vec3, bias float64) float64 {
+	if c.Z > 1 {
+		return 1
+	}
+	b := depth.Bounds()
+	x := b.Min.X + int(math.Floor(c.X*float64(b.Dx())))
+	y := b.Min.Y + int(math.Floor(c.Y*float64(b.Dy())))
+	d := c.Z - bias
+	lit := 0
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			p := image.Pt(x+dx, y+dy)
+			if !p.In(b) {
+				lit++
+				continue
+			}
+			if d <= UnpackDepth(color.RGBAModel.Convert(depth.At(p.X, p.Y)).(color.RGBA)) {
+				lit++
+			}
+		}
+	}
+	return float64(lit) / 9
+}
@@ -0,0 +1,261 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shadow
+
+import (
+	"azul3d.org/v1/gfx"
+	"azul3d.org/v1/gfx/glsl"
+	gmath "azul3d.org/v1/math"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// recorder is a canvas that records the objects drawn onto it.
+type recorder struct {
+	gfx.Canvas
+	clears int
+	drawn  []*gfx.Object
+}
+
+func (r *recorder) Clear(rect image.Rectangle, bg gfx.Color) {
+	r.clears++
+}
+
+func (r *recorder) Draw(rect image.Rectangle, o *gfx.Object, c *gfx.Camera) {
+	r.drawn = append(r.drawn, o)
+}
+
+// newBox returns a new object with a single mesh spanning a 2x2x2 box at the
+// given position.
+func newBox(pos gmath.Vec3) *gfx.Object {
+	o := gfx.NewObject()
+	o.Meshes = []*gfx.Mesh{{
+		Vertices: []gfx.Vec3{{-1, -1, -1}, {1, 1, 1}, {-1, 1, -1}},
+	}}
+	o.SetPos(pos)
+	return o
+}
+
+func newCamera() *gfx.Camera {
+	cam := gfx.NewCamera()
+	cam.SetPersp(image.Rect(0, 0, 800, 600), 75, 0.5, 500)
+	cam.SetPos(gmath.Vec3{0, -20, 10})
+	cam.SetRot(gmath.Vec3{-20, 0, 30})
+	return cam
+}
+
+func TestPackDepth(t *testing.T) {
+	last := -1.0
+	for i := 0; i <= 1000; i++ {
+		d := float64(i) / 1000
+		got := UnpackDepth(PackDepth(d))
+		if math.Abs(got-math.Min(d, maxDepth)) > 1e-6 {
+			t.Fatalf("depth %v unpacked as %v", d, got)
+		}
+		if got < last {
+			t.Fatalf("depth %v unpacked below the previous one", d)
+		}
+		last = got
+	}
+	if d := UnpackDepth(PackDepth(1)); d >= 1 {
+		t.Fatalf("depth 1 unpacked as %v", d)
+	}
+	if UnpackDepth(color.RGBA{255, 255, 255, 255}) <= 1 {
+		t.Fatal("clear color is not beyond the far depth")
+	}
+}
+
+func TestSplits(t *testing.T) {
+	s := Splits(1, 100, 2, 1)
+	want := []float64{1, 10, 100}
+	for i := range want {
+		if math.Abs(s[i]-want[i]) > 1e-9 {
+			t.Fatalf("got %v want %v", s, want)
+		}
+	}
+	s = Splits(1, 100, 4, 0.5)
+	for i := 1; i < len(s); i++ {
+		if s[i] <= s[i-1] {
+			t.Fatalf("splits %v are not increasing", s)
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	cam := newCamera()
+	dir := gmath.Vec3{1, 0.5, -2}
+	csm := NewCascades(3, 512)
+	csm.MaxDistance = 100
+	if !csm.Fit(dir, cam) {
+		t.Fatal("Fit failed")
+	}
+	splits := csm.Splits()
+	if len(splits) != 4 || splits[3] != 100 {
+		t.Fatalf("got splits %v", splits)
+	}
+	corners, _ := cam.FrustumCorners()
+	near, far := 0.5, 500.0
+
+	// Each part of the frustum is inside of it's shadow map.
+	for i, m := range csm.Maps {
+		for _, d := range splits[i : i+2] {
+			for j := 0; j < 4; j++ {
+				f := (d - near) / (far - near)
+				p := corners[j].Add(corners[j+4].Sub(corners[j]).MulScalar(f))
+				if _, ok := m.Project(p); !ok {
+					t.Fatalf("cascade %d: %v is outside of the shadow map", i, p)
+				}
+			}
+		}
+
+		// Depth increases along the light's direction.
+		p := corners[0].Add(corners[4]).MulScalar(0.5)
+		a, _ := m.Project(p)
+		b, _ := m.Project(p.Add(dir.MulScalar(0.01)))
+		if b.Z <= a.Z {
+			t.Fatalf("cascade %d: depth %v then %v along the light", i, a.Z, b.Z)
+		}
+	}
+}
+
+func TestShadowing(t *testing.T) {
+	// A square occluder above the ground, lit from straight above.
+	m := NewMap(256)
+	m.FitDirectional(gmath.Vec3{0, 0, -1}, []gmath.Vec3{
+		{-10, -10, 0}, {10, 10, 0}, {-10, -10, 6}, {10, 10, 6},
+	})
+	min, _ := m.Project(gmath.Vec3{-2, -2, 5})
+	max, _ := m.Project(gmath.Vec3{2, 2, 5})
+
+	// Render the occluder in software.
+	depth := image.NewRGBA(image.Rect(0, 0, m.Size(), m.Size()))
+	for i := range depth.Pix {
+		depth.Pix[i] = 255
+	}
+	size := float64(m.Size())
+	for y := int(min.Y * size); y < int(max.Y*size); y++ {
+		for x := int(min.X * size); x < int(max.X*size); x++ {
+			depth.SetRGBA(x, y, PackDepth(min.Z))
+		}
+	}
+
+	for _, tst := range []struct {
+		p   gmath.Vec3
+		lit float64
+	}{
+		{gmath.Vec3{0, 0, 0}, 0},
+		{gmath.Vec3{5, 5, 0}, 1},
+		{gmath.Vec3{-5, 1, 0}, 1},
+		{gmath.Vec3{1, -1.5, 2}, 0},
+		{gmath.Vec3{0, 0, 5}, 1},
+		{gmath.Vec3{0, 0, 8}, 1},
+	} {
+		c, _ := m.Project(tst.p)
+		if lit := Lit(depth, c, m.Bias); lit != tst.lit {
+			t.Errorf("%v: got lit %v want %v", tst.p, lit, tst.lit)
+		}
+	}
+
+	// The edge of the shadow is partially lit.
+	c, _ := m.Project(gmath.Vec3{2, 0, 0})
+	if lit := Lit(depth, c, m.Bias); lit <= 0 || lit >= 1 {
+		t.Errorf("got lit %v at the edge", lit)
+	}
+}
+
+func TestDraw(t *testing.T) {
+	m := NewMap(128)
+	m.FitDirectional(gmath.Vec3{0, 0, -1}, []gmath.Vec3{{-10, -10, 0}, {10, 10, 0}})
+
+	// Casters above the fitted volume are drawn, as they cast shadows into
+	// it.
+	inside := newBox(gmath.Vec3{0, 0, 1})
+	inside.FaceCulling = gfx.NoFaceCulling
+	inside.DepthTest = false
+	above := newBox(gmath.Vec3{0, 0, 50})
+	outside := newBox(gmath.Vec3{100, 100, 1})
+
+	r := &recorder{Canvas: gfx.Nil()}
+	m.Draw(r, []*gfx.Object{inside, above, outside})
+	if r.clears != 1 || len(r.drawn) != 2 {
+		t.Fatalf("got %d clears and %d draws, want 1 and 2", r.clears, len(r.drawn))
+	}
+	p := r.drawn[0]
+	if p.Shaders[0] != m.shader || p.Meshes[0] != inside.Meshes[0] || p.Transform != inside.Transform {
+		t.Fatal("caster drawn with the wrong shader, mesh or transform")
+	}
+	if p.FaceCulling != gfx.NoFaceCulling || p.DepthTest || len(p.Textures) != 1 {
+		t.Fatal("caster drawn with the wrong state or textures")
+	}
+
+	// Proxies are reused, and forgotten once their caster is not drawn.
+	r.drawn = nil
+	m.Draw(r, []*gfx.Object{inside})
+	if len(r.drawn) != 1 || r.drawn[0] != p || len(m.proxies) != 1 {
+		t.Fatal("proxy not reused or forgotten")
+	}
+
+	// Rendering to a texture works offscreen.
+	nr := gfx.Nil()
+	csm := NewCascades(2, 64)
+	csm.Fit(gmath.Vec3{0, 0, -1}, newCamera())
+	csm.Render(nr, []*gfx.Object{inside, above, outside})
+	if n := len(csm.Textures()); n != 2 {
+		t.Fatalf("got %d textures, want 2", n)
+	}
+}
+
+func TestShaderInputs(t *testing.T) {
+	frag := []byte("#version 120\nvarying vec3 worldPos;\n" + GLSL(3, 1) + `
+void main()
+{
+	gl_FragColor = vec4(vec3(shadow(worldPos)), 1.0);
+}
+`)
+	vert := []byte(`#version 120
+attribute vec3 Vertex;
+uniform mat4 MVP;
+varying vec3 worldPos;
+void main()
+{
+	worldPos = Vertex;
+	gl_Position = MVP * vec4(Vertex, 1.0);
+}
+`)
+	r, err := glsl.Reflect(vert, frag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 3; i++ {
+		if _, ok := r.Uniforms[fmt.Sprintf("Texture%d", i)]; !ok {
+			t.Fatalf("Texture%d is not declared", i)
+		}
+	}
+
+	csm := NewCascades(3, 256)
+	csm.Fit(gmath.Vec3{0, 1, -1}, newCamera())
+	inputs := make(map[string]interface{})
+	csm.SetInputs(inputs)
+	builtin := func(name string) bool {
+		return name == "MVP"
+	}
+	if err := r.Validate(inputs, builtin); err != nil {
+		t.Fatal(err)
+	}
+
+	// A single map.
+	r, err = glsl.Reflect(vert, []byte("#version 120\nvarying vec3 worldPos;\n"+GLSL(1, 0)+"void main()\n{\n\tgl_FragColor = vec4(shadow(worldPos));\n}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs = make(map[string]interface{})
+	csm.Maps[0].SetInputs(inputs)
+	if err := r.Validate(inputs, builtin); err != nil {
+		t.Fatal(err)
+	}
+}
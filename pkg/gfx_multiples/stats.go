@@ -0,0 +1,68 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gfx
+
+// Stats describes the work performed by a renderer during a single frame, and
+// the native resources it has loaded at the end of that frame.
+//
+// A frame is everything between two calls to Render, including drawing onto
+// canvases returned by RenderToTexture.
+type Stats struct {
+	// The number of draw calls issued, generally one per mesh of each object
+	// drawn.
+	DrawCalls int
+
+	// The number of render state changes, for example enabling blending or
+	// switching shader programs. Redundant state changes are not performed,
+	// and are not counted.
+	StateChanges int
+
+	// The number of triangles drawn.
+	Triangles int
+
+	// The number of bytes uploaded to the graphics hardware for meshes and
+	// textures. Loading a mesh or texture counts towards the frame that is
+	// current once the load completes.
+	MeshBytes, TextureBytes int64
+
+	// The number of native meshes, textures, and shaders that are currently
+	// loaded, i.e. those loaded and not yet destroyed or garbage collected.
+	Meshes, Textures, Shaders int
+
+	// The number of occlusion query results received, and the sum of the
+	// sample counts they reported.
+	OcclusionQueries int
+	SamplesPassed    int
+}
+
+// TextureBytes returns the number of bytes that a texture of the given format
+// and size occupies once uploaded, as counted by Stats.TextureBytes. Block
+// compressed (DXT) formats are stored in blocks of 4x4 pixels. If mipmapped is
+// true then the smaller levels of the texture's mipmap chain are included.
+func TextureBytes(f TexFormat, width, height int, mipmapped bool) int64 {
+	var total int64
+	for {
+		bw, bh := int64(width+3)/4, int64(height+3)/4
+		switch f {
+		case RGB:
+			total += int64(width) * int64(height) * 3
+		case DXT1, DXT1RGBA:
+			total += bw * bh * 8
+		case DXT3, DXT5:
+			total += bw * bh * 16
+		default:
+			total += int64(width) * int64(height) * 4
+		}
+		if !mipmapped || (width <= 1 && height <= 1) {
+			return total
+		}
+		if width > 1 {
+			width /= 2
+		}
+		if height > 1 {
+			height /= 2
+		}
+	}
+}
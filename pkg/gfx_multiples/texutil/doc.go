@@ -0,0 +1,15 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package texutil implements a pure-Go texture processing pipeline for gfx
+// textures.
+//
+// It provides generation of mipmap chains from a source image using a choice
+// of filters (with optional gamma-correct filtering), and encoding and
+// decoding of the DXT (also known as S3TC or BC1-3) block compressed texture
+// formats listed by gfx.TexFormat.
+//
+// Images produced by this package are always *image.RGBA, which (like the
+// gfx.RGBA texture format) stores premultiplied alpha.
+package texutil
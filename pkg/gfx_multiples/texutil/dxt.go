@@ -0,0 +1,547 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texutil
+
+import (
+	"azul3d.org/v1/gfx"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+)
+
+var (
+	// ErrFormat is returned by EncodeDXT and DecodeDXT when the given
+	// texture format is not one of the DXT formats.
+	ErrFormat = errors.New("texutil: texture format is not a DXT format")
+
+	// ErrShortData is returned by DecodeDXT when the given data is too short
+	// to hold an image of the given size.
+	ErrShortData = errors.New("texutil: not enough data for image size")
+)
+
+// BlockSize returns the size in bytes of a single 4x4 pixel block of the
+// given DXT texture format, or zero if the format is not a DXT format.
+func BlockSize(f gfx.TexFormat) int {
+	switch f {
+	case gfx.DXT1, gfx.DXT1RGBA:
+		return 8
+	case gfx.DXT3, gfx.DXT5:
+		return 16
+	}
+	return 0
+}
+
+// EncodedSize returns the size in bytes of an image with the given width and
+// height encoded in the given DXT texture format, or zero if the format is not
+// a DXT format.
+func EncodedSize(width, height int, f gfx.TexFormat) int {
+	return ((width + 3) / 4) * ((height + 3) / 4) * BlockSize(f)
+}
+
+// EncodeDXT encodes the source image into the given DXT texture format. Blocks
+// are stored in row-major order, images whose dimensions are not a multiple of
+// four are padded by repeating their edge pixels.
+//
+// For the DXT1 format alpha is ignored. For the DXT1RGBA format pixels with
+// an alpha value of less than one half are encoded as fully transparent, and
+// all others as fully opaque.
+//
+// If the format is not a DXT format then ErrFormat is returned.
+func EncodeDXT(src image.Image, f gfx.TexFormat) ([]byte, error) {
+	bs := BlockSize(f)
+	if bs == 0 {
+		return nil, ErrFormat
+	}
+	b := src.Bounds()
+	out := make([]byte, 0, EncodedSize(b.Dx(), b.Dy(), f))
+	var blk block
+	for by := b.Min.Y; by < b.Max.Y; by += 4 {
+		for bx := b.Min.X; bx < b.Max.X; bx += 4 {
+			blk.load(src, bx, by)
+			switch f {
+			case gfx.DXT1:
+				out = blk.encodeColor(out, false, false)
+			case gfx.DXT1RGBA:
+				out = blk.encodeColor(out, true, false)
+			case gfx.DXT3:
+				out = blk.encodeExplicitAlpha(out)
+				out = blk.encodeColor(out, false, true)
+			case gfx.DXT5:
+				out = blk.encodeInterpolatedAlpha(out)
+				out = blk.encodeColor(out, false, true)
+			}
+		}
+	}
+	return out, nil
+}
+
+// DecodeDXT decodes the given data, encoded in the given DXT texture format,
+// into an image of the given width and height.
+//
+// If the format is not a DXT format then ErrFormat is returned. If the data
+// is too short to hold an image of the given size then ErrShortData is
+// returned.
+func DecodeDXT(data []byte, width, height int, f gfx.TexFormat) (*image.RGBA, error) {
+	bs := BlockSize(f)
+	if bs == 0 {
+		return nil, ErrFormat
+	}
+	if len(data) < EncodedSize(width, height, f) {
+		return nil, ErrShortData
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	var px [16]color.RGBA
+	for by := 0; by < height; by += 4 {
+		for bx := 0; bx < width; bx += 4 {
+			switch f {
+			case gfx.DXT1:
+				decodeColor(&px, data, false, false)
+			case gfx.DXT1RGBA:
+				decodeColor(&px, data, true, false)
+			case gfx.DXT3:
+				decodeColor(&px, data[8:], false, true)
+				decodeExplicitAlpha(&px, data)
+			case gfx.DXT5:
+				decodeColor(&px, data[8:], false, true)
+				decodeInterpolatedAlpha(&px, data)
+			}
+			data = data[bs:]
+			for i, c := range px {
+				x, y := bx+i%4, by+i/4
+				if x >= width || y >= height {
+					continue
+				}
+				if f == gfx.DXT3 || f == gfx.DXT5 {
+					// Keep the decoded color a valid premultiplied one.
+					c.R, c.G, c.B = minByte(c.R, c.A), minByte(c.G, c.A), minByte(c.B, c.A)
+				}
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+	return img, nil
+}
+
+func minByte(a, b uint8) uint8 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// block is a single 4x4 block of pixels being encoded.
+type block struct {
+	px [16]color.RGBA
+}
+
+// load loads the 4x4 block of pixels at x, y from the source image, repeating
+// edge pixels for blocks that lie partially outside of the image.
+func (b *block) load(src image.Image, x, y int) {
+	r := src.Bounds()
+	for i := range b.px {
+		px, py := x+i%4, y+i/4
+		if px >= r.Max.X {
+			px = r.Max.X - 1
+		}
+		if py >= r.Max.Y {
+			py = r.Max.Y - 1
+		}
+		b.px[i] = color.RGBAModel.Convert(src.At(px, py)).(color.RGBA)
+	}
+}
+
+// pack565 converts an 8-bit color into a 16-bit 5:6:5 one, with rounding.
+func pack565(r, g, b float64) uint16 {
+	q := func(v float64, max int) uint16 {
+		i := int(v*float64(max)/255 + 0.5)
+		if i < 0 {
+			i = 0
+		} else if i > max {
+			i = max
+		}
+		return uint16(i)
+	}
+	return q(r, 31)<<11 | q(g, 63)<<5 | q(b, 31)
+}
+
+// unpack565 converts a 16-bit 5:6:5 color into an 8-bit one.
+func unpack565(c uint16) [3]int {
+	r, g, b := int(c>>11&31), int(c>>5&63), int(c&31)
+	return [3]int{r<<3 | r>>2, g<<2 | g>>4, b<<3 | b>>2}
+}
+
+// palette returns the colors of a DXT color block with the given endpoints.
+// The fourth color is meaningless when threeColor is true.
+func palette(c0, c1 uint16, threeColor bool) (p [4][3]int) {
+	p[0], p[1] = unpack565(c0), unpack565(c1)
+	for i := 0; i < 3; i++ {
+		if threeColor {
+			p[2][i] = (p[0][i] + p[1][i]) / 2
+		} else {
+			p[2][i] = (2*p[0][i] + p[1][i]) / 3
+			p[3][i] = (p[0][i] + 2*p[1][i]) / 3
+		}
+	}
+	return
+}
+
+// encodeColor appends the 64-bit color block of the pixels to out. If
+// punchThrough is true then pixels with less than half alpha are encoded as
+// transparent using the three-color mode. If fourColor is true then the block
+// must never use the three-color mode (as in DXT3 and DXT5).
+func (b *block) encodeColor(out []byte, punchThrough, fourColor bool) []byte {
+	var (
+		transparent [16]bool
+		anyAlpha    bool
+		pts         [][3]float64
+	)
+	for i, c := range b.px {
+		if punchThrough && c.A < 128 {
+			transparent[i] = true
+			anyAlpha = true
+			continue
+		}
+		pts = append(pts, [3]float64{float64(c.R), float64(c.G), float64(c.B)})
+	}
+	threeColor := anyAlpha && !fourColor
+
+	var c0, c1 uint16
+	if len(pts) > 0 {
+		lo, hi := principalEndpoints(pts)
+		c0, c1 = pack565(hi[0], hi[1], hi[2]), pack565(lo[0], lo[1], lo[2])
+	}
+	c0, c1, indices := b.fitIndices(c0, c1, threeColor, &transparent)
+
+	// Refine the endpoints using a least squares fit of the chosen indices,
+	// and keep the result if it has less error.
+	if r0, r1, ok := b.refit(indices, threeColor, &transparent); ok {
+		r0, r1, rIndices := b.fitIndices(r0, r1, threeColor, &transparent)
+		if b.colorError(r0, r1, rIndices, threeColor, &transparent) < b.colorError(c0, c1, indices, threeColor, &transparent) {
+			c0, c1, indices = r0, r1, rIndices
+		}
+	}
+
+	var bits uint32
+	for i, idx := range indices {
+		bits |= uint32(idx) << uint(2*i)
+	}
+	var buf [8]byte
+	binary.LittleEndian.PutUint16(buf[0:], c0)
+	binary.LittleEndian.PutUint16(buf[2:], c1)
+	binary.LittleEndian.PutUint32(buf[4:], bits)
+	return append(out, buf[:]...)
+}
+
+// fitIndices orders the endpoints for the requested mode and chooses the
+// nearest palette index for each pixel.
+func (b *block) fitIndices(c0, c1 uint16, threeColor bool, transparent *[16]bool) (uint16, uint16, [16]uint8) {
+	var indices [16]uint8
+	if threeColor {
+		// Three color mode is selected by c0 <= c1.
+		if c0 > c1 {
+			c0, c1 = c1, c0
+		}
+	} else {
+		// Four color mode is selected by c0 > c1.
+		if c0 < c1 {
+			c0, c1 = c1, c0
+		}
+		if c0 == c1 {
+			// Every pixel is exactly the first color.
+			return c0, c1, indices
+		}
+	}
+	p := palette(c0, c1, threeColor)
+	n := 4
+	if threeColor {
+		n = 3
+	}
+	for i, c := range b.px {
+		if transparent[i] {
+			indices[i] = 3
+			continue
+		}
+		best, bestDist := 0, -1
+		for j := 0; j < n; j++ {
+			d := colorDist(c, p[j])
+			if bestDist < 0 || d < bestDist {
+				best, bestDist = j, d
+			}
+		}
+		indices[i] = uint8(best)
+	}
+	return c0, c1, indices
+}
+
+// colorError returns the total squared error of encoding the block with the
+// given endpoints and indices.
+func (b *block) colorError(c0, c1 uint16, indices [16]uint8, threeColor bool, transparent *[16]bool) int {
+	p := palette(c0, c1, threeColor)
+	var err int
+	for i, c := range b.px {
+		if !transparent[i] {
+			err += colorDist(c, p[indices[i]])
+		}
+	}
+	return err
+}
+
+func colorDist(c color.RGBA, p [3]int) int {
+	dr, dg, db := int(c.R)-p[0], int(c.G)-p[1], int(c.B)-p[2]
+	return dr*dr + dg*dg + db*db
+}
+
+// refit solves for the endpoints that best (in the least squares sense)
+// reproduce the pixels given their palette indices.
+func (b *block) refit(indices [16]uint8, threeColor bool, transparent *[16]bool) (c0, c1 uint16, ok bool) {
+	// The weight of the first endpoint for each palette index.
+	weights := [4]float64{1, 0, 2.0 / 3.0, 1.0 / 3.0}
+	if threeColor {
+		weights[2] = 0.5
+	}
+	var (
+		aa, bb, ab float64
+		ax, bx     [3]float64
+	)
+	for i, c := range b.px {
+		if transparent[i] {
+			continue
+		}
+		a := weights[indices[i]]
+		w := 1 - a
+		aa += a * a
+		bb += w * w
+		ab += a * w
+		for k, v := range [3]float64{float64(c.R), float64(c.G), float64(c.B)} {
+			ax[k] += a * v
+			bx[k] += w * v
+		}
+	}
+	det := aa*bb - ab*ab
+	if det > -1e-6 && det < 1e-6 {
+		return 0, 0, false
+	}
+	var e0, e1 [3]float64
+	for k := 0; k < 3; k++ {
+		e0[k] = (ax[k]*bb - bx[k]*ab) / det
+		e1[k] = (bx[k]*aa - ax[k]*ab) / det
+	}
+	return pack565(e0[0], e0[1], e0[2]), pack565(e1[0], e1[1], e1[2]), true
+}
+
+// principalEndpoints returns the two extreme points of the given colors
+// projected onto their principal axis.
+func principalEndpoints(pts [][3]float64) (lo, hi [3]float64) {
+	var mean [3]float64
+	for _, p := range pts {
+		for k := range mean {
+			mean[k] += p[k]
+		}
+	}
+	for k := range mean {
+		mean[k] /= float64(len(pts))
+	}
+
+	// Covariance matrix (symmetric).
+	var cov [6]float64
+	for _, p := range pts {
+		r, g, b := p[0]-mean[0], p[1]-mean[1], p[2]-mean[2]
+		cov[0] += r * r
+		cov[1] += r * g
+		cov[2] += r * b
+		cov[3] += g * g
+		cov[4] += g * b
+		cov[5] += b * b
+	}
+
+	// Find the principal axis via power iteration, starting from the column
+	// of the covariance matrix with the largest variance.
+	axis := [3]float64{cov[0], cov[1], cov[2]}
+	if cov[3] > cov[0] && cov[3] >= cov[5] {
+		axis = [3]float64{cov[1], cov[3], cov[4]}
+	} else if cov[5] > cov[0] && cov[5] > cov[3] {
+		axis = [3]float64{cov[2], cov[4], cov[5]}
+	}
+	for i := 0; i < 8; i++ {
+		x := cov[0]*axis[0] + cov[1]*axis[1] + cov[2]*axis[2]
+		y := cov[1]*axis[0] + cov[3]*axis[1] + cov[4]*axis[2]
+		z := cov[2]*axis[0] + cov[4]*axis[1] + cov[5]*axis[2]
+		m := x
+		if y*y > m*m {
+			m = y
+		}
+		if z*z > m*m {
+			m = z
+		}
+		if m == 0 {
+			// All colors are equal.
+			return mean, mean
+		}
+		axis = [3]float64{x / m, y / m, z / m}
+	}
+
+	minT, maxT := 0.0, 0.0
+	for i, p := range pts {
+		t := (p[0]-mean[0])*axis[0] + (p[1]-mean[1])*axis[1] + (p[2]-mean[2])*axis[2]
+		if i == 0 || t < minT {
+			minT = t
+		}
+		if i == 0 || t > maxT {
+			maxT = t
+		}
+	}
+	lenSq := axis[0]*axis[0] + axis[1]*axis[1] + axis[2]*axis[2]
+	for k := 0; k < 3; k++ {
+		lo[k] = mean[k] + axis[k]*minT/lenSq
+		hi[k] = mean[k] + axis[k]*maxT/lenSq
+	}
+	return
+}
+
+// encodeExplicitAlpha appends the 64-bit DXT3 alpha block of the pixels to
+// out.
+func (b *block) encodeExplicitAlpha(out []byte) []byte {
+	var bits uint64
+	for i, c := range b.px {
+		a := (uint64(c.A)*15 + 127) / 255
+		bits |= a << uint(4*i)
+	}
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], bits)
+	return append(out, buf[:]...)
+}
+
+// alphaPalette returns the alpha values of a DXT5 alpha block with the given
+// endpoints.
+func alphaPalette(a0, a1 uint8) (p [8]int) {
+	p[0], p[1] = int(a0), int(a1)
+	if a0 > a1 {
+		for i := 1; i < 7; i++ {
+			p[i+1] = ((7-i)*p[0] + i*p[1]) / 7
+		}
+		return
+	}
+	for i := 1; i < 5; i++ {
+		p[i+1] = ((5-i)*p[0] + i*p[1]) / 5
+	}
+	p[6], p[7] = 0, 255
+	return
+}
+
+// fitAlpha chooses the nearest alpha palette index for each pixel, returning
+// the indices and the total squared error.
+func (b *block) fitAlpha(a0, a1 uint8) (indices [16]uint8, err int) {
+	p := alphaPalette(a0, a1)
+	for i, c := range b.px {
+		best, bestDist := 0, -1
+		for j, v := range p {
+			d := int(c.A) - v
+			d *= d
+			if bestDist < 0 || d < bestDist {
+				best, bestDist = j, d
+			}
+		}
+		indices[i] = uint8(best)
+		err += bestDist
+	}
+	return
+}
+
+// encodeInterpolatedAlpha appends the 64-bit DXT5 alpha block of the pixels to
+// out.
+func (b *block) encodeInterpolatedAlpha(out []byte) []byte {
+	var (
+		min, max   uint8 = 255, 0
+		min6, max6 uint8 = 255, 0
+		has6       bool
+	)
+	for _, c := range b.px {
+		if c.A < min {
+			min = c.A
+		}
+		if c.A > max {
+			max = c.A
+		}
+		if c.A != 0 && c.A != 255 {
+			has6 = true
+			if c.A < min6 {
+				min6 = c.A
+			}
+			if c.A > max6 {
+				max6 = c.A
+			}
+		}
+	}
+
+	// Eight value mode (a0 > a1), unless every alpha is equal in which case
+	// a0 == a1 and every index is zero.
+	a0, a1 := max, min
+	indices, err := b.fitAlpha(a0, a1)
+
+	// Six value mode (a0 <= a1) has explicit 0 and 255 values, which may fit
+	// blocks with both extremes better.
+	if has6 {
+		if i6, err6 := b.fitAlpha(min6, max6); err6 < err {
+			a0, a1, indices = min6, max6, i6
+		}
+	}
+
+	var bits uint64
+	for i, idx := range indices {
+		bits |= uint64(idx) << uint(3*i)
+	}
+	var buf [8]byte
+	buf[0], buf[1] = a0, a1
+	for i := 0; i < 6; i++ {
+		buf[2+i] = uint8(bits >> uint(8*i))
+	}
+	return append(out, buf[:]...)
+}
+
+// decodeColor decodes the 64-bit color block at the start of data into px. If
+// punchThrough is true the fourth color of three-color blocks is transparent
+// black (rather than opaque black). If fourColor is true then the block is
+// always decoded in four-color mode (as in DXT3 and DXT5).
+func decodeColor(px *[16]color.RGBA, data []byte, punchThrough, fourColor bool) {
+	c0 := binary.LittleEndian.Uint16(data[0:])
+	c1 := binary.LittleEndian.Uint16(data[2:])
+	bits := binary.LittleEndian.Uint32(data[4:])
+	threeColor := !fourColor && c0 <= c1
+	p := palette(c0, c1, threeColor)
+	for i := range px {
+		idx := bits >> uint(2*i) & 3
+		c := p[idx]
+		px[i] = color.RGBA{uint8(c[0]), uint8(c[1]), uint8(c[2]), 255}
+		if threeColor && idx == 3 {
+			px[i] = color.RGBA{0, 0, 0, 255}
+			if punchThrough {
+				px[i].A = 0
+			}
+		}
+	}
+}
+
+// decodeExplicitAlpha decodes the 64-bit DXT3 alpha block at the start of
+// data into the alpha components of px.
+func decodeExplicitAlpha(px *[16]color.RGBA, data []byte) {
+	bits := binary.LittleEndian.Uint64(data)
+	for i := range px {
+		px[i].A = uint8(bits>>uint(4*i)&15) * 17
+	}
+}
+
+// decodeInterpolatedAlpha decodes the 64-bit DXT5 alpha block at the start of
+// data into the alpha components of px.
+func decodeInterpolatedAlpha(px *[16]color.RGBA, data []byte) {
+	p := alphaPalette(data[0], data[1])
+	var bits uint64
+	for i := 0; i < 6; i++ {
+		bits |= uint64(data[2+i]) << uint(8*i)
+	}
+	for i := range px {
+		px[i].A = uint8(p[bits>>uint(3*i)&7])
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texutil
+
+import "math"
+
+// Filter specifies a single filter used when downsampling images.
+type Filter uint8
+
+const (
+	// Box is a box filter, each destination pixel is the (area weighted)
+	// average of the source pixels it covers. It is fast, but tends to
+	// produce blurry mipmaps.
+	Box Filter = iota
+
+	// Kaiser is a windowed sinc filter using a Kaiser window. It produces
+	// sharper mipmaps with fewer aliasing artifacts than Box, at the cost of
+	// being slower.
+	Kaiser
+)
+
+// String returns a string representation of this filter.
+//
+// For example: Box -> "Box", Kaiser -> "Kaiser".
+func (f Filter) String() string {
+	switch f {
+	case Box:
+		return "Box"
+	case Kaiser:
+		return "Kaiser"
+	}
+	return "Filter(invalid)"
+}
+
+// Parameters of the Kaiser filter.
+const (
+	kaiserWidth = 3.0
+	kaiserAlpha = 4.0
+)
+
+// support returns the support radius of the filter, in destination pixels (or
+// source pixels, when upsampling).
+func (f Filter) support() float64 {
+	if f == Kaiser {
+		return kaiserWidth
+	}
+	return 0.5
+}
+
+// weight returns the weight of the filter at the given distance from the
+// center of the filter, in destination pixels (or source pixels, when
+// upsampling).
+func (f Filter) weight(x float64) float64 {
+	if f == Kaiser {
+		if x < -kaiserWidth || x > kaiserWidth {
+			return 0
+		}
+		return sinc(x) * kaiserWindow(x/kaiserWidth)
+	}
+	if x >= -0.5 && x < 0.5 {
+		return 1
+	}
+	return 0
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	x *= math.Pi
+	return math.Sin(x) / x
+}
+
+// kaiserWindow returns the Kaiser window at x, which must be in the range of
+// -1 to 1.
+func kaiserWindow(x float64) float64 {
+	return bessel0(kaiserAlpha*math.Sqrt(1-x*x)) / bessel0(kaiserAlpha)
+}
+
+// bessel0 returns the zeroth order modified bessel function of the first kind
+// at x.
+func bessel0(x float64) float64 {
+	const epsilon = 1e-6
+	sum, term := 1.0, 1.0
+	half := x / 2
+	for k := 1; term > epsilon*sum; k++ {
+		t := half / float64(k)
+		term *= t * t
+		sum += term
+	}
+	return sum
+}
+
+// kernel is a precomputed one dimensional filter kernel, for each destination
+// pixel it stores the first source pixel and the normalized weights of the
+// source pixels that contribute to it.
+type kernel struct {
+	start   []int
+	weights [][]float64
+}
+
+// newKernel computes the kernel for resampling srcSize pixels into dstSize
+// pixels using the given filter. Source pixels past the edges are clamped.
+func newKernel(f Filter, srcSize, dstSize int) *kernel {
+	k := &kernel{
+		start:   make([]int, dstSize),
+		weights: make([][]float64, dstSize),
+	}
+	scale := float64(srcSize) / float64(dstSize)
+
+	// When upsampling the filter is not narrowed below a source pixel, or
+	// else it would fall between source pixels.
+	fs := math.Max(scale, 1)
+	radius := f.support() * fs
+	for i := 0; i < dstSize; i++ {
+		center := (float64(i) + 0.5) * scale
+		first := int(math.Floor(center - radius))
+		last := int(math.Ceil(center + radius))
+		var (
+			w   = make([]float64, 0, last-first+1)
+			sum float64
+		)
+		for j := first; j <= last; j++ {
+			v := f.weight((float64(j) + 0.5 - center) / fs)
+			w = append(w, v)
+			sum += v
+		}
+		if sum != 0 {
+			for j := range w {
+				w[j] /= sum
+			}
+		}
+		k.start[i] = first
+		k.weights[i] = w
+	}
+	return k
+}
@@ -0,0 +1,239 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texutil
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Options specifies how images are resampled.
+type Options struct {
+	// The filter to use for resampling.
+	Filter Filter
+
+	// If non-zero, the gamma that the color components of images are encoded
+	// with (e.g. 2.2 for typical sRGB images). Color components are converted
+	// to linear space before filtering and converted back afterwards, which
+	// avoids mipmaps becoming darker than their source image.
+	//
+	// If zero, filtering is performed directly on the encoded components.
+	Gamma float64
+}
+
+// DefaultOptions are the default options used when nil options are given to a
+// function of this package.
+var DefaultOptions = &Options{
+	Filter: Box,
+	Gamma:  2.2,
+}
+
+// MipLevels returns the number of levels in a full mipmap chain for an image
+// of the given size (i.e. including the base level, down to a 1x1 image).
+func MipLevels(width, height int) int {
+	levels := 1
+	for width > 1 || height > 1 {
+		width, height = halve(width), halve(height)
+		levels++
+	}
+	return levels
+}
+
+func halve(v int) int {
+	if v <= 1 {
+		return 1
+	}
+	return v / 2
+}
+
+// floatImage is an image whose premultiplied RGBA components are stored as
+// floating-point numbers in the 0-1 range (in linear space, if gamma
+// correction is in use).
+type floatImage struct {
+	width, height int
+	pix           []float64
+}
+
+// newFloatImage converts the given image into a float image, linearizing it's
+// color components using the given gamma (if non-zero).
+func newFloatImage(src image.Image, gamma float64) *floatImage {
+	b := src.Bounds()
+	f := &floatImage{
+		width:  b.Dx(),
+		height: b.Dy(),
+		pix:    make([]float64, b.Dx()*b.Dy()*4),
+	}
+	i := 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, b, a := src.At(x, y).RGBA()
+			fa := float64(a) / 0xffff
+			f.pix[i+0] = toLinear(float64(r)/0xffff, fa, gamma)
+			f.pix[i+1] = toLinear(float64(g)/0xffff, fa, gamma)
+			f.pix[i+2] = toLinear(float64(b)/0xffff, fa, gamma)
+			f.pix[i+3] = fa
+			i += 4
+		}
+	}
+	return f
+}
+
+// toLinear converts the premultiplied component c with alpha a to a linear
+// premultiplied component.
+func toLinear(c, a, gamma float64) float64 {
+	if gamma == 0 || a == 0 {
+		return c
+	}
+	return math.Pow(clamp01(c/a), gamma) * a
+}
+
+// fromLinear is the inverse of toLinear.
+func fromLinear(c, a, gamma float64) float64 {
+	if gamma == 0 || a == 0 {
+		return c
+	}
+	return math.Pow(clamp01(c/a), 1/gamma) * a
+}
+
+func clamp01(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+// rgba converts the float image back into an 8-bit RGBA image, encoding the
+// color components with the given gamma (if non-zero).
+func (f *floatImage) rgba(gamma float64) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, f.width, f.height))
+	for i := 0; i < len(f.pix); i += 4 {
+		a := clamp01(f.pix[i+3])
+		for c := 0; c < 3; c++ {
+			v := clamp01(fromLinear(f.pix[i+c], a, gamma))
+			if v > a {
+				// Keep the result a valid premultiplied color.
+				v = a
+			}
+			img.Pix[i+c] = uint8(v*255 + 0.5)
+		}
+		img.Pix[i+3] = uint8(a*255 + 0.5)
+	}
+	return img
+}
+
+// resize resamples the float image to the given size using the given filter,
+// as two separable passes.
+func (f *floatImage) resize(filter Filter, width, height int) *floatImage {
+	tmp := &floatImage{
+		width:  width,
+		height: f.height,
+		pix:    make([]float64, width*f.height*4),
+	}
+	kx := newKernel(filter, f.width, width)
+	for y := 0; y < f.height; y++ {
+		row := f.pix[y*f.width*4:]
+		for x := 0; x < width; x++ {
+			var acc [4]float64
+			for j, w := range kx.weights[x] {
+				if w == 0 {
+					continue
+				}
+				s := clampInt(kx.start[x]+j, f.width) * 4
+				acc[0] += row[s+0] * w
+				acc[1] += row[s+1] * w
+				acc[2] += row[s+2] * w
+				acc[3] += row[s+3] * w
+			}
+			copy(tmp.pix[(y*width+x)*4:], acc[:])
+		}
+	}
+
+	dst := &floatImage{
+		width:  width,
+		height: height,
+		pix:    make([]float64, width*height*4),
+	}
+	ky := newKernel(filter, f.height, height)
+	for y := 0; y < height; y++ {
+		var (
+			start   = ky.start[y]
+			weights = ky.weights[y]
+		)
+		for x := 0; x < width; x++ {
+			var acc [4]float64
+			for j, w := range weights {
+				if w == 0 {
+					continue
+				}
+				s := (clampInt(start+j, tmp.height)*width + x) * 4
+				acc[0] += tmp.pix[s+0] * w
+				acc[1] += tmp.pix[s+1] * w
+				acc[2] += tmp.pix[s+2] * w
+				acc[3] += tmp.pix[s+3] * w
+			}
+			copy(dst.pix[(y*width+x)*4:], acc[:])
+		}
+	}
+	return dst
+}
+
+func clampInt(v, size int) int {
+	if v < 0 {
+		return 0
+	}
+	if v >= size {
+		return size - 1
+	}
+	return v
+}
+
+// Resize returns the source image resampled to the given width and height
+// using the given options. If the options are nil then DefaultOptions are
+// used.
+func Resize(src image.Image, width, height int, o *Options) *image.RGBA {
+	if o == nil {
+		o = DefaultOptions
+	}
+	f := newFloatImage(src, o.Gamma)
+	return f.resize(o.Filter, width, height).rgba(o.Gamma)
+}
+
+// Mipmaps generates a full mipmap chain for the source image using the given
+// options. If the options are nil then DefaultOptions are used.
+//
+// The first image in the returned slice is the base level (i.e. a copy of the
+// source image), each following level is half the size of the previous one
+// (rounded down) until a 1x1 image is reached. Each level is filtered from
+// the unquantized previous level, so rounding errors do not accumulate along
+// the chain.
+func Mipmaps(src image.Image, o *Options) []*image.RGBA {
+	if o == nil {
+		o = DefaultOptions
+	}
+	b := src.Bounds()
+	levels := make([]*image.RGBA, 0, MipLevels(b.Dx(), b.Dy()))
+
+	base := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			base.Set(x-b.Min.X, y-b.Min.Y, color.RGBAModel.Convert(src.At(x, y)))
+		}
+	}
+	levels = append(levels, base)
+	if b.Empty() {
+		return levels
+	}
+
+	f := newFloatImage(base, o.Gamma)
+	for f.width > 1 || f.height > 1 {
+		f = f.resize(o.Filter, halve(f.width), halve(f.height))
+		levels = append(levels, f.rgba(o.Gamma))
+	}
+	return levels
+}
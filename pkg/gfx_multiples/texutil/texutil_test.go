@@ -0,0 +1,317 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package texutil
+
+import (
+	"azul3d.org/v1/gfx"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// gradient returns a w*h image with a smooth color gradient and an alpha
+// gradient along the Y axis. The gradients span at least 32 pixels, so that
+// they remain smooth for small images.
+func gradient(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	sw, sh := w, h
+	if sw < 32 {
+		sw = 32
+	}
+	if sh < 32 {
+		sh = 32
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			a := uint8(255 * y / sh)
+			img.SetRGBA(x, y, color.RGBA{
+				uint8(int(a) * x / sw),
+				uint8(int(a) * (sw - x) / sw),
+				a / 2,
+				a,
+			})
+		}
+	}
+	return img
+}
+
+// checker returns a w*h checkerboard of opaque black and white pixels.
+func checker(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{0, 0, 0, 255}
+			if (x+y)%2 == 0 {
+				c = color.RGBA{255, 255, 255, 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// maxError returns the largest per-component difference between two images
+// of equal size, optionally ignoring the alpha component.
+func maxError(a, b *image.RGBA, alpha bool) int {
+	var max int
+	for i := range a.Pix {
+		if !alpha && i%4 == 3 {
+			continue
+		}
+		d := int(a.Pix[i]) - int(b.Pix[i])
+		if d < 0 {
+			d = -d
+		}
+		if d > max {
+			max = d
+		}
+	}
+	return max
+}
+
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func TestMipLevels(t *testing.T) {
+	tests := []struct {
+		w, h, want int
+	}{
+		{1, 1, 1},
+		{2, 2, 2},
+		{256, 256, 9},
+		{256, 16, 9},
+		{5, 3, 3},
+	}
+	for _, tst := range tests {
+		if got := MipLevels(tst.w, tst.h); got != tst.want {
+			t.Errorf("MipLevels(%d, %d) = %d, want %d", tst.w, tst.h, got, tst.want)
+		}
+	}
+}
+
+func TestMipmaps(t *testing.T) {
+	for _, f := range []Filter{Box, Kaiser} {
+		levels := Mipmaps(gradient(37, 12), &Options{Filter: f, Gamma: 2.2})
+		if len(levels) != MipLevels(37, 12) {
+			t.Fatalf("%v: got %d levels, want %d", f, len(levels), MipLevels(37, 12))
+		}
+		w, h := 37, 12
+		for i, l := range levels {
+			if l.Bounds().Dx() != w || l.Bounds().Dy() != h {
+				t.Errorf("%v: level %d is %v, want %dx%d", f, i, l.Bounds(), w, h)
+			}
+			w, h = halve(w), halve(h)
+		}
+	}
+}
+
+func TestMipmapsConstant(t *testing.T) {
+	c := color.RGBA{40, 80, 120, 200}
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	for _, f := range []Filter{Box, Kaiser} {
+		for _, gamma := range []float64{0, 2.2} {
+			for i, l := range Mipmaps(img, &Options{Filter: f, Gamma: gamma}) {
+				for p := 0; p < len(l.Pix); p += 4 {
+					got := color.RGBA{l.Pix[p], l.Pix[p+1], l.Pix[p+2], l.Pix[p+3]}
+					if absDiff(got.R, c.R) > 1 || absDiff(got.G, c.G) > 1 || absDiff(got.B, c.B) > 1 || got.A != c.A {
+						t.Errorf("%v gamma=%v: level %d got %v, want %v", f, gamma, i, got, c)
+						break
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestResizeUp(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	src.SetRGBA(0, 0, color.RGBA{200, 100, 50, 255})
+	src.SetRGBA(1, 0, color.RGBA{100, 200, 50, 255})
+	src.SetRGBA(0, 1, color.RGBA{50, 100, 200, 255})
+	src.SetRGBA(1, 1, color.RGBA{150, 150, 150, 255})
+	for _, f := range []Filter{Box, Kaiser} {
+		dst := Resize(src, 4, 4, &Options{Filter: f})
+		if dst.Bounds().Dx() != 4 || dst.Bounds().Dy() != 4 {
+			t.Fatalf("%v: got %v, want 4x4", f, dst.Bounds())
+		}
+		for p := 0; p < len(dst.Pix); p += 4 {
+			r, g, b, a := dst.Pix[p], dst.Pix[p+1], dst.Pix[p+2], dst.Pix[p+3]
+			if r == 0 || g == 0 || b == 0 || a != 255 {
+				t.Fatalf("%v: got pixel %v at %d", f, color.RGBA{r, g, b, a}, p/4)
+			}
+		}
+		if f == Box {
+			// Each source pixel covers 2x2 destination pixels.
+			if got := dst.RGBAAt(1, 1); got != src.RGBAAt(0, 0) {
+				t.Fatalf("%v: got %v, want %v", f, got, src.RGBAAt(0, 0))
+			}
+			if got := dst.RGBAAt(2, 3); got != src.RGBAAt(1, 1) {
+				t.Fatalf("%v: got %v, want %v", f, got, src.RGBAAt(1, 1))
+			}
+		}
+	}
+}
+
+func TestMipmapsGamma(t *testing.T) {
+	src := checker(8, 8)
+	linear := Mipmaps(src, &Options{Filter: Box})
+	correct := Mipmaps(src, &Options{Filter: Box, Gamma: 2.2})
+
+	// Averaging black and white without gamma correction gives a value of
+	// one half in gamma space, which is much too dark.
+	if v := linear[1].Pix[0]; v < 127 || v > 128 {
+		t.Errorf("without gamma: got %d, want 127-128", v)
+	}
+	// With gamma correction it gives one half in linear space.
+	if v := correct[1].Pix[0]; v < 185 || v > 187 {
+		t.Errorf("with gamma: got %d, want 185-187", v)
+	}
+}
+
+func TestEncodeDXTErrors(t *testing.T) {
+	if _, err := EncodeDXT(checker(4, 4), gfx.RGBA); err != ErrFormat {
+		t.Errorf("EncodeDXT: got %v, want ErrFormat", err)
+	}
+	if _, err := DecodeDXT(nil, 4, 4, gfx.RGB); err != ErrFormat {
+		t.Errorf("DecodeDXT: got %v, want ErrFormat", err)
+	}
+	if _, err := DecodeDXT(make([]byte, 8), 4, 4, gfx.DXT5); err != ErrShortData {
+		t.Errorf("DecodeDXT: got %v, want ErrShortData", err)
+	}
+}
+
+func TestDXTRoundTrip(t *testing.T) {
+	tests := []struct {
+		format          gfx.TexFormat
+		colorErr, alpha int
+	}{
+		{gfx.DXT1, 24, -1},
+		{gfx.DXT3, 24, 9},
+		{gfx.DXT5, 24, 4},
+	}
+	for _, tst := range tests {
+		for _, size := range []image.Point{{32, 32}, {13, 7}, {1, 1}} {
+			src := gradient(size.X, size.Y)
+			data, err := EncodeDXT(src, tst.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(data) != EncodedSize(size.X, size.Y, tst.format) {
+				t.Fatalf("got %d bytes, want %d", len(data), EncodedSize(size.X, size.Y, tst.format))
+			}
+			dst, err := DecodeDXT(data, size.X, size.Y, tst.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dst.Bounds().Size() != size {
+				t.Fatalf("decoded size %v, want %v", dst.Bounds().Size(), size)
+			}
+			if tst.alpha < 0 {
+				// DXT1 is opaque, compare against the colors only.
+				if e := maxError(src, dst, false); e > tst.colorErr {
+					t.Errorf("format %v size %v: color error %d > %d", tst.format, size, e, tst.colorErr)
+				}
+				continue
+			}
+			var alphaErr int
+			for i := 3; i < len(src.Pix); i += 4 {
+				d := int(src.Pix[i]) - int(dst.Pix[i])
+				if d < 0 {
+					d = -d
+				}
+				if d > alphaErr {
+					alphaErr = d
+				}
+			}
+			if alphaErr > tst.alpha {
+				t.Errorf("format %v size %v: alpha error %d > %d", tst.format, size, alphaErr, tst.alpha)
+			}
+			if e := maxError(src, dst, false); e > tst.colorErr {
+				t.Errorf("format %v size %v: color error %d > %d", tst.format, size, e, tst.colorErr)
+			}
+		}
+	}
+}
+
+func TestDXTExact(t *testing.T) {
+	// Two colors exactly representable in 5:6:5 must survive unchanged.
+	src := image.NewRGBA(image.Rect(0, 0, 8, 4))
+	for i := 0; i < 32; i++ {
+		c := color.RGBA{255, 0, 0, 255}
+		if i%3 == 0 {
+			c = color.RGBA{0, 0, 255, 255}
+		}
+		src.SetRGBA(i%8, i/8, c)
+	}
+	for _, f := range []gfx.TexFormat{gfx.DXT1, gfx.DXT1RGBA, gfx.DXT3, gfx.DXT5} {
+		data, _ := EncodeDXT(src, f)
+		dst, _ := DecodeDXT(data, 8, 4, f)
+		if e := maxError(src, dst, true); e != 0 {
+			t.Errorf("format %v: error %d, want 0", f, e)
+		}
+	}
+}
+
+func TestDXT1RGBA(t *testing.T) {
+	src := checker(8, 8)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			if x < 4 && y%2 == 0 {
+				src.SetRGBA(x, y, color.RGBA{})
+			}
+		}
+	}
+	data, _ := EncodeDXT(src, gfx.DXT1RGBA)
+	dst, _ := DecodeDXT(data, 8, 8, gfx.DXT1RGBA)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			want := src.RGBAAt(x, y)
+			if got := dst.RGBAAt(x, y); got != want {
+				t.Errorf("(%d, %d): got %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
+
+func BenchmarkMipmapsBox(b *testing.B) {
+	src := gradient(256, 256)
+	o := &Options{Filter: Box, Gamma: 2.2}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		Mipmaps(src, o)
+	}
+}
+
+func BenchmarkMipmapsKaiser(b *testing.B) {
+	src := gradient(256, 256)
+	o := &Options{Filter: Kaiser, Gamma: 2.2}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		Mipmaps(src, o)
+	}
+}
+
+func BenchmarkEncodeDXT1(b *testing.B) {
+	src := gradient(256, 256)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		EncodeDXT(src, gfx.DXT1)
+	}
+}
+
+func BenchmarkEncodeDXT5(b *testing.B) {
+	src := gradient(256, 256)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		EncodeDXT(src, gfx.DXT5)
+	}
+}
@@ -214,7 +214,7 @@ func (t *Transform) Parent() *Transform {
 // whether quaternion or euler rotation will be used by this transform.
 func (t *Transform) SetQuat(q math.Quat) {
 	t.access.Lock()
-	if (*t.quat) != q {
+	if t.quat == nil || *t.quat != q {
 		t.built = nil
 		t.quat = &q
 	}
@@ -344,6 +344,47 @@ func (t *Transform) Shear() math.Vec3 {
 	return s
 }
 
+// SetPose sets the position, quaternion rotation, scale and shear of this
+// transform at once to the ones described by the given pose.
+//
+// As with SetQuat, this transform will use quaternion rotation after this
+// call.
+func (t *Transform) SetPose(p Pose) {
+	t.access.Lock()
+	if t.quat == nil || *t.quat != p.Rot || t.pos != p.Pos || t.scale != p.Scale || t.shear != p.Shear {
+		t.built = nil
+		t.quat = &p.Rot
+		t.pos = p.Pos
+		t.scale = p.Scale
+		t.shear = p.Shear
+	}
+	t.access.Unlock()
+}
+
+// Pose returns the local position, rotation, scale and shear of this
+// transform as a pose, which may be interpolated or blended with others:
+//
+//  // Move t halfway towards b.
+//  t.SetPose(t.Pose().Lerp(b.Pose(), 0.5))
+//
+// If this transform is using euler rotation (see IsQuat) then it is converted
+// to a quaternion rotation.
+func (t *Transform) Pose() Pose {
+	t.access.RLock()
+	p := Pose{
+		Pos:   t.pos,
+		Scale: t.scale,
+		Shear: t.shear,
+	}
+	if t.quat != nil {
+		p.Rot = *t.quat
+	} else {
+		p.Rot = math.QuatFromHpr(t.rot.XyzToHpr().Radians(), math.CoordSysZUpRight)
+	}
+	t.access.RUnlock()
+	return p
+}
+
 // Reset sets all of the values of this transform to the default ones.
 func (t *Transform) Reset() {
 	t.access.Lock()
@@ -56,3 +56,21 @@ func (v Vec3) Vec3() math.Vec3 {
 func ConvertVec3(v math.Vec3) Vec3 {
 	return Vec3{float32(v.X), float32(v.Y), float32(v.Z)}
 }
+
+// Vec4 represents a 32-bit floating point four-component vector for
+// compatability with graphics hardware.
+// math.Vec4 should be used anywhere that an explicit 32-bit type is not
+// needed.
+type Vec4 struct {
+	X, Y, Z, W float32
+}
+
+// Vec4 converts this 32-bit Vec4 to a 64-bit math.Vec4 vector.
+func (v Vec4) Vec4() math.Vec4 {
+	return math.Vec4{float64(v.X), float64(v.Y), float64(v.Z), float64(v.W)}
+}
+
+// ConvertVec4 converts the 64-bit math.Vec4 to a 32-bit Vec4 vector.
+func ConvertVec4(v math.Vec4) Vec4 {
+	return Vec4{float32(v.X), float32(v.Y), float32(v.Z), float32(v.W)}
+}
@@ -0,0 +1,22 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package xform implements a transform graph with batched world-matrix
+// updates.
+//
+// A gfx.Transform recomputes (and compares against a copy of) it's parent
+// every time it's matrix is requested, which becomes costly for deep
+// hierarchies such as skeletons or large scene trees. In contrast a Graph
+// tracks which nodes have changed: modifying a node marks it and all of it's
+// descendants dirty, and Graph.Update recomputes only the dirty world
+// matrices, in a single pass over the nodes in topological (parents before
+// children) order.
+//
+// The world matrix of a single node may also be queried at any time, in which
+// case only the dirty ancestors of that node are recomputed.
+//
+// Node components (position, rotation, scale and shear) have the same meaning
+// as those of a gfx.Transform, such that a node produces the exact same
+// matrices as an equivalent gfx.Transform hierarchy.
+package xform
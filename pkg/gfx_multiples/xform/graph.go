@@ -0,0 +1,184 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xform
+
+import (
+	"azul3d.org/v1/math"
+	"sync"
+)
+
+// Graph is a forest of transform nodes. It is safe to use from multiple
+// goroutines concurrently, as are the nodes within it.
+type Graph struct {
+	access sync.Mutex
+
+	// All of the nodes in the graph, in topological order if reorder is false.
+	nodes []*Node
+
+	// Whether or not nodes must be reordered before the next batched update.
+	reorder bool
+
+	// The number of nodes with dirty world matrices.
+	dirty int
+}
+
+// NewNode creates and returns a new node in this graph with the given parent
+// (or nil for a root node). The node has the default components (i.e. an
+// identity local transformation).
+func (g *Graph) NewNode(parent *Node) *Node {
+	if parent != nil && parent.g != g {
+		panic("xform: parent node is not in the same graph")
+	}
+	g.access.Lock()
+	defer g.access.Unlock()
+	n := &Node{
+		g:          g,
+		scale:      math.Vec3One,
+		local:      math.Mat4Identity,
+		world:      math.Mat4Identity,
+		worldDirty: true,
+	}
+	g.dirty++
+	n.index = len(g.nodes)
+	g.nodes = append(g.nodes, n)
+	if parent != nil {
+		g.setParent(n, parent)
+	}
+	return n
+}
+
+// Remove removes the given node and all of it's descendants from the graph.
+// Removed nodes must not be used afterwards.
+func (g *Graph) Remove(n *Node) {
+	g.access.Lock()
+	if n.g != g {
+		g.access.Unlock()
+		panic("xform: node is not in the graph")
+	}
+	if n.parent != nil {
+		n.parent.removeChild(n)
+		n.parent = nil
+	}
+	removed := make(map[*Node]bool)
+	var visit func(n *Node)
+	visit = func(n *Node) {
+		removed[n] = true
+		if n.worldDirty {
+			g.dirty--
+		}
+		n.g = nil
+		for _, c := range n.children {
+			visit(c)
+		}
+	}
+	visit(n)
+	nodes := g.nodes[:0]
+	for _, other := range g.nodes {
+		if !removed[other] {
+			other.index = len(nodes)
+			nodes = append(nodes, other)
+		}
+	}
+	for i := len(nodes); i < len(g.nodes); i++ {
+		g.nodes[i] = nil
+	}
+	g.nodes = nodes
+	g.access.Unlock()
+}
+
+// Len returns the number of nodes in the graph.
+func (g *Graph) Len() int {
+	g.access.Lock()
+	l := len(g.nodes)
+	g.access.Unlock()
+	return l
+}
+
+// Dirty returns the number of nodes whose world matrix needs to be
+// recomputed.
+func (g *Graph) Dirty() int {
+	g.access.Lock()
+	d := g.dirty
+	g.access.Unlock()
+	return d
+}
+
+// Update recomputes the world matrix of every dirty node in the graph, in
+// topological order, and returns the number of nodes that were recomputed.
+func (g *Graph) Update() int {
+	g.access.Lock()
+	defer g.access.Unlock()
+	if g.dirty == 0 {
+		return 0
+	}
+	if g.reorder {
+		g.sort()
+	}
+	updated := 0
+	for _, n := range g.nodes {
+		if n.worldDirty {
+			n.updateWorld()
+			updated++
+		}
+	}
+	g.dirty = 0
+	return updated
+}
+
+// sort sorts the nodes into topological order (parents before children),
+// keeping the relative order of root nodes and of siblings. The graph's lock
+// must be held.
+func (g *Graph) sort() {
+	sorted := make([]*Node, 0, len(g.nodes))
+	var visit func(n *Node)
+	visit = func(n *Node) {
+		n.index = len(sorted)
+		sorted = append(sorted, n)
+		for _, c := range n.children {
+			visit(c)
+		}
+	}
+	for _, n := range g.nodes {
+		if n.parent == nil {
+			visit(n)
+		}
+	}
+	g.nodes = sorted
+	g.reorder = false
+}
+
+// setParent sets the parent of the node. The graph's lock must be held.
+func (g *Graph) setParent(n, p *Node) {
+	if n.parent == p {
+		return
+	}
+	if p != nil {
+		if p.g != g {
+			panic("xform: parent node is not in the same graph")
+		}
+		for a := p; a != nil; a = a.parent {
+			if a == n {
+				panic("xform: SetParent would create a cycle")
+			}
+		}
+	}
+	if n.parent != nil {
+		n.parent.removeChild(n)
+	}
+	n.parent = p
+	if p != nil {
+		p.children = append(p.children, n)
+		if p.index > n.index {
+			// The parent would come after the child.
+			g.reorder = true
+		}
+	}
+	n.markDirty()
+}
+
+// NewGraph returns a new, empty, transform graph.
+func NewGraph() *Graph {
+	return new(Graph)
+}
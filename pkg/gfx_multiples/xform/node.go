@@ -0,0 +1,262 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xform
+
+import "azul3d.org/v1/math"
+
+// Node is a single transform in a graph. It's components have the same
+// meaning as those of a gfx.Transform.
+type Node struct {
+	g        *Graph
+	parent   *Node
+	children []*Node
+
+	// Index of this node in the graph's node slice.
+	index int
+
+	// The position, rotation (HPR in radians, as built by gfx.Transform),
+	// scaling, and shearing components.
+	pos, hpr, scale, shear math.Vec3
+
+	// The quaternion rotation, if in use.
+	quat   math.Quat
+	isQuat bool
+
+	// The cached local and world matrices, and whether or not they must be
+	// recomputed.
+	local, world           math.Mat4
+	localDirty, worldDirty bool
+}
+
+// removeChild removes c from the children of n. The graph's lock must be held.
+func (n *Node) removeChild(c *Node) {
+	for i, child := range n.children {
+		if child == c {
+			n.children = append(n.children[:i], n.children[i+1:]...)
+			return
+		}
+	}
+}
+
+// markDirty marks the world matrix of this node and all of it's descendants
+// as dirty. The graph's lock must be held.
+//
+// If a node is dirty then all of it's descendants are as well, so marking
+// stops at nodes which are already dirty.
+func (n *Node) markDirty() {
+	if n.worldDirty {
+		return
+	}
+	n.worldDirty = true
+	n.g.dirty++
+	for _, c := range n.children {
+		c.markDirty()
+	}
+}
+
+// setLocalDirty marks the local matrix of this node as dirty. The graph's lock
+// must be held.
+func (n *Node) setLocalDirty() {
+	n.localDirty = true
+	n.markDirty()
+}
+
+// updateWorld recomputes the local (if needed) and world matrices of this
+// node, whose parent must have an up-to-date world matrix. The graph's lock
+// must be held.
+func (n *Node) updateWorld() {
+	if n.localDirty {
+		hpr := n.hpr
+		if n.isQuat {
+			hpr = n.quat.Hpr(math.CoordSysZUpRight)
+		}
+		scaleShearHpr := math.Mat3Compose(n.scale, n.shear, hpr, math.CoordSysZUpRight)
+		n.local = math.Mat4Identity.SetUpperMat3(scaleShearHpr)
+		n.local = n.local.SetTranslation(n.pos)
+		n.localDirty = false
+	}
+	n.world = n.local
+	if n.parent != nil {
+		n.world = n.world.Mul(n.parent.world)
+	}
+	n.worldDirty = false
+}
+
+// updateChain recomputes the world matrix of this node and of each of it's
+// dirty ancestors, without a batched update of the whole graph. The graph's
+// lock must be held.
+func (n *Node) updateChain() {
+	if !n.worldDirty {
+		return
+	}
+	if n.parent != nil {
+		n.parent.updateChain()
+	}
+	n.updateWorld()
+	n.g.dirty--
+}
+
+// Graph returns the graph that this node belongs to.
+func (n *Node) Graph() *Graph {
+	return n.g
+}
+
+// SetParent sets the parent of this node, or makes it a root node if p is nil.
+// The parent must be in the same graph and must not be a descendant of this
+// node (or this node itself), or else a panic will occur.
+func (n *Node) SetParent(p *Node) {
+	n.g.access.Lock()
+	defer n.g.access.Unlock()
+	n.g.setParent(n, p)
+}
+
+// Parent returns the parent of this node, or nil if it is a root node.
+func (n *Node) Parent() *Node {
+	n.g.access.Lock()
+	p := n.parent
+	n.g.access.Unlock()
+	return p
+}
+
+// Children returns a new slice of the children of this node.
+func (n *Node) Children() []*Node {
+	n.g.access.Lock()
+	c := make([]*Node, len(n.children))
+	copy(c, n.children)
+	n.g.access.Unlock()
+	return c
+}
+
+// SetPos sets the local position of this node.
+func (n *Node) SetPos(p math.Vec3) {
+	n.g.access.Lock()
+	if n.pos != p {
+		n.pos = p
+		n.setLocalDirty()
+	}
+	n.g.access.Unlock()
+}
+
+// Pos returns the local position of this node.
+func (n *Node) Pos() math.Vec3 {
+	n.g.access.Lock()
+	p := n.pos
+	n.g.access.Unlock()
+	return p
+}
+
+// SetRot sets the euler rotation of this node in degrees about their
+// respective axis, like gfx.Transform.SetRot.
+func (n *Node) SetRot(r math.Vec3) {
+	n.g.access.Lock()
+	hpr := r.XyzToHpr().Radians()
+	if n.isQuat || n.hpr != hpr {
+		n.hpr = hpr
+		n.isQuat = false
+		n.setLocalDirty()
+	}
+	n.g.access.Unlock()
+}
+
+// Rot returns the euler rotation of this node in degrees. If the node uses
+// quaternion rotation it is converted to euler rotation.
+func (n *Node) Rot() math.Vec3 {
+	n.g.access.Lock()
+	hpr := n.hpr
+	if n.isQuat {
+		hpr = n.quat.Hpr(math.CoordSysZUpRight)
+	}
+	n.g.access.Unlock()
+	return hpr.HprToXyz().Degrees()
+}
+
+// SetQuat sets the quaternion rotation of this node, like
+// gfx.Transform.SetQuat.
+func (n *Node) SetQuat(q math.Quat) {
+	n.g.access.Lock()
+	if !n.isQuat || n.quat != q {
+		n.quat = q
+		n.isQuat = true
+		n.setLocalDirty()
+	}
+	n.g.access.Unlock()
+}
+
+// Quat returns the quaternion rotation of this node. If the node uses euler
+// rotation it is converted to a quaternion.
+func (n *Node) Quat() math.Quat {
+	n.g.access.Lock()
+	q := n.quat
+	if !n.isQuat {
+		q = math.QuatFromHpr(n.hpr, math.CoordSysZUpRight)
+	}
+	n.g.access.Unlock()
+	return q
+}
+
+// SetScale sets the local scale of this node.
+func (n *Node) SetScale(s math.Vec3) {
+	n.g.access.Lock()
+	if n.scale != s {
+		n.scale = s
+		n.setLocalDirty()
+	}
+	n.g.access.Unlock()
+}
+
+// Scale returns the local scale of this node.
+func (n *Node) Scale() math.Vec3 {
+	n.g.access.Lock()
+	s := n.scale
+	n.g.access.Unlock()
+	return s
+}
+
+// SetShear sets the local shear of this node.
+func (n *Node) SetShear(s math.Vec3) {
+	n.g.access.Lock()
+	if n.shear != s {
+		n.shear = s
+		n.setLocalDirty()
+	}
+	n.g.access.Unlock()
+}
+
+// Shear returns the local shear of this node.
+func (n *Node) Shear() math.Vec3 {
+	n.g.access.Lock()
+	s := n.shear
+	n.g.access.Unlock()
+	return s
+}
+
+// LocalMat4 returns the matrix built from the components of this node (i.e.
+// not including any parent transformation).
+func (n *Node) LocalMat4() math.Mat4 {
+	n.g.access.Lock()
+	n.updateChain()
+	l := n.local
+	n.g.access.Unlock()
+	return l
+}
+
+// Mat4 returns the local-to-world matrix of this node, recomputing it (and
+// those of it's dirty ancestors) if needed. It implements the
+// gfx.Transformable interface.
+func (n *Node) Mat4() math.Mat4 {
+	n.g.access.Lock()
+	n.updateChain()
+	w := n.world
+	n.g.access.Unlock()
+	return w
+}
+
+// Dirty tells if the world matrix of this node must be recomputed.
+func (n *Node) Dirty() bool {
+	n.g.access.Lock()
+	d := n.worldDirty
+	n.g.access.Unlock()
+	return d
+}
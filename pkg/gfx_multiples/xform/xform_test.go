@@ -0,0 +1,241 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package xform
+
+import (
+	"azul3d.org/v1/gfx"
+	"azul3d.org/v1/math"
+	"testing"
+)
+
+func matNear(a, b math.Mat4) bool {
+	for i := range a {
+		for j := range a[i] {
+			d := a[i][j] - b[i][j]
+			if d < -1e-9 || d > 1e-9 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// chain builds a chain of n nodes and an equivalent chain of gfx.Transforms,
+// returning the leaves of both.
+func chain(g *Graph, n int) (nodes []*Node, transforms []*gfx.Transform) {
+	var (
+		parent  *Node
+		tParent *gfx.Transform
+	)
+	for i := 0; i < n; i++ {
+		node := g.NewNode(parent)
+		t := gfx.NewTransform()
+		t.SetParent(tParent)
+
+		pos := math.Vec3{float64(i), 1, float64(i % 3)}
+		rot := math.Vec3{float64(i * 5), 0, float64(i * 7)}
+		node.SetPos(pos)
+		node.SetRot(rot)
+		t.SetPos(pos)
+		t.SetRot(rot)
+		if i%4 == 0 {
+			node.SetScale(math.Vec3{1.1, 1, 0.9})
+			t.SetScale(math.Vec3{1.1, 1, 0.9})
+		}
+
+		nodes = append(nodes, node)
+		transforms = append(transforms, t)
+		parent, tParent = node, t
+	}
+	return
+}
+
+func TestMatchesTransform(t *testing.T) {
+	g := NewGraph()
+	nodes, transforms := chain(g, 10)
+	if n := g.Update(); n != 10 {
+		t.Fatalf("updated %d nodes, want 10", n)
+	}
+	for i, n := range nodes {
+		if !matNear(n.Mat4(), transforms[i].Mat4()) {
+			t.Errorf("node %d:\n%v\nwant\n%v", i, n.Mat4(), transforms[i].Mat4())
+		}
+		if !matNear(n.LocalMat4(), transforms[i].LocalMat4()) {
+			t.Errorf("node %d local matrix differs", i)
+		}
+	}
+}
+
+func TestDirtyPropagation(t *testing.T) {
+	g := NewGraph()
+	nodes, _ := chain(g, 10)
+	g.Update()
+	if g.Dirty() != 0 || g.Update() != 0 {
+		t.Fatal("graph should be clean")
+	}
+
+	nodes[5].SetPos(math.Vec3{1, 2, 3})
+	if g.Dirty() != 5 {
+		t.Errorf("got %d dirty nodes, want 5", g.Dirty())
+	}
+	if nodes[4].Dirty() || !nodes[5].Dirty() || !nodes[9].Dirty() {
+		t.Error("wrong nodes marked dirty")
+	}
+
+	// Setting an unchanged value does not dirty anything.
+	g.Update()
+	nodes[5].SetPos(math.Vec3{1, 2, 3})
+	if g.Dirty() != 0 {
+		t.Errorf("got %d dirty nodes, want 0", g.Dirty())
+	}
+
+	// Querying a single node only updates it's ancestors.
+	nodes[7].SetScale(math.Vec3{2, 2, 2})
+	nodes[8].Mat4()
+	if nodes[8].Dirty() || !nodes[9].Dirty() || g.Dirty() != 1 {
+		t.Errorf("lazy update: %d dirty nodes, want 1", g.Dirty())
+	}
+	if n := g.Update(); n != 1 {
+		t.Errorf("updated %d nodes, want 1", n)
+	}
+}
+
+func TestReparent(t *testing.T) {
+	g := NewGraph()
+	a := g.NewNode(nil)
+	b := g.NewNode(nil)
+	a.SetPos(math.Vec3{1, 0, 0})
+	b.SetPos(math.Vec3{0, 1, 0})
+
+	// Parent a to b, which comes later in order.
+	a.SetParent(b)
+	g.Update()
+	if got := a.Mat4().Translation(); !got.Equals(math.Vec3{1, 1, 0}) {
+		t.Errorf("got %v, want {1, 1, 0}", got)
+	}
+	if len(b.Children()) != 1 || a.Parent() != b {
+		t.Error("wrong hierarchy")
+	}
+
+	// Cycles are not allowed.
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected panic")
+			}
+		}()
+		b.SetParent(a)
+	}()
+
+	a.SetParent(nil)
+	g.Update()
+	if got := a.Mat4().Translation(); !got.Equals(math.Vec3{1, 0, 0}) {
+		t.Errorf("got %v, want {1, 0, 0}", got)
+	}
+
+	c := g.NewNode(a)
+	g.Remove(a)
+	if g.Len() != 1 || c.Graph() != nil {
+		t.Errorf("remove: %d nodes left, want 1", g.Len())
+	}
+	if g.Update() != 0 {
+		t.Error("removed nodes should not be updated")
+	}
+}
+
+func TestQuat(t *testing.T) {
+	g := NewGraph()
+	n := g.NewNode(nil)
+	tf := gfx.NewTransform()
+	q := math.QuatFromHpr(math.Vec3{0.5, 0.25, 0.1}, math.CoordSysZUpRight)
+	n.SetQuat(q)
+	tf.SetRot(q.Hpr(math.CoordSysZUpRight).HprToXyz().Degrees())
+	if !matNear(n.Mat4(), tf.Mat4()) {
+		t.Errorf("quaternion node:\n%v\nwant\n%v", n.Mat4(), tf.Mat4())
+	}
+	if n.Quat() != q {
+		t.Errorf("Quat() = %v, want %v", n.Quat(), q)
+	}
+}
+
+// tree builds a tree of the given depth with the given number of children per
+// node, returning the root and leaves of both a graph and an equivalent
+// gfx.Transform hierarchy.
+func tree(depth, fanout int) (root *Node, leaves []*Node, tRoot *gfx.Transform, tLeaves []*gfx.Transform) {
+	g := NewGraph()
+	root = g.NewNode(nil)
+	tRoot = gfx.NewTransform()
+	var build func(n *Node, t *gfx.Transform, d int)
+	build = func(n *Node, t *gfx.Transform, d int) {
+		if d == depth {
+			leaves = append(leaves, n)
+			tLeaves = append(tLeaves, t)
+			return
+		}
+		for i := 0; i < fanout; i++ {
+			c := g.NewNode(n)
+			ct := gfx.NewTransform()
+			ct.SetParent(t)
+			pos := math.Vec3{float64(i), 1, 0}
+			c.SetPos(pos)
+			ct.SetPos(pos)
+			build(c, ct, d+1)
+		}
+	}
+	build(root, tRoot, 0)
+	return
+}
+
+// The benchmarks below move the root of a hierarchy and then query the world
+// matrices of it's leaves.
+//
+// Note that gfx.Transform only compares against a copy of it's direct parent
+// to decide whether or not to rebuild, so changes to more distant ancestors
+// are not picked up (i.e. the Transform benchmarks may skip work that the
+// Graph benchmarks must perform).
+
+func BenchmarkGraphDeep(b *testing.B) {
+	g := NewGraph()
+	nodes, _ := chain(g, 64)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		nodes[0].SetPos(math.Vec3{float64(i), 0, 0})
+		g.Update()
+		nodes[63].Mat4()
+	}
+}
+
+func BenchmarkTransformDeep(b *testing.B) {
+	g := NewGraph()
+	_, transforms := chain(g, 64)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		transforms[0].SetPos(math.Vec3{float64(i), 0, 0})
+		transforms[63].Mat4()
+	}
+}
+
+func BenchmarkGraphWide(b *testing.B) {
+	root, leaves, _, _ := tree(4, 6)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		root.SetPos(math.Vec3{float64(i), 0, 0})
+		root.Graph().Update()
+		for _, l := range leaves {
+			l.Mat4()
+		}
+	}
+}
+
+func BenchmarkTransformWide(b *testing.B) {
+	_, _, root, leaves := tree(4, 6)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		root.SetPos(math.Vec3{float64(i), 0, 0})
+		for _, l := range leaves {
+			l.Mat4()
+		}
+	}
+}
@@ -20,6 +20,16 @@ var (
 	InvalidExt = errors.New("invalid file extension")
 )
 
+func init() {
+	// Custom vertex attribute data is stored in an interface, the concrete
+	// types must be registered for gob encoding.
+	gob.Register([]float32(nil))
+	gob.Register([]gfx.TexCoord(nil))
+	gob.Register([]gfx.Vec3(nil))
+	gob.Register([]gfx.Vec4(nil))
+	gob.Register([]int32(nil))
+}
+
 // Scene represents a single graphics scene.
 type Scene struct {
 	// A map of properties for the scene by name.
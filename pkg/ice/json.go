@@ -348,6 +348,22 @@ type jsonMesh struct {
 	Vertices       [][3]float32
 	Colors         [][4]float32
 	TexCoords      [][][2]float32
+	Normals        [][3]float32
+	Attribs        map[string]*jsonAttrib
+}
+
+// jsonAttrib is a single custom vertex attribute. The data slice holds the
+// components of each vertex one after another (e.g. X, Y, Z, X, Y, Z, ...
+// for a "vec3" attribute).
+type jsonAttrib struct {
+	Type string
+	Data []float64
+}
+
+func (j *jsonAttrib) attrib() gfx.VertexAttrib {
+	return gfx.VertexAttrib{
+		Data: attribData(j.Type, j.Data),
+	}
 }
 
 func (j *jsonMesh) mesh() *gfx.Mesh {
@@ -358,6 +374,7 @@ func (j *jsonMesh) mesh() *gfx.Mesh {
 		Vertices:       make([]gfx.Vec3, len(j.Vertices)),
 		Colors:         make([]gfx.Color, len(j.Colors)),
 		TexCoords:      make([]gfx.TexCoordSet, len(j.TexCoords)),
+		Normals:        make([]gfx.Vec3, len(j.Normals)),
 	}
 	m.AABB = j.AABB.rect3()
 	for i, v := range j.Indices {
@@ -375,6 +392,15 @@ func (j *jsonMesh) mesh() *gfx.Mesh {
 			m.TexCoords[tcs].Slice[i] = texCoord(v)
 		}
 	}
+	for i, v := range j.Normals {
+		m.Normals[i] = gfxVec3(v)
+	}
+	if len(j.Attribs) > 0 {
+		m.Attribs = make(map[string]gfx.VertexAttrib, len(j.Attribs))
+		for name, a := range j.Attribs {
+			m.Attribs[name] = a.attrib()
+		}
+	}
 	return m
 }
 
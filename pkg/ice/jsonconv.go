@@ -185,6 +185,45 @@ func gfxVec3(v [3]float32) gfx.Vec3 {
 	return gfx.Vec3{v[0], v[1], v[2]}
 }
 
+// attribData converts the flattened components of a custom vertex attribute
+// of the given type ("float", "vec2", "vec3", "vec4" or "int") into a data
+// slice suitable for gfx.VertexAttrib. Unknown types return nil.
+func attribData(typ string, v []float64) interface{} {
+	switch typ {
+	case "float":
+		d := make([]float32, len(v))
+		for i := range d {
+			d[i] = float32(v[i])
+		}
+		return d
+	case "vec2":
+		d := make([]gfx.TexCoord, len(v)/2)
+		for i := range d {
+			d[i] = gfx.TexCoord{float32(v[i*2]), float32(v[i*2+1])}
+		}
+		return d
+	case "vec3":
+		d := make([]gfx.Vec3, len(v)/3)
+		for i := range d {
+			d[i] = gfx.Vec3{float32(v[i*3]), float32(v[i*3+1]), float32(v[i*3+2])}
+		}
+		return d
+	case "vec4":
+		d := make([]gfx.Vec4, len(v)/4)
+		for i := range d {
+			d[i] = gfx.Vec4{float32(v[i*4]), float32(v[i*4+1]), float32(v[i*4+2]), float32(v[i*4+3])}
+		}
+		return d
+	case "int":
+		d := make([]int32, len(v))
+		for i := range d {
+			d[i] = int32(v[i])
+		}
+		return d
+	}
+	return nil
+}
+
 func vec3(v [3]float64) math.Vec3 {
 	return math.Vec3{v[0], v[1], v[2]}
 }
@@ -14,7 +14,5 @@
 // searches for objects intersecting or completely contained within some
 // defined space (a 3D rectangle, sphere, or viewing frustum).
 //
-// TODO: add Contains
-// TODO: add Intersects
 // TODO: nearest to point, nearest to ... Distancer?
 package octree
@@ -44,8 +44,8 @@ func (ff frustum) Contains(bb gfx.Boundable) bool {
 // Frustum returns a Container usable for searching for objects inside or
 // intersecting with the given viewing frustum (projection) matrix, for
 // instance:
-//  tree.In(Frustum(f), results, stop)
-//  tree.Intersect(Frustum(f), results, stop)
+//  tree.In(Frustum(f), callback)
+//  tree.Intersect(Frustum(f), callback)
 //
 // The matrix may be composed (e.g. view * projection).
 func Frustum(f lmath.Mat4) Container {
@@ -20,8 +20,8 @@ func (c rect) Contains(b gfx.Boundable) bool {
 
 // Rect3 returns a Container usable for searching for objects inside or
 // intersecting with the given 3D rectangle, for instance:
-//  tree.In(Rect3(r), results, stop)
-//  tree.Intersect(Rect3(r), results, stop)
+//  tree.In(Rect3(r), callback)
+//  tree.Intersect(Rect3(r), callback)
 func Rect3(r lmath.Rect3) Container {
 	return rect(r)
 }
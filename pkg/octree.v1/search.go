@@ -0,0 +1,92 @@
+// Copyright 2014 The Azul3D Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package octree
+
+import (
+	"azul3d.org/gfx.v1"
+)
+
+// search invokes the callback for each object in the node n, or any node below
+// it, that is a valid result of the search. Only Intersects is asked of the
+// search, unless contain is true, in which case s must be a Container.
+//
+// The search is performed against the bounds the objects had when they where
+// added to the tree (or last updated), and not their current bounds.
+//
+// False is returned if the callback stopped the search.
+//
+// The tree's read lock must be held for this method to operate safely.
+func (n *Node) search(s Intersector, contain bool, callback func(b gfx.Boundable) bool) bool {
+	// If the node is not at all intersecting, then there is no need to search
+	// it or it's children.
+	nb := gfx.Bounds(n.bounds)
+	if !n.bounds.Empty() && !s.Intersects(nb) {
+		return true
+	}
+
+	// If the node is completely contained, then all of it's objects are valid
+	// results, and so are those of it's children.
+	all := contain && !n.bounds.Empty() && s.(Container).Contains(nb)
+
+	for _, octObjs := range n.objects {
+		for _, e := range octObjs {
+			if !all {
+				eb := gfx.Bounds(*e.bounds)
+				if contain && !s.(Container).Contains(eb) {
+					continue
+				}
+				if !contain && !s.Intersects(eb) {
+					continue
+				}
+			}
+			if !callback(e.b) {
+				return false
+			}
+		}
+	}
+
+	for _, child := range n.children {
+		if child != nil && !child.search(s, contain, callback) {
+			return false
+		}
+	}
+	return true
+}
+
+// Intersect invokes the callback for each object in the tree that is
+// intersecting the search area, for instance:
+//  tree.Intersect(Sphere(s), func(b gfx.Boundable) bool {
+//      fmt.Println(b, "is intersecting the sphere")
+//      return true
+//  })
+//
+// If the callback returns false, the search is stopped. The tree is read
+// locked during the search, so the callback must not modify it.
+//
+// The search is performed against the bounds of objects at the time they where
+// added to the tree (or last updated, see Update).
+func (t *Tree) Intersect(s Intersector, callback func(b gfx.Boundable) bool) {
+	t.RLock()
+	t.root.search(s, false, callback)
+	t.RUnlock()
+}
+
+// In invokes the callback for each object in the tree that is completely
+// contained within the search area, for instance:
+//  tree.In(Rect3(r), func(b gfx.Boundable) bool {
+//      fmt.Println(b, "is inside the rectangle")
+//      return true
+//  })
+//
+// If the callback returns false, the search is stopped. The tree is read
+// locked during the search, so the callback must not modify it.
+//
+// The search is performed against the bounds of objects at the time they where
+// added to the tree (or last updated, see Update).
+func (t *Tree) In(c Container, callback func(b gfx.Boundable) bool) {
+	t.RLock()
+	t.root.search(c, true, callback)
+	t.RUnlock()
+}
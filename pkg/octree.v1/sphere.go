@@ -21,8 +21,8 @@ func (s sphere) Contains(b gfx.Boundable) bool {
 
 // Sphere returns a Container usable for searching for objects inside or
 // intersecting with the given 3D sphere, for instance:
-//  tree.In(Sphere(1.0, math.Vec3{0, 0, 0}), results, stop)
-//  tree.Intersect(Sphere(1.0, math.Vec3{0, 0, 0}), results, stop)
+//  tree.In(Sphere(1.0, math.Vec3{0, 0, 0}), callback)
+//  tree.Intersect(Sphere(1.0, math.Vec3{0, 0, 0}), callback)
 func Sphere(s lmath.Sphere) Container {
 	return sphere(s)
 }
@@ -145,7 +145,7 @@ func (t *Tree) Has(b gfx.Boundable) bool {
 // decimate removes the node n from the tree if it does not have any objects or
 // child nodes.
 func (t *Tree) decimate(n *Node) {
-	if n.parent != nil {
+	if n.parent == nil {
 		// No parent node, it must be the root node. We can't decimate it.
 		return
 	}
@@ -165,13 +165,13 @@ func (t *Tree) decimate(n *Node) {
 	}
 
 	// Remove from the parent's children list.
-	n.parent = nil
 	for nodeIndex, node := range n.parent.children {
 		if n == node {
 			n.parent.children[nodeIndex] = nil
 			t.numNodes--
 		}
 	}
+	n.parent = nil
 }
 
 // Remove tries to remove the given boundable object from the tree. The bounds
@@ -255,7 +255,7 @@ func (t *Tree) Update(old, b gfx.Boundable) bool {
 
 				// It still fits in this octant node though: add it to that node.
 				addTarget = n
-			} else {
+			} else if n.parent != nil {
 				fitsParent := bb.In(n.parent.bounds)
 				if fitsParent {
 					// It still fits in the parent node; add it there.
@@ -100,6 +100,104 @@ func TestRemoval(t *testing.T) {
 	}
 }
 
+func TestRemoveLast(t *testing.T) {
+	tree := New()
+	o := random()
+	tree.Add(o)
+	if !tree.Remove(o) {
+		t.Fatal("Failed to remove the only object in the tree.")
+	}
+	if tree.NumObjects() != 0 {
+		t.Fatal("NumObjects", tree.NumObjects(), "want 0")
+	}
+}
+
+func TestUpdateOutside(t *testing.T) {
+	tree := New()
+	o := random()
+	tree.Add(o)
+
+	// Move the object far outside of the root node.
+	moved := gfx.Bounds(lmath.Rect3(o).Add(lmath.Vec3{100, 100, 100}))
+	if !tree.Update(o, moved) {
+		t.Fatal("Failed to update the object.")
+	}
+	if !tree.Has(moved) || tree.Has(o) {
+		t.Fatal("Update did not replace the object.")
+	}
+}
+
+// testSearch tests that the search finds the same objects as testing each
+// object by brute force.
+func testSearch(t *testing.T, c Container, contain bool) {
+	tree := New()
+	objs := make([]gfx.Boundable, 1000)
+	for i := range objs {
+		objs[i] = random()
+		tree.Add(objs[i])
+	}
+
+	found := make(map[gfx.Boundable]bool)
+	callback := func(b gfx.Boundable) bool {
+		if found[b] {
+			t.Fatal("Object found twice.")
+		}
+		found[b] = true
+		return true
+	}
+	if contain {
+		tree.In(c, callback)
+	} else {
+		tree.Intersect(c, callback)
+	}
+
+	want := 0
+	for _, o := range objs {
+		valid := c.Intersects(o)
+		if contain {
+			valid = c.Contains(o)
+		}
+		if valid {
+			want++
+		}
+		if valid != found[o] {
+			t.Fatalf("Object %v: got %v want %v", o, found[o], valid)
+		}
+	}
+	if want == 0 {
+		t.Fatal("Nothing to search for.")
+	}
+}
+
+func TestIntersect(t *testing.T) {
+	testSearch(t, Rect3(lmath.Rect3{
+		Min: lmath.Vec3{-.25, -.25, -.25},
+		Max: lmath.Vec3{.1, .1, .1},
+	}), false)
+}
+
+func TestIn(t *testing.T) {
+	testSearch(t, Sphere(lmath.Sphere{Radius: .3}), true)
+}
+
+func TestSearchStop(t *testing.T) {
+	tree := New()
+	for i := 0; i < 500; i++ {
+		tree.Add(random())
+	}
+	n := 0
+	tree.Intersect(Rect3(lmath.Rect3{
+		Min: lmath.Vec3{-1, -1, -1},
+		Max: lmath.Vec3{1, 1, 1},
+	}), func(b gfx.Boundable) bool {
+		n++
+		return n < 10
+	})
+	if n != 10 {
+		t.Fatal("Search visited", n, "objects after being stopped, want 10")
+	}
+}
+
 // Benchmarks the cost of updating a single boundable in the octree by adding,
 // removing, then adding the new version of it.
 // This is to see how much faster the Update method is in comparison.
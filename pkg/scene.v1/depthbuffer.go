@@ -0,0 +1,198 @@
+package scene
+
+import (
+	"math"
+
+	"azul3d.org/gfx.v1"
+	"azul3d.org/lmath.v1"
+)
+
+// clipEpsilon is the minimum clip-space W coordinate of a vertex that is
+// rasterized, vertices nearer to (or behind) the eye are not.
+const clipEpsilon = 1e-6
+
+// DepthBuffer is a software depth buffer, used for occlusion culling without
+// hardware occlusion queries (see Occlusion). Occluders are rasterized into it
+// with DrawObject or DrawTriangle, and bounding boxes are then tested against
+// it with Occluded.
+//
+// The depth buffer is typically much smaller than the viewport, as it only
+// needs to be precise enough to cull objects hidden behind large occluders.
+type DepthBuffer struct {
+	// The size of the depth buffer, in pixels.
+	Width, Height int
+
+	// The depth of each pixel (from the bottom-left), in the range of zero
+	// (the near plane) to one (the far plane), or +Inf if nothing is there.
+	Depth []float64
+
+	// The view-projection matrix.
+	vp lmath.Mat4
+}
+
+// Clear clears the depth buffer and sets the view-projection matrix, which
+// transforms world space coordinates into clip space, used for the next
+// draws and tests.
+func (d *DepthBuffer) Clear(vp lmath.Mat4) {
+	d.vp = vp
+	inf := math.Inf(1)
+	for i := range d.Depth {
+		d.Depth[i] = inf
+	}
+}
+
+// screen returns the window coordinates (and depth, in the range of zero to
+// one) of the given clip space coordinates.
+func (d *DepthBuffer) screen(c lmath.Vec4) lmath.Vec3 {
+	return lmath.Vec3{
+		X: (c.X/c.W*0.5 + 0.5) * float64(d.Width),
+		Y: (c.Y/c.W*0.5 + 0.5) * float64(d.Height),
+		Z: c.Z/c.W*0.5 + 0.5,
+	}
+}
+
+// clip transforms the given point by the given matrix into clip space.
+func clip(p lmath.Vec3, m lmath.Mat4) lmath.Vec4 {
+	return lmath.Vec4{p.X, p.Y, p.Z, 1}.Transform(m)
+}
+
+// edge returns twice the signed area of the triangle a, b, p.
+func edge(a, b, p lmath.Vec3) float64 {
+	return (b.X-a.X)*(p.Y-a.Y) - (b.Y-a.Y)*(p.X-a.X)
+}
+
+// rasterize rasterizes the triangle of the given clip space coordinates.
+func (d *DepthBuffer) rasterize(ca, cb, cc lmath.Vec4) {
+	// Triangles crossing the near plane are skipped, as they would need to be
+	// clipped. Skipping an occluder is conservative, it only means less is
+	// occluded.
+	if ca.W < clipEpsilon || cb.W < clipEpsilon || cc.W < clipEpsilon {
+		return
+	}
+	a, b, c := d.screen(ca), d.screen(cb), d.screen(cc)
+	area := edge(a, b, c)
+	if area == 0 {
+		return
+	}
+
+	// The bounding rectangle of the triangle, clamped to the buffer.
+	minX := int(math.Max(0, math.Floor(math.Min(a.X, math.Min(b.X, c.X)))))
+	minY := int(math.Max(0, math.Floor(math.Min(a.Y, math.Min(b.Y, c.Y)))))
+	maxX := int(math.Min(float64(d.Width-1), math.Ceil(math.Max(a.X, math.Max(b.X, c.X)))))
+	maxY := int(math.Min(float64(d.Height-1), math.Ceil(math.Max(a.Y, math.Max(b.Y, c.Y)))))
+
+	for y := minY; y <= maxY; y++ {
+		for x := minX; x <= maxX; x++ {
+			// Sample at the pixel center, the triangle may be wound either way.
+			p := lmath.Vec3{X: float64(x) + 0.5, Y: float64(y) + 0.5}
+			w0 := edge(b, c, p) / area
+			w1 := edge(c, a, p) / area
+			w2 := edge(a, b, p) / area
+			if w0 < 0 || w1 < 0 || w2 < 0 {
+				continue
+			}
+
+			// Depth is affine in window space.
+			z := w0*a.Z + w1*b.Z + w2*c.Z
+			if z < 0 || z > 1 {
+				continue
+			}
+			i := y*d.Width + x
+			if z < d.Depth[i] {
+				d.Depth[i] = z
+			}
+		}
+	}
+}
+
+// DrawTriangle rasterizes the given world space triangle into the depth
+// buffer.
+func (d *DepthBuffer) DrawTriangle(a, b, c lmath.Vec3) {
+	d.rasterize(clip(a, d.vp), clip(b, d.vp), clip(c, d.vp))
+}
+
+// DrawObject rasterizes the triangles of each mesh of the given object into
+// the depth buffer. Meshes whose data was not kept after being loaded (see
+// gfx.Mesh.KeepDataOnLoad) are not rasterized.
+//
+// This method properly read-locks the object and it's meshes.
+func (d *DepthBuffer) DrawObject(o *gfx.Object) {
+	o.RLock()
+	defer o.RUnlock()
+
+	mvp := d.vp
+	if o.Transform != nil {
+		mvp = o.Transform.Convert(gfx.LocalToWorld).Mul(d.vp)
+	}
+	vertex := func(v gfx.Vec3) lmath.Vec4 {
+		return clip(lmath.Vec3{float64(v.X), float64(v.Y), float64(v.Z)}, mvp)
+	}
+	for _, m := range o.Meshes {
+		m.RLock()
+		if len(m.Indices) > 0 {
+			for i := 0; i+2 < len(m.Indices); i += 3 {
+				a, b, c := m.Indices[i], m.Indices[i+1], m.Indices[i+2]
+				if int(a) >= len(m.Vertices) || int(b) >= len(m.Vertices) || int(c) >= len(m.Vertices) {
+					continue
+				}
+				d.rasterize(vertex(m.Vertices[a]), vertex(m.Vertices[b]), vertex(m.Vertices[c]))
+			}
+		} else {
+			for i := 0; i+2 < len(m.Vertices); i += 3 {
+				d.rasterize(vertex(m.Vertices[i]), vertex(m.Vertices[i+1]), vertex(m.Vertices[i+2]))
+			}
+		}
+		m.RUnlock()
+	}
+}
+
+// Occluded tells if the given world space bounding box is completely hidden
+// behind the occluders drawn into the depth buffer. It is conservative: the
+// screen-space rectangle and nearest depth of the box are tested, and boxes
+// which the eye is near to (or inside of) are never occluded.
+func (d *DepthBuffer) Occluded(b lmath.Rect3) bool {
+	var (
+		minX, minY = math.Inf(1), math.Inf(1)
+		maxX, maxY = math.Inf(-1), math.Inf(-1)
+		near       = math.Inf(1)
+	)
+	for _, corner := range b.Corners() {
+		c := clip(corner, d.vp)
+		if c.W < clipEpsilon {
+			return false
+		}
+		s := d.screen(c)
+		minX, maxX = math.Min(minX, s.X), math.Max(maxX, s.X)
+		minY, maxY = math.Min(minY, s.Y), math.Max(maxY, s.Y)
+		near = math.Min(near, s.Z)
+	}
+	if near < 0 {
+		// Crosses the near plane.
+		return false
+	}
+
+	// Every pixel the rectangle touches must be nearer than the box.
+	x0 := int(math.Max(0, math.Floor(minX)))
+	y0 := int(math.Max(0, math.Floor(minY)))
+	x1 := int(math.Min(float64(d.Width-1), math.Ceil(maxX)))
+	y1 := int(math.Min(float64(d.Height-1), math.Ceil(maxY)))
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			if d.Depth[y*d.Width+x] >= near {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// NewDepthBuffer returns a new depth buffer of the given size.
+func NewDepthBuffer(width, height int) *DepthBuffer {
+	d := &DepthBuffer{
+		Width:  width,
+		Height: height,
+		Depth:  make([]float64, width*height),
+	}
+	d.Clear(lmath.Mat4Identity)
+	return d
+}
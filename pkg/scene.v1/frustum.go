@@ -0,0 +1,92 @@
+package scene
+
+import (
+	"azul3d.org/gfx.v1"
+	"azul3d.org/lmath.v1"
+)
+
+var (
+	// Get an matrix which will translate our matrix from ZUpRight to YUpRight
+	zUpRightToYUpRight = lmath.CoordSysZUpRight.ConvertMat4(lmath.CoordSysYUpRight)
+)
+
+// plane is a plane in the form a*x + b*y + c*z + d = 0, points for which the
+// left-hand side is positive are in front of it.
+type plane struct {
+	a, b, c, d float64
+}
+
+// frustum is a viewing frustum described by six planes facing inwards.
+type frustum [6]plane
+
+// viewProjection returns the matrix which transforms world coordinates into
+// clip space coordinates for the given camera.
+//
+// The camera's read lock must be held for this function to operate safely.
+func viewProjection(cam *gfx.Camera) lmath.Mat4 {
+	view, _ := cam.Transform.Convert(gfx.LocalToWorld).Inverse()
+	view = view.Mul(zUpRightToYUpRight)
+	return view.Mul(cam.Projection.Mat4())
+}
+
+// eyePos returns the world space position of the given camera.
+//
+// The camera's read lock must be held for this function to operate safely.
+func eyePos(cam *gfx.Camera) lmath.Vec3 {
+	return cam.Transform.ConvertPos(lmath.Vec3{}, gfx.LocalToWorld)
+}
+
+// newFrustum returns the frustum of the given view-projection matrix (see
+// viewProjection).
+//
+// As points are row vectors the clip space coordinates are dot products of
+// the point and the matrix's columns, and the planes are extracted as
+// described in:
+//  Fast Extraction of Viewing Frustum Planes from the World-View-Projection
+//  Matrix, by Gil Gribb and Klaus Hartmann.
+func newFrustum(m lmath.Mat4) frustum {
+	col := func(i int) plane {
+		return plane{m[0][i], m[1][i], m[2][i], m[3][i]}
+	}
+	add := func(p, q plane, sign float64) plane {
+		return plane{p.a + sign*q.a, p.b + sign*q.b, p.c + sign*q.c, p.d + sign*q.d}
+	}
+	w := col(3)
+	return frustum{
+		add(w, col(0), 1),  // Left.
+		add(w, col(0), -1), // Right.
+		add(w, col(1), 1),  // Bottom.
+		add(w, col(1), -1), // Top.
+		add(w, col(2), 1),  // Near.
+		add(w, col(2), -1), // Far.
+	}
+}
+
+// intersects tells if the given bounding box is (at least partially) inside
+// of the frustum. It is conservative: boxes near the corners of the frustum
+// may be reported as intersecting even though they are outside of it.
+func (f frustum) intersects(b lmath.Rect3) bool {
+	for _, p := range f {
+		// Test the corner of the box furthest along the plane's normal, if
+		// it is behind the plane then so is the entire box.
+		x, y, z := b.Min.X, b.Min.Y, b.Min.Z
+		if p.a >= 0 {
+			x = b.Max.X
+		}
+		if p.b >= 0 {
+			y = b.Max.Y
+		}
+		if p.c >= 0 {
+			z = b.Max.Z
+		}
+		if p.a*x+p.b*y+p.c*z+p.d < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Intersects implements the octree.Intersector interface, see intersects.
+func (f frustum) Intersects(b gfx.Boundable) bool {
+	return f.intersects(b.Bounds())
+}
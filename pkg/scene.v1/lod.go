@@ -0,0 +1,379 @@
+package scene
+
+import (
+	"image"
+	"math"
+	"sync"
+	"time"
+
+	"azul3d.org/gfx.v1"
+	"azul3d.org/lmath.v1"
+)
+
+// LODMetric describes how the level of detail of an LOD is selected.
+type LODMetric uint8
+
+const (
+	// Distance selects levels by the distance of the object to the camera,
+	// the threshold of each level is the maximum distance at which it is
+	// drawn.
+	Distance LODMetric = iota
+
+	// ScreenSize selects levels by the size of the object on screen, as a
+	// fraction of the height of the viewport. The threshold of each level is
+	// the minimum size at which it is drawn.
+	ScreenSize
+)
+
+// FadeInput is the name of the shader input, a float32 in the range of zero
+// to one, by which a level's shader must multiply the alpha of it's output for
+// levels to be cross-faded, see LOD.SetFade. It is one whenever a level is not
+// being faded.
+const FadeInput = "LODFade"
+
+// level is a single level of detail.
+type level struct {
+	threshold float64
+
+	// The object drawn for this level, and the object (with it's own copy of
+	// the level's shader) drawn while fading in or out of this level.
+	o, fade *gfx.Object
+}
+
+// lodState is the state of an LOD for a single camera.
+type lodState struct {
+	// The current level, and the level being faded out of (or -1).
+	level, prev int
+
+	// The time at which the last change of levels occurred.
+	changed time.Time
+}
+
+// LOD is a drawable which draws one of multiple levels of detail (i.e. sets
+// of meshes and their shader) of an object, selected for each camera that it
+// is drawn with. Levels are added most detailed first (see AddLevel):
+//  lod := scene.NewLOD(tree, scene.Distance)
+//  lod.AddLevel(highMeshes, shader, 20)
+//  lod.AddLevel(lowMeshes, shader, 100)
+//  lod.AddLevel(billboard, billboardShader, math.Inf(1))
+//
+// Each level is drawn with the transform, graphics state and textures of the
+// base object given to NewLOD.
+//
+// An LOD implements the gfx.Boundable interface (using the bounds of the most
+// detailed level) such that it is culled when in a Spatial scene.
+type LOD struct {
+	access     sync.Mutex
+	base       *gfx.Object
+	metric     LODMetric
+	levels     []*level
+	hysteresis float64
+	fadeTime   time.Duration
+	cams       map[*gfx.Camera]*lodState
+}
+
+// AddLevel adds a new level of detail, less detailed than the ones added
+// before it, drawing the given meshes with the given shader.
+//
+// For the Distance metric the threshold is the maximum distance at which the
+// level is drawn, and thresholds should be increasing. For the ScreenSize
+// metric the threshold is the minimum size at which the level is drawn, and
+// thresholds should be decreasing. If no level matches then nothing is drawn,
+// such that distant (or small) objects are culled, use a threshold of
+// math.Inf(1) (or zero) for the last level to always draw it.
+//
+// The shader's FadeInput input is set to one, such that shaders which support
+// cross-fading (see SetFade) are fully visible when the level is not faded.
+func (l *LOD) AddLevel(meshes []*gfx.Mesh, shader *gfx.Shader, threshold float64) {
+	if shader != nil {
+		shader.Lock()
+		if shader.Inputs == nil {
+			shader.Inputs = make(map[string]interface{})
+		}
+		shader.Inputs[FadeInput] = float32(1)
+		shader.Unlock()
+	}
+
+	o := gfx.NewObject()
+	o.Shader = shader
+	o.Meshes = meshes
+
+	l.access.Lock()
+	l.levels = append(l.levels, &level{threshold: threshold, o: o})
+	l.access.Unlock()
+}
+
+// SetHysteresis sets the hysteresis used to avoid popping when the object is
+// near the threshold of a level, as a fraction of the threshold. For instance
+// with a hysteresis of 0.1 and a Distance threshold of 100, the level changes
+// to a less detailed one beyond a distance of 110 and only changes back
+// closer than 90. The default is zero.
+func (l *LOD) SetHysteresis(h float64) {
+	l.access.Lock()
+	l.hysteresis = h
+	l.access.Unlock()
+}
+
+// SetFade sets the duration over which levels are cross-faded, or zero
+// (the default) to switch between levels instantly.
+//
+// While fading both levels are drawn using gfx.AlphaToCoverage, each with a
+// copy of it's shader whose inputs are those of the level's shader, except
+// FadeInput which is the fraction of the level that is visible. Shaders must
+// multiply the alpha of their output by it, and must keep their GLSL sources
+// after being loaded (see gfx.Shader.KeepDataOnLoad) such that they can be
+// copied. Levels whose shader has no sources are switched instantly.
+//
+// The copied shaders are shared by all cameras, if multiple cameras are
+// fading the same level at once the fade of the last one drawn is used.
+func (l *LOD) SetFade(d time.Duration) {
+	l.access.Lock()
+	l.fadeTime = d
+	l.access.Unlock()
+}
+
+// Level returns the index of the level most recently selected for the given
+// camera, len(levels) if no level was, or -1 if the LOD has not been drawn
+// with the camera.
+func (l *LOD) Level(cam *gfx.Camera) int {
+	l.access.Lock()
+	defer l.access.Unlock()
+	if st, ok := l.cams[cam]; ok {
+		return st.level
+	}
+	return -1
+}
+
+// Forget forgets the state (the selected level) of the given camera, which
+// should be done once the camera will no longer be used with the LOD.
+func (l *LOD) Forget(cam *gfx.Camera) {
+	l.access.Lock()
+	delete(l.cams, cam)
+	l.access.Unlock()
+}
+
+// Bounds implements the gfx.Boundable interface by returning the bounds of
+// the most detailed level.
+func (l *LOD) Bounds() lmath.Rect3 {
+	l.access.Lock()
+	defer l.access.Unlock()
+	if len(l.levels) == 0 {
+		return lmath.Rect3Zero
+	}
+	l.sync(l.levels[0].o)
+	return l.levels[0].o.Bounds()
+}
+
+// sync updates the given level object with the transform, state and textures
+// of the base object.
+//
+// The LOD's lock must be held for this method to operate safely.
+func (l *LOD) sync(o *gfx.Object) {
+	l.base.RLock()
+	o.Lock()
+	o.Transform = l.base.Transform
+	o.State = l.base.State
+	o.OcclusionTest = l.base.OcclusionTest
+	o.Textures = append(o.Textures[:0], l.base.Textures...)
+	o.Unlock()
+	l.base.RUnlock()
+}
+
+// metricValue returns the value of the LOD's metric for the given camera.
+//
+// The LOD's lock must be held for this method to operate safely.
+func (l *LOD) metricValue(cam *gfx.Camera, b lmath.Rect3) float64 {
+	cam.RLock()
+	eye := eyePos(cam)
+	proj := cam.Projection.Mat4()
+	cam.RUnlock()
+
+	dist := b.Center().Sub(eye).Length()
+	if l.metric == Distance {
+		return dist
+	}
+
+	// The fraction of the viewport's height covered by the bounding sphere,
+	// proj[1][1] is the vertical scale of the projection.
+	radius := b.Size().Length() / 2
+	if proj[3][3] == 1 {
+		// Orthographic projection.
+		return radius * proj[1][1]
+	}
+	return radius * proj[1][1] / math.Max(dist, 1e-9)
+}
+
+// pick returns the index of the first level whose threshold (scaled by k)
+// matches the given metric value, or len(levels) if none does.
+//
+// The LOD's lock must be held for this method to operate safely.
+func (l *LOD) pick(m, k float64) int {
+	for i, lvl := range l.levels {
+		if l.metric == Distance && m < lvl.threshold*k {
+			return i
+		}
+		if l.metric == ScreenSize && m >= lvl.threshold/k {
+			return i
+		}
+	}
+	return len(l.levels)
+}
+
+// choose returns the level to draw for the given metric value, given the
+// current level (or -1), applying hysteresis.
+//
+// The LOD's lock must be held for this method to operate safely.
+func (l *LOD) choose(m float64, cur int) int {
+	if cur < 0 {
+		return l.pick(m, 1)
+	}
+	if coarser := l.pick(m, 1+l.hysteresis); coarser > cur {
+		return coarser
+	}
+	if finer := l.pick(m, 1-l.hysteresis); finer < cur {
+		return finer
+	}
+	return cur
+}
+
+// copyShader returns a copy of the given shader with it's own (empty) inputs,
+// or nil if the shader's sources have not been kept.
+func copyShader(s *gfx.Shader) *gfx.Shader {
+	if s == nil {
+		return nil
+	}
+	s.RLock()
+	defer s.RUnlock()
+	if len(s.GLSLVert) == 0 || len(s.GLSLFrag) == 0 {
+		return nil
+	}
+	return &gfx.Shader{
+		Name:           s.Name,
+		GLSLVert:       s.GLSLVert,
+		GLSLFrag:       s.GLSLFrag,
+		KeepDataOnLoad: true,
+		Inputs:         make(map[string]interface{}, len(s.Inputs)+1),
+	}
+}
+
+// copyInputs replaces the inputs of the shader dst with those of the shader
+// src, such that changes made to the inputs of a level's shader are seen by
+// it's copy.
+//
+// The write lock of dst and the read lock of src must be held for this
+// function to operate safely.
+func copyInputs(dst, src *gfx.Shader) {
+	for name := range dst.Inputs {
+		if _, ok := src.Inputs[name]; !ok {
+			delete(dst.Inputs, name)
+		}
+	}
+	for name, v := range src.Inputs {
+		dst.Inputs[name] = v
+	}
+}
+
+// fadeObject returns the object to draw the given level with, faded by f.
+// It returns nil if the level cannot be faded.
+//
+// The LOD's lock must be held for this method to operate safely.
+func (l *LOD) fadeObject(lvl *level, f float64) *gfx.Object {
+	if lvl.fade == nil {
+		lvl.o.RLock()
+		s := copyShader(lvl.o.Shader)
+		lvl.o.RUnlock()
+		if s == nil {
+			return nil
+		}
+		lvl.fade = gfx.NewObject()
+		lvl.fade.Shader = s
+	}
+	fade := lvl.fade
+	l.sync(fade)
+
+	lvl.o.RLock()
+	fade.Lock()
+	fade.Meshes = append(fade.Meshes[:0], lvl.o.Meshes...)
+	fade.State.AlphaMode = gfx.AlphaToCoverage
+	fade.Unlock()
+	shader := lvl.o.Shader
+	lvl.o.RUnlock()
+
+	// The level's shader inputs may have changed since the last fade.
+	shader.RLock()
+	fade.Shader.Lock()
+	copyInputs(fade.Shader, shader)
+	fade.Shader.Inputs[FadeInput] = float32(f)
+	fade.Shader.Unlock()
+	shader.RUnlock()
+	return fade
+}
+
+// DrawTo implements the gfx.Drawable interface. The level to draw is selected
+// using the given camera, if the camera is nil then the most detailed level is
+// drawn.
+func (l *LOD) DrawTo(c gfx.Canvas, bounds image.Rectangle, cam *gfx.Camera) {
+	l.access.Lock()
+	defer l.access.Unlock()
+	if len(l.levels) == 0 {
+		return
+	}
+	for _, lvl := range l.levels {
+		l.sync(lvl.o)
+	}
+	if cam == nil {
+		c.Draw(bounds, l.levels[0].o, cam)
+		return
+	}
+
+	// Select the level for the camera.
+	now := time.Now()
+	st, ok := l.cams[cam]
+	if !ok {
+		st = &lodState{level: -1, prev: -1}
+		l.cams[cam] = st
+	}
+	next := l.choose(l.metricValue(cam, l.levels[0].o.Bounds()), st.level)
+	if next != st.level {
+		st.prev = st.level
+		st.level = next
+		st.changed = now
+	}
+
+	// Cross-fade between the previous and the current level.
+	elapsed := now.Sub(st.changed)
+	if st.prev >= 0 && l.fadeTime > 0 && elapsed < l.fadeTime {
+		f := float64(elapsed) / float64(l.fadeTime)
+		var in, out *gfx.Object
+		if st.level < len(l.levels) {
+			in = l.fadeObject(l.levels[st.level], f)
+		}
+		if st.prev < len(l.levels) {
+			out = l.fadeObject(l.levels[st.prev], 1-f)
+		}
+		if (in != nil || st.level == len(l.levels)) && (out != nil || st.prev == len(l.levels)) {
+			if out != nil {
+				c.Draw(bounds, out, cam)
+			}
+			if in != nil {
+				c.Draw(bounds, in, cam)
+			}
+			return
+		}
+	}
+	st.prev = -1
+	if st.level < len(l.levels) {
+		c.Draw(bounds, l.levels[st.level].o, cam)
+	}
+}
+
+// NewLOD returns a new LOD of the given base object, using the given metric to
+// select levels. The base object itself is not drawn, but it's transform,
+// graphics state and textures are used by every level.
+func NewLOD(base *gfx.Object, metric LODMetric) *LOD {
+	return &LOD{
+		base:   base,
+		metric: metric,
+		cams:   make(map[*gfx.Camera]*lodState),
+	}
+}
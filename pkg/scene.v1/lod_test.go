@@ -0,0 +1,161 @@
+package scene
+
+import (
+	"testing"
+	"time"
+
+	"azul3d.org/gfx.v1"
+	"azul3d.org/lmath.v1"
+)
+
+// newLOD returns a new LOD of an object at the given position, whose levels
+// are unit boxes with the given thresholds.
+func newLOD(pos lmath.Vec3, metric LODMetric, thresholds ...float64) (*LOD, *gfx.Object) {
+	base := gfx.NewObject()
+	base.SetPos(pos)
+	l := NewLOD(base, metric)
+	for _, th := range thresholds {
+		l.AddLevel(newBox(lmath.Vec3{}, unit).Meshes, &gfx.Shader{}, th)
+	}
+	return l, base
+}
+
+// expectLevel draws the LOD and fails the test unless the given level was
+// selected and drawn.
+func expectLevel(t *testing.T, l *LOD, cam *gfx.Camera, want int) {
+	c := newRecorder()
+	l.DrawTo(c, view, cam)
+	if got := l.Level(cam); got != want {
+		t.Fatalf("got level %d want %d", got, want)
+	}
+	if want == len(l.levels) {
+		if len(c.drawn) != 0 {
+			t.Fatal("drawn without a level")
+		}
+		return
+	}
+	if len(c.drawn) != 1 || c.drawn[0] != l.levels[want].o {
+		t.Fatalf("level %d not drawn", want)
+	}
+}
+
+func TestLODHysteresis(t *testing.T) {
+	l, base := newLOD(lmath.Vec3{}, Distance, 10, 100)
+	l.SetHysteresis(0.1)
+	cam := newCamera()
+	if l.Level(cam) != -1 {
+		t.Fatal("level selected before drawing")
+	}
+
+	for _, step := range []struct {
+		dist  float64
+		level int
+	}{
+		{5, 0},
+		{10.5, 0}, // Within 10*(1+0.1).
+		{11.5, 1},
+		{9.5, 1}, // Within 10*(1-0.1).
+		{8.5, 0},
+		{200, 2},
+		{105, 2},
+		{89, 1},
+	} {
+		base.SetPos(lmath.Vec3{0, step.dist, 0})
+		expectLevel(t, l, cam, step.level)
+	}
+
+	// Other cameras select their own level.
+	expectLevel(t, l, newCamera(), 1)
+	l.Forget(cam)
+	if l.Level(cam) != -1 {
+		t.Fatal("level not forgotten")
+	}
+
+	// Without a camera the most detailed level is drawn.
+	c := newRecorder()
+	l.DrawTo(c, view, nil)
+	if len(c.drawn) != 1 || c.drawn[0] != l.levels[0].o {
+		t.Fatal("most detailed level not drawn without a camera")
+	}
+}
+
+func TestLODScreenSize(t *testing.T) {
+	l, base := newLOD(lmath.Vec3{}, ScreenSize, 0.5, 0.1, 0)
+	cam := newCamera()
+	for _, step := range []struct {
+		dist  float64
+		level int
+	}{
+		{1, 0},
+		{5, 1},
+		{50, 2},
+		{1, 0},
+	} {
+		base.SetPos(lmath.Vec3{0, step.dist, 0})
+		expectLevel(t, l, cam, step.level)
+	}
+}
+
+func TestLODFade(t *testing.T) {
+	base := gfx.NewObject()
+	base.SetPos(lmath.Vec3{0, 5, 0})
+	l := NewLOD(base, Distance)
+	shaders := make([]*gfx.Shader, 2)
+	for i := range shaders {
+		shaders[i] = &gfx.Shader{
+			GLSLVert: []byte("vert"),
+			GLSLFrag: []byte("frag"),
+			Inputs:   map[string]interface{}{"Color": i},
+		}
+		l.AddLevel(newBox(lmath.Vec3{}, unit).Meshes, shaders[i], float64(10*(i+1)))
+	}
+	l.SetFade(time.Hour)
+	cam := newCamera()
+
+	// Levels which are not faded are fully visible.
+	expectLevel(t, l, cam, 0)
+	for _, s := range shaders {
+		if s.Inputs[FadeInput] != float32(1) {
+			t.Fatalf("got %v %v want 1", FadeInput, s.Inputs[FadeInput])
+		}
+	}
+
+	// Both levels are drawn with copies of their shaders while fading.
+	fade := func() (out, in *gfx.Shader) {
+		c := newRecorder()
+		l.DrawTo(c, view, cam)
+		if len(c.drawn) != 2 || c.drawn[0] != l.levels[0].fade || c.drawn[1] != l.levels[1].fade {
+			t.Fatal("faded levels not drawn")
+		}
+		return c.drawn[0].Shader, c.drawn[1].Shader
+	}
+	base.SetPos(lmath.Vec3{0, 15, 0})
+	out, in := fade()
+	if out == shaders[0] || in == shaders[1] {
+		t.Fatal("faded with the level's own shader")
+	}
+	if f := out.Inputs[FadeInput].(float32); f < 0.99 {
+		t.Fatalf("got %v %v fading out want ~1", FadeInput, f)
+	}
+	if f := in.Inputs[FadeInput].(float32); f > 0.01 {
+		t.Fatalf("got %v %v fading in want ~0", FadeInput, f)
+	}
+	if out.Inputs["Color"] != 0 || in.Inputs["Color"] != 1 {
+		t.Fatal("inputs not copied")
+	}
+
+	// Changes to the level's shader inputs are copied each time.
+	shaders[0].Inputs["Color"] = 2
+	shaders[1].Inputs["Extra"] = 3
+	delete(shaders[1].Inputs, "Color")
+	out, in = fade()
+	if out.Inputs["Color"] != 2 || in.Inputs["Extra"] != 3 {
+		t.Fatal("changed inputs not copied")
+	}
+	if _, ok := in.Inputs["Color"]; ok {
+		t.Fatal("deleted input not removed")
+	}
+	if in.Inputs[FadeInput] == float32(1) || shaders[1].Inputs[FadeInput] != float32(1) {
+		t.Fatalf("%v copied between shaders", FadeInput)
+	}
+}
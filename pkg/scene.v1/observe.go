@@ -0,0 +1,231 @@
+package scene
+
+import (
+	"fmt"
+	"image"
+	"sync"
+
+	"azul3d.org/gfx.v1"
+	"azul3d.org/lmath.v1"
+)
+
+// EventKind describes a single kind of change to a scene.
+type EventKind uint8
+
+const (
+	// Added is the kind of event sent when a drawable is added to the scene.
+	Added EventKind = iota
+
+	// Removed is the kind of event sent when a drawable is removed from the
+	// scene.
+	Removed
+
+	// Moved is the kind of event sent when the transform of an object in the
+	// scene has changed.
+	Moved
+)
+
+// String returns a string representation of this event kind.
+func (k EventKind) String() string {
+	switch k {
+	case Added:
+		return "Added"
+	case Removed:
+		return "Removed"
+	case Moved:
+		return "Moved"
+	}
+	return fmt.Sprintf("EventKind(%d)", uint8(k))
+}
+
+// Event describes a single change to a scene.
+type Event struct {
+	// The kind of change.
+	Kind EventKind
+
+	// The drawable that was changed.
+	Drawable gfx.Drawable
+}
+
+// Observer is notified of the changes to an observed scene, see Observed.
+type Observer interface {
+	// Observe is invoked with all of the changes that occurred since the
+	// last delivery, in the order that they occurred. The slice is shared
+	// by all observers and must not be modified or retained.
+	Observe(events []Event)
+}
+
+// Observed implements the Scene interface by wrapping another scene and
+// notifying observers of the changes made to it, see Observe.
+type Observed struct {
+	Scene
+
+	access sync.Mutex
+
+	// Serializes delivery of events, such that batches arrive in order.
+	deliver sync.Mutex
+
+	observers []Observer
+	pending   []Event
+
+	// The world transformation of each object in the scene, as of the last
+	// delivery.
+	transforms map[*gfx.Object]lmath.Mat4
+}
+
+// worldMat4 returns the world transformation of the given object.
+func worldMat4(o *gfx.Object) lmath.Mat4 {
+	o.RLock()
+	defer o.RUnlock()
+	if o.Transform == nil {
+		return lmath.Mat4Identity
+	}
+	return o.Transform.Convert(gfx.LocalToWorld)
+}
+
+// Add implements the Scene interface. An Added event is sent if the drawable
+// was added to the scene.
+func (s *Observed) Add(d gfx.Drawable) bool {
+	s.access.Lock()
+	defer s.access.Unlock()
+	if !s.Scene.Add(d) {
+		return false
+	}
+	if o, ok := d.(*gfx.Object); ok {
+		s.transforms[o] = worldMat4(o)
+	}
+	s.pending = append(s.pending, Event{Kind: Added, Drawable: d})
+	return true
+}
+
+// Remove implements the Scene interface. A Removed event is sent if the
+// drawable was removed from the scene.
+func (s *Observed) Remove(d gfx.Drawable) bool {
+	s.access.Lock()
+	defer s.access.Unlock()
+	if !s.Scene.Remove(d) {
+		return false
+	}
+	if o, ok := d.(*gfx.Object); ok {
+		delete(s.transforms, o)
+	}
+	s.pending = append(s.pending, Event{Kind: Removed, Drawable: d})
+	return true
+}
+
+// Notify causes the given observer to be notified of all future changes to
+// the scene. Notifying the same observer twice has no effect.
+func (s *Observed) Notify(o Observer) {
+	s.access.Lock()
+	defer s.access.Unlock()
+	for _, other := range s.observers {
+		if other == o {
+			return
+		}
+	}
+	s.observers = append(s.observers, o)
+}
+
+// StopNotify causes the given observer to no longer be notified of changes
+// to the scene.
+func (s *Observed) StopNotify(o Observer) {
+	s.access.Lock()
+	defer s.access.Unlock()
+	for i, other := range s.observers {
+		if other == o {
+			s.observers = append(s.observers[:i], s.observers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Flush detects which objects in the scene have moved and then delivers all
+// of the changes made since the last delivery to each observer. It is called
+// automatically each time the scene is drawn, and only needs to be called
+// directly for scenes that are not drawn (e.g. on a server).
+//
+// Detecting movement compares the world transformation of every object in the
+// scene, so each flush takes time proportional to the number of objects (and
+// not to the number of changes). Objects removed from the given scene directly
+// are no longer observed once flushed.
+//
+// Observers may modify the scene, but must not call Flush, while being
+// notified.
+func (s *Observed) Flush() {
+	s.deliver.Lock()
+	defer s.deliver.Unlock()
+
+	s.access.Lock()
+	events := s.pending
+	s.pending = nil
+	for o, old := range s.transforms {
+		if !s.Scene.Has(o) {
+			// Removed from the given scene directly.
+			delete(s.transforms, o)
+			continue
+		}
+		m := worldMat4(o)
+		if m != old {
+			s.transforms[o] = m
+			events = append(events, Event{Kind: Moved, Drawable: o})
+		}
+	}
+	observers := make([]Observer, len(s.observers))
+	copy(observers, s.observers)
+	s.access.Unlock()
+
+	if len(events) == 0 {
+		return
+	}
+	for _, o := range observers {
+		o.Observe(events)
+	}
+}
+
+// DrawTo implements the Scene interface. Changes made to the scene are
+// delivered to observers (see Flush) before the scene is drawn.
+func (s *Observed) DrawTo(c gfx.Canvas, bounds image.Rectangle, cam *gfx.Camera) {
+	s.Flush()
+	s.Scene.DrawTo(c, bounds, cam)
+}
+
+// Observe returns a scene which notifies observers of changes made to the
+// given scene, for instance a physics system might watch for objects being
+// added to the scene:
+//  type physics struct{ ... }
+//
+//  func (p *physics) Observe(events []scene.Event) {
+//      for _, ev := range events {
+//          switch ev.Kind {
+//          case scene.Added:
+//              p.addBody(ev.Drawable)
+//          case scene.Removed:
+//              p.removeBody(ev.Drawable)
+//          }
+//      }
+//  }
+//
+//  s := scene.Observe(scene.NewSpatial())
+//  s.Notify(&physics{})
+//
+// Changes are batched and delivered once per frame (each time the scene is
+// drawn, see Flush). Added and Removed events are sent for each drawable added
+// to or removed from the returned scene (and not for changes made to the given
+// scene directly). A Moved event is sent for each *gfx.Object in the scene
+// (including those already in the given scene) whose world transformation has
+// changed since the last delivery.
+func Observe(s Scene) *Observed {
+	o := &Observed{
+		Scene:      s,
+		transforms: make(map[*gfx.Object]lmath.Mat4),
+	}
+
+	// Objects already in the scene are observed for movement, too.
+	s.Iter(func(d gfx.Drawable) bool {
+		if obj, ok := d.(*gfx.Object); ok {
+			o.transforms[obj] = worldMat4(obj)
+		}
+		return true
+	})
+	return o
+}
@@ -0,0 +1,116 @@
+package scene
+
+import (
+	"testing"
+
+	"azul3d.org/gfx.v1"
+	"azul3d.org/lmath.v1"
+)
+
+// watcher is an observer which records each batch of events it is notified
+// of.
+type watcher struct {
+	batches [][]Event
+}
+
+func (w *watcher) Observe(events []Event) {
+	cpy := make([]Event, len(events))
+	copy(cpy, events)
+	w.batches = append(w.batches, cpy)
+}
+
+// expect fails the test unless the given number of batches were delivered,
+// the last of which being the given events.
+func (w *watcher) expect(t *testing.T, batches int, want ...Event) {
+	if len(w.batches) != batches {
+		t.Fatalf("got %d batches want %d", len(w.batches), batches)
+	}
+	got := w.batches[len(w.batches)-1]
+	if len(got) != len(want) {
+		t.Fatalf("got events %v want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got events %v want %v", got, want)
+		}
+	}
+}
+
+func TestObserve(t *testing.T) {
+	existing := newBox(lmath.Vec3{0, 10, 0}, unit)
+	spatial := NewSpatial()
+	spatial.Add(existing)
+
+	s := Observe(spatial)
+	w := &watcher{}
+	s.Notify(w)
+	s.Notify(w)
+
+	// Changes are batched until flushed, in order.
+	a := newBox(lmath.Vec3{0, 20, 0}, unit)
+	b := other{gfx.NewObject()}
+	s.Add(a)
+	s.Add(b)
+	s.Add(a)
+	s.Remove(b)
+	s.Remove(b)
+	if len(w.batches) != 0 {
+		t.Fatal("notified before flushing")
+	}
+	s.Flush()
+	w.expect(t, 1,
+		Event{Added, a},
+		Event{Added, b},
+		Event{Removed, b},
+	)
+
+	// Nothing is delivered without changes.
+	s.Flush()
+	if len(w.batches) != 1 {
+		t.Fatal("notified without changes")
+	}
+
+	// Objects already in the scene and added ones are observed for movement,
+	// drawing the scene flushes it.
+	existing.SetPos(lmath.Vec3{0, 30, 0})
+	s.DrawTo(newRecorder(), view, newCamera())
+	w.expect(t, 2, Event{Moved, existing})
+
+	a.SetPos(lmath.Vec3{0, 40, 0})
+	s.Remove(a)
+	a.SetPos(lmath.Vec3{0, 50, 0})
+	s.Flush()
+	w.expect(t, 3, Event{Removed, a})
+
+	// Objects removed from the given scene directly are no longer observed.
+	spatial.Remove(existing)
+	existing.SetPos(lmath.Vec3{0, 60, 0})
+	s.Flush()
+	if len(w.batches) != 3 {
+		t.Fatal("notified of an object removed from the given scene")
+	}
+	if _, ok := s.transforms[existing]; ok {
+		t.Fatal("object removed from the given scene still observed")
+	}
+
+	// Observers that stopped are no longer notified.
+	s.StopNotify(w)
+	s.Add(a)
+	s.Flush()
+	if len(w.batches) != 3 {
+		t.Fatal("notified after StopNotify")
+	}
+}
+
+func TestEventKindString(t *testing.T) {
+	for k, want := range map[EventKind]string{
+		Added:        "Added",
+		Removed:      "Removed",
+		Moved:        "Moved",
+		EventKind(9): "EventKind(9)",
+	} {
+		if k.String() != want {
+			t.Fatalf("got %q want %q", k.String(), want)
+		}
+	}
+}
@@ -0,0 +1,451 @@
+package scene
+
+import (
+	"image"
+	"math"
+	"sort"
+	"sync"
+
+	"azul3d.org/gfx.v1"
+	"azul3d.org/lmath.v1"
+	"azul3d.org/octree.v1"
+)
+
+// occlusionState is the occlusion state of a single octree node or drawable.
+type occlusionState struct {
+	// The last frame in which it was seen (i.e. inside the camera's view), and
+	// the last frame in which it was found to be visible.
+	seen, visible int
+
+	// The bounding box query, and the frame in which it was issued (or -1 if
+	// no query result is pending).
+	query   *gfx.Object
+	queried int
+
+	// The copy of the object drawn with an occlusion test in it's place
+	// (such that the object's own OcclusionTest is left untouched), and the
+	// frame in which it was drawn (or -1 if no result is pending).
+	test  *gfx.Object
+	drawn int
+}
+
+// Occlusion implements the Scene interface by culling drawables hidden behind
+// others, in addition to those outside of the camera's view (see Spatial). It
+// traverses the octree hierarchically front-to-back, such that entire regions
+// hidden behind nearby objects are skipped at once.
+//
+// By default hardware occlusion queries are used (see gfx.Object.OcclusionTest
+// and gfx.NativeObject.SampleCount), which requires a renderer that supports
+// them (see gfx.GPUInfo.OcclusionQuery):
+//  Objects found visible are drawn with an occlusion test (using a copy of
+//  the object, whose OcclusionTest is left untouched), whose sample count in
+//  the next frame tells if they are still visible.
+//  Objects and octree nodes found hidden are not drawn, instead their bounding
+//  box is drawn (invisibly) with an occlusion test each frame, such that they
+//  are drawn again once the box becomes visible.
+//
+// As query results are only available in the next frame, newly visible
+// objects appear one frame late. To avoid flickering, objects are drawn for a
+// number of frames after they were last found visible (see SetPersist), and
+// objects the eye is near to (or inside of) are always drawn. Things which come
+// back into view (or whose octree node is found visible again) are drawn, as
+// if newly seen, until tested again.
+//
+// Alternatively a software depth buffer may be used (see SetDepthBuffer), for
+// instance for headless tests using gfx.Nil. Drawables are then drawn in
+// front-to-back order and objects are rasterized into the depth buffer as they
+// are drawn, hidden drawables are culled in the same frame.
+//
+// Only *gfx.Object drawables may hide others, other boundable drawables are
+// drawn whenever they are inside of the camera's view.
+type Occlusion struct {
+	*Spatial
+
+	access sync.Mutex
+
+	// The shader used to draw bounding box queries, it need only transform
+	// the vertices as nothing is written to the color or depth buffers.
+	queryShader *gfx.Shader
+
+	// A unit cube mesh, transformed to draw bounding box queries.
+	cube *gfx.Mesh
+
+	// The software depth buffer to use instead of queries, or nil.
+	depth *DepthBuffer
+
+	// The number of frames that things remain visible for, see SetPersist.
+	persist int
+
+	frame  int
+	states map[interface{}]*occlusionState
+}
+
+// occlusionFrame is the state of the traversal of a single frame.
+type occlusionFrame struct {
+	c      gfx.Canvas
+	bounds image.Rectangle
+	cam    *gfx.Camera
+	f      frustum
+	eye    lmath.Vec3
+
+	// The distance to the near clipping plane, boxes within it of the eye are
+	// always visible.
+	near float64
+}
+
+// SetPersist sets the number of frames that drawables (and octree nodes)
+// remain visible after they were last found visible by a query, before a
+// result of them being hidden is trusted. The default is two.
+func (s *Occlusion) SetPersist(frames int) {
+	s.access.Lock()
+	s.persist = frames
+	s.access.Unlock()
+}
+
+// SetDepthBuffer sets the software depth buffer to use instead of hardware
+// occlusion queries, or nil to use queries.
+func (s *Occlusion) SetDepthBuffer(d *DepthBuffer) {
+	s.access.Lock()
+	s.depth = d
+	s.access.Unlock()
+}
+
+// state returns the occlusion state of the given key, creating it if needed.
+// Things that are newly seen, or that were not seen in the last frame (e.g.
+// because their octree node was hidden), are considered visible as their
+// pending results are stale.
+//
+// The lock must be held for this method to operate safely.
+func (s *Occlusion) state(k interface{}) *occlusionState {
+	st, ok := s.states[k]
+	if !ok {
+		st = new(occlusionState)
+		s.states[k] = st
+	}
+	if !ok || st.seen < s.frame-1 {
+		st.visible = s.frame
+		st.queried = -1
+		st.drawn = -1
+	}
+	st.seen = s.frame
+	return st
+}
+
+// sampleCount returns the number of samples that passed the last occlusion
+// test of the given object, or -1 if no result is available.
+func sampleCount(o *gfx.Object) int {
+	o.RLock()
+	defer o.RUnlock()
+	if o.NativeObject == nil {
+		return -1
+	}
+	return o.NativeObject.SampleCount()
+}
+
+// isVisible collects the pending query results of the given state, and then
+// tells if it is considered visible.
+//
+// The lock must be held for this method to operate safely.
+func (s *Occlusion) isVisible(st *occlusionState) bool {
+	if st.queried >= 0 && st.queried < s.frame {
+		if sampleCount(st.query) != 0 {
+			st.visible = st.queried
+		}
+		st.queried = -1
+	}
+	if st.drawn >= 0 && st.drawn < s.frame {
+		if sampleCount(st.test) != 0 {
+			st.visible = st.drawn
+		}
+		st.drawn = -1
+	}
+	return s.frame-st.visible <= s.persist
+}
+
+// query draws a bounding box query for the given state.
+//
+// The lock must be held for this method to operate safely.
+func (s *Occlusion) query(fr *occlusionFrame, st *occlusionState, b lmath.Rect3) {
+	if s.queryShader == nil {
+		return
+	}
+	if st.query == nil {
+		q := gfx.NewObject()
+		q.Shader = s.queryShader
+		q.Meshes = []*gfx.Mesh{s.cube}
+		q.OcclusionTest = true
+		q.State.WriteRed = false
+		q.State.WriteGreen = false
+		q.State.WriteBlue = false
+		q.State.WriteAlpha = false
+		q.State.DepthWrite = false
+		q.State.DepthTest = true
+		q.State.FaceCulling = gfx.NoFaceCulling
+		st.query = q
+	}
+
+	// Flat boxes are given some thickness, such that they may pass samples.
+	size := b.Size()
+	pad := 1e-3 * math.Max(size.X, math.Max(size.Y, size.Z))
+	size = lmath.Vec3{size.X + pad, size.Y + pad, size.Z + pad}
+
+	st.query.Lock()
+	st.query.Transform.SetPos(b.Center())
+	st.query.Transform.SetScale(size)
+	st.query.Unlock()
+	fr.c.Draw(fr.bounds, st.query, fr.cam)
+	st.queried = s.frame
+}
+
+// nearEye tells if the eye is within the near plane distance of the given box,
+// in which case a bounding box query could be clipped.
+func (fr *occlusionFrame) nearEye(b lmath.Rect3) bool {
+	p, m := fr.eye, fr.near
+	return p.X >= b.Min.X-m && p.X <= b.Max.X+m &&
+		p.Y >= b.Min.Y-m && p.Y <= b.Max.Y+m &&
+		p.Z >= b.Min.Z-m && p.Z <= b.Max.Z+m
+}
+
+// sortByDist sorts the given boundables (and their bounds) front-to-back.
+type sortByDist struct {
+	eye    lmath.Vec3
+	b      []gfx.Boundable
+	bounds []lmath.Rect3
+}
+
+func (s sortByDist) Len() int { return len(s.b) }
+func (s sortByDist) Swap(i, j int) {
+	s.b[i], s.b[j] = s.b[j], s.b[i]
+	s.bounds[i], s.bounds[j] = s.bounds[j], s.bounds[i]
+}
+func (s sortByDist) Less(i, j int) bool {
+	di := s.bounds[i].Center().Sub(s.eye).Length()
+	dj := s.bounds[j].Center().Sub(s.eye).Length()
+	return di < dj
+}
+
+// visit draws the visible contents of the given octree node, and returns
+// whether or not any of it was visible.
+//
+// The lock must be held for this method to operate safely.
+func (s *Occlusion) visit(fr *occlusionFrame, n *octree.Node) bool {
+	nb := n.Bounds()
+	if !nb.Empty() {
+		if !fr.f.intersects(nb) {
+			return false
+		}
+		if s.depth != nil {
+			if !fr.nearEye(nb) && s.depth.Occluded(nb) {
+				return false
+			}
+		} else {
+			st := s.state(n)
+			if !s.isVisible(st) && !fr.nearEye(nb) {
+				s.query(fr, st, nb)
+				return false
+			}
+		}
+	}
+
+	// Collect the objects of this node, and the child nodes, front-to-back.
+	var objs, children sortByDist
+	objs.eye, children.eye = fr.eye, fr.eye
+	for oct := 0; oct < 9; oct++ {
+		for i := 0; i < n.NumObjects(oct); i++ {
+			b := n.Object(oct, i)
+			bb := b.Bounds()
+			if fr.f.intersects(bb) {
+				objs.b = append(objs.b, b)
+				objs.bounds = append(objs.bounds, bb)
+			}
+		}
+	}
+	for i := 0; i < 8; i++ {
+		if c := n.Child(octree.ChildIndex(i)); c != nil {
+			children.b = append(children.b, c)
+			children.bounds = append(children.bounds, c.Bounds())
+		}
+	}
+	sort.Sort(objs)
+	sort.Sort(children)
+
+	visible := false
+	for i, b := range objs.b {
+		if s.visitObject(fr, b, objs.bounds[i]) {
+			visible = true
+		}
+	}
+	for _, c := range children.b {
+		if s.visit(fr, c.(*octree.Node)) {
+			visible = true
+		}
+	}
+	if visible && s.depth == nil && !nb.Empty() {
+		s.state(n).visible = s.frame
+	}
+	return visible
+}
+
+// visitObject draws the given drawable (whose bounds are b) if it is visible,
+// and returns whether or not it was.
+//
+// The lock must be held for this method to operate safely.
+func (s *Occlusion) visitObject(fr *occlusionFrame, b gfx.Boundable, bb lmath.Rect3) bool {
+	d := b.(gfx.Drawable)
+	o, isObject := b.(*gfx.Object)
+	if !isObject {
+		d.DrawTo(fr.c, fr.bounds, fr.cam)
+		return true
+	}
+
+	if s.depth != nil {
+		if !fr.nearEye(bb) && s.depth.Occluded(bb) {
+			return false
+		}
+		d.DrawTo(fr.c, fr.bounds, fr.cam)
+		s.depth.DrawObject(o)
+		return true
+	}
+
+	st := s.state(o)
+	if !s.isVisible(st) && !fr.nearEye(bb) {
+		s.query(fr, st, bb)
+		return false
+	}
+	if st.test == nil {
+		st.test = gfx.NewObject()
+	}
+	syncTest(st.test, o)
+	fr.c.Draw(fr.bounds, st.test, fr.cam)
+	st.drawn = s.frame
+	return true
+}
+
+// syncTest updates the given copy of an object, drawn with an occlusion test
+// in place of the object, with the object's transform, shader, meshes,
+// textures and state.
+func syncTest(t, o *gfx.Object) {
+	o.RLock()
+	t.Lock()
+	t.Transform = o.Transform
+	t.Shader = o.Shader
+	t.Meshes = append(t.Meshes[:0], o.Meshes...)
+	t.Textures = append(t.Textures[:0], o.Textures...)
+	t.State = o.State
+	t.OcclusionTest = true
+	t.Unlock()
+	o.RUnlock()
+}
+
+// destroy destroys the native object of the given object, if it has one.
+func destroy(o *gfx.Object) {
+	if o == nil {
+		return
+	}
+	o.Lock()
+	if o.NativeObject != nil {
+		o.NativeObject.Destroy()
+		o.NativeObject = nil
+	}
+	o.Unlock()
+}
+
+// DrawTo implements the Scene interface. If the camera is nil, or if there is
+// neither a query shader nor a depth buffer, then drawables are drawn as with
+// Spatial.
+func (s *Occlusion) DrawTo(c gfx.Canvas, bounds image.Rectangle, cam *gfx.Camera) {
+	s.access.Lock()
+	defer s.access.Unlock()
+	if cam == nil || (s.depth == nil && s.queryShader == nil) {
+		// No camera, or no way to test for occlusion.
+		s.Spatial.DrawTo(c, bounds, cam)
+		return
+	}
+	s.frame++
+
+	// Update the positions of drawables which have moved.
+	s.Spatial.access.Lock()
+	for b, old := range s.Spatial.bounds {
+		s.Spatial.update(b, old)
+	}
+	s.Spatial.access.Unlock()
+
+	s.Spatial.access.RLock()
+	defer s.Spatial.access.RUnlock()
+
+	cam.RLock()
+	vp := viewProjection(cam)
+	fr := &occlusionFrame{
+		c:      c,
+		bounds: bounds,
+		cam:    cam,
+		f:      newFrustum(vp),
+		eye:    eyePos(cam),
+	}
+	proj := cam.Projection.Mat4()
+	cam.RUnlock()
+	if proj[3][3] != 1 && proj[2][2] != 1 {
+		// Perspective projection, see lmath.Mat4Perspective.
+		fr.near = 2 * proj[3][2] / (proj[2][2] - 1)
+	}
+
+	if s.depth != nil {
+		s.depth.Clear(vp)
+	}
+	s.visit(fr, s.Spatial.tree.Root())
+	for _, d := range s.Spatial.others {
+		d.DrawTo(c, bounds, cam)
+	}
+
+	// Forget about the state of things which have not been seen in a while.
+	for k, st := range s.states {
+		if s.frame-st.seen > 60 {
+			destroy(st.query)
+			destroy(st.test)
+			delete(s.states, k)
+		}
+	}
+}
+
+// unitCube returns a new mesh of a cube centered at the origin whose sides are
+// one unit long.
+func unitCube() *gfx.Mesh {
+	m := new(gfx.Mesh)
+	for i := 0; i < 8; i++ {
+		v := gfx.Vec3{-.5, -.5, -.5}
+		if i&1 != 0 {
+			v.X = .5
+		}
+		if i&2 != 0 {
+			v.Y = .5
+		}
+		if i&4 != 0 {
+			v.Z = .5
+		}
+		m.Vertices = append(m.Vertices, v)
+	}
+	m.Indices = []uint32{
+		0, 2, 1, 1, 2, 3, // -Z
+		4, 5, 6, 5, 7, 6, // +Z
+		0, 1, 4, 1, 5, 4, // -Y
+		2, 6, 3, 3, 6, 7, // +Y
+		0, 4, 2, 2, 4, 6, // -X
+		1, 3, 5, 3, 7, 5, // +X
+	}
+	return m
+}
+
+// NewOcclusion returns a new occlusion culling scene. The given shader is used
+// to draw bounding box queries, it need only transform the vertices of the box
+// as nothing is written to the color or depth buffers. If it is nil then a
+// software depth buffer must be used (see SetDepthBuffer), or else nothing is
+// culled for being hidden.
+func NewOcclusion(queryShader *gfx.Shader) *Occlusion {
+	return &Occlusion{
+		Spatial:     NewSpatial(),
+		queryShader: queryShader,
+		cube:        unitCube(),
+		persist:     2,
+		states:      make(map[interface{}]*occlusionState),
+	}
+}
@@ -0,0 +1,170 @@
+package scene
+
+import (
+	"image"
+	"testing"
+
+	"azul3d.org/gfx.v1"
+	"azul3d.org/lmath.v1"
+)
+
+// box returns the bounds of a unit box centered at the given position.
+func box(pos lmath.Vec3) lmath.Rect3 {
+	return lmath.Rect3{
+		Min: pos.SubScalar(0.5),
+		Max: pos.AddScalar(0.5),
+	}
+}
+
+func TestDepthBuffer(t *testing.T) {
+	d := NewDepthBuffer(64, 48)
+	vp := viewProjection(newCamera())
+	d.Clear(vp)
+
+	// A 10x10 wall, ten units in front of the camera.
+	a := lmath.Vec3{-5, 10, -5}
+	b := lmath.Vec3{5, 10, -5}
+	c := lmath.Vec3{5, 10, 5}
+	e := lmath.Vec3{-5, 10, 5}
+	d.DrawTriangle(a, b, c)
+	d.DrawTriangle(a, c, e)
+
+	for _, tst := range []struct {
+		pos      lmath.Vec3
+		occluded bool
+	}{
+		{lmath.Vec3{0, 20, 0}, true},
+		{lmath.Vec3{3, 30, -3}, true},
+		{lmath.Vec3{0, 5, 0}, false},   // In front of the wall.
+		{lmath.Vec3{12, 20, 0}, false}, // Beside the wall.
+		{lmath.Vec3{0, -20, 0}, false}, // Behind the camera.
+		{lmath.Vec3{}, false},          // Around the eye.
+	} {
+		if got := d.Occluded(box(tst.pos)); got != tst.occluded {
+			t.Fatalf("box at %v: got occluded %v want %v", tst.pos, got, tst.occluded)
+		}
+	}
+
+	// The wall is at the center of the buffer, and not at it's corners.
+	center := d.Depth[24*d.Width+32]
+	if center <= 0 || center >= 1 {
+		t.Fatalf("got depth %v at the center", center)
+	}
+	if d.Depth[0] < 1 {
+		t.Fatalf("got depth %v at the corner", d.Depth[0])
+	}
+
+	// Clearing removes the wall.
+	d.Clear(vp)
+	if d.Occluded(box(lmath.Vec3{0, 20, 0})) {
+		t.Fatal("occluded after clearing")
+	}
+}
+
+func TestOcclusionDepthBuffer(t *testing.T) {
+	s := NewOcclusion(nil)
+	s.SetDepthBuffer(NewDepthBuffer(64, 48))
+	wall := newBox(lmath.Vec3{0, 10, 0}, lmath.Vec3{10, 0.5, 10})
+	hidden := newBox(lmath.Vec3{0, 20, 0}, unit)
+	visible := newBox(lmath.Vec3{12, 20, 0}, unit)
+	behind := newBox(lmath.Vec3{0, -20, 0}, unit)
+	o := other{gfx.NewObject()}
+	for _, d := range []gfx.Drawable{hidden, visible, behind, wall, o} {
+		s.Add(d)
+	}
+	cam := newCamera()
+
+	c := newRecorder()
+	s.DrawTo(c, view, cam)
+	if len(c.drawn) != 3 || c.index(wall) != 0 || c.index(visible) == -1 || c.index(o.o) == -1 {
+		t.Fatalf("got %d drawn want the wall first, the visible object and the other drawable", len(c.drawn))
+	}
+
+	// Moving the wall reveals the hidden object.
+	wall.SetPos(lmath.Vec3{0, -30, 0})
+	c = newRecorder()
+	s.DrawTo(c, view, cam)
+	if len(c.drawn) != 3 || c.index(hidden) == -1 || c.index(visible) == -1 {
+		t.Fatalf("got %d drawn want the previously hidden and visible objects", len(c.drawn))
+	}
+
+	// Without a camera everything is drawn.
+	c = newRecorder()
+	s.DrawTo(c, view, nil)
+	if len(c.drawn) != 5 {
+		t.Fatalf("got %d drawn without a camera want 5", len(c.drawn))
+	}
+}
+
+// samples is a native object which reports a fixed sample count.
+type samples int
+
+func (s samples) Destroy()         {}
+func (s samples) SampleCount() int { return int(s) }
+
+// sampler is a recorder whose drawn objects report the given sample count.
+type sampler struct {
+	*recorder
+	n samples
+}
+
+func (s sampler) Draw(rect image.Rectangle, o *gfx.Object, c *gfx.Camera) {
+	s.recorder.Draw(rect, o, c)
+	o.Lock()
+	o.NativeObject = s.n
+	o.Unlock()
+}
+
+func TestOcclusionQueries(t *testing.T) {
+	query := &gfx.Shader{Name: "query"}
+	s := NewOcclusion(query)
+	s.SetPersist(1)
+	a := newBox(lmath.Vec3{0, 20, 0}, unit)
+	s.Add(a)
+	cam := newCamera()
+
+	// draw draws a frame, and returns the objects drawn in place of a (with
+	// an occlusion test) and the number of queries drawn.
+	draw := func(n samples) (tests []*gfx.Object, queries int) {
+		c := sampler{newRecorder(), n}
+		s.DrawTo(c, view, cam)
+		for _, d := range c.drawn {
+			switch {
+			case d == a:
+				t.Fatal("drawn without an occlusion test")
+			case !d.OcclusionTest:
+				t.Fatal("drawn without an occlusion test")
+			case d.Shader == query:
+				queries++
+			default:
+				tests = append(tests, d)
+			}
+		}
+		if a.OcclusionTest {
+			t.Fatal("OcclusionTest of the object was modified")
+		}
+		return
+	}
+
+	// Objects are visible at first, and drawn with an occlusion test.
+	tests, queries := draw(0)
+	if len(tests) != 1 || queries != 0 || tests[0].Meshes[0] != a.Meshes[0] || tests[0].Transform != a.Transform {
+		t.Fatalf("got %d tests and %d queries want one test of the object", len(tests), queries)
+	}
+
+	// No samples passed, after persisting for a frame only queries are drawn.
+	if tests, _ = draw(0); len(tests) != 1 {
+		t.Fatal("not drawn while persisting")
+	}
+	if tests, queries = draw(0); len(tests) != 0 || queries == 0 {
+		t.Fatalf("got %d tests and %d queries of hidden object", len(tests), queries)
+	}
+
+	// Once the queries pass samples the object is drawn again.
+	for i := 0; i < 4 && len(tests) == 0; i++ {
+		tests, _ = draw(1)
+	}
+	if len(tests) != 1 {
+		t.Fatal("not drawn after becoming visible")
+	}
+}
@@ -1,7 +1,257 @@
 package scene
 
+import (
+	"image"
+	"sort"
+	"sync"
+
+	"azul3d.org/gfx.v1"
+	"azul3d.org/lmath.v1"
+)
+
+// drawKey describes everything about an object that determines whether and
+// when it is drawn, such that the draw order can be reused when no key has
+// changed.
+type drawKey struct {
+	o           *gfx.Object
+	bounds      lmath.Rect3
+	shader      *gfx.Shader
+	texture     *gfx.Texture
+	state       gfx.State
+	transparent bool
+}
+
+// objectKey returns the key of the given object.
+func objectKey(o *gfx.Object) drawKey {
+	// Bounds locks the object itself.
+	b := o.Bounds()
+
+	o.RLock()
+	k := drawKey{
+		o:           o,
+		bounds:      b,
+		shader:      o.Shader,
+		state:       o.State,
+		transparent: o.State.AlphaMode == gfx.AlphaBlend,
+	}
+	if len(o.Textures) > 0 {
+		k.texture = o.Textures[0]
+	}
+	o.RUnlock()
+	return k
+}
+
+// equalKeys tells if the two slices of keys are equal, in any order (as the
+// order in which scenes iterate may differ each time, e.g. with Spatial).
+func equalKeys(a, b []drawKey) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	i := 0
+	for ; i < len(a); i++ {
+		if a[i] != b[i] {
+			break
+		}
+	}
+	if i == len(a) {
+		return true
+	}
+
+	// Compare the remaining keys by object.
+	rest := make(map[*gfx.Object]drawKey, len(b)-i)
+	for _, k := range b[i:] {
+		rest[k.o] = k
+	}
+	for _, k := range a[i:] {
+		if other, ok := rest[k.o]; !ok || other != k {
+			return false
+		}
+	}
+	return true
+}
+
+// drawItem is a single object to be drawn, along with it's sorting criteria:
+// the IDs of it's shader, texture and state and it's distance to the camera.
+type drawItem struct {
+	o                      *gfx.Object
+	shader, texture, state int
+	dist                   float64
+}
+
+// byOpaque sorts opaque objects such that changes of shader, texture and
+// state are minimized, and then front-to-back.
+type byOpaque []drawItem
+
+func (b byOpaque) Len() int      { return len(b) }
+func (b byOpaque) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byOpaque) Less(i, j int) bool {
+	x, y := b[i], b[j]
+	switch {
+	case x.shader != y.shader:
+		return x.shader < y.shader
+	case x.texture != y.texture:
+		return x.texture < y.texture
+	case x.state != y.state:
+		return x.state < y.state
+	}
+	return x.dist < y.dist
+}
+
+// byTransparent sorts transparent objects back-to-front, and then such that
+// changes of shader, texture and state are minimized.
+type byTransparent []drawItem
+
+func (b byTransparent) Len() int      { return len(b) }
+func (b byTransparent) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b byTransparent) Less(i, j int) bool {
+	x, y := b[i], b[j]
+	switch {
+	case x.dist != y.dist:
+		return x.dist > y.dist
+	case x.shader != y.shader:
+		return x.shader < y.shader
+	case x.texture != y.texture:
+		return x.texture < y.texture
+	}
+	return x.state < y.state
+}
+
+// id returns the ID of the given value in the given map, assigning the next
+// one if it has none yet.
+func id(ids map[interface{}]int, v interface{}) int {
+	i, ok := ids[v]
+	if !ok {
+		i = len(ids)
+		ids[v] = i
+	}
+	return i
+}
+
+// optiDraw implements the Scene interface by wrapping another scene and
+// optimizing how it's objects are drawn, see OptiDraw.
+type optiDraw struct {
+	Scene
+
+	access sync.Mutex
+
+	// The camera, view-projection matrix and object keys of the last draw.
+	cam  *gfx.Camera
+	vp   lmath.Mat4
+	keys []drawKey
+
+	// The resulting draw order, opaque objects first and then transparent
+	// ones.
+	order  []*gfx.Object
+	opaque int
+}
+
+// sort determines the draw order of the objects with the given keys, seen by
+// the given camera.
+//
+// The scene's lock must be held for this method to operate safely.
+func (s *optiDraw) sort(keys []drawKey, cam *gfx.Camera, vp lmath.Mat4, eye lmath.Vec3) {
+	var (
+		f                   = newFrustum(vp)
+		shaders             = make(map[interface{}]int)
+		textures            = make(map[interface{}]int)
+		states              = make(map[interface{}]int)
+		opaque, transparent []drawItem
+	)
+	for _, k := range keys {
+		if cam != nil && !f.intersects(k.bounds) {
+			// Outside of the camera's view.
+			continue
+		}
+		it := drawItem{
+			o:       k.o,
+			shader:  id(shaders, k.shader),
+			texture: id(textures, k.texture),
+			state:   id(states, k.state),
+			dist:    k.bounds.Center().Sub(eye).Length(),
+		}
+		if k.transparent {
+			transparent = append(transparent, it)
+		} else {
+			opaque = append(opaque, it)
+		}
+	}
+	sort.Sort(byOpaque(opaque))
+	sort.Sort(byTransparent(transparent))
+
+	s.order = s.order[:0]
+	for _, it := range opaque {
+		s.order = append(s.order, it.o)
+	}
+	for _, it := range transparent {
+		s.order = append(s.order, it.o)
+	}
+	s.opaque = len(opaque)
+}
+
+// DrawTo implements the Scene interface.
+func (s *optiDraw) DrawTo(c gfx.Canvas, bounds image.Rectangle, cam *gfx.Camera) {
+	s.access.Lock()
+	defer s.access.Unlock()
+
+	// Collect the keys of the objects in the scene, and the other drawables.
+	var (
+		keys   = make([]drawKey, 0, len(s.keys))
+		others []gfx.Drawable
+	)
+	s.Scene.Iter(func(d gfx.Drawable) bool {
+		if o, ok := d.(*gfx.Object); ok {
+			keys = append(keys, objectKey(o))
+		} else {
+			others = append(others, d)
+		}
+		return true
+	})
+
+	var (
+		vp  lmath.Mat4
+		eye lmath.Vec3
+	)
+	if cam != nil {
+		cam.RLock()
+		vp = viewProjection(cam)
+		eye = eyePos(cam)
+		cam.RUnlock()
+	}
+
+	// Only sort again if the camera or an object has changed.
+	if cam != s.cam || vp != s.vp || !equalKeys(keys, s.keys) {
+		s.sort(keys, cam, vp, eye)
+		s.cam = cam
+		s.vp = vp
+		s.keys = keys
+	}
+
+	// Draw opaque objects, then any other drawables (in the order of the
+	// scene), and then transparent objects.
+	for _, o := range s.order[:s.opaque] {
+		c.Draw(bounds, o, cam)
+	}
+	for _, d := range others {
+		d.DrawTo(c, bounds, cam)
+	}
+	for _, o := range s.order[s.opaque:] {
+		c.Draw(bounds, o, cam)
+	}
+}
+
 // OptiDraw returns a scene that optimizes draw calls for all of the objects in
-// the given scene.
+// the given scene. Adding, removing and iterating objects is performed on the
+// given scene directly, but when drawn the returned scene:
+//  Skips objects whose bounds are outside of the camera's viewing frustum.
+//  Draws opaque objects first, sorted to minimize changes of shader, texture
+//  and state, and front-to-back within each group.
+//  Draws transparent (alpha blended) objects last, sorted back-to-front.
+//
+// Drawables that are not a *gfx.Object are drawn as-is, in the order of the
+// scene, after the opaque objects.
+//
+// The draw order is cached and reused for as long as no object has moved or
+// changed it's shader, textures or state, and the camera has not moved.
 func OptiDraw(s Scene) Scene {
-	return s
+	return &optiDraw{Scene: s}
 }
@@ -0,0 +1,92 @@
+package scene
+
+import (
+	"testing"
+
+	"azul3d.org/gfx.v1"
+	"azul3d.org/lmath.v1"
+)
+
+func TestOptiDrawOrder(t *testing.T) {
+	a, b := &gfx.Shader{Name: "a"}, &gfx.Shader{Name: "b"}
+	near := newBox(lmath.Vec3{0, 10, 0}, unit)
+	far := newBox(lmath.Vec3{0, 50, 0}, unit)
+	odd := newBox(lmath.Vec3{0, 30, 0}, unit)
+	near.Shader, far.Shader, odd.Shader = a, a, b
+
+	glassNear := newBox(lmath.Vec3{2, 20, 0}, unit)
+	glassFar := newBox(lmath.Vec3{2, 40, 0}, unit)
+	glassNear.State.AlphaMode = gfx.AlphaBlend
+	glassFar.State.AlphaMode = gfx.AlphaBlend
+
+	behind := newBox(lmath.Vec3{0, -10, 0}, unit)
+	o := other{gfx.NewObject()}
+
+	s := OptiDraw(new(list))
+	for _, d := range []gfx.Drawable{far, glassNear, odd, behind, o, near, glassFar} {
+		s.Add(d)
+	}
+	c := newRecorder()
+	s.DrawTo(c, view, newCamera())
+
+	if len(c.drawn) != 6 || c.index(behind) != -1 {
+		t.Fatalf("got %d objects drawn want 6 (behind the camera drawn: %v)", len(c.drawn), c.index(behind) != -1)
+	}
+
+	// Opaque objects first, grouped by shader and front-to-back.
+	if i := c.index(odd); i != 0 && i != 2 {
+		t.Fatal("opaque objects not grouped by shader")
+	}
+	if c.index(near) > c.index(far) || c.index(near) > 2 || c.index(far) > 2 {
+		t.Fatal("opaque objects not drawn front-to-back")
+	}
+
+	// Then other drawables, and then transparent objects back-to-front.
+	if c.index(o.o) != 3 {
+		t.Fatal("other drawable not drawn after opaque objects")
+	}
+	if c.index(glassFar) != 4 || c.index(glassNear) != 5 {
+		t.Fatal("transparent objects not drawn back-to-front")
+	}
+}
+
+func TestOptiDrawCache(t *testing.T) {
+	a := newBox(lmath.Vec3{0, 10, 0}, unit)
+	b := newBox(lmath.Vec3{0, 20, 0}, unit)
+	s := OptiDraw(new(list))
+	s.Add(a)
+	s.Add(b)
+	od := s.(*optiDraw)
+	cam := newCamera()
+
+	draw := func(first, second *gfx.Object) {
+		c := newRecorder()
+		s.DrawTo(c, view, cam)
+		if len(c.drawn) != 2 || c.drawn[0] != first || c.drawn[1] != second {
+			t.Fatalf("got draw order %v", c.drawn)
+		}
+	}
+	draw(a, b)
+
+	// Drawing again without changes reuses the order.
+	keys := od.keys
+	draw(a, b)
+	if &od.keys[0] != &keys[0] {
+		t.Fatal("sorted again without changes")
+	}
+
+	// Moving an object invalidates the order.
+	a.SetPos(lmath.Vec3{0, 30, 0})
+	draw(b, a)
+	if &od.keys[0] == &keys[0] {
+		t.Fatal("not sorted again after an object moved")
+	}
+
+	// So does moving the camera.
+	keys = od.keys
+	cam.SetPos(lmath.Vec3{0, -10, 0})
+	draw(b, a)
+	if &od.keys[0] == &keys[0] {
+		t.Fatal("not sorted again after the camera moved")
+	}
+}
@@ -0,0 +1,120 @@
+package scene
+
+import (
+	"image"
+
+	"azul3d.org/gfx.v1"
+	"azul3d.org/lmath.v1"
+)
+
+// view is the viewing rectangle that scenes are drawn with in tests.
+var view = image.Rect(0, 0, 800, 600)
+
+// recorder is a canvas which records the objects drawn onto it, in order.
+type recorder struct {
+	gfx.Canvas
+	drawn []*gfx.Object
+}
+
+func (r *recorder) Draw(rect image.Rectangle, o *gfx.Object, c *gfx.Camera) {
+	r.Canvas.Draw(rect, o, c)
+	r.drawn = append(r.drawn, o)
+}
+
+// index returns the index at which the given object was first drawn, or -1 if
+// it was not.
+func (r *recorder) index(o *gfx.Object) int {
+	for i, d := range r.drawn {
+		if d == o {
+			return i
+		}
+	}
+	return -1
+}
+
+// newRecorder returns a new recorder which draws to a nil renderer.
+func newRecorder() *recorder {
+	return &recorder{Canvas: gfx.Nil()}
+}
+
+// list is a scene which keeps it's drawables in the order they were added.
+// Unlike other scenes it is not safe for concurrent use.
+type list []gfx.Drawable
+
+func (l *list) Add(d gfx.Drawable) bool {
+	if l.Has(d) {
+		return false
+	}
+	*l = append(*l, d)
+	return true
+}
+
+func (l *list) Has(d gfx.Drawable) bool {
+	for _, e := range *l {
+		if e == d {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *list) Remove(d gfx.Drawable) bool {
+	for i, e := range *l {
+		if e == d {
+			*l = append((*l)[:i], (*l)[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (l *list) Iter(callback func(d gfx.Drawable) (stop bool)) {
+	for _, d := range *l {
+		if !callback(d) {
+			return
+		}
+	}
+}
+
+func (l *list) DrawTo(c gfx.Canvas, bounds image.Rectangle, cam *gfx.Camera) {
+	for _, d := range *l {
+		d.DrawTo(c, bounds, cam)
+	}
+}
+
+// other is a drawable which is not boundable, it simply draws it's object.
+type other struct {
+	o *gfx.Object
+}
+
+func (d other) DrawTo(c gfx.Canvas, bounds image.Rectangle, cam *gfx.Camera) {
+	c.Draw(bounds, d.o, cam)
+}
+
+// newBox returns a new object whose mesh is a box of the given size, centered
+// at the given position.
+func newBox(pos, size lmath.Vec3) *gfx.Object {
+	m := unitCube()
+	for i, v := range m.Vertices {
+		m.Vertices[i] = gfx.Vec3{
+			X: v.X * float32(size.X),
+			Y: v.Y * float32(size.Y),
+			Z: v.Z * float32(size.Z),
+		}
+	}
+	o := gfx.NewObject()
+	o.Meshes = []*gfx.Mesh{m}
+	o.SetPos(pos)
+	return o
+}
+
+// unit is the size of a unit box, see newBox.
+var unit = lmath.Vec3{1, 1, 1}
+
+// newCamera returns a new perspective camera at the origin, looking down the
+// +Y axis.
+func newCamera() *gfx.Camera {
+	cam := gfx.NewCamera()
+	cam.SetPersp(view, 75, 0.1, 1000)
+	return cam
+}
@@ -0,0 +1,253 @@
+package scene
+
+import (
+	"image"
+	"sync"
+
+	"azul3d.org/gfx.v1"
+	"azul3d.org/lmath.v1"
+	"azul3d.org/octree.v1"
+)
+
+// Spatial implements the Scene interface using an octree. Drawables which are
+// also boundable (e.g. *gfx.Object) are indexed by their bounds, such that:
+//  Add, Has and Remove are fast regardless of the number of objects.
+//  DrawTo only draws the drawables that are inside of the camera's view.
+//  Gameplay code may search for drawables in a region (see Intersect and In).
+//
+// Drawables that are not boundable are kept in a slice, they are always drawn
+// (after the boundable ones) and never found by searches.
+//
+// The order in which drawables are iterated and drawn is undefined, use
+// OptiDraw for a scene whose draw order matters (e.g. one with transparent
+// objects):
+//  s := scene.OptiDraw(scene.NewSpatial())
+type Spatial struct {
+	access sync.RWMutex
+	tree   *octree.Tree
+
+	// The bounds of each boundable drawable, as of when it was last indexed.
+	bounds map[gfx.Boundable]lmath.Rect3
+
+	// The drawables that are not boundable.
+	others []gfx.Drawable
+}
+
+// has is short-handed for Has without the lock held.
+func (s *Spatial) has(d gfx.Drawable) bool {
+	if b, ok := d.(gfx.Boundable); ok {
+		_, ok = s.bounds[b]
+		return ok
+	}
+	for _, other := range s.others {
+		if d == other {
+			return true
+		}
+	}
+	return false
+}
+
+// Implements the Scene interface.
+func (s *Spatial) Add(d gfx.Drawable) bool {
+	s.access.Lock()
+	defer s.access.Unlock()
+
+	// Is it already in the scene? If so don't add it.
+	if s.has(d) {
+		return false
+	}
+	if b, ok := d.(gfx.Boundable); ok {
+		s.bounds[b] = b.Bounds()
+		s.tree.Add(b)
+	} else {
+		s.others = append(s.others, d)
+	}
+	return true
+}
+
+// Implements the Scene interface.
+func (s *Spatial) Has(d gfx.Drawable) bool {
+	s.access.RLock()
+	defer s.access.RUnlock()
+	return s.has(d)
+}
+
+// Implements the Scene interface.
+func (s *Spatial) Remove(d gfx.Drawable) bool {
+	s.access.Lock()
+	defer s.access.Unlock()
+
+	if b, ok := d.(gfx.Boundable); ok {
+		if _, ok = s.bounds[b]; !ok {
+			return false
+		}
+		delete(s.bounds, b)
+		s.tree.Remove(b)
+		return true
+	}
+	for i, other := range s.others {
+		if d == other {
+			// Delete it from the slice.
+			s.others = append(s.others[:i], s.others[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Implements the Scene interface.
+//
+// The drawables are collected before the callback is invoked, such that the
+// callback may modify the scene.
+func (s *Spatial) Iter(callback func(d gfx.Drawable) (stop bool)) {
+	s.access.RLock()
+	all := make([]gfx.Drawable, 0, len(s.bounds)+len(s.others))
+	for b := range s.bounds {
+		all = append(all, b.(gfx.Drawable))
+	}
+	all = append(all, s.others...)
+	s.access.RUnlock()
+
+	for _, d := range all {
+		if !callback(d) {
+			return
+		}
+	}
+}
+
+// update re-indexes the given boundable drawable if it's bounds have changed,
+// it returns whether or not they had.
+//
+// The scene's write lock must be held for this method to operate safely.
+func (s *Spatial) update(b gfx.Boundable, old lmath.Rect3) bool {
+	bounds := b.Bounds()
+	if bounds == old {
+		return false
+	}
+	s.bounds[b] = bounds
+	s.tree.Update(b, b)
+	return true
+}
+
+// Update updates the position of the given drawable in the scene, because it
+// has moved (or otherwise changed it's bounds). False is returned if the
+// drawable is not in the scene or is not boundable.
+//
+// Positions of all drawables are updated each time the scene is drawn, this
+// method only needs to be called for searches to find a drawable where it has
+// moved to before the scene is drawn again.
+func (s *Spatial) Update(d gfx.Drawable) bool {
+	s.access.Lock()
+	defer s.access.Unlock()
+
+	b, ok := d.(gfx.Boundable)
+	if !ok {
+		return false
+	}
+	old, ok := s.bounds[b]
+	if !ok {
+		return false
+	}
+	s.update(b, old)
+	return true
+}
+
+// DrawTo implements the Scene interface.
+//
+// The positions of drawables which have moved since the last draw are updated
+// first, and then only the drawables inside of the camera's viewing frustum
+// are drawn. If the camera is nil then all drawables are drawn.
+func (s *Spatial) DrawTo(c gfx.Canvas, bounds image.Rectangle, cam *gfx.Camera) {
+	s.access.Lock()
+	for b, old := range s.bounds {
+		s.update(b, old)
+	}
+	s.access.Unlock()
+
+	s.access.RLock()
+	defer s.access.RUnlock()
+
+	if cam == nil {
+		for b := range s.bounds {
+			b.(gfx.Drawable).DrawTo(c, bounds, cam)
+		}
+	} else {
+		cam.RLock()
+		f := newFrustum(viewProjection(cam))
+		cam.RUnlock()
+
+		s.tree.Intersect(f, func(b gfx.Boundable) bool {
+			b.(gfx.Drawable).DrawTo(c, bounds, cam)
+			return true
+		})
+	}
+	for _, d := range s.others {
+		d.DrawTo(c, bounds, cam)
+	}
+}
+
+// search collects the results of searching the octree, and then invokes the
+// callback for each one.
+func (s *Spatial) search(find func(t *octree.Tree, fn func(b gfx.Boundable) bool), callback func(d gfx.Drawable) bool) {
+	var results []gfx.Drawable
+	s.access.RLock()
+	find(s.tree, func(b gfx.Boundable) bool {
+		results = append(results, b.(gfx.Drawable))
+		return true
+	})
+	s.access.RUnlock()
+
+	for _, d := range results {
+		if !callback(d) {
+			return
+		}
+	}
+}
+
+// Intersect invokes the callback for each drawable in the scene whose bounds
+// intersect the given search area, for instance to find everything within the
+// blast radius of an explosion:
+//  s.Intersect(octree.Sphere(blast), func(d gfx.Drawable) bool {
+//      damage(d)
+//      return true
+//  })
+//
+// If the callback returns false, the search is stopped. The results are
+// collected before the callback is invoked, such that the callback may modify
+// the scene.
+//
+// Drawables are found where they where when the scene was last drawn (or when
+// they where last updated, see Update).
+func (s *Spatial) Intersect(search octree.Intersector, callback func(d gfx.Drawable) bool) {
+	s.search(func(t *octree.Tree, fn func(b gfx.Boundable) bool) {
+		t.Intersect(search, fn)
+	}, callback)
+}
+
+// In is like Intersect, except it only finds the drawables in the scene whose
+// bounds are completely inside of the given search area, for instance:
+//  s.In(octree.Rect3(room), func(d gfx.Drawable) bool {
+//      fmt.Println(d, "is in the room")
+//      return true
+//  })
+func (s *Spatial) In(search octree.Container, callback func(d gfx.Drawable) bool) {
+	s.search(func(t *octree.Tree, fn func(b gfx.Boundable) bool) {
+		t.In(search, fn)
+	}, callback)
+}
+
+// NewSpatial returns a new spatially indexed scene. It is short-handed for:
+//  NewSpatialTree(octree.New())
+func NewSpatial() *Spatial {
+	return NewSpatialTree(octree.New())
+}
+
+// NewSpatialTree returns a new spatially indexed scene using the given empty
+// octree, which allows for specifying it's split factor and initial size (see
+// octree.NewTree).
+func NewSpatialTree(t *octree.Tree) *Spatial {
+	return &Spatial{
+		tree:   t,
+		bounds: make(map[gfx.Boundable]lmath.Rect3, 128),
+	}
+}
@@ -0,0 +1,130 @@
+package scene
+
+import (
+	"testing"
+
+	"azul3d.org/gfx.v1"
+	"azul3d.org/lmath.v1"
+	"azul3d.org/octree.v1"
+)
+
+func TestSpatialAddRemove(t *testing.T) {
+	s := NewSpatial()
+	a := newBox(lmath.Vec3{0, 10, 0}, unit)
+	o := other{gfx.NewObject()}
+	if !s.Add(a) || !s.Add(o) || s.Add(a) || s.Add(o) {
+		t.Fatal("bad Add results")
+	}
+	if !s.Has(a) || !s.Has(o) {
+		t.Fatal("added drawables not in the scene")
+	}
+	n := 0
+	s.Iter(func(d gfx.Drawable) bool {
+		n++
+		return true
+	})
+	if n != 2 {
+		t.Fatalf("iterated %d drawables want 2", n)
+	}
+	if !s.Remove(a) || !s.Remove(o) || s.Remove(a) || s.Remove(o) {
+		t.Fatal("bad Remove results")
+	}
+	if s.Has(a) || s.Has(o) {
+		t.Fatal("removed drawables still in the scene")
+	}
+}
+
+func TestSpatialCulling(t *testing.T) {
+	s := NewSpatial()
+	front := newBox(lmath.Vec3{0, 10, 0}, unit)
+	behind := newBox(lmath.Vec3{0, -10, 0}, unit)
+	aside := newBox(lmath.Vec3{100, 10, 0}, unit)
+	beyond := newBox(lmath.Vec3{0, 2000, 0}, unit)
+	o := other{gfx.NewObject()}
+	for _, d := range []gfx.Drawable{front, behind, aside, beyond, o} {
+		s.Add(d)
+	}
+
+	c := newRecorder()
+	s.DrawTo(c, view, newCamera())
+	if len(c.drawn) != 2 || c.index(front) == -1 || c.index(o.o) != 1 {
+		t.Fatalf("got %d drawn want the front object and then the other drawable", len(c.drawn))
+	}
+
+	// Without a camera everything is drawn.
+	c = newRecorder()
+	s.DrawTo(c, view, nil)
+	if len(c.drawn) != 5 {
+		t.Fatalf("got %d drawn without a camera want 5", len(c.drawn))
+	}
+
+	// Objects which move into the view are drawn.
+	behind.SetPos(lmath.Vec3{0, 20, 0})
+	c = newRecorder()
+	s.DrawTo(c, view, newCamera())
+	if len(c.drawn) != 3 || c.index(behind) == -1 {
+		t.Fatal("moved object not drawn")
+	}
+}
+
+func TestSpatialSearch(t *testing.T) {
+	s := NewSpatial()
+	a := newBox(lmath.Vec3{0, 10, 0}, unit)
+	b := newBox(lmath.Vec3{0, 20, 0}, unit)
+	s.Add(a)
+	s.Add(b)
+	s.Add(other{gfx.NewObject()})
+
+	find := func(search func(fn func(d gfx.Drawable) bool)) []gfx.Drawable {
+		var found []gfx.Drawable
+		search(func(d gfx.Drawable) bool {
+			found = append(found, d)
+			return true
+		})
+		return found
+	}
+	around := func(p lmath.Vec3, r float64) octree.Container {
+		return octree.Sphere(lmath.Sphere{Center: p, Radius: r})
+	}
+	room := octree.Rect3(lmath.Rect3{
+		Min: lmath.Vec3{-5, 5, -5},
+		Max: lmath.Vec3{5, 15, 5},
+	})
+
+	found := find(func(fn func(d gfx.Drawable) bool) { s.Intersect(around(lmath.Vec3{0, 10, 0}, 1), fn) })
+	if len(found) != 1 || found[0] != a {
+		t.Fatalf("Intersect found %v", found)
+	}
+	found = find(func(fn func(d gfx.Drawable) bool) { s.In(room, fn) })
+	if len(found) != 1 || found[0] != a {
+		t.Fatalf("In found %v", found)
+	}
+
+	// Moved objects are found where they were, until updated.
+	a.SetPos(lmath.Vec3{50, 50, 50})
+	found = find(func(fn func(d gfx.Drawable) bool) { s.In(room, fn) })
+	if len(found) != 1 || found[0] != a {
+		t.Fatal("moved object found before being updated")
+	}
+	if !s.Update(a) || s.Update(other{}) || s.Update(newBox(lmath.Vec3{}, unit)) {
+		t.Fatal("bad Update results")
+	}
+	found = find(func(fn func(d gfx.Drawable) bool) { s.In(room, fn) })
+	if len(found) != 0 {
+		t.Fatalf("In found %v after moving out of the room", found)
+	}
+	found = find(func(fn func(d gfx.Drawable) bool) { s.Intersect(around(lmath.Vec3{50, 50, 50}, 1), fn) })
+	if len(found) != 1 || found[0] != a {
+		t.Fatalf("Intersect found %v after update", found)
+	}
+
+	// Stopping the search.
+	n := 0
+	s.Intersect(around(lmath.Vec3{}, 1000), func(d gfx.Drawable) bool {
+		n++
+		return false
+	})
+	if n != 1 {
+		t.Fatalf("search not stopped, got %d results", n)
+	}
+}
@@ -0,0 +1,256 @@
+package scene
+
+import (
+	"image"
+	"math"
+
+	"azul3d.org/gfx.v1"
+	"azul3d.org/lmath.v1"
+)
+
+// Viewport is a rectangular area of a Layout into which a scene is drawn with
+// it's own camera.
+type Viewport struct {
+	// The scene drawn into the viewport.
+	Scene Scene
+
+	// The camera the scene is drawn with, or nil to use the camera given to
+	// the layout's DrawTo method.
+	Camera *gfx.Camera
+
+	// The area of the viewport as fractions of the bounds given to the
+	// layout's DrawTo method, where (0, 0) is the top-left corner and
+	// (1, 1) is the bottom-right one.
+	X, Y, Width, Height float64
+
+	// Whether or not the color (and depth) buffer of the viewport's area is
+	// cleared before the scene is drawn into it, and the color to clear it
+	// to.
+	Clear, ClearDepth bool
+	Color             gfx.Color
+
+	// Whether or not the horizontal scale of the camera's projection is
+	// adjusted to match the aspect ratio of the viewport's area, such that a
+	// camera whose projection was set up for the entire window (see
+	// gfx.Camera.SetPersp) is not stretched when drawn into it.
+	FixAspect bool
+
+	// The camera actually used to draw the scene, with the projection mapped
+	// onto the viewport's area. It is kept between draws such that drawables
+	// which store state per-camera (e.g. LOD) continue to work.
+	cam *gfx.Camera
+}
+
+// Bounds returns the rectangle of the viewport within the given area.
+func (v *Viewport) Bounds(area image.Rectangle) image.Rectangle {
+	w, h := float64(area.Dx()), float64(area.Dy())
+	r := image.Rect(
+		area.Min.X+int(math.Floor(v.X*w+0.5)),
+		area.Min.Y+int(math.Floor(v.Y*h+0.5)),
+		area.Min.X+int(math.Floor((v.X+v.Width)*w+0.5)),
+		area.Min.Y+int(math.Floor((v.Y+v.Height)*h+0.5)),
+	)
+	return r.Intersect(area)
+}
+
+// viewportMat4 returns the matrix which maps the normalized device coordinates
+// of the entire canvas (whose bounds are given) onto the given rectangle of
+// it.
+//
+// Canvases draw to their entire area and only clip drawing to the rectangle
+// given to them, so the projection must place the scene in it instead.
+func viewportMat4(r, canvas image.Rectangle) lmath.Mat4 {
+	cw, ch := float64(canvas.Dx()), float64(canvas.Dy())
+	cx := float64(r.Min.X+r.Max.X)/2 - float64(canvas.Min.X)
+	cy := float64(r.Min.Y+r.Max.Y)/2 - float64(canvas.Min.Y)
+
+	// Rectangles are from the top-left, device coordinates from the
+	// bottom-left.
+	return lmath.Mat4{
+		{float64(r.Dx()) / cw, 0, 0, 0},
+		{0, float64(r.Dy()) / ch, 0, 0},
+		{0, 0, 1, 0},
+		{2*cx/cw - 1, 1 - 2*cy/ch, 0, 1},
+	}
+}
+
+// camera returns the camera to draw the viewport's scene with, given the
+// camera that the layout is drawn with, the viewport's rectangle and the
+// bounds of the canvas. It returns nil if there is no camera.
+func (v *Viewport) camera(cam *gfx.Camera, r, canvas image.Rectangle) *gfx.Camera {
+	if v.Camera != nil {
+		cam = v.Camera
+	}
+	if cam == nil || canvas.Empty() {
+		return nil
+	}
+	cam.RLock()
+	obj := cam.Object
+	proj := cam.Projection.Mat4()
+	cam.RUnlock()
+
+	if v.FixAspect {
+		// Keep the vertical scale, such that pixels are square.
+		proj[0][0] = proj[1][1] * float64(r.Dy()) / float64(r.Dx())
+	}
+	proj = proj.Mul(viewportMat4(r, canvas))
+
+	if v.cam == nil {
+		v.cam = &gfx.Camera{}
+	}
+	v.cam.Object = obj
+	v.cam.Projection = gfx.ConvertMat4(proj)
+	return v.cam
+}
+
+// Layout implements the Scene interface by drawing scenes into multiple
+// viewports of the canvas, each with it's own camera. For instance a racing
+// game might use split-screen with a minimap in the corner:
+//  l := scene.SplitScreen(world, player1Cam, player2Cam)
+//  l = append(l, &scene.Viewport{
+//      Scene:  world,
+//      Camera: mapCam,
+//      X:      0.8, Y: 0.05,
+//      Width:  0.15, Height: 0.15,
+//      Clear:  true, ClearDepth: true,
+//  })
+//
+// Viewports are drawn in order, such that later ones (e.g. picture-in-picture
+// or a minimap) are drawn on top of earlier ones. Only the area of each
+// viewport is cleared, and the projection of each camera is mapped onto the
+// viewport's area.
+//
+// The other methods of the Scene interface act on every distinct scene of the
+// layout, like Multi does.
+//
+// Viewports must not be modified while the layout is being drawn.
+type Layout []*Viewport
+
+// scenes returns the distinct, non-nil scenes of the layout's viewports.
+func (l Layout) scenes() []Scene {
+	var scenes []Scene
+	for _, v := range l {
+		if v == nil || v.Scene == nil {
+			continue
+		}
+		dup := false
+		for _, s := range scenes {
+			if s == v.Scene {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			scenes = append(scenes, v.Scene)
+		}
+	}
+	return scenes
+}
+
+// Add implements the Scene interface by calling Add(d) on every distinct
+// scene of the layout, and returning true if any of them added it.
+func (l Layout) Add(d gfx.Drawable) bool {
+	added := false
+	for _, s := range l.scenes() {
+		if s.Add(d) {
+			added = true
+		}
+	}
+	return added
+}
+
+// Has implements the Scene interface by calling Has(d) on every distinct
+// scene of the layout, and returning the first (true) result.
+func (l Layout) Has(d gfx.Drawable) bool {
+	for _, s := range l.scenes() {
+		if s.Has(d) {
+			return true
+		}
+	}
+	return false
+}
+
+// Remove implements the Scene interface by calling Remove(d) on every
+// distinct scene of the layout, and returning true if any of them removed it.
+func (l Layout) Remove(d gfx.Drawable) bool {
+	removed := false
+	for _, s := range l.scenes() {
+		if s.Remove(d) {
+			removed = true
+		}
+	}
+	return removed
+}
+
+// Iter implements the Scene interface by iterating over every distinct scene
+// of the layout in order. A drawable which is in multiple scenes is iterated
+// once for each of them.
+func (l Layout) Iter(callback func(d gfx.Drawable) bool) {
+	stopped := false
+	for _, s := range l.scenes() {
+		s.Iter(func(d gfx.Drawable) bool {
+			if !callback(d) {
+				stopped = true
+				return false
+			}
+			return true
+		})
+		if stopped {
+			return
+		}
+	}
+}
+
+// DrawTo implements the Scene interface by drawing the scene of each viewport
+// into it's area of the given bounds. Viewports without a camera of their own
+// use the given camera.
+func (l Layout) DrawTo(c gfx.Canvas, bounds image.Rectangle, cam *gfx.Camera) {
+	canvas := c.Bounds()
+	if bounds.Empty() {
+		bounds = canvas
+	}
+	for _, v := range l {
+		if v == nil || v.Scene == nil {
+			continue
+		}
+		r := v.Bounds(bounds)
+		if r.Empty() {
+			// Canvases treat an empty rectangle as the entire canvas.
+			continue
+		}
+		if v.Clear {
+			c.Clear(r, v.Color)
+		}
+		if v.ClearDepth {
+			c.ClearDepth(r, 1.0)
+		}
+		v.Scene.DrawTo(c, r, v.camera(cam, r, canvas))
+	}
+}
+
+// SplitScreen returns a layout which draws the given scene once for each of
+// the given cameras, in a grid of equally sized viewports. Two cameras are
+// placed side by side, three or four in a 2x2 grid, and so on. Each viewport
+// is cleared and has FixAspect set.
+func SplitScreen(s Scene, cams ...*gfx.Camera) Layout {
+	if len(cams) == 0 {
+		return nil
+	}
+	cols := int(math.Ceil(math.Sqrt(float64(len(cams)))))
+	rows := (len(cams) + cols - 1) / cols
+	l := make(Layout, len(cams))
+	for i, cam := range cams {
+		l[i] = &Viewport{
+			Scene:      s,
+			Camera:     cam,
+			X:          float64(i%cols) / float64(cols),
+			Y:          float64(i/cols) / float64(rows),
+			Width:      1 / float64(cols),
+			Height:     1 / float64(rows),
+			Clear:      true,
+			ClearDepth: true,
+			FixAspect:  true,
+		}
+	}
+	return l
+}
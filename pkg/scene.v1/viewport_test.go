@@ -0,0 +1,208 @@
+package scene
+
+import (
+	"image"
+	"testing"
+
+	"azul3d.org/gfx.v1"
+	"azul3d.org/lmath.v1"
+)
+
+// layoutCanvas is a recorder which also records the rectangles that are
+// cleared and drawn into, and the cameras drawn with.
+type layoutCanvas struct {
+	*recorder
+	clears, depthClears, rects []image.Rectangle
+	cams                       []*gfx.Camera
+}
+
+func (c *layoutCanvas) Bounds() image.Rectangle {
+	return view
+}
+
+func (c *layoutCanvas) Clear(r image.Rectangle, bg gfx.Color) {
+	c.clears = append(c.clears, r)
+}
+
+func (c *layoutCanvas) ClearDepth(r image.Rectangle, depth float64) {
+	c.depthClears = append(c.depthClears, r)
+}
+
+func (c *layoutCanvas) Draw(r image.Rectangle, o *gfx.Object, cam *gfx.Camera) {
+	c.recorder.Draw(r, o, cam)
+	c.rects = append(c.rects, r)
+	c.cams = append(c.cams, cam)
+}
+
+// equalRects tells if the two slices of rectangles are equal.
+func equalRects(a, b []image.Rectangle) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestViewportBounds(t *testing.T) {
+	for _, tst := range []struct {
+		v    Viewport
+		area image.Rectangle
+		want image.Rectangle
+	}{
+		{Viewport{X: 0, Y: 0, Width: 1, Height: 1}, view, view},
+		{Viewport{X: 0.75, Y: 0, Width: 0.25, Height: 0.25}, view, image.Rect(600, 0, 800, 150)},
+		{Viewport{X: 0.5, Y: 0.5, Width: 0.5, Height: 0.5}, image.Rect(100, 50, 500, 350), image.Rect(300, 200, 500, 350)},
+		{Viewport{X: 1.0 / 3, Y: 0, Width: 1.0 / 3, Height: 1}, view, image.Rect(267, 0, 533, 600)},
+		{Viewport{X: 0.9, Y: 0, Width: 0.5, Height: 1}, view, image.Rect(720, 0, 800, 600)},
+		{Viewport{X: 2, Y: 0, Width: 1, Height: 1}, view, image.Rectangle{}},
+	} {
+		if got := tst.v.Bounds(tst.area); got != tst.want {
+			t.Fatalf("%+v in %v: got %v want %v", tst.v, tst.area, got, tst.want)
+		}
+	}
+}
+
+func TestViewportMat4(t *testing.T) {
+	// The corners of device coordinates are mapped onto the rectangle, the
+	// top-right one of the canvas here.
+	m := viewportMat4(image.Rect(600, 0, 800, 150), view)
+	tr := lmath.Vec4{1, 1, 0, 1}.Transform(m)
+	bl := lmath.Vec4{-1, -1, 0.5, 1}.Transform(m)
+	if !tr.Vec3().Equals(lmath.Vec3{1, 1, 0}) || !bl.Vec3().Equals(lmath.Vec3{0.5, 0.5, 0.5}) {
+		t.Fatalf("got corners %v and %v", tr, bl)
+	}
+
+	// The entire canvas is not changed, regardless of it's origin.
+	canvas := image.Rect(100, 100, 900, 700)
+	if !viewportMat4(canvas, canvas).Equals(lmath.Mat4Identity) {
+		t.Fatal("entire canvas not mapped onto itself")
+	}
+}
+
+func TestViewportFixAspect(t *testing.T) {
+	cam := newCamera()
+	proj := cam.Projection.Mat4()
+	left := image.Rect(0, 0, 400, 600)
+
+	v := &Viewport{Width: 0.5, Height: 1}
+	p := v.camera(cam, left, view).Projection.Mat4()
+	if !lmath.Equal(p[0][0], proj[0][0]*0.5) || !lmath.Equal(p[1][1], proj[1][1]) {
+		t.Fatalf("got projection %v", p)
+	}
+
+	// Pixels are square, the horizontal scale is that of a 400x600 view.
+	v.FixAspect = true
+	p = v.camera(cam, left, view).Projection.Mat4()
+	if !lmath.Equal(p[0][0], proj[1][1]*600/400*0.5) || !lmath.Equal(p[1][1], proj[1][1]) {
+		t.Fatalf("got projection %v with FixAspect", p)
+	}
+
+	// The center of the camera's view is at the center of the viewport.
+	vc := v.camera(cam, left, view)
+	c := lmath.Vec4{0, 10, 0, 1}.Transform(viewProjection(vc))
+	if !lmath.Equal(c.X/c.W, -0.5) || !lmath.Equal(c.Y/c.W, 0) {
+		t.Fatalf("got center at %v, %v", c.X/c.W, c.Y/c.W)
+	}
+	if vc.Object != cam.Object {
+		t.Fatal("camera transform not shared")
+	}
+	if v.camera(nil, left, view) != nil {
+		t.Fatal("got a camera without one")
+	}
+}
+
+func TestSplitScreen(t *testing.T) {
+	s := NewSpatial()
+	o := newBox(lmath.Vec3{0, 10, 0}, unit)
+	s.Add(o)
+	cams := []*gfx.Camera{newCamera(), newCamera(), newCamera()}
+
+	for _, tst := range []struct {
+		cams  int
+		rects []image.Rectangle
+	}{
+		{1, []image.Rectangle{view}},
+		{2, []image.Rectangle{
+			image.Rect(0, 0, 400, 600),
+			image.Rect(400, 0, 800, 600),
+		}},
+		{3, []image.Rectangle{
+			image.Rect(0, 0, 400, 300),
+			image.Rect(400, 0, 800, 300),
+			image.Rect(0, 300, 400, 600),
+		}},
+	} {
+		l := SplitScreen(s, cams[:tst.cams]...)
+		c := &layoutCanvas{recorder: newRecorder()}
+		l.DrawTo(c, image.Rectangle{}, nil)
+		if !equalRects(c.clears, tst.rects) || !equalRects(c.depthClears, tst.rects) {
+			t.Fatalf("%d cameras: got clears %v want %v", tst.cams, c.clears, tst.rects)
+		}
+		if !equalRects(c.rects, tst.rects) {
+			t.Fatalf("%d cameras: got draws into %v want %v", tst.cams, c.rects, tst.rects)
+		}
+		for i, cam := range c.cams {
+			if cam.Object != cams[i].Object {
+				t.Fatalf("%d cameras: viewport %d drawn with the wrong camera", tst.cams, i)
+			}
+		}
+
+		// The same cameras are used each time.
+		first := c.cams
+		c = &layoutCanvas{recorder: newRecorder()}
+		l.DrawTo(c, image.Rectangle{}, nil)
+		for i := range first {
+			if c.cams[i] != first[i] {
+				t.Fatal("camera not reused")
+			}
+		}
+	}
+	if SplitScreen(s) != nil {
+		t.Fatal("got a layout without cameras")
+	}
+}
+
+func TestLayout(t *testing.T) {
+	a, b := NewSpatial(), NewSpatial()
+	cam := newCamera()
+	l := Layout{
+		{Scene: a, Width: 1, Height: 1},
+		{Scene: b, X: 0.75, Width: 0.25, Height: 0.25, Clear: true},
+		{Scene: a, Width: 0, Height: 1, Clear: true},
+		{Width: 1, Height: 1},
+		nil,
+	}
+
+	o := newBox(lmath.Vec3{0, 10, 0}, unit)
+	if !l.Add(o) || l.Add(o) || !a.Has(o) || !b.Has(o) || !l.Has(o) {
+		t.Fatal("not added to each scene")
+	}
+	n := 0
+	l.Iter(func(d gfx.Drawable) bool {
+		n++
+		return true
+	})
+	if n != 2 {
+		t.Fatalf("iterated %d drawables want 2", n)
+	}
+
+	// Empty viewports are skipped, and only the given area is drawn to.
+	area := image.Rect(0, 0, 400, 300)
+	c := &layoutCanvas{recorder: newRecorder()}
+	l.DrawTo(c, area, cam)
+	want := []image.Rectangle{area, image.Rect(300, 0, 400, 75)}
+	if !equalRects(c.rects, want) || !equalRects(c.clears, want[1:]) || len(c.depthClears) != 0 {
+		t.Fatalf("got draws into %v and clears %v", c.rects, c.clears)
+	}
+	if c.cams[0] == cam || c.cams[0].Object != cam.Object {
+		t.Fatal("not drawn with the layout's camera")
+	}
+
+	if !l.Remove(o) || l.Remove(o) || a.Has(o) || b.Has(o) {
+		t.Fatal("not removed from each scene")
+	}
+}